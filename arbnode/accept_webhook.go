@@ -0,0 +1,142 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	acceptWebhookSentCounter    = metrics.NewRegisteredCounter("arb/sequencer/acceptwebhook/sent", nil)
+	acceptWebhookFailedCounter  = metrics.NewRegisteredCounter("arb/sequencer/acceptwebhook/failed", nil)
+	acceptWebhookDroppedCounter = metrics.NewRegisteredCounter("arb/sequencer/acceptwebhook/dropped", nil)
+)
+
+type AcceptWebhookConfig struct {
+	URL       string        `koanf:"url"`
+	QueueSize int           `koanf:"queue-size"`
+	Timeout   time.Duration `koanf:"timeout"`
+}
+
+var DefaultAcceptWebhookConfig = AcceptWebhookConfig{
+	URL:       "",
+	QueueSize: 1024,
+	Timeout:   time.Second * 5,
+}
+
+func AcceptWebhookConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", DefaultAcceptWebhookConfig.URL, "URL to POST the hash, sender, and nonce of each transaction the sequencer includes in a block to (empty to disable)")
+	f.Int(prefix+".queue-size", DefaultAcceptWebhookConfig.QueueSize, "notifications to queue for the webhook before dropping new ones")
+	f.Duration(prefix+".timeout", DefaultAcceptWebhookConfig.Timeout, "timeout for each webhook request")
+}
+
+func (c *AcceptWebhookConfig) Validate() error {
+	if c.URL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid sequencer accept-webhook url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("sequencer accept-webhook url \"%v\" must be http or https", c.URL)
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("sequencer accept-webhook queue-size must be positive, not %v", c.QueueSize)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("sequencer accept-webhook timeout must be positive, not %v", c.Timeout)
+	}
+	return nil
+}
+
+type AcceptedTransaction struct {
+	TxHash      common.Hash    `json:"txHash"`
+	Sender      common.Address `json:"sender"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+}
+
+// acceptWebhook delivers accepted transactions to a webhook from its own thread. Notifications are
+// queued without blocking, so if the webhook falls behind, new ones are dropped.
+type acceptWebhook struct {
+	url     string
+	timeout time.Duration
+	queue   chan AcceptedTransaction
+	client  *http.Client
+}
+
+func newAcceptWebhook(config *AcceptWebhookConfig) *acceptWebhook {
+	if config.URL == "" {
+		return nil
+	}
+	return &acceptWebhook{
+		url:     config.URL,
+		timeout: config.Timeout,
+		queue:   make(chan AcceptedTransaction, config.QueueSize),
+		client:  &http.Client{},
+	}
+}
+
+func (w *acceptWebhook) enqueue(accepted AcceptedTransaction) {
+	select {
+	case w.queue <- accepted:
+	default:
+		acceptWebhookDroppedCounter.Inc(1)
+	}
+}
+
+func (w *acceptWebhook) run(ctx context.Context) {
+	for {
+		select {
+		case accepted := <-w.queue:
+			if err := w.post(ctx, &accepted); err != nil {
+				acceptWebhookFailedCounter.Inc(1)
+				log.Debug("sequencer accept webhook failed", "tx", accepted.TxHash, "err", err)
+			} else {
+				acceptWebhookSentCounter.Inc(1)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *acceptWebhook) post(ctx context.Context, accepted *AcceptedTransaction) error {
+	body, err := json.Marshal(accepted)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	// reading the body to the end lets the connection be reused for the next notification
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned status %v", resp.Status)
+	}
+	return nil
+}
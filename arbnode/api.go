@@ -17,9 +17,14 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/das"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/validator"
 	"github.com/pkg/errors"
@@ -79,17 +84,314 @@ func (a *BlockValidatorDebugAPI) ValidateBlock(
 }
 
 type ArbAPI struct {
-	txPublisher TransactionPublisher
+	txPublisher   TransactionPublisher
+	blockchain    *core.BlockChain
+	cachingConfig *CachingConfig
 }
 
 func (a *ArbAPI) CheckPublisherHealth(ctx context.Context) error {
 	return a.txPublisher.CheckHealth(ctx)
 }
 
+type NitroAPI struct {
+	blockchain      *core.BlockChain
+	txStreamer      *TransactionStreamer
+	inboxReader     *InboxReader
+	batchPoster     *BatchPoster
+	broadcastServer *broadcaster.Broadcaster
+	chainDb         ethdb.Database
+	arbDb           ethdb.Database
+	staker          *validator.Staker
+	txPublisher     TransactionPublisher
+	cachingConfig   *CachingConfig
+	config          ConfigFetcher
+}
+
+// NitroAdminAPI holds the nitroadmin methods, which change the node's state and are only served
+// to operators who enable that namespace
+type NitroAdminAPI struct {
+	inboxReader *InboxReader
+}
+
+// ResyncInbox makes the inbox reader read L1 again from fromL1Block, which can't be below the
+// finalized L1 block, without duplicating the messages it already has
+func (api *NitroAdminAPI) ResyncInbox(ctx context.Context, fromL1Block hexutil.Uint64) error {
+	if api.inboxReader == nil {
+		return errors.New("inbox reader not enabled")
+	}
+	return api.inboxReader.Resync(uint64(fromL1Block))
+}
+
+// BumpL1Tx replaces the batch poster's pending L1 transaction with the given nonce using a higher fee
+func (api *NitroAPI) BumpL1Tx(ctx context.Context, nonce hexutil.Uint64) error {
+	if api.batchPoster == nil {
+		return errors.New("batch poster not enabled")
+	}
+	return api.batchPoster.BumpL1Tx(ctx, uint64(nonce))
+}
+
+// DasBackendStatus lists the batch poster's DAS backends with their health and exclusion state
+func (api *NitroAPI) DasBackendStatus(ctx context.Context) ([]das.BackendStatus, error) {
+	if api.batchPoster == nil {
+		return nil, errors.New("batch poster not enabled")
+	}
+	reporter, ok := api.batchPoster.daWriter.(das.BackendStatusReporter)
+	if !ok {
+		return nil, errors.New("batch poster isn't storing batches to a DAS aggregator")
+	}
+	return reporter.BackendStatus(), nil
+}
+
+type FeedClient struct {
+	Name         string         `json:"name"`
+	RemoteAddr   string         `json:"remoteAddr"`
+	ConnectedAt  time.Time      `json:"connectedAt"`
+	Age          string         `json:"age"`
+	QueueDepth   hexutil.Uint64 `json:"queueDepth"`
+	MessagesSent hexutil.Uint64 `json:"messagesSent"`
+	Lag          string         `json:"lag"`
+}
+
+// FeedClients lists the clients connected to this node's feed output
+func (api *NitroAPI) FeedClients(ctx context.Context) ([]FeedClient, error) {
+	if api.broadcastServer == nil {
+		return nil, errors.New("feed output not enabled")
+	}
+	clients := []FeedClient{}
+	for _, client := range api.broadcastServer.Clients() {
+		clients = append(clients, FeedClient{
+			Name:         client.Name,
+			RemoteAddr:   client.RemoteAddr,
+			ConnectedAt:  client.ConnectedAt,
+			Age:          client.Age.Round(time.Second).String(),
+			QueueDepth:   hexutil.Uint64(client.QueueDepth),
+			MessagesSent: hexutil.Uint64(client.MessagesSent),
+			Lag:          client.Lag.String(),
+		})
+	}
+	return clients, nil
+}
+
+// DelayedMessages lists the delayed messages pending inclusion in a batch on L1,
+// along with the deadline after which they can be force included
+func (api *NitroAPI) DelayedMessages(ctx context.Context) ([]PendingDelayedMessage, error) {
+	if api.inboxReader == nil {
+		return nil, errors.New("inbox reader not enabled")
+	}
+	return api.inboxReader.PendingDelayedMessages(ctx, api.txStreamer)
+}
+
+// CacheStats reports memory use, trie cache sizes, limits and hit rates, and database compaction statistics
+func (api *NitroAPI) CacheStats(ctx context.Context) (*CacheStats, error) {
+	return &CacheStats{
+		Memory: memoryStats(),
+		Trie:   trieCacheStats(api.blockchain, api.cachingConfig),
+		Databases: []DatabaseStats{
+			databaseStats("l2chaindata", api.chainDb),
+			databaseStats("arbitrumdata", api.arbDb),
+		},
+	}, nil
+}
+
+// FlushState writes the state of recent blocks to disk like a graceful shutdown does, returning once it's written
+func (api *NitroAPI) FlushState(ctx context.Context) error {
+	return api.txStreamer.FlushState(api.cachingConfig)
+}
+
+// BatchPosterBacklog reports how many L2 blocks haven't been posted to L1 in a batch yet, and how old the oldest is
+func (api *NitroAPI) BatchPosterBacklog(ctx context.Context) (*BatchPostingBacklog, error) {
+	if api.inboxReader == nil {
+		return nil, errors.New("inbox reader not enabled")
+	}
+	return batchPostingBacklog(api.inboxReader.Tracker(), api.txStreamer)
+}
+
+// ValidatorStatus reports the validator's stake, challenge, and L1 balance along with the latest confirmed node
+func (api *NitroAPI) ValidatorStatus(ctx context.Context) (*validator.StakerStatus, error) {
+	if api.staker == nil {
+		return nil, errors.New("validator not enabled")
+	}
+	return api.staker.Status(ctx)
+}
+
+type BatchDataResult struct {
+	BatchIndex hexutil.Uint64 `json:"batchIndex"`
+	L1Block    hexutil.Uint64 `json:"l1Block"`
+	L1TxHash   common.Hash    `json:"l1TxHash"`
+	Data       hexutil.Bytes  `json:"data"`
+	DASPayload hexutil.Bytes  `json:"dasPayload,omitempty"`
+}
+
+// GetBatchData returns the serialized sequencer batch that produced the given L2 block.
+// If the batch is a DAS certificate, the referenced payload is resolved from the configured backends.
+func (api *NitroAPI) GetBatchData(ctx context.Context, l2BlockNumber hexutil.Uint64) (*BatchDataResult, error) {
+	if api.inboxReader == nil {
+		return nil, errors.New("inbox reader not enabled")
+	}
+	msgCount, err := api.txStreamer.BlockNumberToMessageCount(uint64(l2BlockNumber))
+	if err != nil {
+		return nil, err
+	}
+	if msgCount == 0 {
+		return nil, fmt.Errorf("block %v is before nitro genesis", l2BlockNumber)
+	}
+	msgIndex := msgCount - 1
+	tracker := api.inboxReader.Tracker()
+	batchCount, err := tracker.GetBatchCount()
+	if err != nil {
+		return nil, err
+	}
+	if batchCount == 0 {
+		return nil, errors.New("no batches read from L1 yet")
+	}
+	lastBatchMessageCount, err := tracker.GetBatchMessageCount(batchCount - 1)
+	if err != nil {
+		return nil, err
+	}
+	if lastBatchMessageCount <= msgIndex {
+		return nil, fmt.Errorf("block %v has not yet been posted in a batch", l2BlockNumber)
+	}
+	batchNum, err := validator.FindBatchContainingMessageIndex(tracker, msgIndex, batchCount)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := api.inboxReader.GetSequencerBatch(ctx, batchNum)
+	if err != nil {
+		return nil, err
+	}
+	data, err := batch.Serialize(ctx, api.inboxReader.Client())
+	if err != nil {
+		return nil, err
+	}
+	result := &BatchDataResult{
+		BatchIndex: hexutil.Uint64(batchNum),
+		L1Block:    hexutil.Uint64(batch.BlockNumber),
+		L1TxHash:   batch.rawLog.TxHash,
+		Data:       data,
+	}
+	if len(data) > 40 && arbstate.IsDASMessageHeaderByte(data[40]) {
+		if tracker.das == nil {
+			return nil, errors.New("batch references DAS data but no data availability service is configured")
+		}
+		payload, err := arbstate.RecoverPayloadFromDasBatch(ctx, batchNum, data, tracker.das, nil, arbstate.KeysetValidate)
+		if err != nil {
+			return nil, err
+		}
+		if payload == nil {
+			return nil, fmt.Errorf("invalid DAS certificate in batch %v", batchNum)
+		}
+		result.DASPayload = payload
+	}
+	return result, nil
+}
+
+type L1CostEstimate struct {
+	L1Fee        *hexutil.Big   `json:"l1Fee"`
+	L1GasUnits   hexutil.Uint64 `json:"l1GasUnits"`
+	PricePerUnit *hexutil.Big   `json:"pricePerUnit"`
+	L2BaseFee    *hexutil.Big   `json:"l2BaseFee"`
+	GasForL1     hexutil.Uint64 `json:"gasForL1"`
+}
+
+// EstimateL1Cost returns the L1 data fee ArbOS would charge for posting the given signed transaction,
+// using the same compressed size estimate and L1 price per unit as the latest block's state.
+func (api *NitroAPI) EstimateL1Cost(ctx context.Context, txBytes hexutil.Bytes) (*L1CostEstimate, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(txBytes); err != nil {
+		return nil, err
+	}
+	state, header, err := stateAndHeader(api.blockchain, api.blockchain.CurrentHeader().Number.Uint64())
+	if err != nil {
+		return nil, translateMissingStateError(err, api.cachingConfig)
+	}
+	l1Pricing := state.L1PricingState()
+	pricePerUnit, err := l1Pricing.PricePerUnit()
+	if err != nil {
+		return nil, err
+	}
+	l1Fee, units := l1Pricing.GetPosterInfo(tx, l1pricing.BatchPosterAddress)
+	var gasForL1 uint64
+	if header.BaseFee != nil && header.BaseFee.Sign() > 0 {
+		gasForL1 = arbmath.BigDiv(l1Fee, header.BaseFee).Uint64()
+	}
+	return &L1CostEstimate{
+		L1Fee:        (*hexutil.Big)(l1Fee),
+		L1GasUnits:   hexutil.Uint64(units),
+		PricePerUnit: (*hexutil.Big)(pricePerUnit),
+		L2BaseFee:    (*hexutil.Big)(header.BaseFee),
+		GasForL1:     hexutil.Uint64(gasForL1),
+	}, nil
+}
+
+type BatchPosterFeeRecipient struct {
+	Poster common.Address `json:"poster"`
+	PayTo  common.Address `json:"payTo"`
+}
+
+type FeeRecipients struct {
+	BlockNumber       uint64                    `json:"blockNumber"`
+	Coinbase          common.Address            `json:"coinbase"`
+	NetworkFeeAccount common.Address            `json:"networkFeeAccount"`
+	InfraFeeAccount   common.Address            `json:"infraFeeAccount"`
+	L1RewardRecipient common.Address            `json:"l1RewardRecipient"`
+	BatchPosters      []BatchPosterFeeRecipient `json:"batchPosters"`
+}
+
+const maxBatchPostersToList = 256
+
+// FeeRecipients returns the addresses that collect fees as of the given block.
+// These are set on-chain through ArbOwner, so this is read only.
+func (api *NitroAPI) FeeRecipients(ctx context.Context, blockNum rpc.BlockNumber) (*FeeRecipients, error) {
+	blockNum, _ = api.blockchain.ClipToPostNitroGenesis(blockNum)
+	state, header, err := stateAndHeader(api.blockchain, uint64(blockNum))
+	if err != nil {
+		return nil, translateMissingStateError(err, api.cachingConfig)
+	}
+	networkFeeAccount, err := state.NetworkFeeAccount()
+	if err != nil {
+		return nil, err
+	}
+	infraFeeAccount, err := state.InfraFeeAccount()
+	if err != nil {
+		return nil, err
+	}
+	l1Pricing := state.L1PricingState()
+	l1RewardRecipient, err := l1Pricing.PayRewardsTo()
+	if err != nil {
+		return nil, err
+	}
+	posterTable := l1Pricing.BatchPosterTable()
+	posters, err := posterTable.AllPosters(maxBatchPostersToList)
+	if err != nil {
+		return nil, err
+	}
+	result := &FeeRecipients{
+		BlockNumber:       header.Number.Uint64(),
+		Coinbase:          header.Coinbase,
+		NetworkFeeAccount: networkFeeAccount,
+		InfraFeeAccount:   infraFeeAccount,
+		L1RewardRecipient: l1RewardRecipient,
+		BatchPosters:      []BatchPosterFeeRecipient{},
+	}
+	for _, poster := range posters {
+		posterState, err := posterTable.OpenPoster(poster, false)
+		if err != nil {
+			return nil, err
+		}
+		payTo, err := posterState.PayTo()
+		if err != nil {
+			return nil, err
+		}
+		result.BatchPosters = append(result.BatchPosters, BatchPosterFeeRecipient{Poster: poster, PayTo: payTo})
+	}
+	return result, nil
+}
+
 type ArbDebugAPI struct {
 	blockchain        *core.BlockChain
 	blockRangeBound   uint64
 	timeoutQueueBound uint64
+	cachingConfig     *CachingConfig
 }
 
 type PricingModelHistory struct {
@@ -165,7 +467,7 @@ func (api *ArbDebugAPI) PricingModel(ctx context.Context, start, end rpc.BlockNu
 	for i := uint64(0); i < uint64(blocks); i++ {
 		state, header, err := stateAndHeader(api.blockchain, first+i*step)
 		if err != nil {
-			return history, err
+			return history, translateMissingStateError(err, api.cachingConfig)
 		}
 		l1Pricing := state.L1PricingState()
 		l2Pricing := state.L2PricingState()
@@ -247,7 +549,7 @@ func (api *ArbDebugAPI) TimeoutQueueHistory(ctx context.Context, start, end rpc.
 	for i := uint64(0); i < uint64(blocks); i++ {
 		state, _, err := stateAndHeader(api.blockchain, first+i*step)
 		if err != nil {
-			return history, err
+			return history, translateMissingStateError(err, api.cachingConfig)
 		}
 		size, err := state.RetryableState().TimeoutQueue.Size()
 		if err != nil {
@@ -276,7 +578,7 @@ func (api *ArbDebugAPI) TimeoutQueue(ctx context.Context, blockNum rpc.BlockNumb
 
 	state, _, err := stateAndHeader(api.blockchain, uint64(blockNum))
 	if err != nil {
-		return queue, err
+		return queue, translateMissingStateError(err, api.cachingConfig)
 	}
 
 	closure := func(index uint64, ticket common.Hash) (bool, error) {
@@ -12,6 +12,7 @@ import (
 
 type TransactionPublisher interface {
 	PublishTransaction(ctx context.Context, tx *types.Transaction) error
+	PublishConditionalTransaction(ctx context.Context, tx *types.Transaction, options *ConditionalOptions) error
 	CheckHealth(ctx context.Context) error
 	Initialize(context.Context) error
 	Start(context.Context) error
@@ -0,0 +1,49 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	batchSubscribersGauge        = metrics.NewRegisteredGauge("arb/batchposter/subscribers", nil)
+	batchSubscriptionDropCounter = metrics.NewRegisteredCounter("arb/batchposter/subscriptions_dropped", nil)
+)
+
+const batchSubscriptionOverflowReason = "batch notification buffer full, resubscribe and check nitro_batchPosterBacklog"
+
+type BatchPostedEvent struct {
+	BatchIndex hexutil.Uint64 `json:"batchIndex"`
+	// the hash of the first L1 transaction sent, a fee bump replaces it with a new hash
+	L1TxHash   common.Hash    `json:"l1TxHash"`
+	FirstBlock hexutil.Uint64 `json:"firstBlock"`
+	LastBlock  hexutil.Uint64 `json:"lastBlock"`
+	// the size of the batch data posted to L1, which is a DAS certificate in AnyTrust mode
+	Size             hexutil.Uint64 `json:"size"`
+	UncompressedSize hexutil.Uint64 `json:"uncompressedSize"`
+	// set on the last notification of a dropped subscription, which receives no further batches
+	Dropped string `json:"dropped,omitempty"`
+}
+
+func newBatchNotifier(bufferSize func() int) *subscriptionNotifier[BatchPostedEvent] {
+	return newSubscriptionNotifier[BatchPostedEvent]("batches", bufferSize, batchSubscribersGauge, batchSubscriptionDropCounter)
+}
+
+// Batches notifies the subscriber of each batch this node's batch poster sends to L1, with the L2
+// blocks it covers. It's subscribed to with nitro_subscribe("batches"). A subscriber that falls
+// more than node.batch-poster.subscription-buffer batches behind is sent a final notification
+// with the dropped reason, and no more after it.
+func (api *NitroAPI) Batches(ctx context.Context) (*rpc.Subscription, error) {
+	if api.batchPoster == nil {
+		return nil, errors.New("batch poster not enabled")
+	}
+	return api.batchPoster.batchNotifier.serve(ctx, BatchPostedEvent{Dropped: batchSubscriptionOverflowReason})
+}
@@ -0,0 +1,75 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestBatchesSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streamer, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	batchPoster := &BatchPoster{
+		streamer:      streamer,
+		batchNotifier: newBatchNotifier(func() int { return 16 }),
+	}
+	server := rpc.NewServer()
+	Require(t, server.RegisterName("nitro", &NitroAPI{batchPoster: batchPoster}))
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	events := make(chan BatchPostedEvent, 1)
+	sub, err := client.Subscribe(ctx, "nitro", events, "batches")
+	Require(t, err)
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1})
+	batchPoster.notifyBatchPosted(batchPosterPosition{MessageCount: 0, NextSeqNum: 3}, 1, tx, 100, 250)
+	block, err := streamer.MessageCountToBlockNumber(1)
+	Require(t, err)
+	select {
+	case event := <-events:
+		if event.BatchIndex != 3 || event.L1TxHash != tx.Hash() || uint64(event.FirstBlock) != uint64(block) || uint64(event.LastBlock) != uint64(block) {
+			Fail(t, "unexpected batch notification", event)
+		}
+		if event.Size != 100 || event.UncompressedSize != 250 || event.Dropped != "" {
+			Fail(t, "unexpected batch sizes in notification", event)
+		}
+	case err := <-sub.Err():
+		Fail(t, "subscription failed", err)
+	case <-time.After(time.Second):
+		Fail(t, "no notification of the posted batch")
+	}
+
+	// unsubscribing stops the notifications
+	sub.Unsubscribe()
+	subscribers := func() int {
+		batchPoster.batchNotifier.mutex.Lock()
+		defer batchPoster.batchNotifier.mutex.Unlock()
+		return len(batchPoster.batchNotifier.subscribers)
+	}
+	for i := 0; i < 100 && subscribers() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if subscribers() != 0 {
+		Fail(t, "subscriber still notified after unsubscribing")
+	}
+
+	// a node without a batch poster has no batches to subscribe to
+	noPoster := rpc.NewServer()
+	Require(t, noPoster.RegisterName("nitro", &NitroAPI{}))
+	defer noPoster.Stop()
+	noPosterClient := rpc.DialInProc(noPoster)
+	defer noPosterClient.Close()
+	if _, err := noPosterClient.Subscribe(ctx, "nitro", make(chan BatchPostedEvent), "batches"); err == nil {
+		Fail(t, "subscribed to batches without a batch poster")
+	}
+}
@@ -19,7 +19,11 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/offchainlabs/nitro/arbnode/dataposter"
@@ -33,6 +37,16 @@ import (
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
 
+var (
+	batchPosterUncompressedSizeHistogram = metrics.NewRegisteredHistogram("arb/batchposter/batch/uncompressed_size", nil, metrics.NewExpDecaySample(1028, 0.015))
+	batchPosterCompressedSizeHistogram   = metrics.NewRegisteredHistogram("arb/batchposter/batch/compressed_size", nil, metrics.NewExpDecaySample(1028, 0.015))
+	batchPosterCompressionRatioGauge     = metrics.NewRegisteredGaugeFloat64("arb/batchposter/batch/compression_ratio", nil)
+	batchPosterMessagesPerBatchHistogram = metrics.NewRegisteredHistogram("arb/batchposter/batch/messages", nil, metrics.NewExpDecaySample(1028, 0.015))
+	batchPosterBalanceGauge              = metrics.NewRegisteredGaugeFloat64("arb/batchposter/l1_balance_wei", nil)
+	batchPosterBacklogMessagesGauge      = metrics.NewRegisteredGauge("arb/batchposter/backlog/messages", nil)
+	batchPosterBacklogAgeGauge           = metrics.NewRegisteredGauge("arb/batchposter/backlog/oldest_age_seconds", nil)
+)
+
 type batchPosterPosition struct {
 	MessageCount        arbutil.MessageIndex
 	DelayedMessageCount uint64
@@ -41,19 +55,21 @@ type batchPosterPosition struct {
 
 type BatchPoster struct {
 	stopwaiter.StopWaiter
-	l1Reader     *headerreader.HeaderReader
-	inbox        *InboxTracker
-	streamer     *TransactionStreamer
-	config       BatchPosterConfigFetcher
-	seqInbox     *bridgegen.SequencerInbox
-	syncMonitor  *SyncMonitor
-	seqInboxABI  *abi.ABI
-	seqInboxAddr common.Address
-	building     *buildingBatch
-	daWriter     das.DataAvailabilityServiceWriter
-	dataPoster   *dataposter.DataPoster[batchPosterPosition]
-	redisLock    *SimpleRedisLock
-	firstAccErr  time.Time // first time a continuous missing accumulator occurred
+	l1Reader      *headerreader.HeaderReader
+	inbox         *InboxTracker
+	streamer      *TransactionStreamer
+	config        BatchPosterConfigFetcher
+	seqInbox      *bridgegen.SequencerInbox
+	syncMonitor   *SyncMonitor
+	seqInboxABI   *abi.ABI
+	seqInboxAddr  common.Address
+	building      *buildingBatch
+	daWriter      das.DataAvailabilityServiceWriter
+	dataPoster    *dataposter.DataPoster[batchPosterPosition]
+	redisLock     *SimpleRedisLock
+	batchNotifier *subscriptionNotifier[BatchPostedEvent]
+	coordinator   *SeqCoordinator
+	firstAccErr   time.Time // first time a continuous missing accumulator occurred
 }
 
 type BatchPosterConfig struct {
@@ -61,15 +77,21 @@ type BatchPosterConfig struct {
 	DisableDasFallbackStoreDataOnChain bool                        `koanf:"disable-das-fallback-store-data-on-chain" reload:"hot"`
 	MaxBatchSize                       int                         `koanf:"max-size" reload:"hot"`
 	MaxBatchPostInterval               time.Duration               `koanf:"max-interval" reload:"hot"`
+	MinBatchPostDelay                  time.Duration               `koanf:"min-delay" reload:"hot"`
 	BatchPollDelay                     time.Duration               `koanf:"poll-delay" reload:"hot"`
 	PostingErrorDelay                  time.Duration               `koanf:"error-delay" reload:"hot"`
 	CompressionLevel                   int                         `koanf:"compression-level" reload:"hot"`
+	AdaptiveCompression                bool                        `koanf:"adaptive-compression" reload:"hot"`
+	AdaptiveCompressionBudget          time.Duration               `koanf:"adaptive-compression-budget" reload:"hot"`
 	DASRetentionPeriod                 time.Duration               `koanf:"das-retention-period" reload:"hot"`
 	GasRefunderAddress                 string                      `koanf:"gas-refunder-address" reload:"hot"`
 	DataPoster                         dataposter.DataPosterConfig `koanf:"data-poster" reload:"hot"`
 	RedisUrl                           string                      `koanf:"redis-url"`
 	RedisLock                          SimpleRedisLockConfig       `koanf:"redis-lock" reload:"hot"`
 	ExtraBatchGas                      uint64                      `koanf:"extra-batch-gas" reload:"hot"`
+	MinL1Balance                       float64                     `koanf:"min-l1-balance" reload:"hot"`
+	SubscriptionBuffer                 int                         `koanf:"subscription-buffer" reload:"hot"`
+	ReplacementTimeout                 time.Duration               `koanf:"replacement-timeout" reload:"hot"`
 }
 
 func (c *BatchPosterConfig) Validate() error {
@@ -79,7 +101,13 @@ func (c *BatchPosterConfig) Validate() error {
 	if c.MaxBatchSize <= 40 {
 		return errors.New("MaxBatchSize too small")
 	}
-	return nil
+	if c.MaxBatchPostInterval > 0 && c.MinBatchPostDelay > c.MaxBatchPostInterval {
+		return fmt.Errorf("batch poster min-delay %v cannot be greater than max-interval %v", c.MinBatchPostDelay, c.MaxBatchPostInterval)
+	}
+	if c.CompressionLevel < brotli.BestSpeed || c.CompressionLevel > brotli.BestCompression {
+		return fmt.Errorf("invalid compression level %v, must be between %v and %v", c.CompressionLevel, brotli.BestSpeed, brotli.BestCompression)
+	}
+	return c.DataPoster.Validate()
 }
 
 type BatchPosterConfigFetcher func() *BatchPosterConfig
@@ -89,12 +117,18 @@ func BatchPosterConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".disable-das-fallback-store-data-on-chain", DefaultBatchPosterConfig.DisableDasFallbackStoreDataOnChain, "If unable to batch to DAS, disable fallback storing data on chain")
 	f.Int(prefix+".max-size", DefaultBatchPosterConfig.MaxBatchSize, "maximum batch size")
 	f.Duration(prefix+".max-interval", DefaultBatchPosterConfig.MaxBatchPostInterval, "maximum batch posting interval")
+	f.Duration(prefix+".min-delay", DefaultBatchPosterConfig.MinBatchPostDelay, "minimum time to wait after the first message before posting a batch, to accumulate more messages")
 	f.Duration(prefix+".poll-delay", DefaultBatchPosterConfig.BatchPollDelay, "how long to delay after successfully posting batch")
 	f.Duration(prefix+".error-delay", DefaultBatchPosterConfig.PostingErrorDelay, "how long to delay after error posting batch")
-	f.Int(prefix+".compression-level", DefaultBatchPosterConfig.CompressionLevel, "batch compression level")
+	f.Int(prefix+".compression-level", DefaultBatchPosterConfig.CompressionLevel, "batch compression level (0-11, higher levels use more CPU to produce smaller batches)")
+	f.Bool(prefix+".adaptive-compression", DefaultBatchPosterConfig.AdaptiveCompression, "try higher compression levels for each batch and post the smallest result")
+	f.Duration(prefix+".adaptive-compression-budget", DefaultBatchPosterConfig.AdaptiveCompressionBudget, "maximum time to spend trying alternative compression levels per batch when adaptive-compression is enabled")
 	f.Duration(prefix+".das-retention-period", DefaultBatchPosterConfig.DASRetentionPeriod, "In AnyTrust mode, the period which DASes are requested to retain the stored batches.")
 	f.String(prefix+".gas-refunder-address", DefaultBatchPosterConfig.GasRefunderAddress, "The gas refunder contract address (optional)")
 	f.Uint64(prefix+".extra-batch-gas", DefaultBatchPosterConfig.ExtraBatchGas, "use this much more gas than estimation says is necessary to post batches")
+	f.Float64(prefix+".min-l1-balance", DefaultBatchPosterConfig.MinL1Balance, "pause batch posting while the poster's L1 balance (in ETH) is below this amount (0 to disable)")
+	f.Int(prefix+".subscription-buffer", DefaultBatchPosterConfig.SubscriptionBuffer, "batches to buffer for each nitro_subscribe(\"batches\") subscriber before dropping it for falling behind")
+	f.Duration(prefix+".replacement-timeout", DefaultBatchPosterConfig.ReplacementTimeout, "if a batch transaction is still pending this long after it was last sent, replace it with a higher fee regardless of data-poster.replacement-times (0 to disable)")
 	f.String(prefix+".redis-url", DefaultBatchPosterConfig.RedisUrl, "if non-empty, the Redis URL to store queued transactions in")
 	RedisLockConfigAddOptions(prefix+".redis-lock", f)
 	dataposter.DataPosterConfigAddOptions(prefix+".data-poster", f)
@@ -107,24 +141,33 @@ var DefaultBatchPosterConfig = BatchPosterConfig{
 	BatchPollDelay:                     time.Second * 10,
 	PostingErrorDelay:                  time.Second * 10,
 	MaxBatchPostInterval:               time.Hour,
+	MinBatchPostDelay:                  0,
 	CompressionLevel:                   brotli.DefaultCompression,
+	AdaptiveCompression:                false,
+	AdaptiveCompressionBudget:          time.Second,
 	DASRetentionPeriod:                 time.Hour * 24 * 15,
 	GasRefunderAddress:                 "",
 	ExtraBatchGas:                      50_000,
+	MinL1Balance:                       0,
+	SubscriptionBuffer:                 16,
+	ReplacementTimeout:                 0,
 	DataPoster:                         dataposter.DefaultDataPosterConfig,
 }
 
 var TestBatchPosterConfig = BatchPosterConfig{
-	Enable:               true,
-	MaxBatchSize:         100000,
-	BatchPollDelay:       time.Millisecond * 10,
-	PostingErrorDelay:    time.Millisecond * 10,
-	MaxBatchPostInterval: 0,
-	CompressionLevel:     2,
-	DASRetentionPeriod:   time.Hour * 24 * 15,
-	GasRefunderAddress:   "",
-	ExtraBatchGas:        10_000,
-	DataPoster:           dataposter.TestDataPosterConfig,
+	Enable:                    true,
+	MaxBatchSize:              100000,
+	BatchPollDelay:            time.Millisecond * 10,
+	PostingErrorDelay:         time.Millisecond * 10,
+	MaxBatchPostInterval:      0,
+	CompressionLevel:          2,
+	AdaptiveCompression:       false,
+	AdaptiveCompressionBudget: time.Second,
+	DASRetentionPeriod:        time.Hour * 24 * 15,
+	GasRefunderAddress:        "",
+	ExtraBatchGas:             10_000,
+	SubscriptionBuffer:        16,
+	DataPoster:                dataposter.TestDataPosterConfig,
 }
 
 func NewBatchPoster(l1Reader *headerreader.HeaderReader, inbox *InboxTracker, streamer *TransactionStreamer, syncMonitor *SyncMonitor, config BatchPosterConfigFetcher, contractAddress common.Address, transactOpts *bind.TransactOpts, daWriter das.DataAvailabilityServiceWriter) (*BatchPoster, error) {
@@ -162,10 +205,12 @@ func NewBatchPoster(l1Reader *headerreader.HeaderReader, inbox *InboxTracker, st
 		daWriter:     daWriter,
 		redisLock:    redisLock,
 	}
+	b.batchNotifier = newBatchNotifier(func() int { return config().SubscriptionBuffer })
 	dataPosterConfigFetcher := func() *dataposter.DataPosterConfig {
 		return &config().DataPoster
 	}
-	b.dataPoster, err = dataposter.NewDataPoster(l1Reader, transactOpts, redisClient, redisLock, dataPosterConfigFetcher, b.getBatchPosterPosition)
+	replacementTimeout := func() time.Duration { return config().ReplacementTimeout }
+	b.dataPoster, err = dataposter.NewDataPoster(l1Reader, transactOpts, redisClient, redisLock, dataPosterConfigFetcher, replacementTimeout, b.getBatchPosterPosition)
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +249,8 @@ type batchSegments struct {
 	delayedMsg            uint64
 	sizeLimit             int
 	compressionLevel      int
+	adaptiveCompression   bool
+	adaptiveBudget        time.Duration
 	newUncompressedSize   int
 	totalUncompressedSize int
 	lastCompressedSize    int
@@ -223,12 +270,14 @@ func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig) *batchSegm
 		panic("MaxBatchSize too small")
 	}
 	return &batchSegments{
-		compressedBuffer: compressedBuffer,
-		compressedWriter: brotli.NewWriterLevel(compressedBuffer, config.CompressionLevel),
-		sizeLimit:        config.MaxBatchSize - 40, // TODO
-		compressionLevel: config.CompressionLevel,
-		rawSegments:      make([][]byte, 0, 128),
-		delayedMsg:       firstDelayed,
+		compressedBuffer:    compressedBuffer,
+		compressedWriter:    brotli.NewWriterLevel(compressedBuffer, config.CompressionLevel),
+		sizeLimit:           config.MaxBatchSize - 40, // TODO
+		compressionLevel:    config.CompressionLevel,
+		adaptiveCompression: config.AdaptiveCompression,
+		adaptiveBudget:      config.AdaptiveCompressionBudget,
+		rawSegments:         make([][]byte, 0, 128),
+		delayedMsg:          firstDelayed,
 	}
 }
 
@@ -412,12 +461,59 @@ func (s *batchSegments) CloseAndGetBytes() ([]byte, error) {
 		return nil, err
 	}
 	compressedBytes := s.compressedBuffer.Bytes()
+	if s.adaptiveCompression {
+		compressedBytes, err = s.tryAlternativeCompression(compressedBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
 	fullMsg := make([]byte, 1, len(compressedBytes)+1)
 	fullMsg[0] = arbstate.BrotliMessageHeaderByte
 	fullMsg = append(fullMsg, compressedBytes...)
 	return fullMsg, nil
 }
 
+func (s *batchSegments) compressAtLevel(level int) ([]byte, error) {
+	buffer := bytes.NewBuffer(make([]byte, 0, s.sizeLimit*2))
+	writer := brotli.NewWriterLevel(buffer, level)
+	for _, segment := range s.rawSegments {
+		encoded, err := rlp.EncodeToBytes(segment)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(encoded); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// tryAlternativeCompression recompresses the batch at higher levels until the budget runs out,
+// returning the smallest compressed data found.
+func (s *batchSegments) tryAlternativeCompression(compressed []byte) ([]byte, error) {
+	start := time.Now()
+	best := compressed
+	bestLevel := s.compressionLevel
+	for level := brotli.BestCompression; level > s.compressionLevel; level-- {
+		if time.Since(start) >= s.adaptiveBudget {
+			break
+		}
+		candidate, err := s.compressAtLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidate) < len(best) {
+			best = candidate
+			bestLevel = level
+		}
+	}
+	metrics.GetOrRegisterCounter(fmt.Sprintf("arb/batchposter/compression/winner/level_%d", bestLevel), nil).Inc(1)
+	return best, nil
+}
+
 func (b *BatchPoster) encodeAddBatch(seqNum *big.Int, prevMsgNum arbutil.MessageIndex, newMsgNum arbutil.MessageIndex, message []byte, delayedMsg uint64) ([]byte, error) {
 	method, ok := b.seqInboxABI.Methods["addSequencerL2BatchFromOrigin0"]
 	if !ok {
@@ -470,7 +566,9 @@ func (b *BatchPoster) estimateGas(ctx context.Context, sequencerMessage []byte,
 	return gas + b.config().ExtraBatchGas, nil
 }
 
-func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) error {
+// maybePostSequencerBatch posts a batch if one is due. With force, the pending messages are posted
+// without waiting for the batch to fill or for the posting delays to pass.
+func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context, force bool) error {
 	nonce, batchPosition, err := b.dataPoster.GetNextNonceAndMeta(ctx)
 	if err != nil {
 		return err
@@ -498,7 +596,11 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) error {
 	nextMessageTime := time.Unix(int64(firstMsg.Message.Header.Timestamp), 0)
 
 	config := b.config()
-	forcePostBatch := time.Since(nextMessageTime) >= config.MaxBatchPostInterval
+	if !force && time.Since(nextMessageTime) < config.MinBatchPostDelay {
+		// give more messages a chance to accumulate before posting
+		return nil
+	}
+	forcePostBatch := force || time.Since(nextMessageTime) >= config.MaxBatchPostInterval
 	haveUsefulMessage := false
 
 	for b.building.msgCount < msgCount {
@@ -530,6 +632,13 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) error {
 		// don't post anything for now
 		return nil
 	}
+	sufficientBalance, err := b.checkL1Balance(ctx, config)
+	if err != nil {
+		return err
+	}
+	if !sufficientBalance {
+		return nil
+	}
 	sequencerMsg, err := b.building.segments.CloseAndGetBytes()
 	if err != nil {
 		return err
@@ -539,6 +648,14 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) error {
 		b.building = nil // a closed batchSegments can't be reused
 		return nil
 	}
+	uncompressedSize := b.building.segments.totalUncompressedSize
+	compressedSize := len(sequencerMsg)
+	batchPosterUncompressedSizeHistogram.Update(int64(uncompressedSize))
+	batchPosterCompressedSizeHistogram.Update(int64(compressedSize))
+	batchPosterMessagesPerBatchHistogram.Update(int64(b.building.msgCount - batchPosition.MessageCount))
+	if uncompressedSize > 0 {
+		batchPosterCompressionRatioGauge.Update(float64(compressedSize) / float64(uncompressedSize))
+	}
 
 	if b.daWriter != nil {
 		cert, err := b.daWriter.Store(ctx, sequencerMsg, uint64(time.Now().Add(config.DASRetentionPeriod).Unix()), []byte{}) // b.daWriter will append signature if enabled
@@ -565,7 +682,13 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) error {
 		DelayedMessageCount: b.building.segments.delayedMsg,
 		NextSeqNum:          batchPosition.NextSeqNum + 1,
 	}
-	err = b.dataPoster.PostTransaction(ctx, nextMessageTime, nonce, newMeta, b.seqInboxAddr, data, gasLimit)
+	if b.coordinator != nil {
+		// don't post messages sequenced by a node that lost the chosen lock without noticing
+		if err := b.coordinator.CheckFencing(ctx); err != nil {
+			return err
+		}
+	}
+	tx, err := b.dataPoster.PostTransaction(ctx, nextMessageTime, nonce, newMeta, b.seqInboxAddr, data, gasLimit)
 	if err != nil {
 		return err
 	}
@@ -577,11 +700,124 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) error {
 		"prev delayed", batchPosition.DelayedMessageCount,
 		"current delayed", b.building.segments.delayedMsg,
 		"total segments", len(b.building.segments.rawSegments),
+		"uncompressed size", uncompressedSize,
+		"compressed size", compressedSize,
 	)
+	b.notifyBatchPosted(batchPosition, b.building.msgCount, tx, len(sequencerMsg), uncompressedSize)
 	b.building = nil
 	return nil
 }
 
+func (b *BatchPoster) notifyBatchPosted(batchPosition batchPosterPosition, msgCount arbutil.MessageIndex, tx *types.Transaction, size int, uncompressedSize int) {
+	firstBlock, err := b.streamer.MessageCountToBlockNumber(batchPosition.MessageCount + 1)
+	if err != nil {
+		log.Warn("error getting first block of posted batch", "err", err)
+		return
+	}
+	lastBlock, err := b.streamer.MessageCountToBlockNumber(msgCount)
+	if err != nil {
+		log.Warn("error getting last block of posted batch", "err", err)
+		return
+	}
+	b.batchNotifier.notify(BatchPostedEvent{
+		BatchIndex:       hexutil.Uint64(batchPosition.NextSeqNum),
+		L1TxHash:         tx.Hash(),
+		FirstBlock:       hexutil.Uint64(firstBlock),
+		LastBlock:        hexutil.Uint64(lastBlock),
+		Size:             hexutil.Uint64(size),
+		UncompressedSize: hexutil.Uint64(uncompressedSize),
+	})
+}
+
+// checkL1Balance updates the balance gauge and returns false if posting should pause due to a low balance.
+// With the check disabled it doesn't query L1 at all.
+func (b *BatchPoster) checkL1Balance(ctx context.Context, config *BatchPosterConfig) (bool, error) {
+	if config.MinL1Balance <= 0 {
+		return true, nil
+	}
+	balance, err := b.l1Reader.Client().BalanceAt(ctx, b.dataPoster.From(), nil)
+	if err != nil {
+		return false, fmt.Errorf("error getting batch poster L1 balance: %w", err)
+	}
+	balanceFloat, _ := new(big.Float).SetInt(balance).Float64()
+	batchPosterBalanceGauge.Update(balanceFloat)
+	if balanceFloat < config.MinL1Balance*params.Ether {
+		log.Error("batch poster L1 balance is below the configured minimum, pausing batch posting", "address", b.dataPoster.From(), "balance", balance, "minBalanceEth", config.MinL1Balance)
+		return false, nil
+	}
+	return true, nil
+}
+
+type BatchPostingBacklog struct {
+	MessageCount        hexutil.Uint64  `json:"messageCount"`
+	PostedMessageCount  hexutil.Uint64  `json:"postedMessageCount"`
+	UnpostedMessages    hexutil.Uint64  `json:"unpostedMessages"`
+	OldestUnpostedAge   string          `json:"oldestUnpostedAge,omitempty"`
+	OldestUnpostedBlock *hexutil.Uint64 `json:"oldestUnpostedBlock,omitempty"`
+	oldestUnpostedAge   time.Duration
+}
+
+// batchPostingBacklog compares the messages the streamer has with those in batches seen on L1.
+// Each message is one L2 block.
+func batchPostingBacklog(inbox *InboxTracker, streamer *TransactionStreamer) (*BatchPostingBacklog, error) {
+	msgCount, err := streamer.GetMessageCount()
+	if err != nil {
+		return nil, err
+	}
+	batchCount, err := inbox.GetBatchCount()
+	if err != nil {
+		return nil, err
+	}
+	var postedCount arbutil.MessageIndex
+	if batchCount > 0 {
+		postedCount, err = inbox.GetBatchMessageCount(batchCount - 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	backlog := &BatchPostingBacklog{
+		MessageCount:       hexutil.Uint64(msgCount),
+		PostedMessageCount: hexutil.Uint64(postedCount),
+	}
+	if msgCount <= postedCount {
+		return backlog, nil
+	}
+	backlog.UnpostedMessages = hexutil.Uint64(msgCount - postedCount)
+	oldest, err := streamer.GetMessage(postedCount)
+	if err != nil {
+		return nil, err
+	}
+	block := hexutil.Uint64(arbutil.MessageCountToBlockNumber(postedCount+1, streamer.bc.Config().ArbitrumChainParams.GenesisBlockNum))
+	backlog.OldestUnpostedBlock = &block
+	if oldest.Message != nil && oldest.Message.Header != nil {
+		backlog.oldestUnpostedAge = time.Since(time.Unix(int64(oldest.Message.Header.Timestamp), 0))
+		backlog.OldestUnpostedAge = backlog.oldestUnpostedAge.Round(time.Second).String()
+	}
+	return backlog, nil
+}
+
+func (b *BatchPoster) updateBacklogMetrics() {
+	backlog, err := batchPostingBacklog(b.inbox, b.streamer)
+	if err != nil {
+		log.Warn("error computing batch posting backlog", "err", err)
+		return
+	}
+	batchPosterBacklogMessagesGauge.Update(int64(backlog.UnpostedMessages))
+	batchPosterBacklogAgeGauge.Update(int64(backlog.oldestUnpostedAge / time.Second))
+}
+
+// BumpL1Tx replaces a pending batch posting transaction with a higher fee
+func (b *BatchPoster) BumpL1Tx(ctx context.Context, nonce uint64) error {
+	return b.dataPoster.BumpTransaction(ctx, nonce)
+}
+
+func (b *BatchPoster) SetSeqCoordinator(coordinator *SeqCoordinator) {
+	if b.Started() {
+		panic("trying to set coordinator after start")
+	}
+	b.coordinator = coordinator
+}
+
 func (b *BatchPoster) Start(ctxIn context.Context) {
 	b.dataPoster.Start(ctxIn)
 	b.redisLock.Start(ctxIn)
@@ -591,7 +827,8 @@ func (b *BatchPoster) Start(ctxIn context.Context) {
 			b.building = nil
 			return b.config().BatchPollDelay
 		}
-		err := b.maybePostSequencerBatch(ctx)
+		err := b.maybePostSequencerBatch(ctx, false)
+		b.updateBacklogMetrics()
 		if err != nil {
 			b.building = nil
 			logLevel := log.Error
@@ -619,3 +856,32 @@ func (b *BatchPoster) StopAndWait() {
 	b.dataPoster.StopAndWait()
 	b.redisLock.StopAndWait()
 }
+
+// FlushAndStop stops the posting loop, then posts the messages not yet in a batch until they're all
+// posted or ctx expires, before stopping like StopAndWait
+func (b *BatchPoster) FlushAndStop(ctx context.Context) {
+	b.StopWaiter.StopAndWait()
+	for ctx.Err() == nil && b.redisLock.AttemptLock(ctx) {
+		_, position, err := b.dataPoster.GetNextNonceAndMeta(ctx)
+		if err != nil {
+			log.Error("error reading batch poster position before stopping", "err", err)
+			break
+		}
+		msgCount, err := b.streamer.GetMessageCount()
+		if err != nil || msgCount <= position.MessageCount {
+			break
+		}
+		if err := b.maybePostSequencerBatch(ctx, true); err != nil {
+			log.Error("error posting final batch", "err", err)
+			break
+		}
+		_, posted, err := b.dataPoster.GetNextNonceAndMeta(ctx)
+		if err != nil || posted.MessageCount == position.MessageCount {
+			// nothing more could be posted, such as with too low a balance
+			break
+		}
+		log.Info("posted batch before stopping", "from", position.MessageCount, "to", posted.MessageCount, "messages", msgCount)
+	}
+	b.dataPoster.StopAndWait()
+	b.redisLock.StopAndWait()
+}
@@ -0,0 +1,95 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+type TrieCacheStats struct {
+	DirtySize     common.StorageSize `json:"dirtySize"`
+	PreimagesSize common.StorageSize `json:"preimagesSize"`
+	CleanLimit    common.StorageSize `json:"cleanLimit"`
+	CleanHits     int64              `json:"cleanHits"`
+	CleanMisses   int64              `json:"cleanMisses"`
+	CleanHitRatio *float64           `json:"cleanHitRatio,omitempty"`
+	CleanRead     common.StorageSize `json:"cleanRead"`
+	CleanWritten  common.StorageSize `json:"cleanWritten"`
+}
+
+type DatabaseStats struct {
+	Name       string `json:"name"`
+	Compaction string `json:"compaction,omitempty"`
+	IO         string `json:"io,omitempty"`
+}
+
+type MemoryStats struct {
+	HeapAlloc common.StorageSize `json:"heapAlloc"`
+	HeapInuse common.StorageSize `json:"heapInuse"`
+	Sys       common.StorageSize `json:"sys"`
+	NumGC     uint32             `json:"numGC"`
+}
+
+type CacheStats struct {
+	Memory    MemoryStats     `json:"memory"`
+	Trie      TrieCacheStats  `json:"trie"`
+	Databases []DatabaseStats `json:"databases"`
+}
+
+// registeredCount reads one of geth's trie/memcache/clean meters, which are zero if metrics are disabled
+func registeredCount(name string) int64 {
+	switch m := metrics.DefaultRegistry.Get(name).(type) {
+	case metrics.Meter:
+		return m.Count()
+	case metrics.Counter:
+		return m.Count()
+	}
+	return 0
+}
+
+func trieCacheStats(blockchain *core.BlockChain, cachingConfig *CachingConfig) TrieCacheStats {
+	dirty, preimages := blockchain.StateCache().TrieDB().Size()
+	stats := TrieCacheStats{
+		DirtySize:     dirty,
+		PreimagesSize: preimages,
+		CleanLimit:    common.StorageSize(trieCleanCacheLimit(cachingConfig)) * 1024 * 1024,
+		CleanHits:     registeredCount("trie/memcache/clean/hit"),
+		CleanMisses:   registeredCount("trie/memcache/clean/miss"),
+		CleanRead:     common.StorageSize(registeredCount("trie/memcache/clean/read")),
+		CleanWritten:  common.StorageSize(registeredCount("trie/memcache/clean/write")),
+	}
+	if lookups := stats.CleanHits + stats.CleanMisses; lookups > 0 {
+		ratio := float64(stats.CleanHits) / float64(lookups)
+		stats.CleanHitRatio = &ratio
+	}
+	return stats
+}
+
+// databaseStats returns the database's own statistics, which are empty for backends that don't report them
+func databaseStats(name string, db ethdb.Database) DatabaseStats {
+	stats := DatabaseStats{Name: name}
+	if compaction, err := db.Stat("leveldb.stats"); err == nil {
+		stats.Compaction = compaction
+	}
+	if io, err := db.Stat("leveldb.iostats"); err == nil {
+		stats.IO = io
+	}
+	return stats
+}
+
+func memoryStats() MemoryStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return MemoryStats{
+		HeapAlloc: common.StorageSize(mem.HeapAlloc),
+		HeapInuse: common.StorageSize(mem.HeapInuse),
+		Sys:       common.StorageSize(mem.Sys),
+		NumGC:     mem.NumGC,
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type BackupConfig struct {
+	Enable   bool          `koanf:"enable"`
+	Interval time.Duration `koanf:"interval"`
+	Dir      string        `koanf:"dir"`
+	Retain   int           `koanf:"retain"`
+}
+
+var DefaultBackupConfig = BackupConfig{
+	Enable:   false,
+	Interval: 24 * time.Hour,
+	Dir:      "",
+	Retain:   3,
+}
+
+func BackupConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultBackupConfig.Enable, "periodically back up the chain databases")
+	f.Duration(prefix+".interval", DefaultBackupConfig.Interval, "how often to back up the chain databases")
+	f.String(prefix+".dir", DefaultBackupConfig.Dir, "directory to write backups to, each in its own timestamped subdirectory")
+	f.Int(prefix+".retain", DefaultBackupConfig.Retain, "number of backups to keep, deleting the oldest")
+}
+
+func (c *BackupConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Dir == "" {
+		return errors.New("backups enabled but no backup directory set")
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("invalid backup interval %v", c.Interval)
+	}
+	if c.Retain < 1 {
+		return fmt.Errorf("must retain at least one backup, not %v", c.Retain)
+	}
+	return nil
+}
+
+const (
+	backupDirPrefix     = "nitro-backup-"
+	backupTimeFormat    = "20060102-150405"
+	backupBatchSize     = 16 * 1024 * 1024
+	backupIncompleteTag = ".incomplete"
+	// blocks copied to the backup's freezer per write, which syncs its files
+	backupAncientBatchBlocks = 10000
+)
+
+// The tables of the chain freezer, which holds blocks too old to be reorged
+var backupAncientTables = []string{"hashes", "headers", "bodies", "receipts", "diffs"}
+
+type ChainBackup struct {
+	stopwaiter.StopWaiter
+	config  *BackupConfig
+	chainDb ethdb.Database
+	arbDb   ethdb.Database
+}
+
+func NewChainBackup(config *BackupConfig, chainDb ethdb.Database, arbDb ethdb.Database) *ChainBackup {
+	return &ChainBackup{
+		config:  config,
+		chainDb: chainDb,
+		arbDb:   arbDb,
+	}
+}
+
+func (b *ChainBackup) Start(ctxIn context.Context) {
+	b.StopWaiter.Start(ctxIn, b)
+	// the first backup waits an interval too, rather than adding to the load of starting up
+	b.LaunchThread(func(ctx context.Context) {
+		for {
+			timer := time.NewTimer(b.config.Interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if err := b.Backup(ctx); err != nil {
+				log.Error("chain database backup failed", "err", err)
+			}
+		}
+	})
+}
+
+// Backup writes a consistent copy of the databases to a new directory, then deletes backups past the retention limit
+func (b *ChainBackup) Backup(ctx context.Context) error {
+	start := time.Now()
+	name := backupDirPrefix + start.UTC().Format(backupTimeFormat)
+	tmpDir := filepath.Join(b.config.Dir, name+backupIncompleteTag)
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return err
+	}
+	if err := backupDatabase(ctx, b.chainDb, filepath.Join(tmpDir, "l2chaindata"), true); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("error backing up l2chaindata: %w", err)
+	}
+	if err := backupDatabase(ctx, b.arbDb, filepath.Join(tmpDir, "arbitrumdata"), false); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("error backing up arbitrumdata: %w", err)
+	}
+	dir := filepath.Join(b.config.Dir, name)
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return err
+	}
+	size, err := dirSize(dir)
+	if err != nil {
+		log.Warn("error measuring chain database backup", "dir", dir, "err", err)
+	}
+	log.Info("backed up chain databases", "dir", dir, "size", common.StorageSize(size), "elapsed", time.Since(start))
+	return b.pruneBackups()
+}
+
+// backupDatabase copies a database through one iterator, which reads from a single snapshot of the key-value
+// store. Ancient data is copied afterwards, since it's only ever appended to with blocks no longer in the snapshot.
+func backupDatabase(ctx context.Context, db ethdb.Database, dir string, withAncients bool) error {
+	var backup ethdb.Database
+	var err error
+	if withAncients {
+		backup, err = rawdb.NewLevelDBDatabaseWithFreezer(dir, 16, 16, filepath.Join(dir, "ancient"), "", false)
+	} else {
+		backup, err = rawdb.NewLevelDBDatabase(dir, 16, 16, "", false)
+	}
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	batch := backup.NewBatch()
+	for it.Next() {
+		if batch.ValueSize() >= backupBatchSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if !withAncients {
+		return nil
+	}
+
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	for start := uint64(0); start < frozen; start += backupAncientBatchBlocks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := start + backupAncientBatchBlocks
+		if end > frozen {
+			end = frozen
+		}
+		_, err := backup.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for number := start; number < end; number++ {
+				for _, table := range backupAncientTables {
+					item, err := db.Ancient(table, number)
+					if err != nil {
+						return fmt.Errorf("error reading ancient %v %v: %w", table, number, err)
+					}
+					if err := op.AppendRaw(table, number, item); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneBackups deletes the oldest complete backups beyond the retention limit
+func (b *ChainBackup) pruneBackups() error {
+	entries, err := os.ReadDir(b.config.Dir)
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() && strings.HasPrefix(name, backupDirPrefix) && !strings.HasSuffix(name, backupIncompleteTag) {
+			backups = append(backups, name)
+		}
+	}
+	// timestamped names sort chronologically
+	sort.Strings(backups)
+	for len(backups) > b.config.Retain {
+		dir := filepath.Join(b.config.Dir, backups[0])
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		log.Info("deleted old chain database backup", "dir", dir)
+		backups = backups[1:]
+	}
+	return nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
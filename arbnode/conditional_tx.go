@@ -0,0 +1,152 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	flag "github.com/spf13/pflag"
+)
+
+var ErrConditionNotMet = errors.New("transaction condition not met")
+
+// the cost of checking an account's storage root, which copies its storage trie to apply the
+// block's pending writes and hashes it, against 1 for reading a storage slot
+const storageRootCheckCost = 100
+
+type ConditionalTxConfig struct {
+	Enable  bool `koanf:"enable" reload:"hot"`
+	MaxCost int  `koanf:"max-cost" reload:"hot"`
+}
+
+var DefaultConditionalTxConfig = ConditionalTxConfig{
+	Enable:  false,
+	MaxCost: 1000,
+}
+
+func ConditionalTxConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultConditionalTxConfig.Enable, "serve eth_sendRawTransactionConditional, which the sequencer includes only if its conditions hold")
+	f.Int(prefix+".max-cost", DefaultConditionalTxConfig.MaxCost, "maximum cost of the conditions of a conditional transaction, where each storage slot checked costs 1 and each storage root 100")
+}
+
+func (c *ConditionalTxConfig) Validate() error {
+	if c.MaxCost < 1 {
+		return fmt.Errorf("conditional-tx max-cost must be positive, not %v", c.MaxCost)
+	}
+	return nil
+}
+
+// RootHashOrSlots is a known account's condition, either the root hash of its storage or the
+// values of some of its storage slots
+type RootHashOrSlots struct {
+	RootHash  *common.Hash
+	SlotValue map[common.Hash]common.Hash
+}
+
+func (r *RootHashOrSlots) UnmarshalJSON(data []byte) error {
+	var hash common.Hash
+	if err := json.Unmarshal(data, &hash); err == nil {
+		r.RootHash = &hash
+		return nil
+	}
+	return json.Unmarshal(data, &r.SlotValue)
+}
+
+func (r RootHashOrSlots) MarshalJSON() ([]byte, error) {
+	if r.RootHash != nil {
+		return json.Marshal(*r.RootHash)
+	}
+	return json.Marshal(r.SlotValue)
+}
+
+// ConditionalOptions are the conditions, all checked against the block being built, under which a
+// conditional transaction may be included
+type ConditionalOptions struct {
+	KnownAccounts  map[common.Address]RootHashOrSlots `json:"knownAccounts"`
+	BlockNumberMin *hexutil.Uint64                    `json:"blockNumberMin,omitempty"`
+	BlockNumberMax *hexutil.Uint64                    `json:"blockNumberMax,omitempty"`
+	TimestampMin   *hexutil.Uint64                    `json:"timestampMin,omitempty"`
+	TimestampMax   *hexutil.Uint64                    `json:"timestampMax,omitempty"`
+}
+
+// Cost weighs the storage slots and roots the options check by how much work they take while the
+// block is built
+func (o *ConditionalOptions) Cost() int {
+	cost := 0
+	for _, account := range o.KnownAccounts {
+		if account.RootHash != nil {
+			cost += storageRootCheckCost
+		} else {
+			cost += len(account.SlotValue)
+		}
+	}
+	return cost
+}
+
+func (o *ConditionalOptions) Check(header *types.Header, statedb *state.StateDB) error {
+	blockNumber := header.Number.Uint64()
+	if o.BlockNumberMin != nil && blockNumber < uint64(*o.BlockNumberMin) {
+		return fmt.Errorf("%w: block number %v is before the minimum %v", ErrConditionNotMet, blockNumber, *o.BlockNumberMin)
+	}
+	if o.BlockNumberMax != nil && blockNumber > uint64(*o.BlockNumberMax) {
+		return fmt.Errorf("%w: block number %v is after the maximum %v", ErrConditionNotMet, blockNumber, *o.BlockNumberMax)
+	}
+	if o.TimestampMin != nil && header.Time < uint64(*o.TimestampMin) {
+		return fmt.Errorf("%w: timestamp %v is before the minimum %v", ErrConditionNotMet, header.Time, *o.TimestampMin)
+	}
+	if o.TimestampMax != nil && header.Time > uint64(*o.TimestampMax) {
+		return fmt.Errorf("%w: timestamp %v is after the maximum %v", ErrConditionNotMet, header.Time, *o.TimestampMax)
+	}
+	for address, account := range o.KnownAccounts {
+		if account.RootHash != nil {
+			root := types.EmptyRootHash
+			if trie := statedb.StorageTrie(address); trie != nil {
+				root = trie.Hash()
+			}
+			if root != *account.RootHash {
+				return fmt.Errorf("%w: storage root of %v is %v, not %v", ErrConditionNotMet, address, root, *account.RootHash)
+			}
+			continue
+		}
+		for slot, value := range account.SlotValue {
+			if actual := statedb.GetState(address, slot); actual != value {
+				return fmt.Errorf("%w: storage slot %v of %v is %v, not %v", ErrConditionNotMet, slot, address, actual, value)
+			}
+		}
+	}
+	return nil
+}
+
+// ConditionalTxAPI serves eth_sendRawTransactionConditional alongside geth's eth namespace
+type ConditionalTxAPI struct {
+	txPublisher TransactionPublisher
+	config      func() *ConditionalTxConfig
+}
+
+// SendRawTransactionConditional submits a transaction the sequencer only includes if the options'
+// conditions hold for the block it would be included in
+func (api *ConditionalTxAPI) SendRawTransactionConditional(ctx context.Context, input hexutil.Bytes, options ConditionalOptions) (common.Hash, error) {
+	config := api.config()
+	if !config.Enable {
+		return common.Hash{}, errors.New("eth_sendRawTransactionConditional is disabled on this node")
+	}
+	if cost := options.Cost(); cost > config.MaxCost {
+		return common.Hash{}, fmt.Errorf("conditions cost %v to check, more than the limit of %v", cost, config.MaxCost)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if err := api.txPublisher.PublishConditionalTransaction(ctx, tx, &options); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
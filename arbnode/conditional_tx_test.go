@@ -0,0 +1,55 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestConditionalOptions(t *testing.T) {
+	input := `{
+		"knownAccounts": {
+			"0x000000000000000000000000000000000000aaaa": "0x1111111111111111111111111111111111111111111111111111111111111111",
+			"0x000000000000000000000000000000000000bbbb": {
+				"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002",
+				"0x0000000000000000000000000000000000000000000000000000000000000003": "0x0000000000000000000000000000000000000000000000000000000000000004"
+			}
+		},
+		"blockNumberMin": "0x10",
+		"blockNumberMax": "0x20",
+		"timestampMax": "0x1000"
+	}`
+	var options ConditionalOptions
+	Require(t, json.Unmarshal([]byte(input), &options))
+
+	rootAccount := options.KnownAccounts[common.HexToAddress("0xaaaa")]
+	if rootAccount.RootHash == nil || *rootAccount.RootHash != common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111") {
+		Fail(t, "expected a storage root condition, got", rootAccount)
+	}
+	slotAccount := options.KnownAccounts[common.HexToAddress("0xbbbb")]
+	if slotAccount.RootHash != nil || len(slotAccount.SlotValue) != 2 {
+		Fail(t, "expected two storage slot conditions, got", slotAccount)
+	}
+	if cost := options.Cost(); cost != storageRootCheckCost+2 {
+		Fail(t, "expected a storage root and two slots to cost", storageRootCheckCost+2, "got", cost)
+	}
+
+	options.KnownAccounts = nil
+	check := func(number int64, time uint64) error {
+		return options.Check(&types.Header{Number: big.NewInt(number), Time: time}, nil)
+	}
+	Require(t, check(0x10, 0x1000))
+	Require(t, check(0x20, 0))
+	for _, header := range [][2]int64{{0xf, 0}, {0x21, 0}, {0x18, 0x1001}} {
+		if err := check(header[0], uint64(header[1])); !errors.Is(err, ErrConditionNotMet) {
+			Fail(t, "expected block", header[0], "at time", header[1], "to fail the conditions, got", err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Recommended ranges for options that are valid but ill-advised outside them
+const (
+	MaxRecommendedBlockSpeed        = time.Second
+	MinRecommendedL1PollInterval    = time.Second
+	MinRecommendedBatchPollDelay    = time.Second
+	MaxRecommendedBatchPostInterval = 12 * time.Hour
+	MinRecommendedStakerInterval    = 10 * time.Second
+	MaxRecommendedFeeCapGwei        = 1000.
+)
+
+func warnIfBelow(option string, value, recommended time.Duration) {
+	if value < recommended {
+		log.Warn("option is below its recommended minimum", "option", option, "value", value, "recommended", recommended)
+	}
+}
+
+func warnIfAbove(option string, value, recommended time.Duration) {
+	if value > recommended {
+		log.Warn("option is above its recommended maximum", "option", option, "value", value, "recommended", recommended)
+	}
+}
+
+// WarnUnrecommended logs warnings for enabled features configured outside their recommended ranges
+func (c *Config) WarnUnrecommended() {
+	if c.Sequencer.Enable {
+		warnIfAbove("node.sequencer.max-block-speed", c.Sequencer.MaxBlockSpeed, MaxRecommendedBlockSpeed)
+	}
+	if c.L1Reader.Enable {
+		warnIfBelow("node.l1-reader.poll-interval", c.L1Reader.PollInterval, MinRecommendedL1PollInterval)
+	}
+	if c.BatchPoster.Enable {
+		warnIfBelow("node.batch-poster.poll-delay", c.BatchPoster.BatchPollDelay, MinRecommendedBatchPollDelay)
+		warnIfAbove("node.batch-poster.max-interval", c.BatchPoster.MaxBatchPostInterval, MaxRecommendedBatchPostInterval)
+		if c.BatchPoster.DataPoster.MaxFeeCapGwei > MaxRecommendedFeeCapGwei {
+			log.Warn(
+				"option is above its recommended maximum",
+				"option", "node.batch-poster.data-poster.max-fee-cap-gwei",
+				"value", c.BatchPoster.DataPoster.MaxFeeCapGwei,
+				"recommended", MaxRecommendedFeeCapGwei,
+			)
+		}
+	}
+	if c.Validator.Enable {
+		warnIfBelow("node.validator.staker-interval", c.Validator.StakerInterval, MinRecommendedStakerInterval)
+	}
+}
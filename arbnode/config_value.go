@@ -0,0 +1,193 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const redactedConfigValue = "<redacted>"
+
+// GetConfigValue returns the current value of a node config option, like
+// "node.sequencer.max-block-speed", or of every option under a prefix like "node.sequencer".
+// Secrets and URL passwords are redacted.
+func (api *NitroAPI) GetConfigValue(path string) (interface{}, error) {
+	if api.config == nil {
+		return nil, errors.New("config not available")
+	}
+	return configValue(api.config.Get(), "node", path)
+}
+
+// RedactedConfigValue returns the value of an option as nitro_getConfigValue does, with structs of
+// options as nested maps and secrets redacted. The whole value is redacted if secret is set.
+func RedactedConfigValue(secret bool, value interface{}) interface{} {
+	return redactConfigValue(secret, reflect.ValueOf(value))
+}
+
+// SecretConfigField returns whether a config field is tagged secret:"true", redacting its value and
+// those of any options under it
+func SecretConfigField(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// configValue resolves path, which starts with prefix, against the koanf tags of config
+func configValue(config interface{}, prefix string, path string) (interface{}, error) {
+	value := reflect.ValueOf(config)
+	secret := false
+	if path != prefix {
+		if !strings.HasPrefix(path, prefix+".") {
+			return nil, fmt.Errorf("unknown config path %q, only %v options are available", path, prefix)
+		}
+		for _, part := range strings.Split(strings.TrimPrefix(path, prefix+"."), ".") {
+			field, fieldSecret, ok := configField(value, part)
+			if !ok {
+				return nil, fmt.Errorf("unknown config path %q", path)
+			}
+			value = field
+			secret = secret || fieldSecret
+		}
+	}
+	return redactConfigValue(secret, value), nil
+}
+
+// configField returns the field of the struct value with the koanf tag name, and whether it's secret
+func configField(value reflect.Value, name string) (reflect.Value, bool, bool) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}, false, false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, false, false
+	}
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if SquashedConfigField(field) {
+			if nested, secret, ok := configField(value.Field(i), name); ok {
+				return nested, secret || SecretConfigField(field), true
+			}
+			continue
+		}
+		if field.Tag.Get("koanf") == name {
+			return value.Field(i), SecretConfigField(field), true
+		}
+	}
+	return reflect.Value{}, false, false
+}
+
+// SquashedConfigField returns whether the options of a struct field are inlined into its parent's,
+// like the embedded geth config tagged koanf:",squash" in RPCConfig
+func SquashedConfigField(field reflect.StructField) bool {
+	tag := field.Tag.Get("koanf")
+	if field.Anonymous && tag == "" {
+		return true
+	}
+	_, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "squash" {
+			return true
+		}
+	}
+	return false
+}
+
+// redactConfigValue converts the value of an option to what the RPC returns, a map of option names
+// for a struct and a duration as a string, with secrets redacted
+func redactConfigValue(secret bool, value reflect.Value) interface{} {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if duration, ok := value.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		options := make(map[string]interface{})
+		valueType := value.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldSecret := secret || SecretConfigField(field)
+			if SquashedConfigField(field) {
+				if nested, ok := redactConfigValue(fieldSecret, value.Field(i)).(map[string]interface{}); ok {
+					for tag, option := range nested {
+						options[tag] = option
+					}
+				}
+				continue
+			}
+			tag := field.Tag.Get("koanf")
+			if tag == "" {
+				continue
+			}
+			options[tag] = redactConfigValue(fieldSecret, value.Field(i))
+		}
+		return options
+	case reflect.String:
+		if secret && value.String() != "" {
+			return redactedConfigValue
+		}
+		return redactURLPassword(value.String())
+	case reflect.Slice:
+		if secret && value.Len() > 0 {
+			return redactedConfigValue
+		}
+		if value.Type().Elem().Kind() == reflect.String {
+			values := make([]string, value.Len())
+			for i := range values {
+				values[i] = redactURLPassword(value.Index(i).String())
+			}
+			return values
+		}
+	}
+	return value.Interface()
+}
+
+// redactURLPassword hides the password of a URL, such as a redis URL, leaving other strings alone
+func redactURLPassword(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+	if _, set := parsed.User.Password(); !set {
+		return value
+	}
+	return parsed.Redacted()
+}
+
+// IsRedactedConfigValue returns whether value is a secret or a URL with its password redacted, as
+// written by RedactedConfigValue
+func IsRedactedConfigValue(value string) bool {
+	if value == redactedConfigValue {
+		return true
+	}
+	if !strings.Contains(value, "@") {
+		return false
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return false
+	}
+	// the placeholder url.URL.Redacted writes for the password
+	password, set := parsed.User.Password()
+	return set && password == "xxxxx"
+}
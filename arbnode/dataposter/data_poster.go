@@ -12,10 +12,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/go-redis/redis/v8"
 	"github.com/offchainlabs/nitro/arbutil"
@@ -26,6 +28,12 @@ import (
 	flag "github.com/spf13/pflag"
 )
 
+var (
+	replacementCounter = metrics.NewRegisteredCounter("arb/dataposter/replacements", nil)
+	tipCapGauge        = metrics.NewRegisteredGauge("arb/dataposter/tip_cap", nil)
+	revertedCounter    = metrics.NewRegisteredCounter("arb/dataposter/reverted", nil)
+)
+
 type queuedTransaction[Meta any] struct {
 	FullTx          *types.Transaction
 	Data            types.DynamicFeeTx
@@ -33,6 +41,7 @@ type queuedTransaction[Meta any] struct {
 	Sent            bool
 	Created         time.Time // may be earlier than the tx was given to the tx poster
 	NextReplacement time.Time
+	Replaced        []common.Hash `rlp:"optional"` // hashes of the earlier versions of FullTx, any of which may be included
 }
 
 type QueueStorage[Item any] interface {
@@ -48,6 +57,11 @@ type DataPosterConfig struct {
 	L1LookBehind      uint64                     `koanf:"l1-look-behind" reload:"hot"`
 	MaxFeeCapGwei     float64                    `koanf:"max-fee-cap-gwei" reload:"hot"`
 	MaxFeeCapDoubling time.Duration              `koanf:"max-fee-cap-doubling" reload:"hot"`
+	PriorityFee       PriorityFeeConfig          `koanf:"priority-fee" reload:"hot"`
+}
+
+func (c *DataPosterConfig) Validate() error {
+	return c.PriorityFee.Validate()
 }
 
 type DataPosterConfigFetcher func() *DataPosterConfig
@@ -58,6 +72,7 @@ func DataPosterConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Float64(prefix+".max-fee-cap-gwei", DefaultDataPosterConfig.MaxFeeCapGwei, "the maximum fee cap to use, doubled every max-fee-cap-doubling")
 	f.Duration(prefix+".max-fee-cap-doubling", DefaultDataPosterConfig.MaxFeeCapDoubling, "after this duration, double the fee cap (repeats)")
 	signature.SimpleHmacConfigAddOptions(prefix+".redis-signer", f)
+	PriorityFeeConfigAddOptions(prefix+".priority-fee", f)
 }
 
 var DefaultDataPosterConfig = DataPosterConfig{
@@ -65,6 +80,7 @@ var DefaultDataPosterConfig = DataPosterConfig{
 	L1LookBehind:      2,
 	MaxFeeCapGwei:     100.,
 	MaxFeeCapDoubling: 2 * time.Hour,
+	PriorityFee:       DefaultPriorityFeeConfig,
 }
 
 var TestDataPosterConfig = DataPosterConfig{
@@ -73,18 +89,20 @@ var TestDataPosterConfig = DataPosterConfig{
 	L1LookBehind:      0,
 	MaxFeeCapGwei:     100.,
 	MaxFeeCapDoubling: 5 * time.Second,
+	PriorityFee:       DefaultPriorityFeeConfig,
 }
 
 // DataPoster must be RLP serializable and deserializable
 type DataPoster[Meta any] struct {
 	stopwaiter.StopWaiter
-	headerReader      *headerreader.HeaderReader
-	client            arbutil.L1Interface
-	auth              *bind.TransactOpts
-	redisLock         AttemptLocker
-	config            DataPosterConfigFetcher
-	replacementTimes  []time.Duration
-	metadataRetriever func(ctx context.Context, blockNum *big.Int) (Meta, error)
+	headerReader       *headerreader.HeaderReader
+	client             arbutil.L1Interface
+	auth               *bind.TransactOpts
+	redisLock          AttemptLocker
+	config             DataPosterConfigFetcher
+	replacementTimes   []time.Duration
+	replacementTimeout func() time.Duration
+	metadataRetriever  func(ctx context.Context, blockNum *big.Int) (Meta, error)
 
 	// these fields are protected by the mutex
 	mutex      sync.Mutex
@@ -93,13 +111,14 @@ type DataPoster[Meta any] struct {
 	nonce      uint64
 	queue      QueueStorage[queuedTransaction[Meta]]
 	errorCount map[uint64]int // number of consecutive intermittent errors rbf-ing or sending, per nonce
+	resyncMeta bool           // a transaction reverted, so the queued metadata no longer matches L1
 }
 
 type AttemptLocker interface {
 	AttemptLock(context.Context) bool
 }
 
-func NewDataPoster[Meta any](headerReader *headerreader.HeaderReader, auth *bind.TransactOpts, redisClient redis.UniversalClient, redisLock AttemptLocker, config DataPosterConfigFetcher, metadataRetriever func(ctx context.Context, blockNum *big.Int) (Meta, error)) (*DataPoster[Meta], error) {
+func NewDataPoster[Meta any](headerReader *headerreader.HeaderReader, auth *bind.TransactOpts, redisClient redis.UniversalClient, redisLock AttemptLocker, config DataPosterConfigFetcher, replacementTimeout func() time.Duration, metadataRetriever func(ctx context.Context, blockNum *big.Int) (Meta, error)) (*DataPoster[Meta], error) {
 	var replacementTimes []time.Duration
 	var lastReplacementTime time.Duration
 	for _, s := range strings.Split(config().ReplacementTimes, ",") {
@@ -129,15 +148,16 @@ func NewDataPoster[Meta any](headerReader *headerreader.HeaderReader, auth *bind
 		}
 	}
 	return &DataPoster[Meta]{
-		headerReader:      headerReader,
-		client:            headerReader.Client(),
-		auth:              auth,
-		config:            config,
-		replacementTimes:  replacementTimes,
-		metadataRetriever: metadataRetriever,
-		queue:             queue,
-		redisLock:         redisLock,
-		errorCount:        make(map[uint64]int),
+		headerReader:       headerReader,
+		client:             headerReader.Client(),
+		auth:               auth,
+		config:             config,
+		replacementTimes:   replacementTimes,
+		replacementTimeout: replacementTimeout,
+		metadataRetriever:  metadataRetriever,
+		queue:              queue,
+		redisLock:          redisLock,
+		errorCount:         make(map[uint64]int),
 	}, nil
 }
 
@@ -157,11 +177,16 @@ func (p *DataPoster[Meta]) GetNextNonceAndMeta(ctx context.Context) (uint64, Met
 	if err != nil {
 		return 0, emptyMeta, err
 	}
-	if lastQueueItem != nil {
+	if lastQueueItem != nil && !p.resyncMeta {
 		return lastQueueItem.Data.Nonce + 1, lastQueueItem.Meta, nil
 	}
+	nonce := p.nonce
+	if lastQueueItem != nil {
+		// the remaining queued transactions were built on the reverted one's metadata and will revert too
+		nonce = lastQueueItem.Data.Nonce + 1
+	}
 	meta, err := p.metadataRetriever(ctx, p.lastBlock)
-	return p.nonce, meta, err
+	return nonce, meta, err
 }
 
 const minRbfIncrease arbmath.Bips = arbmath.OneInBips * 11 / 10
@@ -171,7 +196,7 @@ func (p *DataPoster[Meta]) getFeeAndTipCaps(ctx context.Context, lastTipCap *big
 	if err != nil {
 		return nil, nil, err
 	}
-	newTipCap, err := p.client.SuggestGasTipCap(ctx)
+	newTipCap, err := p.getTipCap(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -206,16 +231,21 @@ func (p *DataPoster[Meta]) getFeeAndTipCaps(ctx context.Context, lastTipCap *big
 		)
 		newFeeCap = maxFeeCap
 	}
+	if arbmath.BigGreaterThan(newTipCap, newFeeCap) {
+		newTipCap = newFeeCap
+	}
+	tipCapGauge.Update(newTipCap.Int64())
 
 	return newFeeCap, newTipCap, nil
 }
 
-func (p *DataPoster[Meta]) PostTransaction(ctx context.Context, dataCreatedAt time.Time, nonce uint64, meta Meta, to common.Address, calldata []byte, gasLimit uint64) error {
+// PostTransaction queues and sends a transaction, returning it as first sent
+func (p *DataPoster[Meta]) PostTransaction(ctx context.Context, dataCreatedAt time.Time, nonce uint64, meta Meta, to common.Address, calldata []byte, gasLimit uint64) (*types.Transaction, error) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 	feeCap, tipCap, err := p.getFeeAndTipCaps(ctx, nil, dataCreatedAt)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	inner := types.DynamicFeeTx{
 		Nonce:     nonce,
@@ -228,7 +258,7 @@ func (p *DataPoster[Meta]) PostTransaction(ctx context.Context, dataCreatedAt ti
 	}
 	fullTx, err := p.auth.Signer(p.auth.From, types.NewTx(&inner))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	queuedTx := queuedTransaction[Meta]{
 		Data:            inner,
@@ -236,9 +266,28 @@ func (p *DataPoster[Meta]) PostTransaction(ctx context.Context, dataCreatedAt ti
 		Meta:            meta,
 		Sent:            false,
 		Created:         dataCreatedAt,
-		NextReplacement: time.Now().Add(p.replacementTimes[0]),
+		NextReplacement: p.capReplacementTime(time.Now().Add(p.replacementTimes[0])),
+	}
+	err = p.saveTx(ctx, nil, &queuedTx)
+	if err != nil {
+		return nil, err
+	}
+	// the queued metadata was read from L1, so later transactions can build on it again
+	p.resyncMeta = false
+	return fullTx, p.sendTx(ctx, &queuedTx, &queuedTx)
+}
+
+// capReplacementTime ensures a transaction isn't left pending longer than the replacement timeout
+func (p *DataPoster[Meta]) capReplacementTime(next time.Time) time.Time {
+	timeout := p.replacementTimeout()
+	if timeout <= 0 {
+		return next
+	}
+	latest := time.Now().Add(timeout)
+	if next.After(latest) {
+		return latest
 	}
-	return p.sendTx(ctx, nil, &queuedTx)
+	return next
 }
 
 // the mutex must be held by the caller
@@ -266,7 +315,7 @@ func (p *DataPoster[Meta]) sendTx(ctx context.Context, prevTx *queuedTransaction
 			return err
 		}
 	} else {
-		log.Info("DataPoster sent transaction", "nonce", newTx.FullTx.Nonce(), "hash", newTx.FullTx.Hash(), "feeCap", newTx.FullTx.GasFeeCap())
+		log.Info("DataPoster sent transaction", "nonce", newTx.FullTx.Nonce(), "hash", newTx.FullTx.Hash(), "feeCap", newTx.FullTx.GasFeeCap(), "tipCap", newTx.FullTx.GasTipCap())
 	}
 	newerTx := *newTx
 	newerTx.Sent = true
@@ -307,6 +356,7 @@ func (p *DataPoster[Meta]) replaceTx(ctx context.Context, prevTx *queuedTransact
 		newTx.NextReplacement = prevTx.Created.Add(replacement)
 		break
 	}
+	newTx.NextReplacement = p.capReplacementTime(newTx.NextReplacement)
 	newTx.Sent = false
 	newTx.Data.GasFeeCap = newFeeCap
 	newTx.Data.GasTipCap = newTipCap
@@ -314,10 +364,34 @@ func (p *DataPoster[Meta]) replaceTx(ctx context.Context, prevTx *queuedTransact
 	if err != nil {
 		return err
 	}
+	newTx.Replaced = append(append([]common.Hash{}, prevTx.Replaced...), prevTx.FullTx.Hash())
+	replacementCounter.Inc(1)
+	log.Info("DataPoster replacing transaction", "nonce", newTx.Data.Nonce, "prevFeeCap", prevTx.Data.GasFeeCap, "newFeeCap", newFeeCap, "prevTipCap", prevTx.Data.GasTipCap, "newTipCap", newTipCap)
 
 	return p.sendTx(ctx, prevTx, &newTx)
 }
 
+// BumpTransaction immediately replaces the queued transaction with the given nonce with a higher fee
+func (p *DataPoster[Meta]) BumpTransaction(ctx context.Context, nonce uint64) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	err := p.updateState(ctx)
+	if err != nil {
+		return err
+	}
+	if nonce < p.nonce {
+		return fmt.Errorf("transaction with nonce %v already included (current nonce %v)", nonce, p.nonce)
+	}
+	queueContents, err := p.queue.GetContents(ctx, nonce, 1)
+	if err != nil {
+		return err
+	}
+	if len(queueContents) == 0 || queueContents[0].Data.Nonce != nonce {
+		return fmt.Errorf("no queued transaction with nonce %v", nonce)
+	}
+	return p.replaceTx(ctx, queueContents[0])
+}
+
 // the mutex must be held by the caller
 func (p *DataPoster[Meta]) updateState(ctx context.Context) error {
 	header, err := p.client.HeaderByNumber(ctx, nil)
@@ -335,7 +409,11 @@ func (p *DataPoster[Meta]) updateState(ctx context.Context) error {
 				delete(p.errorCount, x)
 			}
 		}
-		err := p.queue.Prune(ctx, nonce)
+		err := p.checkForReverts(ctx, p.nonce, nonce)
+		if err != nil {
+			return err
+		}
+		err = p.queue.Prune(ctx, nonce)
 		if err != nil {
 			return err
 		}
@@ -349,6 +427,66 @@ func (p *DataPoster[Meta]) updateState(ctx context.Context) error {
 	return nil
 }
 
+// checkForReverts looks for queued transactions that were included in L1 but reverted.
+// If one did, the next transaction's metadata is re-read from L1, re-queueing its data.
+// The mutex must be held by the caller.
+func (p *DataPoster[Meta]) checkForReverts(ctx context.Context, fromNonce uint64, toNonce uint64) error {
+	first, err := p.queue.GetContents(ctx, fromNonce, 1)
+	if err != nil {
+		return err
+	}
+	if len(first) == 0 {
+		return nil
+	}
+	// on startup, the queue still starts at the last nonce the previous poster saw confirmed
+	if first[0].Data.Nonce > fromNonce {
+		fromNonce = first[0].Data.Nonce
+	}
+	if fromNonce >= toNonce {
+		return nil
+	}
+	included, err := p.queue.GetContents(ctx, fromNonce, toNonce-fromNonce)
+	if err != nil {
+		return err
+	}
+	for _, tx := range included {
+		if tx.Data.Nonce >= toNonce {
+			continue
+		}
+		receipt, err := p.includedReceipt(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if receipt == nil {
+			log.Warn("no version of included transaction found on L1", "nonce", tx.Data.Nonce, "hash", tx.FullTx.Hash(), "replaced", len(tx.Replaced))
+			continue
+		}
+		if receipt.Status == types.ReceiptStatusFailed {
+			revertedCounter.Inc(1)
+			log.Error("DataPoster transaction reverted, re-reading metadata from L1", "nonce", tx.Data.Nonce, "hash", receipt.TxHash, "block", receipt.BlockNumber)
+			p.resyncMeta = true
+		}
+	}
+	return nil
+}
+
+// includedReceipt returns the receipt of whichever version of the transaction was included, or nil if
+// none of them were found
+func (p *DataPoster[Meta]) includedReceipt(ctx context.Context, tx *queuedTransaction[Meta]) (*types.Receipt, error) {
+	hashes := append([]common.Hash{tx.FullTx.Hash()}, tx.Replaced...)
+	for _, hash := range hashes {
+		receipt, err := p.client.TransactionReceipt(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return receipt, nil
+	}
+	return nil, nil
+}
+
 const maxConsecutiveIntermittentErrors = 10
 
 func (p *DataPoster[Meta]) maybeLogError(err error, tx *queuedTransaction[Meta], msg string) {
@@ -0,0 +1,135 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/headerreader"
+)
+
+// revertingL1 is an L1 where the poster's nonce has advanced past transactions with the given receipts
+type revertingL1 struct {
+	arbutil.L1Interface
+	nonce    uint64
+	baseFee  int64
+	receipts map[common.Hash]*types.Receipt
+	sent     []*types.Transaction
+}
+
+func (c *revertingL1) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), BaseFee: big.NewInt(c.baseFee)}, nil
+}
+
+func (c *revertingL1) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *revertingL1) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return big.NewInt(1e18), nil
+}
+
+func (c *revertingL1) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(params.GWei), nil
+}
+
+func (c *revertingL1) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *revertingL1) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, ok := c.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+type alwaysLocked struct{}
+
+func (alwaysLocked) AttemptLock(context.Context) bool {
+	return true
+}
+
+func TestRevertedPostResyncsMetadata(t *testing.T) {
+	ctx := context.Background()
+	client := &revertingL1{baseFee: params.GWei, receipts: make(map[common.Hash]*types.Receipt)}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// meta is the number of batches the L1 contract has after the transaction
+	onChainBatches := uint64(0)
+	newPoster := func() *DataPoster[uint64] {
+		reader := headerreader.New(client, func() *headerreader.Config { return &headerreader.TestConfig })
+		p, err := NewDataPoster(reader, auth, nil, alwaysLocked{}, func() *DataPosterConfig { return &TestDataPosterConfig }, func() time.Duration { return 0 }, func(ctx context.Context, blockNum *big.Int) (uint64, error) {
+			return onChainBatches, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	post := func(p *DataPoster[uint64], expectedNonce uint64, expectedMeta uint64) *types.Transaction {
+		t.Helper()
+		nonce, meta, err := p.GetNextNonceAndMeta(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nonce != expectedNonce || meta != expectedMeta {
+			t.Fatal("expected nonce", expectedNonce, "and meta", expectedMeta, "but got", nonce, "and", meta)
+		}
+		tx, err := p.PostTransaction(ctx, time.Now(), nonce, meta+1, common.Address{}, nil, 100000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	p := newPoster()
+	first := post(p, 0, 0)
+	second := post(p, 1, 1)
+	post(p, 2, 2)
+
+	// the first post succeeds, and the original version of the second is included and reverts
+	client.baseFee *= 2
+	if err := p.BumpTransaction(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if client.sent[len(client.sent)-1].Hash() == second.Hash() {
+		t.Fatal("bumping the transaction didn't replace it")
+	}
+	client.receipts[first.Hash()] = &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: first.Hash()}
+	client.receipts[second.Hash()] = &types.Receipt{Status: types.ReceiptStatusFailed, TxHash: second.Hash(), BlockNumber: big.NewInt(99)}
+	client.nonce = 2
+	onChainBatches = 1
+
+	// the remaining queued transaction was built on the reverted one and will revert too
+	third := post(p, 3, onChainBatches)
+	// once a transaction is queued with the L1 metadata, later posts build on it again
+	post(p, 4, onChainBatches+1)
+
+	// a restarted poster checks the transactions confirmed while it was down
+	client.receipts[third.Hash()] = &types.Receipt{Status: types.ReceiptStatusFailed, TxHash: third.Hash(), BlockNumber: big.NewInt(100)}
+	client.nonce = 4
+	onChainBatches = 2
+	restarted := newPoster()
+	restarted.queue = p.queue
+	post(restarted, 5, onChainBatches)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/params"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+const (
+	PriorityFeeStrategySuggested  = "suggested"
+	PriorityFeeStrategyFixed      = "fixed"
+	PriorityFeeStrategyMultiple   = "multiple"
+	PriorityFeeStrategyPercentile = "percentile"
+)
+
+type PriorityFeeConfig struct {
+	Strategy   string  `koanf:"strategy" reload:"hot"`
+	FixedGwei  float64 `koanf:"fixed-gwei" reload:"hot"`
+	Multiple   float64 `koanf:"multiple" reload:"hot"`
+	Percentile float64 `koanf:"percentile" reload:"hot"`
+}
+
+func (c *PriorityFeeConfig) Validate() error {
+	switch c.Strategy {
+	case PriorityFeeStrategySuggested:
+	case PriorityFeeStrategyFixed:
+		if c.FixedGwei < 0 {
+			return fmt.Errorf("priority fee fixed-gwei %v cannot be negative", c.FixedGwei)
+		}
+	case PriorityFeeStrategyMultiple:
+		if c.Multiple <= 0 {
+			return fmt.Errorf("priority fee multiple %v must be positive", c.Multiple)
+		}
+	case PriorityFeeStrategyPercentile:
+		if c.Percentile < 0 || c.Percentile > 100 {
+			return fmt.Errorf("priority fee percentile %v must be between 0 and 100", c.Percentile)
+		}
+	default:
+		return fmt.Errorf("invalid priority fee strategy \"%v\"", c.Strategy)
+	}
+	return nil
+}
+
+func PriorityFeeConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".strategy", DefaultPriorityFeeConfig.Strategy, "how to pick the priority fee (suggested, fixed, multiple, or percentile)")
+	f.Float64(prefix+".fixed-gwei", DefaultPriorityFeeConfig.FixedGwei, "priority fee to use with the fixed strategy, in gwei")
+	f.Float64(prefix+".multiple", DefaultPriorityFeeConfig.Multiple, "multiple of the L1 node's suggested priority fee to use with the multiple strategy")
+	f.Float64(prefix+".percentile", DefaultPriorityFeeConfig.Percentile, "percentile (0-100) of priority fees paid in the latest L1 block to use with the percentile strategy")
+}
+
+var DefaultPriorityFeeConfig = PriorityFeeConfig{
+	Strategy:   PriorityFeeStrategySuggested,
+	FixedGwei:  1.,
+	Multiple:   1.,
+	Percentile: 50.,
+}
+
+func (p *DataPoster[Meta]) getTipCap(ctx context.Context) (*big.Int, error) {
+	config := &p.config().PriorityFee
+	switch config.Strategy {
+	case PriorityFeeStrategyFixed:
+		return new(big.Int).SetUint64(uint64(config.FixedGwei * params.GWei)), nil
+	case PriorityFeeStrategyMultiple:
+		suggested, err := p.client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return arbmath.BigMulByFrac(suggested, int64(config.Multiple*10000), 10000), nil
+	case PriorityFeeStrategyPercentile:
+		tip, err := p.percentileTipCap(ctx, config.Percentile)
+		if err != nil || tip != nil {
+			return tip, err
+		}
+		// the latest block had no transactions to sample from
+		return p.client.SuggestGasTipCap(ctx)
+	default:
+		return p.client.SuggestGasTipCap(ctx)
+	}
+}
+
+// percentileTipCap returns the given percentile of the tips paid in the latest L1 block, or nil if it was empty
+func (p *DataPoster[Meta]) percentileTipCap(ctx context.Context, percentile float64) (*big.Int, error) {
+	latestHeader, err := p.headerReader.LastHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	block, err := p.client.BlockByHash(ctx, latestHeader.Hash())
+	if err != nil {
+		return nil, err
+	}
+	var tips []*big.Int
+	for _, tx := range block.Transactions() {
+		tip, err := tx.EffectiveGasTip(block.BaseFee())
+		if err != nil {
+			continue
+		}
+		tips = append(tips, tip)
+	}
+	if len(tips) == 0 {
+		return nil, nil
+	}
+	sort.Slice(tips, func(i, j int) bool {
+		return tips[i].Cmp(tips[j]) < 0
+	})
+	index := int(float64(len(tips)-1) * percentile / 100)
+	return new(big.Int).Set(tips[index]), nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbos"
+)
+
+var delayedPendingGauge = metrics.NewRegisteredGauge("arb/inbox/delayed_pending", nil)
+
+// GetDelayedMessagesPosted returns how many delayed messages have been read by batches posted to L1
+func (t *InboxTracker) GetDelayedMessagesPosted() (uint64, error) {
+	batchCount, err := t.GetBatchCount()
+	if err != nil || batchCount == 0 {
+		return 0, err
+	}
+	metadata, err := t.GetBatchMetadata(batchCount - 1)
+	if err != nil {
+		return 0, err
+	}
+	return metadata.DelayedMessageCount, nil
+}
+
+// GetPendingDelayedRange returns the range of delayed messages not yet read by a batch posted to L1
+func (t *InboxTracker) GetPendingDelayedRange() (uint64, uint64, error) {
+	delayedCount, err := t.GetDelayedCount()
+	if err != nil {
+		return 0, 0, err
+	}
+	posted, err := t.GetDelayedMessagesPosted()
+	if err != nil {
+		return 0, 0, err
+	}
+	if posted > delayedCount {
+		// the delayed messages of the latest batches haven't been read yet
+		posted = delayedCount
+	}
+	return posted, delayedCount, nil
+}
+
+func (t *InboxTracker) updateDelayedPendingGauge() {
+	start, end, err := t.GetPendingDelayedRange()
+	if err != nil {
+		log.Warn("failed to count pending delayed messages", "err", err)
+		return
+	}
+	delayedPendingGauge.Update(int64(end - start))
+}
+
+// delayedInclusionDeadline returns the L1 block and timestamp after which a delayed message
+// can be force included, bypassing the sequencer
+func delayedInclusionDeadline(msg *arbos.L1IncomingMessage, variation MaxTimeVariation) (uint64, uint64) {
+	return msg.Header.BlockNumber + variation.DelayBlocks, msg.Header.Timestamp + variation.DelaySeconds
+}
+
+type PendingDelayedMessage struct {
+	Index             hexutil.Uint64 `json:"index"`
+	Kind              uint8          `json:"kind"`
+	L1Block           hexutil.Uint64 `json:"l1Block"`
+	Timestamp         hexutil.Uint64 `json:"timestamp"`
+	Age               string         `json:"age"`
+	DeadlineL1Block   hexutil.Uint64 `json:"deadlineL1Block"`
+	DeadlineTimestamp hexutil.Uint64 `json:"deadlineTimestamp"`
+	Sequenced         bool           `json:"sequenced"` // in the L2 chain, but not yet in a batch posted to L1
+	Overdue           bool           `json:"overdue"`   // can currently be force included on L1
+}
+
+const maxPendingDelayedToList = 1024
+
+// PendingDelayedMessages lists the delayed messages that haven't been included in a batch on L1
+func (r *InboxReader) PendingDelayedMessages(ctx context.Context, streamer *TransactionStreamer) ([]PendingDelayedMessage, error) {
+	start, end, err := r.tracker.GetPendingDelayedRange()
+	if err != nil {
+		return nil, err
+	}
+	pending := []PendingDelayedMessage{}
+	if start == end {
+		return pending, nil
+	}
+	if end-start > maxPendingDelayedToList {
+		end = start + maxPendingDelayedToList
+	}
+	header, err := r.l1Reader.LastHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	variation, err := r.sequencerInbox.GetMaxTimeVariation(ctx, header.Number)
+	if err != nil {
+		return nil, err
+	}
+	sequenced, err := streamer.GetDelayedMessagesRead()
+	if err != nil {
+		return nil, err
+	}
+	for index := start; index < end; index++ {
+		msg, err := r.tracker.GetDelayedMessage(index)
+		if err != nil {
+			return nil, err
+		}
+		deadlineBlock, deadlineTimestamp := delayedInclusionDeadline(msg, variation)
+		pending = append(pending, PendingDelayedMessage{
+			Index:             hexutil.Uint64(index),
+			Kind:              msg.Header.Kind,
+			L1Block:           hexutil.Uint64(msg.Header.BlockNumber),
+			Timestamp:         hexutil.Uint64(msg.Header.Timestamp),
+			Age:               time.Since(time.Unix(int64(msg.Header.Timestamp), 0)).Round(time.Second).String(),
+			DeadlineL1Block:   hexutil.Uint64(deadlineBlock),
+			DeadlineTimestamp: hexutil.Uint64(deadlineTimestamp),
+			Sequenced:         index < sequenced,
+			Overdue:           header.Number.Uint64() > deadlineBlock && header.Time > deadlineTimestamp,
+		})
+	}
+	return pending, nil
+}
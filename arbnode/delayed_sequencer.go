@@ -8,10 +8,12 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	flag "github.com/spf13/pflag"
 
 	"github.com/offchainlabs/nitro/arbos"
@@ -20,15 +22,20 @@ import (
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
 
+var delayedOverdueCounter = metrics.NewRegisteredCounter("arb/sequencer/delayed_overdue", nil)
+
 type DelayedSequencer struct {
 	stopwaiter.StopWaiter
 	l1Reader                 *headerreader.HeaderReader
 	bridge                   *DelayedBridge
+	sequencerInbox           *SequencerInbox
 	inbox                    *InboxTracker
 	txStreamer               *TransactionStreamer
 	coordinator              *SeqCoordinator
 	waitingForFinalizedBlock *big.Int
+	waitingForMargin         *inclusionMarginStart
 	config                   DelayedSequencerConfigFetcher
+	sequencerConfig          SequencerConfigFetcher
 }
 
 type DelayedSequencerConfig struct {
@@ -61,27 +68,63 @@ var TestDelayedSequencerConfig = DelayedSequencerConfig{
 	UseMergeFinality:    true,
 }
 
-func NewDelayedSequencer(l1Reader *headerreader.HeaderReader, reader *InboxReader, txStreamer *TransactionStreamer, coordinator *SeqCoordinator, config DelayedSequencerConfigFetcher) (*DelayedSequencer, error) {
+func NewDelayedSequencer(l1Reader *headerreader.HeaderReader, reader *InboxReader, txStreamer *TransactionStreamer, coordinator *SeqCoordinator, config DelayedSequencerConfigFetcher, sequencerConfig SequencerConfigFetcher) (*DelayedSequencer, error) {
 	return &DelayedSequencer{
-		l1Reader:    l1Reader,
-		bridge:      reader.DelayedBridge(),
-		inbox:       reader.Tracker(),
-		coordinator: coordinator,
-		txStreamer:  txStreamer,
-		config:      config,
+		l1Reader:        l1Reader,
+		bridge:          reader.DelayedBridge(),
+		sequencerInbox:  reader.sequencerInbox,
+		inbox:           reader.Tracker(),
+		coordinator:     coordinator,
+		txStreamer:      txStreamer,
+		config:          config,
+		sequencerConfig: sequencerConfig,
 	}, nil
 }
 
-func (d *DelayedSequencer) getDelayedMessagesRead() (uint64, error) {
-	pos, err := d.txStreamer.GetMessageCount()
-	if err != nil || pos == 0 {
-		return 0, err
+// inclusionMarginStart is the L1 block and timestamp from which a delayed message is within the
+// inclusion margin of its force inclusion deadline
+type inclusionMarginStart struct {
+	block     uint64
+	timestamp uint64
+	margin    time.Duration
+}
+
+func (m *inclusionMarginStart) reached(header *types.Header) bool {
+	return header.Number.Uint64() >= m.block || header.Time >= m.timestamp
+}
+
+// getInclusionMarginStart converts the margin to L1 blocks at the block time seen since the message
+// was sent, or the one implied by the max time variation if no time has passed since
+func getInclusionMarginStart(msg *arbos.L1IncomingMessage, variation MaxTimeVariation, header *types.Header, margin time.Duration) inclusionMarginStart {
+	deadlineBlock, deadlineTimestamp := delayedInclusionDeadline(msg, variation)
+	marginSeconds := uint64(margin / time.Second)
+	elapsedBlocks, elapsedSeconds := elapsedL1Time(msg.Header.BlockNumber, msg.Header.Timestamp, header)
+	if elapsedBlocks == 0 || elapsedSeconds == 0 {
+		elapsedBlocks, elapsedSeconds = variation.DelayBlocks, variation.DelaySeconds
 	}
-	lastMsg, err := d.txStreamer.GetMessage(pos - 1)
-	if err != nil {
-		return 0, err
+	var marginBlocks uint64
+	if elapsedSeconds > 0 {
+		// round up, to sequence early rather than late
+		marginBlocks = arbmath.SaturatingUMul(marginSeconds, elapsedBlocks)
+		marginBlocks = arbmath.SaturatingUAdd(marginBlocks, elapsedSeconds-1) / elapsedSeconds
+	}
+	return inclusionMarginStart{
+		block:     arbmath.SaturatingUSub(deadlineBlock, marginBlocks),
+		timestamp: arbmath.SaturatingUSub(deadlineTimestamp, marginSeconds),
+		margin:    margin,
 	}
-	return lastMsg.DelayedMessagesRead, nil
+}
+
+// elapsedL1Time returns how many L1 blocks and seconds header is past the given block and timestamp,
+// which together give the recent L1 block time
+func elapsedL1Time(block uint64, timestamp uint64, header *types.Header) (uint64, uint64) {
+	return arbmath.SaturatingUSub(header.Number.Uint64(), block), arbmath.SaturatingUSub(header.Time, timestamp)
+}
+
+// pastInclusionDeadline reports whether a delayed message can already be force included on L1
+func pastInclusionDeadline(msg *arbos.L1IncomingMessage, variation MaxTimeVariation, header *types.Header) bool {
+	deadlineBlock, deadlineTimestamp := delayedInclusionDeadline(msg, variation)
+	return header.Number.Uint64() > deadlineBlock && header.Time > deadlineTimestamp
 }
 
 func (d *DelayedSequencer) update(ctx context.Context, lastBlockHeader *types.Header) error {
@@ -114,27 +157,35 @@ func (d *DelayedSequencer) update(ctx context.Context, lastBlockHeader *types.He
 		finalized = header.Number
 	}
 
+	margin := d.sequencerConfig().DelayedInclusionMargin
 	if d.waitingForFinalizedBlock != nil && arbmath.BigLessThan(finalized, d.waitingForFinalizedBlock) {
-		return nil
+		// nothing can be sequenced until the next message is finalized or nears its deadline
+		waiting := d.waitingForMargin
+		if margin <= 0 || (waiting != nil && waiting.margin == margin && !waiting.reached(lastBlockHeader)) {
+			return nil
+		}
 	}
 
 	// Unless we find an unfinalized message (which sets waitingForBlock),
 	// we won't find a new finalized message until FinalizeDistance blocks in the future.
 	d.waitingForFinalizedBlock = arbmath.BigAddByUint(lastBlockHeader.Number, 1)
+	d.waitingForMargin = nil
 
 	dbDelayedCount, err := d.inbox.GetDelayedCount()
 	if err != nil {
 		return err
 	}
-	startPos, err := d.getDelayedMessagesRead()
+	startPos, err := d.txStreamer.GetDelayedMessagesRead()
 	if err != nil {
 		return err
 	}
 
-	// Retrieve all finalized delayed messages
+	// Retrieve all finalized delayed messages, and any close to their force inclusion deadline
 	pos := startPos
 	var lastDelayedAcc common.Hash
 	var messages []*arbos.L1IncomingMessage
+	var variation *MaxTimeVariation
+	accBlock := finalized
 	for pos < dbDelayedCount {
 		msg, acc, err := d.inbox.GetDelayedMessageAndAccumulator(pos)
 		if err != nil {
@@ -142,9 +193,38 @@ func (d *DelayedSequencer) update(ctx context.Context, lastBlockHeader *types.He
 		}
 		blockNumber := arbmath.UintToBig(msg.Header.BlockNumber)
 		if blockNumber.Cmp(finalized) > 0 {
-			// Message isn't finalized yet; stop here
-			d.waitingForFinalizedBlock = blockNumber
-			break
+			if margin <= 0 {
+				// Message isn't finalized yet; stop here
+				d.waitingForFinalizedBlock = blockNumber
+				break
+			}
+			if variation == nil {
+				fetched, err := d.sequencerInbox.GetMaxTimeVariation(ctx, lastBlockHeader.Number)
+				if err != nil {
+					return err
+				}
+				variation = &fetched
+			}
+			marginStart := getInclusionMarginStart(msg, *variation, lastBlockHeader, margin)
+			if !marginStart.reached(lastBlockHeader) {
+				// Message isn't finalized or near its deadline yet; stop here
+				d.waitingForFinalizedBlock = blockNumber
+				d.waitingForMargin = &marginStart
+				break
+			}
+			if pastInclusionDeadline(msg, *variation, lastBlockHeader) {
+				deadlineBlock, deadlineTimestamp := delayedInclusionDeadline(msg, *variation)
+				delayedOverdueCounter.Inc(1)
+				log.Error(
+					"CRITICAL: delayed message can be force included before the sequencer included it",
+					"l1Block", msg.Header.BlockNumber,
+					"timestamp", msg.Header.Timestamp,
+					"deadlineL1Block", deadlineBlock,
+					"deadlineTimestamp", deadlineTimestamp,
+				)
+			}
+			log.Warn("sequencing unfinalized delayed message to meet its force inclusion deadline", "pos", pos, "l1Block", msg.Header.BlockNumber)
+			accBlock = lastBlockHeader.Number
 		}
 		if lastDelayedAcc != (common.Hash{}) {
 			// Ensure that there hasn't been a reorg and this message follows the last
@@ -163,13 +243,13 @@ func (d *DelayedSequencer) update(ctx context.Context, lastBlockHeader *types.He
 
 	// Sequence the delayed messages, if any
 	if len(messages) > 0 {
-		delayedBridgeAcc, err := d.bridge.GetAccumulator(ctx, pos-1, finalized)
+		delayedBridgeAcc, err := d.bridge.GetAccumulator(ctx, pos-1, accBlock)
 		if err != nil {
 			return err
 		}
 		if delayedBridgeAcc != lastDelayedAcc {
 			// Probably a reorg that hasn't been picked up by the inbox reader
-			return fmt.Errorf("inbox reader at delayed message %v db accumulator %v doesn't match delayed bridge accumulator %v at L1 block %v", pos-1, lastDelayedAcc, delayedBridgeAcc, finalized)
+			return fmt.Errorf("inbox reader at delayed message %v db accumulator %v doesn't match delayed bridge accumulator %v at L1 block %v", pos-1, lastDelayedAcc, delayedBridgeAcc, accBlock)
 		}
 
 		err = d.txStreamer.SequenceDelayedMessages(ctx, messages, startPos)
@@ -0,0 +1,61 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbos"
+)
+
+func TestInclusionMarginStart(t *testing.T) {
+	variation := MaxTimeVariation{DelayBlocks: 5760, DelaySeconds: 86400}
+	msg := &arbos.L1IncomingMessage{
+		Header: &arbos.L1IncomingMessageHeader{BlockNumber: 1000, Timestamp: 100000},
+	}
+	header := func(number uint64, timestamp uint64) *types.Header {
+		return &types.Header{Number: new(big.Int).SetUint64(number), Time: timestamp}
+	}
+
+	// 12 second L1 blocks since the message, so an hour margin is 300 blocks
+	start := getInclusionMarginStart(msg, variation, header(1100, 101200), time.Hour)
+	if start.block != 1000+5760-300 || start.timestamp != 100000+86400-3600 {
+		Fail(t, "unexpected margin start", start.block, start.timestamp)
+	}
+	if start.reached(header(1100, 101200)) {
+		Fail(t, "message near its deadline right after it was sent")
+	}
+	if !start.reached(header(start.block, 101200)) {
+		Fail(t, "margin not reached at its L1 block")
+	}
+	if !start.reached(header(1100, start.timestamp)) {
+		Fail(t, "margin not reached at its timestamp")
+	}
+
+	// in the message's own block, the block time comes from the max time variation
+	start = getInclusionMarginStart(msg, variation, header(1000, 100000), time.Hour)
+	if start.block != 1000+5760-240 {
+		Fail(t, "unexpected margin start without elapsed blocks", start.block)
+	}
+
+	// a margin past the whole delay is reached immediately
+	start = getInclusionMarginStart(msg, variation, header(1100, 101200), 48*time.Hour)
+	if start.block != 0 || !start.reached(header(1100, 101200)) {
+		Fail(t, "unexpected margin start for a margin past the delay", start.block, start.timestamp)
+	}
+
+	if pastInclusionDeadline(msg, variation, header(1000+5760, 100000+86401)) {
+		Fail(t, "message past its deadline at the deadline block")
+	}
+	if pastInclusionDeadline(msg, variation, header(1000+5761, 100000+86400)) {
+		Fail(t, "message past its deadline at the deadline timestamp")
+	}
+	if !pastInclusionDeadline(msg, variation, header(1000+5761, 100000+86401)) {
+		Fail(t, "message not past its deadline after both the deadline block and timestamp")
+	}
+}
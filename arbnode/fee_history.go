@@ -0,0 +1,164 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+)
+
+// maxFeeHistoryBlocks bounds how many blocks a single eth_feeHistory call reads
+const maxFeeHistoryBlocks = 1024
+
+// FeeHistoryAPI serves eth_feeHistory using L2's base fee model. It's registered after geth's eth
+// namespace so that its method takes precedence.
+type FeeHistoryAPI struct {
+	blockchain *core.BlockChain
+}
+
+type FeeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the base fee of each block in the range and the next one, how much of the L2
+// speed limit each block used, and the requested percentiles of the priority fees actually paid
+func (api *FeeHistoryAPI) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistoryResult, error) {
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid reward percentile %v", p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return nil, fmt.Errorf("reward percentiles must be ascending, but %v follows %v", p, rewardPercentiles[i-1])
+		}
+	}
+	head := api.blockchain.CurrentBlock().NumberU64()
+	genesis := api.blockchain.Config().ArbitrumChainParams.GenesisBlockNum
+	last := head
+	if lastBlock == rpc.EarliestBlockNumber {
+		last = genesis
+	} else if lastBlock >= 0 {
+		last = uint64(lastBlock)
+	}
+	if last > head {
+		return nil, fmt.Errorf("block %v is beyond the head block %v", last, head)
+	}
+	if last < genesis {
+		return nil, types.ErrUseFallback
+	}
+	count := uint64(blockCount)
+	if count > maxFeeHistoryBlocks {
+		count = maxFeeHistoryBlocks
+	}
+	if count > last-genesis+1 {
+		count = last - genesis + 1
+	}
+	oldest := last + 1 - count
+	result := &FeeHistoryResult{OldestBlock: (*hexutil.Big)(new(big.Int).SetUint64(oldest))}
+	if count == 0 {
+		return result, nil
+	}
+
+	// The base fee stored in the state after a block is the one the next block pays
+	perBlockGasLimit := l2pricing.InitialPerBlockGasLimitV6
+	var nextBaseFee *big.Int
+	if state, _, err := stateAndHeader(api.blockchain, last); err == nil {
+		if limit, err := state.L2PricingState().PerBlockGasLimit(); err == nil && limit > 0 {
+			perBlockGasLimit = limit
+		}
+		if baseFee, err := state.L2PricingState().BaseFeeWei(); err == nil {
+			nextBaseFee = baseFee
+		}
+	}
+
+	for number := oldest; number <= last; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block %v not found", number)
+		}
+		baseFee := block.BaseFee()
+		if baseFee == nil {
+			baseFee = new(big.Int)
+		}
+		result.BaseFee = append(result.BaseFee, (*hexutil.Big)(baseFee))
+		result.GasUsedRatio = append(result.GasUsedRatio, float64(block.GasUsed())/float64(perBlockGasLimit))
+		if len(rewardPercentiles) > 0 {
+			rewards, err := api.blockRewards(block, rewardPercentiles)
+			if err != nil {
+				return nil, err
+			}
+			result.Reward = append(result.Reward, rewards)
+		}
+		if number == last && nextBaseFee == nil {
+			nextBaseFee = baseFee
+		}
+	}
+	result.BaseFee = append(result.BaseFee, (*hexutil.Big)(nextBaseFee))
+	return result, nil
+}
+
+type txGasAndReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+// blockRewards computes the gas weighted percentiles of the tips paid in the block, which are zero
+// where ArbOS drops them
+func (api *FeeHistoryAPI) blockRewards(block *types.Block, percentiles []float64) ([]*hexutil.Big, error) {
+	rewards := make([]*hexutil.Big, len(percentiles))
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		for i := range rewards {
+			rewards[i] = (*hexutil.Big)(new(big.Int))
+		}
+		return rewards, nil
+	}
+	receipts := api.blockchain.GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(txs) {
+		return nil, errors.New("block receipts don't match its transactions")
+	}
+	dropTips := true
+	if info, err := types.DeserializeHeaderExtraInformation(block.Header()); err == nil {
+		dropTips = info.ArbOSFormatVersion < 9
+	}
+	sorted := make([]txGasAndReward, len(txs))
+	for i, tx := range txs {
+		reward := new(big.Int)
+		if !dropTips && tx.Type() < types.ArbitrumDepositTxType {
+			if tip, err := tx.EffectiveGasTip(block.BaseFee()); err == nil {
+				reward = tip
+			}
+		}
+		sorted[i] = txGasAndReward{gasUsed: receipts[i].GasUsed, reward: reward}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].reward.Cmp(sorted[j].reward) < 0
+	})
+	txIndex := 0
+	sumGasUsed := sorted[0].gasUsed
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(block.GasUsed()) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(sorted)-1 {
+			txIndex++
+			sumGasUsed += sorted[txIndex].gasUsed
+		}
+		rewards[i] = (*hexutil.Big)(sorted[txIndex].reward)
+	}
+	return rewards, nil
+}
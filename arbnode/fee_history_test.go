@@ -0,0 +1,84 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+)
+
+func TestFeeHistory(t *testing.T) {
+	ctx := context.Background()
+	config := TestSequencerConfig
+	sequencer, key, bc := newSequencerForTest(t, &config)
+	api := &FeeHistoryAPI{blockchain: bc}
+	feeCap := int64(l2pricing.InitialBaseFeeWei * 2)
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx := signedTestTx(t, bc, key, nonce, feeCap)
+		result := make(chan error, 1)
+		go func() {
+			result <- sequencer.PublishTransaction(ctx, tx)
+		}()
+		for i := 0; i < 100 && len(sequencer.txQueue) == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if !createBlockForTest(sequencer) {
+			Fail(t, "no block sequenced for transaction", nonce)
+		}
+		Require(t, <-result)
+	}
+	head := bc.CurrentBlock().NumberU64()
+	genesis := bc.Config().ArbitrumChainParams.GenesisBlockNum
+
+	// the block count is capped to the blocks there are
+	history, err := api.FeeHistory(ctx, 100, rpc.LatestBlockNumber, []float64{0, 50, 100})
+	Require(t, err)
+	blocks := int(head - genesis + 1)
+	if history.OldestBlock.ToInt().Uint64() != genesis {
+		Fail(t, "expected the history to start at genesis, got", history.OldestBlock)
+	}
+	if len(history.BaseFee) != blocks+1 || len(history.GasUsedRatio) != blocks || len(history.Reward) != blocks {
+		Fail(t, "expected", blocks, "blocks of history and the next base fee, got", len(history.BaseFee), len(history.GasUsedRatio), len(history.Reward))
+	}
+	for i := 0; i < blocks; i++ {
+		block := bc.GetBlockByNumber(genesis + uint64(i))
+		if history.BaseFee[i].ToInt().Cmp(block.BaseFee()) != 0 {
+			Fail(t, "block", block.NumberU64(), "has base fee", block.BaseFee(), "not", history.BaseFee[i])
+		}
+		if (block.GasUsed() > 0) != (history.GasUsedRatio[i] > 0) || history.GasUsedRatio[i] > 1 {
+			Fail(t, "block", block.NumberU64(), "used", block.GasUsed(), "gas, but its gas used ratio is", history.GasUsedRatio[i])
+		}
+		if len(history.Reward[i]) != 3 {
+			Fail(t, "expected a reward for each percentile, got", history.Reward[i])
+		}
+	}
+	// the last base fee is the one the next block pays
+	state, _, err := stateAndHeader(bc, head)
+	Require(t, err)
+	nextBaseFee, err := state.L2PricingState().BaseFeeWei()
+	Require(t, err)
+	if history.BaseFee[blocks].ToInt().Cmp(nextBaseFee) != 0 {
+		Fail(t, "expected the next base fee", nextBaseFee, "got", history.BaseFee[blocks])
+	}
+
+	history, err = api.FeeHistory(ctx, 1, rpc.BlockNumber(head-1), nil)
+	Require(t, err)
+	if history.OldestBlock.ToInt().Uint64() != head-1 || len(history.BaseFee) != 2 || len(history.GasUsedRatio) != 1 || history.Reward != nil {
+		Fail(t, "unexpected history of the block before the head", history)
+	}
+
+	if _, err := api.FeeHistory(ctx, 1, rpc.BlockNumber(head+1), nil); err == nil {
+		Fail(t, "returned the history of a block past the head")
+	}
+	for _, percentiles := range [][]float64{{-1}, {101}, {50, 10}} {
+		if _, err := api.FeeHistory(ctx, 1, rpc.LatestBlockNumber, percentiles); err == nil {
+			Fail(t, "accepted reward percentiles", percentiles)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+const (
+	// finality lag to assume when the L1 node doesn't report a finalized block (two epochs)
+	defaultL1FinalityLagBlocks = 64
+	// how many recent batches to average the posting cadence over
+	batchCadenceSampleSize = 16
+)
+
+const (
+	FinalityStatusNotFound     = "notFound"
+	FinalityStatusPendingBatch = "pendingBatch"
+	FinalityStatusPosted       = "posted"
+	FinalityStatusFinalized    = "finalized"
+)
+
+type FinalityEstimate struct {
+	Status            string          `json:"status"`
+	L2Block           *hexutil.Uint64 `json:"l2Block,omitempty"`
+	BatchIndex        *hexutil.Uint64 `json:"batchIndex,omitempty"`
+	L1Block           *hexutil.Uint64 `json:"l1Block,omitempty"`
+	SecondsToBatch    uint64          `json:"secondsToBatch"`
+	SecondsToFinality uint64          `json:"secondsToFinality"`
+}
+
+// l1BlockTime is the L1 block time, as the seconds that passed over a number of blocks
+type l1BlockTime struct {
+	blocks  uint64
+	seconds uint64
+}
+
+// the block time to assume when the L1 headers don't show one
+var defaultL1BlockTime = l1BlockTime{blocks: 1, seconds: 12}
+
+// recentL1BlockTime derives the L1 block time from the time that passed between two headers, as the
+// delayed sequencer does for its inclusion margin
+func recentL1BlockTime(earlier *types.Header, head *types.Header) l1BlockTime {
+	if earlier == nil {
+		return defaultL1BlockTime
+	}
+	blocks, seconds := elapsedL1Time(earlier.Number.Uint64(), earlier.Time, head)
+	if blocks == 0 || seconds == 0 {
+		return defaultL1BlockTime
+	}
+	return l1BlockTime{blocks: blocks, seconds: seconds}
+}
+
+func (t l1BlockTime) toSeconds(blocks uint64) uint64 {
+	return arbmath.SaturatingUMul(blocks, t.seconds) / t.blocks
+}
+
+func (t l1BlockTime) toBlocks(duration time.Duration) uint64 {
+	return arbmath.SaturatingUMul(uint64(duration/time.Second), t.blocks) / t.seconds
+}
+
+// batchCadenceBlocks estimates how many L1 blocks apart batches are posted from the most recent ones
+func batchCadenceBlocks(tracker *InboxTracker, batchCount uint64, blockTime l1BlockTime) (uint64, error) {
+	fallback := blockTime.toBlocks(DefaultBatchPosterConfig.MaxBatchPostInterval)
+	if batchCount < 2 {
+		return fallback, nil
+	}
+	samples := uint64(batchCadenceSampleSize)
+	if samples > batchCount-1 {
+		samples = batchCount - 1
+	}
+	last, err := tracker.GetBatchMetadata(batchCount - 1)
+	if err != nil {
+		return 0, err
+	}
+	first, err := tracker.GetBatchMetadata(batchCount - 1 - samples)
+	if err != nil {
+		return 0, err
+	}
+	if last.L1Block <= first.L1Block {
+		return 1, nil
+	}
+	return (last.L1Block - first.L1Block) / samples, nil
+}
+
+// EstimateFinality estimates how long until the transaction's batch is posted and then finalized on L1,
+// from the recent batch posting cadence and how far the L1 finalized block currently lags the head
+func (api *NitroAPI) EstimateFinality(ctx context.Context, txHash common.Hash) (*FinalityEstimate, error) {
+	tx, _, blockNumber, _ := rawdb.ReadTransaction(api.chainDb, txHash)
+	if tx == nil {
+		return &FinalityEstimate{Status: FinalityStatusNotFound}, nil
+	}
+	if api.inboxReader == nil {
+		return nil, errors.New("inbox reader not enabled")
+	}
+	l2Block := hexutil.Uint64(blockNumber)
+	estimate := &FinalityEstimate{L2Block: &l2Block}
+
+	msgCount, err := api.txStreamer.BlockNumberToMessageCount(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if msgCount == 0 {
+		// pre-nitro blocks were finalized with the classic chain
+		estimate.Status = FinalityStatusFinalized
+		return estimate, nil
+	}
+	msgIndex := msgCount - 1
+
+	client := api.inboxReader.Client()
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	headNumber := head.Number.Uint64()
+	finalizedNumber := uint64(0)
+	if headNumber > defaultL1FinalityLagBlocks {
+		finalizedNumber = headNumber - defaultL1FinalityLagBlocks
+	}
+	finalized, err := client.HeaderByNumber(ctx, big.NewInt(rpc.FinalizedBlockNumber.Int64()))
+	if err == nil && finalized != nil {
+		finalizedNumber = finalized.Number.Uint64()
+	} else if finalized, err = client.HeaderByNumber(ctx, new(big.Int).SetUint64(finalizedNumber)); err != nil {
+		finalized = nil
+	}
+	blockTime := recentL1BlockTime(finalized, head)
+
+	tracker := api.inboxReader.Tracker()
+	batchCount, err := tracker.GetBatchCount()
+	if err != nil {
+		return nil, err
+	}
+	var lastBatch BatchMetadata
+	if batchCount > 0 {
+		lastBatch, err = tracker.GetBatchMetadata(batchCount - 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if batchCount > 0 && lastBatch.MessageCount > msgIndex {
+		batchNum, err := validator.FindBatchContainingMessageIndex(tracker, msgIndex, batchCount)
+		if err != nil {
+			return nil, err
+		}
+		meta, err := tracker.GetBatchMetadata(batchNum)
+		if err != nil {
+			return nil, err
+		}
+		batchIndex := hexutil.Uint64(batchNum)
+		l1Block := hexutil.Uint64(meta.L1Block)
+		estimate.BatchIndex = &batchIndex
+		estimate.L1Block = &l1Block
+		if meta.L1Block <= finalizedNumber {
+			estimate.Status = FinalityStatusFinalized
+			return estimate, nil
+		}
+		estimate.Status = FinalityStatusPosted
+		estimate.SecondsToFinality = blockTime.toSeconds(meta.L1Block - finalizedNumber)
+		return estimate, nil
+	}
+
+	cadence, err := batchCadenceBlocks(tracker, batchCount, blockTime)
+	if err != nil {
+		return nil, err
+	}
+	// a batch that's already overdue is assumed to land in the next L1 block
+	blocksToBatch := uint64(1)
+	if batchCount == 0 {
+		blocksToBatch = cadence
+	} else if expected := lastBatch.L1Block + cadence; expected > headNumber {
+		blocksToBatch = expected - headNumber
+	}
+	finalityLag := uint64(0)
+	if headNumber > finalizedNumber {
+		finalityLag = headNumber - finalizedNumber
+	}
+	estimate.Status = FinalityStatusPendingBatch
+	estimate.SecondsToBatch = blockTime.toSeconds(blocksToBatch)
+	estimate.SecondsToFinality = blockTime.toSeconds(blocksToBatch + finalityLag)
+	return estimate, nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestRecentL1BlockTime(t *testing.T) {
+	header := func(number uint64, timestamp uint64) *types.Header {
+		return &types.Header{Number: new(big.Int).SetUint64(number), Time: timestamp}
+	}
+
+	// 64 blocks over 256 seconds is a 4 second block time
+	blockTime := recentL1BlockTime(header(1000, 100000), header(1064, 100256))
+	if seconds := blockTime.toSeconds(10); seconds != 40 {
+		Fail(t, "expected 10 blocks to take 40 seconds, got", seconds)
+	}
+	if blocks := blockTime.toBlocks(time.Minute); blocks != 15 {
+		Fail(t, "expected a minute to be 15 blocks, got", blocks)
+	}
+
+	// without time passing between the headers, the default block time is assumed
+	for _, earlier := range []*types.Header{nil, header(1064, 100256), header(1000, 100256)} {
+		if recentL1BlockTime(earlier, header(1064, 100256)) != defaultL1BlockTime {
+			Fail(t, "expected the default block time from", earlier)
+		}
+	}
+}
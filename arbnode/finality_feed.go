@@ -0,0 +1,170 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/util/headerreader"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+type FinalityFeedConfig struct {
+	Output       wsbroadcastserver.BroadcasterConfig `koanf:"output" reload:"hot"`
+	PollInterval time.Duration                       `koanf:"poll-interval" reload:"hot"`
+}
+
+var DefaultFinalityFeedConfig = FinalityFeedConfig{
+	Output:       wsbroadcastserver.DefaultBroadcasterConfig,
+	PollInterval: 12 * time.Second,
+}
+
+func FinalityFeedConfigAddOptions(prefix string, f *flag.FlagSet) {
+	wsbroadcastserver.BroadcasterConfigAddOptions(prefix+".output", f)
+	f.Duration(prefix+".poll-interval", DefaultFinalityFeedConfig.PollInterval, "how often to check the L1 finalized block for newly finalized batches")
+}
+
+func (c *FinalityFeedConfig) Validate() error {
+	if !c.Output.Enable {
+		return nil
+	}
+	if err := c.Output.Archive.Validate(); err != nil {
+		return err
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("invalid finality feed poll interval %v", c.PollInterval)
+	}
+	return nil
+}
+
+// FinalityFeed broadcasts a signed checkpoint on its own feed each time more of the sequencer's
+// batches are in finalized L1 blocks, so clients can follow finality without an L1 connection
+type FinalityFeed struct {
+	stopwaiter.StopWaiter
+	config      func() *FinalityFeedConfig
+	broadcaster *broadcaster.FinalityBroadcaster
+	l1Reader    *headerreader.HeaderReader
+	tracker     *InboxTracker
+	txStreamer  *TransactionStreamer
+
+	finalizedBatchCount uint64
+}
+
+func NewFinalityFeed(config func() *FinalityFeedConfig, finalityBroadcaster *broadcaster.FinalityBroadcaster, l1Reader *headerreader.HeaderReader, tracker *InboxTracker, txStreamer *TransactionStreamer) *FinalityFeed {
+	return &FinalityFeed{
+		config:      config,
+		broadcaster: finalityBroadcaster,
+		l1Reader:    l1Reader,
+		tracker:     tracker,
+		txStreamer:  txStreamer,
+	}
+}
+
+func (f *FinalityFeed) Initialize() error {
+	return f.broadcaster.Initialize()
+}
+
+func (f *FinalityFeed) Start(ctxIn context.Context) error {
+	f.StopWaiter.Start(ctxIn, f)
+	if err := f.broadcaster.Start(f.GetContext()); err != nil {
+		return err
+	}
+	f.CallIteratively(func(ctx context.Context) time.Duration {
+		if err := f.update(ctx); err != nil {
+			log.Warn("error updating finality feed", "err", err)
+		}
+		return f.config().PollInterval
+	})
+	return nil
+}
+
+func (f *FinalityFeed) StopAndWait() {
+	f.StopWaiter.StopAndWait()
+	if f.broadcaster.Started() {
+		f.broadcaster.StopAndWait()
+	}
+}
+
+// finalizedBatchCount returns how many batches were posted at or before l1Block, given that the
+// first from batches were
+func finalizedBatchCount(tracker *InboxTracker, from uint64, batchCount uint64, l1Block uint64) (uint64, error) {
+	var searchErr error
+	count := sort.Search(int(batchCount-from), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		meta, err := tracker.GetBatchMetadata(from + uint64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return meta.L1Block > l1Block
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	return from + uint64(count), nil
+}
+
+func (f *FinalityFeed) update(ctx context.Context) error {
+	finalized, err := f.l1Reader.LatestFinalizedHeader()
+	if err != nil {
+		return err
+	}
+	if finalized == nil {
+		return errors.New("L1 node didn't return a finalized block")
+	}
+	batchCount, err := f.tracker.GetBatchCount()
+	if err != nil {
+		return err
+	}
+	if batchCount < f.finalizedBatchCount {
+		// the batches were reorged out, which finalized L1 blocks shouldn't allow
+		log.Error("finalized batches reorged", "finalizedBatchCount", f.finalizedBatchCount, "batchCount", batchCount)
+		f.finalizedBatchCount = batchCount
+	}
+	count, err := finalizedBatchCount(f.tracker, f.finalizedBatchCount, batchCount, finalized.Number.Uint64())
+	if err != nil {
+		return err
+	}
+	if count <= f.finalizedBatchCount || count == 0 {
+		return nil
+	}
+	meta, err := f.tracker.GetBatchMetadata(count - 1)
+	if err != nil {
+		return err
+	}
+	checkpoint := &broadcaster.FinalityCheckpointMessage{
+		BatchCount:   count,
+		MessageCount: meta.MessageCount,
+		L1Block:      meta.L1Block,
+	}
+	if meta.MessageCount > 0 {
+		blockNumber, err := f.txStreamer.MessageCountToBlockNumber(meta.MessageCount)
+		if err != nil {
+			return err
+		}
+		header := f.txStreamer.bc.GetHeaderByNumber(uint64(blockNumber))
+		if header == nil {
+			// the block isn't executed yet, try again next time
+			return nil
+		}
+		checkpoint.BlockNumber = header.Number.Uint64()
+		checkpoint.BlockHash = header.Hash()
+	}
+	if err := f.broadcaster.BroadcastCheckpoint(checkpoint); err != nil {
+		return err
+	}
+	f.finalizedBatchCount = count
+	return nil
+}
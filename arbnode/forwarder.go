@@ -14,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	flag "github.com/spf13/pflag"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -105,6 +106,26 @@ func (f *TxForwarder) PublishTransaction(inctx context.Context, tx *types.Transa
 	return f.ethClient.SendTransaction(ctx, tx)
 }
 
+func (f *TxForwarder) PublishConditionalTransaction(inctx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
+	if atomic.LoadInt32(&f.enabled) == 0 {
+		return ErrNoSequencer
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	ctx, cancelFunc := f.ctxWithTimeout(inctx)
+	defer cancelFunc()
+	return f.rpcClient.CallContext(ctx, nil, "eth_sendRawTransactionConditional", hexutil.Bytes(data), options)
+}
+
+func (f *TxForwarder) publish(ctx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
+	if options != nil {
+		return f.PublishConditionalTransaction(ctx, tx, options)
+	}
+	return f.PublishTransaction(ctx, tx)
+}
+
 const cacheUpstreamHealth = 2 * time.Second
 const maxHealthTimeout = 10 * time.Second
 
@@ -173,6 +194,10 @@ func (f *TxDropper) PublishTransaction(ctx context.Context, tx *types.Transactio
 	return txDropperErr
 }
 
+func (f *TxDropper) PublishConditionalTransaction(ctx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
+	return txDropperErr
+}
+
 func (f *TxDropper) CheckHealth(ctx context.Context) error {
 	return txDropperErr
 }
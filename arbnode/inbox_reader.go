@@ -31,6 +31,7 @@ type InboxReaderConfig struct {
 	DefaultBlocksToRead uint64        `koanf:"default-blocks-to-read" reload:"hot"`
 	TargetMessagesRead  uint64        `koanf:"target-messages-read" reload:"hot"`
 	MaxBlocksToRead     uint64        `koanf:"max-blocks-to-read" reload:"hot"`
+	L1ReadBatchSize     int           `koanf:"l1-read-batch-size" reload:"hot"`
 }
 
 type InboxReaderConfigFetcher func() *InboxReaderConfig
@@ -39,6 +40,9 @@ func (c *InboxReaderConfig) Validate() error {
 	if c.MaxBlocksToRead == 0 || c.MaxBlocksToRead < c.DefaultBlocksToRead {
 		return errors.New("inbox reader max-blocks-to-read cannot be zero or less than default-blocks-to-read")
 	}
+	if c.L1ReadBatchSize < 0 {
+		return errors.New("inbox reader l1-read-batch-size cannot be negative")
+	}
 	return nil
 }
 
@@ -50,6 +54,7 @@ func InboxReaderConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".default-blocks-to-read", DefaultInboxReaderConfig.DefaultBlocksToRead, "the default number of blocks to read at once (will vary based on traffic by default)")
 	f.Uint64(prefix+".target-messages-read", DefaultInboxReaderConfig.TargetMessagesRead, "if adjust-blocks-to-read is enabled, the target number of messages to read at once")
 	f.Uint64(prefix+".max-blocks-to-read", DefaultInboxReaderConfig.MaxBlocksToRead, "if adjust-blocks-to-read is enabled, the maximum number of blocks to read at once")
+	f.Int(prefix+".l1-read-batch-size", DefaultInboxReaderConfig.L1ReadBatchSize, "the maximum number of batch data lookups to send to L1 in one JSON-RPC batch request, falling back to individual requests if batches fail (0 or 1 to disable)")
 }
 
 var DefaultInboxReaderConfig = InboxReaderConfig{
@@ -60,6 +65,7 @@ var DefaultInboxReaderConfig = InboxReaderConfig{
 	DefaultBlocksToRead: 100,
 	TargetMessagesRead:  500,
 	MaxBlocksToRead:     2000,
+	L1ReadBatchSize:     0,
 }
 
 var TestInboxReaderConfig = InboxReaderConfig{
@@ -70,6 +76,7 @@ var TestInboxReaderConfig = InboxReaderConfig{
 	DefaultBlocksToRead: 100,
 	TargetMessagesRead:  500,
 	MaxBlocksToRead:     2000,
+	L1ReadBatchSize:     16,
 }
 
 type InboxReader struct {
@@ -95,6 +102,11 @@ type InboxReader struct {
 	lastReadMutex      sync.RWMutex
 	lastReadBlock      uint64
 	lastReadBatchCount uint64
+
+	// a requested resync, taken by the run thread
+	resyncMutex sync.Mutex
+	resyncFrom  *big.Int
+	resyncChan  chan struct{}
 }
 
 func NewInboxReader(tracker *InboxTracker, client arbutil.L1Interface, l1Reader *headerreader.HeaderReader, firstMessageBlock *big.Int, delayedBridge *DelayedBridge, sequencerInbox *SequencerInbox, config InboxReaderConfigFetcher) (*InboxReader, error) {
@@ -110,6 +122,7 @@ func NewInboxReader(tracker *InboxTracker, client arbutil.L1Interface, l1Reader
 		l1Reader:          l1Reader,
 		firstMessageBlock: firstMessageBlock,
 		caughtUpChan:      make(chan bool, 1),
+		resyncChan:        make(chan struct{}, 1),
 		config:            config,
 	}, nil
 }
@@ -159,6 +172,45 @@ func (r *InboxReader) Start(ctxIn context.Context) error {
 	return nil
 }
 
+// Resync makes the inbox reader read L1 again from fromBlock, adding anything it finds missing or
+// reorging to what it finds changed, while skipping the messages it already has. It can't resync
+// from before the finalized L1 block, as the messages from then can't have changed.
+func (r *InboxReader) Resync(fromBlock uint64) error {
+	if fromBlock < r.firstMessageBlock.Uint64() {
+		return fmt.Errorf("cannot resync from L1 block %v before the rollup was deployed at %v", fromBlock, r.firstMessageBlock)
+	}
+	finalized, err := r.l1Reader.LatestFinalizedHeader()
+	if err != nil {
+		return err
+	}
+	if finalized != nil && fromBlock < finalized.Number.Uint64() {
+		return fmt.Errorf("cannot resync from L1 block %v below the finalized L1 block %v", fromBlock, finalized.Number)
+	}
+	r.lastReadMutex.RLock()
+	lastReadBlock := r.lastReadBlock
+	r.lastReadMutex.RUnlock()
+	if fromBlock > lastReadBlock {
+		return fmt.Errorf("cannot resync from L1 block %v after the last block read %v", fromBlock, lastReadBlock)
+	}
+	r.resyncMutex.Lock()
+	r.resyncFrom = new(big.Int).SetUint64(fromBlock)
+	r.resyncMutex.Unlock()
+	select {
+	case r.resyncChan <- struct{}{}:
+	default:
+	}
+	log.Info("inbox reader resync requested", "fromBlock", fromBlock)
+	return nil
+}
+
+func (r *InboxReader) takeResync() *big.Int {
+	r.resyncMutex.Lock()
+	defer r.resyncMutex.Unlock()
+	from := r.resyncFrom
+	r.resyncFrom = nil
+	return from
+}
+
 func (r *InboxReader) Tracker() *InboxTracker {
 	return r.tracker
 }
@@ -188,6 +240,12 @@ func (ir *InboxReader) run(ctx context.Context, hadError bool) error {
 	}
 	defer storeSeenBatchCount() // in case of error
 	for {
+		resyncing := false
+		if resyncFrom := ir.takeResync(); resyncFrom != nil {
+			log.Info("inbox reader resyncing", "fromBlock", resyncFrom, "previousFrom", from)
+			from = resyncFrom
+			resyncing = true
+		}
 
 		latestHeader, err := ir.l1Reader.LastHeader(ctx)
 		if err != nil {
@@ -199,9 +257,13 @@ func (ir *InboxReader) run(ctx context.Context, hadError bool) error {
 		neededBlockAdvance := config.DelayBlocks + arbmath.SaturatingUSub(config.MinBlocksToRead, 1)
 		neededBlockHeight := arbmath.BigAddByUint(from, neededBlockAdvance)
 		checkDelayTimer := time.NewTimer(config.CheckDelay)
+		resyncRequested := false
 	WaitForHeight:
 		for arbmath.BigLessThan(currentHeight, neededBlockHeight) {
 			select {
+			case <-ir.resyncChan:
+				resyncRequested = true
+				break WaitForHeight
 			case latestHeader = <-newHeaders:
 				if latestHeader == nil {
 					// shutting down
@@ -215,6 +277,9 @@ func (ir *InboxReader) run(ctx context.Context, hadError bool) error {
 			}
 		}
 		checkDelayTimer.Stop()
+		if resyncRequested {
+			continue
+		}
 
 		if config.DelayBlocks > 0 {
 			currentHeight = new(big.Int).Sub(currentHeight, new(big.Int).SetUint64(config.DelayBlocks))
@@ -299,7 +364,7 @@ func (ir *InboxReader) run(ctx context.Context, hadError bool) error {
 			}
 		}
 
-		if !missingDelayed && !reorgingDelayed && !missingSequencer && !reorgingSequencer {
+		if !resyncing && !missingDelayed && !reorgingDelayed && !missingSequencer && !reorgingSequencer {
 			// There's nothing to do
 			from = arbmath.BigAddByUint(currentHeight, 1)
 			blocksToFetch = config.DefaultBlocksToRead
@@ -470,6 +535,11 @@ func (r *InboxReader) addMessages(ctx context.Context, sequencerBatches []*Seque
 	if err != nil {
 		return false, err
 	}
+	if batchSize := r.config().L1ReadBatchSize; batchSize > 1 {
+		if err := prefetchBatchData(ctx, r.client, sequencerBatches, batchSize); err != nil {
+			return false, err
+		}
+	}
 	err = r.tracker.AddSequencerBatches(ctx, r.client, sequencerBatches)
 	if errors.Is(err, delayedMessagesMismatch) {
 		return true, nil
@@ -509,7 +579,7 @@ func (r *InboxReader) getNextBlockToRead() (*big.Int, error) {
 	return msgBlock, nil
 }
 
-func (r *InboxReader) GetSequencerMessageBytes(ctx context.Context, seqNum uint64) ([]byte, error) {
+func (r *InboxReader) GetSequencerBatch(ctx context.Context, seqNum uint64) (*SequencerInboxBatch, error) {
 	metadata, err := r.tracker.GetBatchMetadata(seqNum)
 	if err != nil {
 		return nil, err
@@ -521,12 +591,24 @@ func (r *InboxReader) GetSequencerMessageBytes(ctx context.Context, seqNum uint6
 	}
 	for _, batch := range seqBatches {
 		if batch.SequenceNumber == seqNum {
-			return batch.Serialize(ctx, r.client)
+			return batch, nil
 		}
 	}
 	return nil, errors.New("sequencer batch not found")
 }
 
+func (r *InboxReader) GetSequencerMessageBytes(ctx context.Context, seqNum uint64) ([]byte, error) {
+	batch, err := r.GetSequencerBatch(ctx, seqNum)
+	if err != nil {
+		return nil, err
+	}
+	return batch.Serialize(ctx, r.client)
+}
+
+func (r *InboxReader) Client() arbutil.L1Interface {
+	return r.client
+}
+
 func (r *InboxReader) GetLastReadBlockAndBatchCount() (uint64, uint64) {
 	r.lastReadMutex.RLock()
 	defer r.lastReadMutex.RUnlock()
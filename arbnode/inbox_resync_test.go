@@ -0,0 +1,87 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/headerreader"
+)
+
+// finalizedL1ForTest only answers for the finalized L1 header
+type finalizedL1ForTest struct {
+	arbutil.L1Interface
+	finalized *types.Header
+}
+
+func (c *finalizedL1ForTest) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil || number.Int64() != rpc.FinalizedBlockNumber.Int64() {
+		return nil, fmt.Errorf("unexpected request for L1 header %v", number)
+	}
+	return c.finalized, nil
+}
+
+func TestResyncInbox(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := (&NitroAdminAPI{}).ResyncInbox(ctx, 0); err == nil {
+		Fail(t, "resync allowed without an inbox reader")
+	}
+
+	l1Reader := headerreader.New(&finalizedL1ForTest{finalized: &types.Header{Number: big.NewInt(50)}}, func() *headerreader.Config { return &headerreader.TestConfig })
+	// the reader isn't polling L1, but the finalized header is fetched with its context
+	l1Reader.StopWaiter.Start(ctx, l1Reader)
+	defer l1Reader.StopWaiter.StopAndWait()
+	reader := &InboxReader{
+		l1Reader:          l1Reader,
+		firstMessageBlock: big.NewInt(10),
+		lastReadBlock:     100,
+		resyncChan:        make(chan struct{}, 1),
+	}
+	api := &NitroAdminAPI{inboxReader: reader}
+
+	// before the rollup, below the finalized block, or past what was read
+	for _, from := range []uint64{5, 40, 101} {
+		if err := api.ResyncInbox(ctx, hexutil.Uint64(from)); err == nil {
+			Fail(t, "resync allowed from L1 block", from)
+		}
+	}
+	if from := reader.takeResync(); from != nil {
+		Fail(t, "rejected resync was requested from", from)
+	}
+	select {
+	case <-reader.resyncChan:
+		Fail(t, "rejected resync woke the inbox reader")
+	default:
+	}
+
+	// the last request before the run thread takes it wins, waking it once
+	Require(t, api.ResyncInbox(ctx, 60))
+	Require(t, api.ResyncInbox(ctx, 100))
+	select {
+	case <-reader.resyncChan:
+	default:
+		Fail(t, "resync didn't wake the inbox reader")
+	}
+	select {
+	case <-reader.resyncChan:
+		Fail(t, "inbox reader woken twice")
+	default:
+	}
+	if from := reader.takeResync(); from == nil || from.Uint64() != 100 {
+		Fail(t, "expected a resync from L1 block 100, got", from)
+	}
+	if from := reader.takeResync(); from != nil {
+		Fail(t, "resync taken twice, the second time from", from)
+	}
+}
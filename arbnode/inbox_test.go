@@ -50,7 +50,7 @@ func NewTransactionStreamerForTest(t *testing.T, ownerAddress common.Address) (*
 		Fail(t, err)
 	}
 
-	inbox, err := NewTransactionStreamer(arbDb, bc, nil, make(chan error, 1))
+	inbox, err := NewTransactionStreamer(arbDb, bc, nil, make(chan error, 1), func() *TransactionStreamerConfig { return &TestTransactionStreamerConfig })
 	if err != nil {
 		Fail(t, err)
 	}
@@ -42,6 +42,8 @@ func NewInboxTracker(db ethdb.Database, txStreamer *TransactionStreamer, das arb
 }
 
 func (t *InboxTracker) SetBlockValidator(validator *validator.BlockValidator) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.validator = validator
 }
 
@@ -270,7 +272,12 @@ func (t *InboxTracker) AddDelayedMessages(messages []*DelayedInboxMessage, hardR
 		pos++
 	}
 
-	return t.setDelayedCountReorgAndWriteBatch(batch, pos, true)
+	err = t.setDelayedCountReorgAndWriteBatch(batch, pos, true)
+	if err != nil {
+		return err
+	}
+	t.updateDelayedPendingGauge()
+	return nil
 }
 
 // All-in-one delayed message count adjuster. Can go forwards or backwards.
@@ -551,6 +558,7 @@ func (t *InboxTracker) AddSequencerBatches(ctx context.Context, client arbutil.L
 	if err != nil {
 		return err
 	}
+	t.updateDelayedPendingGauge()
 
 	if t.validator != nil {
 		batchBytes := make([][]byte, 0, len(batches))
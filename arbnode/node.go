@@ -396,26 +396,34 @@ func DeployOnL1(ctx context.Context, l1client arbutil.L1Interface, deployAuth *b
 }
 
 type Config struct {
-	RPC                    arbitrum.Config                `koanf:"rpc"`
-	Sequencer              SequencerConfig                `koanf:"sequencer" reload:"hot"`
-	L1Reader               headerreader.Config            `koanf:"l1-reader" reload:"hot"`
-	InboxReader            InboxReaderConfig              `koanf:"inbox-reader" reload:"hot"`
-	DelayedSequencer       DelayedSequencerConfig         `koanf:"delayed-sequencer" reload:"hot"`
-	BatchPoster            BatchPosterConfig              `koanf:"batch-poster" reload:"hot"`
-	ForwardingTargetImpl   string                         `koanf:"forwarding-target"`
-	Forwarder              ForwarderConfig                `koanf:"forwarder"`
-	TxPreCheckerStrictness uint                           `koanf:"tx-pre-checker-strictness" reload:"hot"`
-	BlockValidator         validator.BlockValidatorConfig `koanf:"block-validator" reload:"hot"`
-	Feed                   broadcastclient.FeedConfig     `koanf:"feed" reload:"hot"`
-	Validator              validator.L1ValidatorConfig    `koanf:"validator"`
-	SeqCoordinator         SeqCoordinatorConfig           `koanf:"seq-coordinator"`
-	DataAvailability       das.DataAvailabilityConfig     `koanf:"data-availability"`
-	Wasm                   WasmConfig                     `koanf:"wasm"`
-	SyncMonitor            SyncMonitorConfig              `koanf:"sync-monitor"`
-	Dangerous              DangerousConfig                `koanf:"dangerous"`
-	Caching                CachingConfig                  `koanf:"caching"`
-	Archive                bool                           `koanf:"archive"`
-	TxLookupLimit          uint64                         `koanf:"tx-lookup-limit"`
+	Name                   string                          `koanf:"name"`
+	RPC                    RPCConfig                       `koanf:"rpc" reload:"hot"`
+	Sequencer              SequencerConfig                 `koanf:"sequencer" reload:"hot"`
+	L1Reader               headerreader.Config             `koanf:"l1-reader" reload:"hot"`
+	InboxReader            InboxReaderConfig               `koanf:"inbox-reader" reload:"hot"`
+	DelayedSequencer       DelayedSequencerConfig          `koanf:"delayed-sequencer" reload:"hot"`
+	BatchPoster            BatchPosterConfig               `koanf:"batch-poster" reload:"hot"`
+	TransactionStreamer    TransactionStreamerConfig       `koanf:"transaction-streamer" reload:"hot"`
+	ForwardingTargetImpl   string                          `koanf:"forwarding-target"`
+	Forwarder              ForwarderConfig                 `koanf:"forwarder"`
+	TxPreCheckerStrictness uint                            `koanf:"tx-pre-checker-strictness" reload:"hot"`
+	ConditionalTx          ConditionalTxConfig             `koanf:"conditional-tx" reload:"hot"`
+	BlockValidator         validator.BlockValidatorConfig  `koanf:"block-validator" reload:"hot"`
+	Feed                   broadcastclient.FeedConfig      `koanf:"feed" reload:"hot"`
+	FinalityFeed           FinalityFeedConfig              `koanf:"finality-feed" reload:"hot"`
+	Validator              validator.L1ValidatorConfig     `koanf:"validator"`
+	SeqCoordinator         SeqCoordinatorConfig            `koanf:"seq-coordinator"`
+	DataAvailability       das.DataAvailabilityConfig      `koanf:"data-availability"`
+	Wasm                   WasmConfig                      `koanf:"wasm"`
+	SyncMonitor            SyncMonitorConfig               `koanf:"sync-monitor"`
+	Dangerous              DangerousConfig                 `koanf:"dangerous"`
+	Dev                    DevConfig                       `koanf:"dev" reload:"hot"`
+	Backup                 BackupConfig                    `koanf:"backup"`
+	DivergenceCheck        validator.DivergenceCheckConfig `koanf:"divergence-check"`
+	Shutdown               ShutdownConfig                  `koanf:"shutdown"`
+	Caching                CachingConfig                   `koanf:"caching"`
+	Archive                bool                            `koanf:"archive"`
+	TxLookupLimit          uint64                          `koanf:"tx-lookup-limit"`
 }
 
 func (c *Config) Validate() error {
@@ -428,9 +436,50 @@ func (c *Config) Validate() error {
 	if err := c.InboxReader.Validate(); err != nil {
 		return err
 	}
+	if err := c.ConditionalTx.Validate(); err != nil {
+		return err
+	}
 	if err := c.BatchPoster.Validate(); err != nil {
 		return err
 	}
+	if err := c.Validator.Validate(); err != nil {
+		return err
+	}
+	if err := c.Dev.Validate(); err != nil {
+		return err
+	}
+	if err := c.RPC.Validate(); err != nil {
+		return err
+	}
+	if err := c.Backup.Validate(); err != nil {
+		return err
+	}
+	if err := c.SeqCoordinator.Validate(); err != nil {
+		return err
+	}
+	if err := c.Feed.Output.Archive.Validate(); err != nil {
+		return err
+	}
+	if err := c.FinalityFeed.Validate(); err != nil {
+		return err
+	}
+	if err := c.DataAvailability.AggregatorConfig.Validate(); err != nil {
+		return err
+	}
+	if dir := c.Feed.Output.Archive.Dir; dir != "" && dir == c.FinalityFeed.Output.Archive.Dir {
+		return fmt.Errorf("the finality feed and the feed output can't both archive to %v", dir)
+	}
+	if c.FinalityFeed.Output.Enable {
+		if !c.Sequencer.Enable || !c.L1Reader.Enable {
+			return errors.New("the finality feed requires the sequencer and the L1 reader to be enabled")
+		}
+		if c.Feed.Output.Enable && c.Feed.Output.Addr == c.FinalityFeed.Output.Addr && c.Feed.Output.Port == c.FinalityFeed.Output.Port {
+			return fmt.Errorf("the finality feed and the feed output can't both listen on port %v", c.Feed.Output.Port)
+		}
+	}
+	if err := c.Shutdown.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -455,26 +504,34 @@ func (c *Config) ForwardingTarget() string {
 }
 
 func ConfigAddOptions(prefix string, f *flag.FlagSet, feedInputEnable bool, feedOutputEnable bool) {
-	arbitrum.ConfigAddOptions(prefix+".rpc", f)
+	f.String(prefix+".name", ConfigDefault.Name, "human-readable name for this node used in logs and metrics (defaults to the hostname)")
+	RPCConfigAddOptions(prefix+".rpc", f)
 	SequencerConfigAddOptions(prefix+".sequencer", f)
 	headerreader.AddOptions(prefix+".l1-reader", f)
 	InboxReaderConfigAddOptions(prefix+".inbox-reader", f)
 	DelayedSequencerConfigAddOptions(prefix+".delayed-sequencer", f)
 	BatchPosterConfigAddOptions(prefix+".batch-poster", f)
+	TransactionStreamerConfigAddOptions(prefix+".transaction-streamer", f)
 	f.String(prefix+".forwarding-target", ConfigDefault.ForwardingTargetImpl, "transaction forwarding target URL, or \"null\" to disable forwarding (iff not sequencer)")
 	AddOptionsForNodeForwarderConfig(prefix+".forwarder", f)
 	txPreCheckerDescription := "how strict to be when checking txs before forwarding them. 0 = accept anything, " +
 		"10 = should never reject anything that'd succeed, 20 = likely won't reject anything that'd succeed, " +
 		"30 = full validation which may reject txs that would succeed"
 	f.Uint(prefix+".tx-pre-checker-strictness", ConfigDefault.TxPreCheckerStrictness, txPreCheckerDescription)
+	ConditionalTxConfigAddOptions(prefix+".conditional-tx", f)
 	validator.BlockValidatorConfigAddOptions(prefix+".block-validator", f)
 	broadcastclient.FeedConfigAddOptions(prefix+".feed", f, feedInputEnable, feedOutputEnable)
+	FinalityFeedConfigAddOptions(prefix+".finality-feed", f)
 	validator.L1ValidatorConfigAddOptions(prefix+".validator", f)
 	SeqCoordinatorConfigAddOptions(prefix+".seq-coordinator", f)
 	das.DataAvailabilityConfigAddOptions(prefix+".data-availability", f)
 	WasmConfigAddOptions(prefix+".wasm", f)
 	SyncMonitorConfigAddOptions(prefix+".sync-monitor", f)
 	DangerousConfigAddOptions(prefix+".dangerous", f)
+	DevConfigAddOptions(prefix+".dev", f)
+	BackupConfigAddOptions(prefix+".backup", f)
+	validator.DivergenceCheckConfigAddOptions(prefix+".divergence-check", f)
+	ShutdownConfigAddOptions(prefix+".shutdown", f)
 	CachingConfigAddOptions(prefix+".caching", f)
 	f.Uint64(prefix+".tx-lookup-limit", ConfigDefault.TxLookupLimit, "retain the ability to lookup transactions by hash for the past N blocks (0 = all blocks)")
 
@@ -483,22 +540,30 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet, feedInputEnable bool, feed
 }
 
 var ConfigDefault = Config{
-	RPC:                    arbitrum.DefaultConfig,
+	Name:                   "",
+	RPC:                    DefaultRPCConfig,
 	Sequencer:              DefaultSequencerConfig,
 	L1Reader:               headerreader.DefaultConfig,
 	InboxReader:            DefaultInboxReaderConfig,
 	DelayedSequencer:       DefaultDelayedSequencerConfig,
 	BatchPoster:            DefaultBatchPosterConfig,
+	TransactionStreamer:    DefaultTransactionStreamerConfig,
 	ForwardingTargetImpl:   "",
 	TxPreCheckerStrictness: TxPreCheckerStrictnessNone,
+	ConditionalTx:          DefaultConditionalTxConfig,
 	BlockValidator:         validator.DefaultBlockValidatorConfig,
 	Feed:                   broadcastclient.FeedConfigDefault,
+	FinalityFeed:           DefaultFinalityFeedConfig,
 	Validator:              validator.DefaultL1ValidatorConfig,
 	SeqCoordinator:         DefaultSeqCoordinatorConfig,
 	DataAvailability:       das.DefaultDataAvailabilityConfig,
 	Wasm:                   DefaultWasmConfig,
 	SyncMonitor:            DefaultSyncMonitorConfig,
 	Dangerous:              DefaultDangerousConfig,
+	Dev:                    DefaultDevConfig,
+	Backup:                 DefaultBackupConfig,
+	DivergenceCheck:        validator.DefaultDivergenceCheckConfig,
+	Shutdown:               DefaultShutdownConfig,
 	Archive:                false,
 	TxLookupLimit:          40_000_000,
 	Caching:                DefaultCachingConfig,
@@ -510,6 +575,7 @@ func ConfigDefaultL1Test() *Config {
 	config.DelayedSequencer = TestDelayedSequencerConfig
 	config.BatchPoster = TestBatchPosterConfig
 	config.SeqCoordinator = TestSeqCoordinatorConfig
+	config.Shutdown = TestShutdownConfig
 
 	return config
 }
@@ -556,6 +622,85 @@ func DangerousConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int64(prefix+".reorg-to-block", DefaultDangerousConfig.ReorgToBlock, "DANGEROUS! forces a reorg to an old block height. To be used for testing only. -1 to disable")
 }
 
+// DevConfig holds options for local development chains. Consensus rules are on-chain, so these only
+// change how this node produces blocks, and are rejected on chains without debug mode.
+type DevConfig struct {
+	MaxStateGrowthPerBlock uint64 `koanf:"max-state-growth-per-block" reload:"hot"`
+	ArbOSVersion           uint64 `koanf:"arbos-version"`
+}
+
+var DefaultDevConfig = DevConfig{
+	MaxStateGrowthPerBlock: 0,
+	ArbOSVersion:           0,
+}
+
+func DevConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".max-state-growth-per-block", DefaultDevConfig.MaxStateGrowthPerBlock, "DEV ONLY! stop adding txs to a sequenced block once they've added about this many bytes of state (0 = no limit)")
+	f.Uint64(prefix+".arbos-version", DefaultDevConfig.ArbOSVersion, "DEV ONLY! ArbOS version to create a new chain at, which an existing chain must already run or be scheduled to upgrade to (0 = the chain's own)")
+}
+
+func (c *DevConfig) Validate() error {
+	if c.ArbOSVersion > arbosState.MaxSupportedArbosVersion {
+		return fmt.Errorf("node.dev.arbos-version %v is newer than the latest supported ArbOS version %v", c.ArbOSVersion, arbosState.MaxSupportedArbosVersion)
+	}
+	return nil
+}
+
+// ApplyArbOSVersion sets the ArbOS version a new chain is initialized at, which is stored with its chain config
+func (c *DevConfig) ApplyArbOSVersion(chainConfig *params.ChainConfig) error {
+	if c.ArbOSVersion == 0 {
+		return nil
+	}
+	if !chainConfig.DebugMode() {
+		return fmt.Errorf("node.dev.arbos-version requires a chain with debug mode enabled, not chain id %v", chainConfig.ChainID)
+	}
+	chainConfig.ArbitrumChainParams.InitialArbOSVersion = c.ArbOSVersion
+	log.Warn("DEV ONLY! initializing chain at a pinned ArbOS version", "version", c.ArbOSVersion)
+	return nil
+}
+
+// checkArbOSVersion makes sure the chain's state is at the pinned ArbOS version, or will upgrade to it.
+// State created under an earlier version is upgraded by ArbOS itself, once an upgrade is scheduled.
+func (c *DevConfig) checkArbOSVersion(bc *core.BlockChain) error {
+	if c.ArbOSVersion == 0 {
+		return nil
+	}
+	if !bc.Config().DebugMode() {
+		return errors.New("node.dev.arbos-version requires a chain with debug mode enabled")
+	}
+	statedb, err := bc.State()
+	if err != nil {
+		return err
+	}
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return err
+	}
+	current := state.ArbOSVersion()
+	if current == c.ArbOSVersion {
+		return nil
+	}
+	if current > c.ArbOSVersion {
+		return fmt.Errorf("node.dev.arbos-version %v is older than the chain's ArbOS version %v, and ArbOS can't be downgraded", c.ArbOSVersion, current)
+	}
+	upgradeVersion, upgradeTimestamp, err := state.GetScheduledUpgrade()
+	if err != nil {
+		return err
+	}
+	if upgradeVersion != c.ArbOSVersion {
+		return fmt.Errorf(
+			"chain is at ArbOS version %v, schedule the upgrade to node.dev.arbos-version %v with ArbOwner.scheduleArbOSUpgrade",
+			current, c.ArbOSVersion,
+		)
+	}
+	log.Warn("DEV ONLY! chain will upgrade to the pinned ArbOS version", "current", current, "version", c.ArbOSVersion, "timestamp", upgradeTimestamp)
+	return nil
+}
+
+func (c *DevConfig) enabled() bool {
+	return c.MaxStateGrowthPerBlock != 0
+}
+
 type DangerousSequencerConfig struct {
 	NoCoordinator bool `koanf:"no-coordinator"`
 }
@@ -671,8 +816,12 @@ type Node struct {
 	DASLifecycleManager     *das.LifecycleManager
 	ClassicOutboxRetriever  *ClassicOutboxRetriever
 	SyncMonitor             *SyncMonitor
+	ChainBackup             *ChainBackup
+	DivergenceMonitor       *validator.DivergenceMonitor
+	FinalityFeed            *FinalityFeed
 	configFetcher           ConfigFetcher
 	ctx                     context.Context
+	rpcStoppers             []func()
 }
 
 type ConfigFetcher interface {
@@ -682,43 +831,90 @@ type ConfigFetcher interface {
 	Started() bool
 }
 
-func checkArbDbSchemaVersion(arbDb ethdb.Database) error {
-	var version uint64
+func readArbDbSchemaVersion(arbDb ethdb.Database) (uint64, bool, error) {
 	hasVersion, err := arbDb.Has(dbSchemaVersion)
+	if err != nil || !hasVersion {
+		return 0, false, err
+	}
+	versionBytes, err := arbDb.Get(dbSchemaVersion)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(versionBytes) != 8 {
+		return 0, false, fmt.Errorf("invalid database schema version marker %v", versionBytes)
+	}
+	return binary.BigEndian.Uint64(versionBytes), true, nil
+}
+
+func writeArbDbSchemaVersion(db ethdb.KeyValueWriter, version uint64) error {
+	versionBytes := make([]uint8, 8)
+	binary.BigEndian.PutUint64(versionBytes, version)
+	return db.Put(dbSchemaVersion, versionBytes)
+}
+
+func isEmptyDb(db ethdb.Iteratee) (bool, error) {
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	if iter.Next() {
+		return false, nil
+	}
+	return true, iter.Error()
+}
+
+// checkArbDbSchemaVersion refuses to use a database written in a different format than this binary expects
+func checkArbDbSchemaVersion(arbDb ethdb.Database) error {
+	version, hasVersion, err := readArbDbSchemaVersion(arbDb)
 	if err != nil {
 		return err
 	}
-	if hasVersion {
-		versionBytes, err := arbDb.Get(dbSchemaVersion)
+	if !hasVersion {
+		empty, err := isEmptyDb(arbDb)
 		if err != nil {
 			return err
 		}
-		version = binary.BigEndian.Uint64(versionBytes)
+		if empty {
+			return writeArbDbSchemaVersion(arbDb, currentDbSchemaVersion)
+		}
+		// databases from before the marker was introduced are all in the initial format, and are
+		// only stamped by migrating them
+		version = 0
+	}
+	if version > currentDbSchemaVersion {
+		return fmt.Errorf("database schema version %v is newer than the latest version %v supported by this binary, upgrade nitro or use a compatible database", version, currentDbSchemaVersion)
+	}
+	if version < currentDbSchemaVersion {
+		return fmt.Errorf("database schema version %v is older than the version %v expected by this binary, run \"nitro migrate-db\" with the node stopped to migrate it", version, currentDbSchemaVersion)
+	}
+	return nil
+}
+
+// MigrateArbDb runs the registered forward migrations needed to bring the database to the current schema version.
+// Each migration is written together with its version marker, so an interrupted migration can be safely rerun.
+func MigrateArbDb(arbDb ethdb.Database, progress func(from, to uint64)) error {
+	if uint64(len(dbMigrations)) != currentDbSchemaVersion {
+		return fmt.Errorf("have %v database migrations registered for schema version %v", len(dbMigrations), currentDbSchemaVersion)
+	}
+	version, _, err := readArbDbSchemaVersion(arbDb)
+	if err != nil {
+		return err
+	}
+	if version > currentDbSchemaVersion {
+		return fmt.Errorf("refusing to downgrade database from schema version %v to %v", version, currentDbSchemaVersion)
 	}
-	for version != currentDbSchemaVersion {
+	for ; version < currentDbSchemaVersion; version++ {
+		progress(version, version+1)
 		batch := arbDb.NewBatch()
-		switch version {
-		case ^uint64(0):
-			// TODO: write db format upgrade code
-			// This code path is here to avoid a bunch of linter errors
-		default:
-			return fmt.Errorf("unsupported database format version %v", version)
-		}
-
-		// Increment version and flush the batch
-		version++
-		versionBytes := make([]uint8, 8)
-		binary.BigEndian.PutUint64(versionBytes, version)
-		err = batch.Put(dbSchemaVersion, versionBytes)
-		if err != nil {
+		if err := dbMigrations[version](arbDb, batch); err != nil {
+			return fmt.Errorf("error migrating database from schema version %v: %w", version, err)
+		}
+		if err := writeArbDbSchemaVersion(batch, version+1); err != nil {
 			return err
 		}
-		err = batch.Write()
-		if err != nil {
+		if err := batch.Write(); err != nil {
 			return err
 		}
 	}
-	return nil
+	return writeArbDbSchemaVersion(arbDb, version)
 }
 
 func createNodeImpl(
@@ -759,6 +955,9 @@ func createNodeImpl(
 			return nil, err
 		}
 	}
+	if err := config.Dev.checkArbOSVersion(l2BlockChain); err != nil {
+		return nil, err
+	}
 
 	syncMonitor := NewSyncMonitor(&config.SyncMonitor)
 	var classicOutbox *ClassicOutboxRetriever
@@ -789,7 +988,7 @@ func createNodeImpl(
 		l1Reader = headerreader.New(l1client, func() *headerreader.Config { return &config.Get().L1Reader })
 	}
 
-	txStreamer, err := NewTransactionStreamer(arbDb, l2BlockChain, broadcastServer, fatalErrChan)
+	txStreamer, err := NewTransactionStreamer(arbDb, l2BlockChain, broadcastServer, fatalErrChan, func() *TransactionStreamerConfig { return &config.Get().TransactionStreamer })
 	if err != nil {
 		return nil, err
 	}
@@ -814,14 +1013,23 @@ func createNodeImpl(
 		if !(config.SeqCoordinator.Enable || config.Sequencer.Dangerous.NoCoordinator) {
 			return nil, errors.New("sequencer must be enabled with coordinator, unless dangerous.no-coordinator set")
 		}
+		if config.Dev.enabled() && !l2Config.DebugMode() {
+			return nil, errors.New("node.dev options require a chain with debug mode enabled")
+		}
 		sequencerConfigFetcher := func() *SequencerConfig { return &configFetcher.Get().Sequencer }
+		devConfigFetcher := func() *DevConfig {
+			if !l2Config.DebugMode() {
+				return &DefaultDevConfig
+			}
+			return &configFetcher.Get().Dev
+		}
 		if config.L1Reader.Enable {
 			if l1client == nil {
 				return nil, errors.New("l1client is nil")
 			}
-			sequencer, err = NewSequencer(txStreamer, l1Reader, sequencerConfigFetcher)
+			sequencer, err = NewSequencer(txStreamer, l1Reader, sequencerConfigFetcher, devConfigFetcher)
 		} else {
-			sequencer, err = NewSequencer(txStreamer, nil, sequencerConfigFetcher)
+			sequencer, err = NewSequencer(txStreamer, nil, sequencerConfigFetcher, devConfigFetcher)
 		}
 		if err != nil {
 			return nil, err
@@ -848,7 +1056,7 @@ func createNodeImpl(
 	if err != nil {
 		return nil, err
 	}
-	backend, err := arbitrum.NewBackend(stack, &config.RPC, chainDb, arbInterface, syncMonitor)
+	backend, err := arbitrum.NewBackend(stack, &config.RPC.Config, chainDb, arbInterface, syncMonitor)
 	if err != nil {
 		return nil, err
 	}
@@ -872,6 +1080,10 @@ func createNodeImpl(
 			return nil, err
 		}
 	}
+	var chainBackup *ChainBackup
+	if config.Backup.Enable {
+		chainBackup = NewChainBackup(&config.Backup, chainDb, arbDb)
+	}
 	if !config.L1Reader.Enable {
 		return &Node{
 			stack,
@@ -894,19 +1106,28 @@ func createNodeImpl(
 			nil,
 			classicOutbox,
 			syncMonitor,
+			chainBackup,
+			nil,
+			nil,
 			configFetcher,
 			ctx,
+			nil,
 		}, nil
 	}
 
 	if deployInfo == nil {
 		return nil, errors.New("deployinfo is nil")
 	}
-	delayedBridge, err := NewDelayedBridge(l1client, deployInfo.Bridge, deployInfo.DeployedAt)
+	// the inbox reader's requests are bounded so catching up doesn't trip the L1 provider's rate limit
+	inboxClient := l1client
+	if maxConcurrent := config.L1Reader.MaxConcurrentRequests; maxConcurrent > 0 {
+		inboxClient = headerreader.NewLimitedClient(l1client, maxConcurrent)
+	}
+	delayedBridge, err := NewDelayedBridge(inboxClient, deployInfo.Bridge, deployInfo.DeployedAt)
 	if err != nil {
 		return nil, err
 	}
-	sequencerInbox, err := NewSequencerInbox(l1client, deployInfo.SequencerInbox, int64(deployInfo.DeployedAt))
+	sequencerInbox, err := NewSequencerInbox(inboxClient, deployInfo.SequencerInbox, int64(deployInfo.DeployedAt))
 	if err != nil {
 		return nil, err
 	}
@@ -943,7 +1164,7 @@ func createNodeImpl(
 	if err != nil {
 		return nil, err
 	}
-	inboxReader, err := NewInboxReader(inboxTracker, l1client, l1Reader, new(big.Int).SetUint64(deployInfo.DeployedAt), delayedBridge, sequencerInbox, func() *InboxReaderConfig { return &config.Get().InboxReader })
+	inboxReader, err := NewInboxReader(inboxTracker, inboxClient, l1Reader, new(big.Int).SetUint64(deployInfo.DeployedAt), delayedBridge, sequencerInbox, func() *InboxReaderConfig { return &config.Get().InboxReader })
 	if err != nil {
 		return nil, err
 	}
@@ -964,8 +1185,10 @@ func createNodeImpl(
 	var blockValidator *validator.BlockValidator
 	var statelessBlockValidator *validator.StatelessBlockValidator
 
-	if !foundMachines && blockValidatorConf.Enable {
-		return nil, fmt.Errorf("failed to find machines %v", machinesPath)
+	if !foundMachines && blockValidatorConf.Enable && blockValidatorConf.Required {
+		return nil, fmt.Errorf("block validation is enabled but no machines directory was found (last searched %v, current-module-root %q); set --node.wasm.root-path to a directory holding the machines, or --node.block-validator.required=false to run without validation", machinesPath, blockValidatorConf.CurrentModuleRoot)
+	} else if !foundMachines && blockValidatorConf.Enable {
+		log.Error("BLOCK VALIDATION DISABLED: failed to find machines, the node will run without validating blocks", "path", machinesPath, "currentModuleRoot", blockValidatorConf.CurrentModuleRoot)
 	} else if !foundMachines {
 		log.Warn("Failed to find machines", "path", machinesPath)
 	} else {
@@ -1046,6 +1269,23 @@ func createNodeImpl(
 		log.Info("running as validator", "txSender", txSenderPtr, "actingAsWallet", wallet.Address(), "whitelisted", whitelisted, "strategy", config.Validator.Strategy)
 	}
 
+	var divergenceMonitor *validator.DivergenceMonitor
+	if config.DivergenceCheck.Enable {
+		divergenceMonitor, err = validator.NewDivergenceMonitor(&config.DivergenceCheck, l1client, deployInfo.Rollup, l2BlockChain, inboxTracker, txStreamer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var finalityFeed *FinalityFeed
+	if config.FinalityFeed.Output.Enable {
+		if dataSigner == nil {
+			return nil, errors.New("cannot sign finality feed")
+		}
+		finalityBroadcaster := broadcaster.NewFinalityBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &configFetcher.Get().FinalityFeed.Output }, l2ChainId, fatalErrChan, dataSigner)
+		finalityFeed = NewFinalityFeed(func() *FinalityFeedConfig { return &configFetcher.Get().FinalityFeed }, finalityBroadcaster, l1Reader, inboxTracker, txStreamer)
+	}
+
 	var batchPoster *BatchPoster
 	var delayedSequencer *DelayedSequencer
 	if config.BatchPoster.Enable {
@@ -1056,9 +1296,12 @@ func createNodeImpl(
 		if err != nil {
 			return nil, err
 		}
+		if coordinator != nil {
+			batchPoster.SetSeqCoordinator(coordinator)
+		}
 	}
 	// always create DelayedSequencer, it won't do anything if it is disabled
-	delayedSequencer, err = NewDelayedSequencer(l1Reader, inboxReader, txStreamer, coordinator, func() *DelayedSequencerConfig { return &configFetcher.Get().DelayedSequencer })
+	delayedSequencer, err = NewDelayedSequencer(l1Reader, inboxReader, txStreamer, coordinator, func() *DelayedSequencerConfig { return &configFetcher.Get().DelayedSequencer }, func() *SequencerConfig { return &configFetcher.Get().Sequencer })
 	if err != nil {
 		return nil, err
 	}
@@ -1084,8 +1327,12 @@ func createNodeImpl(
 		dasLifecycleManager,
 		classicOutbox,
 		syncMonitor,
+		chainBackup,
+		divergenceMonitor,
+		finalityFeed,
 		configFetcher,
 		ctx,
+		nil,
 	}, nil
 }
 
@@ -1274,7 +1521,7 @@ func SetUpDataAvailability(
 		if err != nil {
 			return nil, nil, err
 		}
-		topLevelDas = das.NewCacheStorageToDASAdapter(topLevelDas, cache)
+		topLevelDas = das.NewCacheStorageToDASAdapter(topLevelDas, das.NewStorageServiceMetricsWrapper(cache, "redis_cache"))
 	}
 	if config.LocalCacheConfig.Enable {
 		cache, err := das.NewBigCacheStorageService(config.LocalCacheConfig, das.NewEmptyStorageService())
@@ -1282,7 +1529,7 @@ func SetUpDataAvailability(
 		if err != nil {
 			return nil, nil, err
 		}
-		topLevelDas = das.NewCacheStorageToDASAdapter(topLevelDas, cache)
+		topLevelDas = das.NewCacheStorageToDASAdapter(topLevelDas, das.NewStorageServiceMetricsWrapper(cache, "local_cache"))
 	}
 
 	if topLevelDas != nil && seqInbox != nil {
@@ -1296,7 +1543,7 @@ func SetUpDataAvailability(
 		return nil, nil, errors.New("data-availability.enable was specified but no Data Availability server types were enabled")
 	}
 
-	return topLevelDas, dasLifecycleManager, nil
+	return das.NewMetricsWrapper(topLevelDas), dasLifecycleManager, nil
 }
 
 func CreateNode(
@@ -1316,6 +1563,7 @@ func CreateNode(
 	if err != nil {
 		return nil, err
 	}
+	config := configFetcher.Get()
 	var apis []rpc.API
 	if currentNode.BlockValidator != nil {
 		apis = append(apis, rpc.API{
@@ -1340,10 +1588,33 @@ func CreateNode(
 	apis = append(apis, rpc.API{
 		Namespace: "arb",
 		Version:   "1.0",
-		Service:   &ArbAPI{currentNode.TxPublisher},
+		Service:   &ArbAPI{currentNode.TxPublisher, l2BlockChain, &config.Caching},
+		Public:    false,
+	})
+	apis = append(apis, rpc.API{
+		Namespace: "nitro",
+		Version:   "1.0",
+		Service: &NitroAPI{
+			blockchain:      l2BlockChain,
+			txStreamer:      currentNode.TxStreamer,
+			inboxReader:     currentNode.InboxReader,
+			batchPoster:     currentNode.BatchPoster,
+			broadcastServer: currentNode.BroadcastServer,
+			chainDb:         chainDb,
+			arbDb:           arbDb,
+			staker:          currentNode.Staker,
+			txPublisher:     currentNode.TxPublisher,
+			cachingConfig:   &config.Caching,
+			config:          configFetcher,
+		},
+		Public: false,
+	})
+	apis = append(apis, rpc.API{
+		Namespace: "nitroadmin",
+		Version:   "1.0",
+		Service:   &NitroAdminAPI{inboxReader: currentNode.InboxReader},
 		Public:    false,
 	})
-	config := configFetcher.Get()
 	apis = append(apis, rpc.API{
 		Namespace: "arbdebug",
 		Version:   "1.0",
@@ -1351,6 +1622,7 @@ func CreateNode(
 			blockchain:        l2BlockChain,
 			blockRangeBound:   config.RPC.ArbDebug.BlockRangeBound,
 			timeoutQueueBound: config.RPC.ArbDebug.TimeoutQueueBound,
+			cachingConfig:     &config.Caching,
 		},
 		Public: false,
 	})
@@ -1363,6 +1635,21 @@ func CreateNode(
 		},
 		Public: false,
 	})
+	apis = append(apis, rpc.API{
+		Namespace: "eth",
+		Version:   "1.0",
+		Service:   &FeeHistoryAPI{blockchain: l2BlockChain},
+		Public:    true,
+	})
+	apis = append(apis, rpc.API{
+		Namespace: "eth",
+		Version:   "1.0",
+		Service: &ConditionalTxAPI{
+			txPublisher: currentNode.TxPublisher,
+			config:      func() *ConditionalTxConfig { return &configFetcher.Get().ConditionalTx },
+		},
+		Public: true,
+	})
 	stack.RegisterAPIs(apis)
 
 	return currentNode, nil
@@ -1395,6 +1682,12 @@ func (n *Node) Start(ctx context.Context) error {
 			return fmt.Errorf("error initializing feed broadcast server: %w", err)
 		}
 	}
+	if n.FinalityFeed != nil {
+		err = n.FinalityFeed.Initialize()
+		if err != nil {
+			return fmt.Errorf("error initializing finality feed: %w", err)
+		}
+	}
 	n.TxStreamer.Start(ctx)
 	if n.InboxReader != nil {
 		err = n.InboxReader.Start(ctx)
@@ -1423,12 +1716,23 @@ func (n *Node) Start(ctx context.Context) error {
 	}
 	if n.BlockValidator != nil {
 		err = n.BlockValidator.Initialize()
-		if err != nil {
+		if err != nil && n.BlockValidator.Required() {
 			return fmt.Errorf("error initializing block validator: %w", err)
-		}
-		err = n.BlockValidator.Start(ctx)
-		if err != nil {
-			return fmt.Errorf("error starting block validator: %w", err)
+		} else if err != nil {
+			log.Error("BLOCK VALIDATION DISABLED: failed to initialize the block validator, the node will run without validating blocks", "err", err)
+			n.TxStreamer.DetachBlockValidator()
+			if n.InboxTracker != nil {
+				n.InboxTracker.SetBlockValidator(nil)
+			}
+			if n.Staker != nil {
+				n.Staker.DetachBlockValidator()
+			}
+			n.BlockValidator = nil
+		} else {
+			err = n.BlockValidator.Start(ctx)
+			if err != nil {
+				return fmt.Errorf("error starting block validator: %w", err)
+			}
 		}
 	}
 	if n.Staker != nil {
@@ -1446,56 +1750,22 @@ func (n *Node) Start(ctx context.Context) error {
 	if n.BroadcastClients != nil {
 		n.BroadcastClients.Start(ctx)
 	}
-	if n.configFetcher != nil {
-		n.configFetcher.Start(ctx)
-	}
-	return nil
-}
-
-func (n *Node) StopAndWait() {
-	if n.configFetcher != nil && n.configFetcher.Started() {
-		n.configFetcher.StopAndWait()
-	}
-	if n.BroadcastClients != nil {
-		n.BroadcastClients.StopAndWait()
+	if n.ChainBackup != nil {
+		n.ChainBackup.Start(ctx)
 	}
-	if n.BroadcastServer != nil && n.BroadcastServer.Started() {
-		n.BroadcastServer.StopAndWait()
+	if n.DivergenceMonitor != nil {
+		n.DivergenceMonitor.Start(ctx)
 	}
-	if n.L1Reader != nil && n.L1Reader.Started() {
-		n.L1Reader.StopAndWait()
-	}
-	if n.BlockValidator != nil && n.BlockValidator.Started() {
-		n.BlockValidator.StopAndWait()
-	}
-	if n.BatchPoster != nil && n.BatchPoster.Started() {
-		n.BatchPoster.StopAndWait()
-	}
-	if n.DelayedSequencer != nil && n.DelayedSequencer.Started() {
-		n.DelayedSequencer.StopAndWait()
-	}
-	if n.InboxReader != nil && n.InboxReader.Started() {
-		n.InboxReader.StopAndWait()
-	}
-	if n.SeqCoordinator != nil && n.SeqCoordinator.Started() {
-		n.SeqCoordinator.StopAndWait()
-	}
-	if n.TxPublisher.Started() {
-		n.TxPublisher.StopAndWait()
-	}
-	if n.TxStreamer.Started() {
-		n.TxStreamer.StopAndWait()
-	}
-	n.ArbInterface.BlockChain().Stop() // does nothing if not running
-	if err := n.Backend.Stop(); err != nil {
-		log.Error("backend stop", "err", err)
-	}
-	if n.DASLifecycleManager != nil {
-		n.DASLifecycleManager.StopAndWaitUntil(2 * time.Second)
+	if n.FinalityFeed != nil {
+		err = n.FinalityFeed.Start(ctx)
+		if err != nil {
+			return fmt.Errorf("error starting finality feed: %w", err)
+		}
 	}
-	if err := n.Stack.Close(); err != nil {
-		log.Error("error on stak close", "err", err)
+	if n.configFetcher != nil {
+		n.configFetcher.Start(ctx)
 	}
+	return nil
 }
 
 func CreateDefaultStackForTest(dataDir string) (*node.Node, error) {
@@ -1514,14 +1784,23 @@ func CreateDefaultStackForTest(dataDir string) (*node.Node, error) {
 	return stack, nil
 }
 
-func DefaultCacheConfigFor(stack *node.Node, cachingConfig *CachingConfig) *core.CacheConfig {
-	baseConf := ethconfig.Defaults
+func baseCacheConfigFor(cachingConfig *CachingConfig) ethconfig.Config {
 	if cachingConfig.Archive {
-		baseConf = ethconfig.ArchiveDefaults
+		return ethconfig.ArchiveDefaults
 	}
+	return ethconfig.Defaults
+}
+
+// trieCleanCacheLimit returns the size in MB of the trie clean cache
+func trieCleanCacheLimit(cachingConfig *CachingConfig) int {
+	return baseCacheConfigFor(cachingConfig).TrieCleanCache
+}
+
+func DefaultCacheConfigFor(stack *node.Node, cachingConfig *CachingConfig) *core.CacheConfig {
+	baseConf := baseCacheConfigFor(cachingConfig)
 
 	return &core.CacheConfig{
-		TrieCleanLimit:      baseConf.TrieCleanCache,
+		TrieCleanLimit:      trieCleanCacheLimit(cachingConfig),
 		TrieCleanJournal:    stack.ResolvePath(baseConf.TrieCleanCacheJournal),
 		TrieCleanRejournal:  baseConf.TrieCleanCacheRejournal,
 		TrieCleanNoPrefetch: baseConf.NoPrefetch,
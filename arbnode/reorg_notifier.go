@@ -0,0 +1,40 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	reorgSubscribersGauge        = metrics.NewRegisteredGauge("arb/txstreamer/reorg/subscribers", nil)
+	reorgSubscriptionDropCounter = metrics.NewRegisteredCounter("arb/txstreamer/reorg/subscriptions_dropped", nil)
+)
+
+const reorgSubscriptionOverflowReason = "reorg notification buffer full, resubscribe and check the chain head"
+
+type ReorgEvent struct {
+	PreviousMessageCount hexutil.Uint64 `json:"previousMessageCount"`
+	MessageCount         hexutil.Uint64 `json:"messageCount"`
+	BlockNumber          hexutil.Uint64 `json:"blockNumber"`
+	BlockHash            *common.Hash   `json:"blockHash,omitempty"`
+	// set on the last notification of a dropped subscription, which receives no further reorgs
+	Dropped string `json:"dropped,omitempty"`
+}
+
+func newReorgNotifier(bufferSize func() int) *subscriptionNotifier[ReorgEvent] {
+	return newSubscriptionNotifier[ReorgEvent]("reorgs", bufferSize, reorgSubscribersGauge, reorgSubscriptionDropCounter)
+}
+
+// Reorgs notifies the subscriber of each reorg of the streamer's messages, with the new message count
+// and head block. A subscriber that falls more than transaction-streamer.reorg-subscription-buffer
+// reorgs behind is sent a final notification with the dropped reason, and no more after it.
+func (api *NitroAPI) Reorgs(ctx context.Context) (*rpc.Subscription, error) {
+	return api.txStreamer.reorgNotifier.serve(ctx, ReorgEvent{Dropped: reorgSubscriptionOverflowReason})
+}
@@ -0,0 +1,92 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/arbitrum"
+	flag "github.com/spf13/pflag"
+)
+
+// RPCConfig adds nitro's options to geth's, under the same prefix
+type RPCConfig struct {
+	arbitrum.Config      `koanf:",squash"`
+	ArchiveFallback      ArchiveFallbackConfig `koanf:"archive-fallback"`
+	SlowRequestThreshold time.Duration         `koanf:"slow-request-threshold" reload:"hot"`
+	MaxConcurrentTraces  int                   `koanf:"max-concurrent-traces"`
+	TraceQueueSize       int                   `koanf:"trace-queue-size"`
+	TraceTimeout         time.Duration         `koanf:"trace-timeout" reload:"hot"`
+	TraceMaxSteps        uint64                `koanf:"trace-max-steps" reload:"hot"`
+}
+
+var DefaultRPCConfig = RPCConfig{
+	Config:               arbitrum.DefaultConfig,
+	ArchiveFallback:      DefaultArchiveFallbackConfig,
+	SlowRequestThreshold: 0,
+	MaxConcurrentTraces:  0,
+	TraceQueueSize:       64,
+	TraceTimeout:         0,
+	TraceMaxSteps:        0,
+}
+
+func RPCConfigAddOptions(prefix string, f *flag.FlagSet) {
+	arbitrum.ConfigAddOptions(prefix, f)
+	ArchiveFallbackConfigAddOptions(prefix+".archive-fallback", f)
+	f.Duration(prefix+".slow-request-threshold", DefaultRPCConfig.SlowRequestThreshold, "log HTTP-RPC requests taking longer than this with their methods, params size, duration, and client IP "+
+		"(0 to disable, it must be set at startup to be changed by a reload)")
+	f.Int(prefix+".max-concurrent-traces", DefaultRPCConfig.MaxConcurrentTraces, "maximum HTTP-RPC requests calling debug_trace* methods to run at once, other requests aren't limited (0 for no limit)")
+	f.Int(prefix+".trace-queue-size", DefaultRPCConfig.TraceQueueSize, "HTTP-RPC trace requests to queue once max-concurrent-traces are running, rejecting more as busy")
+	f.Duration(prefix+".trace-timeout", DefaultRPCConfig.TraceTimeout, "abort tracing a transaction in HTTP-RPC debug_trace* requests after this long, lowering any longer timeout they ask for "+
+		"(0 to disable, it must be set at startup to be changed by a reload)")
+	f.Uint64(prefix+".trace-max-steps", DefaultRPCConfig.TraceMaxSteps, "fail HTTP-RPC debug_trace* requests using the default struct logger that trace a transaction for more than this many steps "+
+		"(0 to disable, it must be set at startup to be changed by a reload)")
+}
+
+func (c *RPCConfig) Validate() error {
+	if c.SlowRequestThreshold < 0 {
+		return fmt.Errorf("invalid slow request threshold %v", c.SlowRequestThreshold)
+	}
+	if c.MaxConcurrentTraces < 0 {
+		return fmt.Errorf("invalid max concurrent traces %v", c.MaxConcurrentTraces)
+	}
+	if c.TraceQueueSize < 0 {
+		return fmt.Errorf("invalid trace queue size %v", c.TraceQueueSize)
+	}
+	if c.TraceTimeout < 0 {
+		return fmt.Errorf("invalid trace timeout %v", c.TraceTimeout)
+	}
+	return c.ArchiveFallback.Validate()
+}
+
+type ArchiveFallbackConfig struct {
+	URL     string        `koanf:"url"`
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+var DefaultArchiveFallbackConfig = ArchiveFallbackConfig{
+	URL:     "",
+	Timeout: 10 * time.Second,
+}
+
+func ArchiveFallbackConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", DefaultArchiveFallbackConfig.URL, "HTTP URL of an archive node to forward HTTP-RPC requests that fail for pruned state to, serving its result instead (empty to disable)")
+	f.Duration(prefix+".timeout", DefaultArchiveFallbackConfig.Timeout, "timeout for requests forwarded to the archive node")
+}
+
+func (c *ArchiveFallbackConfig) Validate() error {
+	if c.URL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(c.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid archive fallback url %q, expected an http or https URL", c.URL)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("invalid archive fallback timeout %v", c.Timeout)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@
 
 package arbnode
 
+import "github.com/ethereum/go-ethereum/ethdb"
+
 var (
 	blockValidatorPrefix     string = "v"         // the prefix for all block validator keys
 	messagePrefix            []byte = []byte("m") // maps a message sequence number to a message
@@ -17,3 +19,6 @@ var (
 )
 
 const currentDbSchemaVersion uint64 = 0
+
+// dbMigrations[v] migrates the database from schema version v to v+1, writing its changes to the batch
+var dbMigrations = []func(arbDb ethdb.Database, batch ethdb.Batch) error{}
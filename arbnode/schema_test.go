@@ -0,0 +1,52 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestArbDbSchemaVersion(t *testing.T) {
+	arbDb := rawdb.NewMemoryDatabase()
+
+	// a fresh database gets the current marker
+	Require(t, checkArbDbSchemaVersion(arbDb))
+	version, hasVersion, err := readArbDbSchemaVersion(arbDb)
+	Require(t, err)
+	if !hasVersion || version != currentDbSchemaVersion {
+		Fail(t, "unexpected schema version", version, hasVersion)
+	}
+	Require(t, checkArbDbSchemaVersion(arbDb))
+
+	// migrating a current database does nothing
+	Require(t, MigrateArbDb(arbDb, func(from, to uint64) {
+		Fail(t, "unexpected migration from", from, "to", to)
+	}))
+
+	// an existing database without a marker is in the initial format, and isn't stamped as current
+	oldDb := rawdb.NewMemoryDatabase()
+	Require(t, oldDb.Put(messageCountKey, []byte{0}))
+	err = checkArbDbSchemaVersion(oldDb)
+	if currentDbSchemaVersion == 0 {
+		Require(t, err)
+	} else if err == nil {
+		Fail(t, "accepted an unmigrated database without a schema version")
+	}
+	_, hasVersion, err = readArbDbSchemaVersion(oldDb)
+	Require(t, err)
+	if hasVersion {
+		Fail(t, "stamped an existing database without migrating it")
+	}
+
+	// a database from a newer binary is rejected
+	Require(t, writeArbDbSchemaVersion(arbDb, currentDbSchemaVersion+1))
+	if checkArbDbSchemaVersion(arbDb) == nil {
+		Fail(t, "accepted a database with a newer schema version")
+	}
+	if MigrateArbDb(arbDb, func(uint64, uint64) {}) == nil {
+		Fail(t, "downgraded a database with a newer schema version")
+	}
+}
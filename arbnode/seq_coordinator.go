@@ -30,9 +30,13 @@ import (
 )
 
 var (
-	isActiveSequencer = metrics.NewRegisteredGauge("arb/sequencer/active", nil)
+	isActiveSequencer        = metrics.NewRegisteredGauge("arb/sequencer/active", nil)
+	fencingRejectionsCounter = metrics.NewRegisteredCounter("arb/coordinator/fencing_rejections", nil)
+	blockedPromotionsCounter = metrics.NewRegisteredCounter("arb/coordinator/promotions_blocked", nil)
 )
 
+var ErrFencedOut = errors.New("sequencer fenced out by a newer chosen sequencer")
+
 type SeqCoordinator struct {
 	stopwaiter.StopWaiter
 
@@ -46,8 +50,18 @@ type SeqCoordinator struct {
 
 	prevChosenSequencer string
 	reportedAlive       bool
+	leaderlessSince     time.Time // when the chosen lease was first seen expired, from workthread
+	promoted            bool      // chosen by auto-promote rather than as the recommended sequencer
 
 	lockoutUntil int64 // atomic
+	// fencingToken is the value of the fencing token key set when this node last took the chosen
+	// key. Another instance taking it increments the key, fencing this one out even if it shares
+	// this node's url or hasn't noticed its lockout expired.
+	fencingToken uint64 // atomic
+	fenced       int32  // atomic, set when fenced out for the work thread to stop acting chosen
+	// fencedOut stays set from being fenced out until this node takes the chosen key again with
+	// a new fencing token, and blocks the batch poster meanwhile
+	fencedOut int32 // atomic
 
 	chosenUpdateMutex sync.Mutex // manages access to chosenOneUpdate
 	redisErrors       int        // error counter, from workthread
@@ -65,6 +79,9 @@ type SeqCoordinatorConfig struct {
 	SafeShutdownDelay     time.Duration              `koanf:"safe-shutdown-delay"`
 	MaxMsgPerPoll         arbutil.MessageIndex       `koanf:"msg-per-poll"`
 	MyUrlImpl             string                     `koanf:"my-url"`
+	AutoPromote           bool                       `koanf:"auto-promote"`
+	PromotionTimeout      time.Duration              `koanf:"promotion-timeout"`
+	ReconcilePromotion    bool                       `koanf:"reconcile-promotion"`
 	Signing               signature.SignVerifyConfig `koanf:"signer"`
 }
 
@@ -76,6 +93,13 @@ func (c *SeqCoordinatorConfig) MyUrl() string {
 	return c.MyUrlImpl
 }
 
+func (c *SeqCoordinatorConfig) Validate() error {
+	if c.PromotionTimeout < 0 {
+		return fmt.Errorf("seq-coordinator promotion-timeout cannot be negative, not %v", c.PromotionTimeout)
+	}
+	return nil
+}
+
 func SeqCoordinatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultSeqCoordinatorConfig.Enable, "enable sequence coordinator")
 	f.String(prefix+".redis-url", DefaultSeqCoordinatorConfig.RedisUrl, "the Redis URL to coordinate via")
@@ -88,6 +112,9 @@ func SeqCoordinatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Duration(prefix+".safe-shutdown-delay", DefaultSeqCoordinatorConfig.SafeShutdownDelay, "if non-zero will add delay after transferring control")
 	f.Uint16(prefix+".msg-per-poll", uint16(DefaultSeqCoordinatorConfig.MaxMsgPerPoll), "will only be marked live if not too far behind")
 	f.String(prefix+".my-url", DefaultSeqCoordinatorConfig.MyUrlImpl, "url for this sequencer if it is the chosen")
+	f.Bool(prefix+".auto-promote", DefaultSeqCoordinatorConfig.AutoPromote, "take over as the chosen sequencer once the chosen lease has been expired for promotion-timeout, even if this isn't the top priority live sequencer")
+	f.Duration(prefix+".promotion-timeout", DefaultSeqCoordinatorConfig.PromotionTimeout, "how long the chosen lease must be expired without renewal before auto-promote takes over")
+	f.Bool(prefix+".reconcile-promotion", DefaultSeqCoordinatorConfig.ReconcilePromotion, "refuse to auto-promote unless this node's message count matches the coordinator's and no feed messages past it are pending")
 	signature.SignVerifyConfigAddOptions(prefix+".signer", f)
 }
 
@@ -103,21 +130,27 @@ var DefaultSeqCoordinatorConfig = SeqCoordinatorConfig{
 	RetryInterval:         time.Second,
 	MaxMsgPerPoll:         2000,
 	MyUrlImpl:             redisutil.INVALID_URL,
+	AutoPromote:           false,
+	PromotionTimeout:      time.Second * 30,
+	ReconcilePromotion:    true,
 	Signing:               signature.DefaultSignVerifyConfig,
 }
 
 var TestSeqCoordinatorConfig = SeqCoordinatorConfig{
-	Enable:            false,
-	RedisUrl:          redisutil.DefaultTestRedisURL,
-	LockoutDuration:   time.Second * 2,
-	LockoutSpare:      time.Millisecond * 10,
-	SeqNumDuration:    time.Minute * 10,
-	UpdateInterval:    time.Millisecond * 10,
-	SafeShutdownDelay: time.Duration(0),
-	RetryInterval:     time.Millisecond * 3,
-	MaxMsgPerPoll:     20,
-	MyUrlImpl:         redisutil.INVALID_URL,
-	Signing:           signature.DefaultSignVerifyConfig,
+	Enable:             false,
+	RedisUrl:           redisutil.DefaultTestRedisURL,
+	LockoutDuration:    time.Second * 2,
+	LockoutSpare:       time.Millisecond * 10,
+	SeqNumDuration:     time.Minute * 10,
+	UpdateInterval:     time.Millisecond * 10,
+	SafeShutdownDelay:  time.Duration(0),
+	RetryInterval:      time.Millisecond * 3,
+	MaxMsgPerPoll:      20,
+	MyUrlImpl:          redisutil.INVALID_URL,
+	AutoPromote:        false,
+	PromotionTimeout:   time.Millisecond * 100,
+	ReconcilePromotion: true,
+	Signing:            signature.DefaultSignVerifyConfig,
 }
 
 func NewSeqCoordinator(dataSigner signature.DataSignerFunc, bpvalidator *contracts.BatchPosterVerifier, streamer *TransactionStreamer, sequencer *Sequencer, sync *SyncMonitor, config SeqCoordinatorConfig) (*SeqCoordinator, error) {
@@ -241,6 +274,8 @@ func (c *SeqCoordinator) chosenOneUpdate(ctx context.Context, msgCountExpected,
 	c.chosenUpdateMutex.Lock()
 	defer c.chosenUpdateMutex.Unlock()
 	lockoutUntil := time.Now().Add(c.config.LockoutDuration)
+	var acquiring, fencedOut bool
+	var newToken *redis.IntCmd
 	err = c.Client.Watch(ctx, func(tx *redis.Tx) error {
 		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
 		var wasEmpty bool
@@ -254,6 +289,17 @@ func (c *SeqCoordinator) chosenOneUpdate(ctx context.Context, msgCountExpected,
 		if !wasEmpty && (current != c.config.MyUrl()) {
 			return fmt.Errorf("%w: failed to catch lock. redis shows chosen: %s", ErrRetrySequencer, current)
 		}
+		remoteToken, err := getFencingToken(ctx, tx)
+		if err != nil {
+			return err
+		}
+		myToken := atomic.LoadUint64(&c.fencingToken)
+		// a node that never held the chosen key takes it over even from an instance with its url
+		acquiring = wasEmpty || myToken == 0
+		if !acquiring && remoteToken != myToken {
+			fencedOut = true
+			return fmt.Errorf("%w: fenced out, fencing token %d superseded by %d", ErrRetrySequencer, myToken, remoteToken)
+		}
 		remoteMsgCount, err := c.getRemoteMsgCountImpl(ctx, tx)
 		if err != nil {
 			return err
@@ -275,6 +321,9 @@ func (c *SeqCoordinator) chosenOneUpdate(ctx context.Context, msgCountExpected,
 		if wasEmpty {
 			pipe.Set(ctx, redisutil.CHOSENSEQ_KEY, c.config.MyUrl(), initialDuration)
 		}
+		if acquiring {
+			newToken = pipe.Incr(ctx, redisutil.FENCING_TOKEN_KEY)
+		}
 		pipe.Set(ctx, redisutil.MSG_COUNT_KEY, msgCountMsg, c.config.SeqNumDuration)
 		myLivelinessKey := redisutil.LivelinessKeyFor(c.config.MyUrl())
 		pipe.Set(ctx, myLivelinessKey, redisutil.LIVELINESS_VAL, initialDuration)
@@ -294,16 +343,65 @@ func (c *SeqCoordinator) chosenOneUpdate(ctx context.Context, msgCountExpected,
 			return fmt.Errorf("chosen sequencer failed to update redis: %w", err)
 		}
 		return nil
-	}, redisutil.CHOSENSEQ_KEY, redisutil.MSG_COUNT_KEY)
+	}, redisutil.CHOSENSEQ_KEY, redisutil.MSG_COUNT_KEY, redisutil.FENCING_TOKEN_KEY)
 
+	if fencedOut {
+		c.fenceOut(err)
+	}
 	if err != nil {
 		return err
 	}
+	if acquiring && newToken != nil {
+		atomic.StoreUint64(&c.fencingToken, uint64(newToken.Val()))
+		atomic.StoreInt32(&c.fencedOut, 0)
+	}
 	isActiveSequencer.Update(1)
 	atomicTimeWrite(&c.lockoutUntil, lockoutUntil.Add(-c.config.LockoutSpare))
 	return nil
 }
 
+func getFencingToken(ctx context.Context, r redis.Cmdable) (uint64, error) {
+	token, err := r.Get(ctx, redisutil.FENCING_TOKEN_KEY).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return token, err
+}
+
+// fenceOut stops this node acting as the chosen sequencer after another instance took over
+func (c *SeqCoordinator) fenceOut(err error) {
+	fencingRejectionsCounter.Inc(1)
+	atomicTimeWrite(&c.lockoutUntil, time.Time{})
+	isActiveSequencer.Update(0)
+	atomic.StoreInt32(&c.fenced, 1)
+	atomic.StoreInt32(&c.fencedOut, 1)
+	log.Error("another sequencer took over the chosen lock, refusing to sequence", "url", c.config.MyUrl(), "err", err)
+}
+
+// CheckFencing returns an error if another instance took the chosen key since this node last
+// held it, so the batch poster doesn't post what this node sequenced during the overlap. It keeps
+// failing until this node takes the chosen key again with a new fencing token.
+func (c *SeqCoordinator) CheckFencing(ctx context.Context) error {
+	myToken := atomic.LoadUint64(&c.fencingToken)
+	if atomic.LoadInt32(&c.fencedOut) != 0 {
+		return fmt.Errorf("%w: fencing token %d superseded", ErrFencedOut, myToken)
+	}
+	if myToken == 0 {
+		// not holding the chosen key, or released it
+		return nil
+	}
+	remoteToken, err := getFencingToken(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+	if remoteToken != myToken {
+		err := fmt.Errorf("%w: fencing token %d superseded by %d", ErrFencedOut, myToken, remoteToken)
+		c.fenceOut(err)
+		return err
+	}
+	return nil
+}
+
 func (c *SeqCoordinator) getRemoteMsgCountImpl(ctx context.Context, r redis.Cmdable) (arbutil.MessageIndex, error) {
 	resStr, err := r.Get(ctx, redisutil.MSG_COUNT_KEY).Result()
 	if errors.Is(err, redis.Nil) {
@@ -336,9 +434,31 @@ func (c *SeqCoordinator) livelinessUpdate(ctx context.Context) error {
 	return nil
 }
 
+// wantsLockoutUpdate tells a promoted chosen sequencer this node is caught up and ready to take over
+func (c *SeqCoordinator) wantsLockoutUpdate(ctx context.Context) error {
+	duration := c.config.LockoutDuration
+	if duration < 2*time.Second {
+		duration = 2 * time.Second
+	}
+	err := c.Client.Set(ctx, redisutil.WantsLockoutKeyFor(c.config.MyUrl()), redisutil.LIVELINESS_VAL, duration).Err()
+	if err != nil {
+		return fmt.Errorf("wants lockout failed to update redis: %w", err)
+	}
+	return nil
+}
+
+func (c *SeqCoordinator) wantsLockout(ctx context.Context, url string) (bool, error) {
+	count, err := c.Client.Exists(ctx, redisutil.WantsLockoutKeyFor(url)).Result()
+	return count > 0, err
+}
+
 func (c *SeqCoordinator) chosenOneRelease(ctx context.Context) error {
 	atomicTimeWrite(&c.lockoutUntil, time.Time{})
 	isActiveSequencer.Update(0)
+	if atomic.LoadInt32(&c.fencedOut) == 0 {
+		// a voluntary release, so another instance taking the key next doesn't fence this one out
+		atomic.StoreUint64(&c.fencingToken, 0)
+	}
 	releaseErr := c.Client.Watch(ctx, func(tx *redis.Tx) error {
 		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
 		if errors.Is(err, redis.Nil) {
@@ -402,7 +522,20 @@ func (c *SeqCoordinator) noRedisError() time.Duration {
 
 // update for the prev known-chosen sequencer (no need to load new messages)
 func (c *SeqCoordinator) updatePrevKnownChosen(ctx context.Context, nextChosen string) time.Duration {
-	if nextChosen != c.config.MyUrl() {
+	handOff := nextChosen != c.config.MyUrl()
+	if handOff && c.promoted {
+		// a promoted sequencer keeps the lock until the recommended one has caught up and is waiting to take it
+		handOff = false
+		if nextChosen != "" {
+			wants, err := c.wantsLockout(ctx, nextChosen)
+			if err != nil {
+				log.Warn("coordinator failed reading whether the recommended sequencer wants the lock", "recommended", nextChosen, "err", err)
+				return c.retryAfterRedisError()
+			}
+			handOff = wants
+		}
+	}
+	if handOff {
 		// was the active sequencer, but no longer
 		setPrevChosenTo := nextChosen
 		if c.sequencer != nil {
@@ -418,6 +551,7 @@ func (c *SeqCoordinator) updatePrevKnownChosen(ctx context.Context, nextChosen s
 			return c.retryAfterRedisError()
 		}
 		c.prevChosenSequencer = setPrevChosenTo
+		c.promoted = false
 		log.Info("released chosen-coordinator lock", "nextChosen", nextChosen)
 		return c.noRedisError()
 	}
@@ -440,12 +574,52 @@ func (c *SeqCoordinator) updatePrevKnownChosen(ctx context.Context, nextChosen s
 	return c.noRedisError()
 }
 
+// promotionDue tracks how long the chosen lease has been expired, returning true once that's
+// longer than promotion-timeout and this synced standby should take over
+func (c *SeqCoordinator) promotionDue(ctx context.Context) bool {
+	if !c.config.AutoPromote || c.sequencer == nil || c.config.MyUrl() == redisutil.INVALID_URL || !c.sync.Synced() {
+		c.leaderlessSince = time.Time{}
+		return false
+	}
+	err := c.Client.Get(ctx, redisutil.CHOSENSEQ_KEY).Err()
+	if err == nil {
+		c.leaderlessSince = time.Time{}
+		return false
+	}
+	if !errors.Is(err, redis.Nil) {
+		log.Warn("coordinator failed reading chosen sequencer lease", "err", err)
+		return false
+	}
+	if c.leaderlessSince.IsZero() {
+		c.leaderlessSince = time.Now()
+		log.Warn("chosen sequencer lease expired without renewal", "promotionTimeout", c.config.PromotionTimeout)
+	}
+	return time.Since(c.leaderlessSince) >= c.config.PromotionTimeout
+}
+
+// reconcilePromotion returns an error if it's unclear whether this node has every message the last
+// chosen sequencer produced, in which case promoting could sequence over them
+func (c *SeqCoordinator) reconcilePromotion(localMsgCount, remoteMsgCount arbutil.MessageIndex) error {
+	if localMsgCount != remoteMsgCount {
+		return fmt.Errorf("local message count %v doesn't match coordinator message count %v", localMsgCount, remoteMsgCount)
+	}
+	if feedEnd := c.streamer.FeedQueuedMessageEnd(); feedEnd > localMsgCount {
+		return fmt.Errorf("feed messages up to %v are pending past local message count %v", feedEnd, localMsgCount)
+	}
+	return nil
+}
+
 func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 	chosenSeq, err := c.RecommendLiveSequencer(ctx)
 	if err != nil {
 		log.Warn("coordinator failed finding live sequencer", "err", err)
 		return c.retryAfterRedisError()
 	}
+	if atomic.SwapInt32(&c.fenced, 0) != 0 && c.prevChosenSequencer == c.config.MyUrl() {
+		// no longer chosen, without having released the lock
+		c.prevChosenSequencer = ""
+		c.promoted = false
+	}
 	if c.prevChosenSequencer == c.config.MyUrl() {
 		return c.updatePrevKnownChosen(ctx, chosenSeq)
 	}
@@ -475,10 +649,14 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 		log.Warn("cannot get remote message count", "err", err)
 		return c.retryAfterRedisError()
 	}
+	promoting := chosenSeq != c.config.MyUrl() && c.promotionDue(ctx)
 	readUntil := remoteMsgCount
-	if readUntil > localMsgCount+c.config.MaxMsgPerPoll {
+	// replay everything the last chosen sequencer stored before taking over, so there's no gap
+	if !promoting && readUntil > localMsgCount+c.config.MaxMsgPerPoll {
 		readUntil = localMsgCount + c.config.MaxMsgPerPoll
 	}
+	replayStart := time.Now()
+	replayFrom := localMsgCount
 	var messages []arbstate.MessageWithMetadata
 	msgToRead := localMsgCount
 	var msgReadErr error
@@ -554,11 +732,18 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 	}
 
 	// can take over as main sequencer?
-	if localMsgCount >= remoteMsgCount && chosenSeq == c.config.MyUrl() {
+	if localMsgCount >= remoteMsgCount && (chosenSeq == c.config.MyUrl() || promoting) {
 		if c.sequencer == nil {
 			log.Error("myurl main sequencer, but no sequencer exists")
 			return c.noRedisError()
 		}
+		if promoting && c.config.ReconcilePromotion {
+			if err := c.reconcilePromotion(localMsgCount, remoteMsgCount); err != nil {
+				blockedPromotionsCounter.Inc(1)
+				log.Error("refusing to promote to chosen sequencer", "err", err, "leaderless", time.Since(c.leaderlessSince))
+				return c.retryAfterRedisError()
+			}
+		}
 		err := c.chosenOneUpdate(ctx, localMsgCount, localMsgCount, nil)
 		if err != nil {
 			// this could be just new messages we didn't get yet - even then, we should retry soon
@@ -567,9 +752,30 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 			if err := c.livelinessUpdate(ctx); err != nil {
 				log.Warn("failed to update liveliness", "err", err)
 			}
+			if chosenSeq == c.config.MyUrl() {
+				// caught up, so a promoted sequencer holding the lock can hand it over
+				if err := c.wantsLockoutUpdate(ctx); err != nil {
+					log.Warn("failed to update wants lockout", "err", err)
+				}
+			}
 			return c.retryAfterRedisError()
 		}
-		log.Info("caught chosen-coordinator lock")
+		if err := c.Client.Del(ctx, redisutil.WantsLockoutKeyFor(c.config.MyUrl())).Err(); err != nil {
+			log.Warn("failed to clear wants lockout", "err", err)
+		}
+		if promoting {
+			log.Warn(
+				"promoted to chosen sequencer after the chosen lease expired",
+				"leaderless", time.Since(c.leaderlessSince),
+				"replayed", localMsgCount-replayFrom,
+				"replayTime", time.Since(replayStart),
+				"recommended", chosenSeq,
+			)
+		} else {
+			log.Info("caught chosen-coordinator lock")
+		}
+		c.leaderlessSince = time.Time{}
+		c.promoted = promoting
 		c.sequencer.DontForward()
 		c.prevChosenSequencer = c.config.MyUrl()
 		return c.noRedisError()
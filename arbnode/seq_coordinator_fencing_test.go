@@ -0,0 +1,96 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+//go:build redistest
+// +build redistest
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/util/redisutil"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+func TestRedisSeqCoordinatorFencing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := TestSeqCoordinatorConfig
+	config.MyUrlImpl = "duplicate"
+	config.Signing.ECDSA.AcceptSequencer = false
+	config.Signing.SymmetricFallback = true
+	config.Signing.SymmetricSign = true
+	config.Signing.Symmetric.Dangerous.DisableSignatureVerification = true
+	config.Signing.Symmetric.SigningKey = ""
+	nullSigner, err := signature.NewSignVerify(&config.Signing, nil, nil)
+	Require(t, err)
+
+	redisClient, err := redisutil.RedisClientFromURL(redisutil.GetTestRedisURL(t))
+	Require(t, err)
+	redisClient.Del(ctx, redisutil.CHOSENSEQ_KEY, redisutil.MSG_COUNT_KEY)
+
+	newCoordinator := func() *SeqCoordinator {
+		redisCoordinator, err := redisutil.NewRedisCoordinator(config.RedisUrl)
+		Require(t, err)
+		return &SeqCoordinator{
+			RedisCoordinator: *redisCoordinator,
+			config:           config,
+			signer:           nullSigner,
+		}
+	}
+	// two instances sharing a url, as when a restarted sequencer's old process is still running
+	first := newCoordinator()
+	second := newCoordinator()
+
+	Require(t, first.chosenOneUpdate(ctx, 0, 1, &arbstate.EmptyTestMessageWithMetadata))
+	if !first.CurrentlyChosen() {
+		Fail(t, "first instance not chosen after taking the lock")
+	}
+	Require(t, first.CheckFencing(ctx))
+
+	Require(t, second.chosenOneUpdate(ctx, 1, 2, &arbstate.EmptyTestMessageWithMetadata))
+	if second.fencingToken <= first.fencingToken {
+		Fail(t, "second instance didn't get a newer fencing token", second.fencingToken, first.fencingToken)
+	}
+
+	// the first instance still thinks it's chosen until it checks
+	if !first.CurrentlyChosen() {
+		Fail(t, "first instance lockout ended early")
+	}
+	err = first.CheckFencing(ctx)
+	if !errors.Is(err, ErrFencedOut) {
+		Fail(t, "expected first instance to be fenced out, got", err)
+	}
+	if first.CurrentlyChosen() {
+		Fail(t, "first instance still chosen after being fenced out")
+	}
+	// the fence holds once its lockout is cleared, until it retakes the key with a new token
+	err = first.CheckFencing(ctx)
+	if !errors.Is(err, ErrFencedOut) {
+		Fail(t, "expected first instance to stay fenced out, got", err)
+	}
+	err = first.chosenOneUpdate(ctx, 2, 3, &arbstate.EmptyTestMessageWithMetadata)
+	if !errors.Is(err, ErrRetrySequencer) {
+		Fail(t, "expected fenced out instance to fail sequencing, got", err)
+	}
+
+	Require(t, second.CheckFencing(ctx))
+	Require(t, second.chosenOneUpdate(ctx, 2, 3, &arbstate.EmptyTestMessageWithMetadata))
+
+	// once the lock is released, the fenced out instance can take it again
+	Require(t, second.chosenOneRelease(ctx))
+	Require(t, second.CheckFencing(ctx))
+	if !errors.Is(first.CheckFencing(ctx), ErrFencedOut) {
+		Fail(t, "first instance unfenced before retaking the lock")
+	}
+	Require(t, first.chosenOneUpdate(ctx, 3, 4, &arbstate.EmptyTestMessageWithMetadata))
+	if !first.CurrentlyChosen() {
+		Fail(t, "first instance not chosen after retaking the released lock")
+	}
+	Require(t, first.CheckFencing(ctx))
+}
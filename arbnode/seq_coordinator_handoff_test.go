@@ -0,0 +1,93 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+//go:build redistest
+// +build redistest
+
+package arbnode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/util/redisutil"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+func TestRedisSeqCoordinatorPromotedHandoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := TestSeqCoordinatorConfig
+	config.Signing.ECDSA.AcceptSequencer = false
+	config.Signing.SymmetricFallback = true
+	config.Signing.SymmetricSign = true
+	config.Signing.Symmetric.Dangerous.DisableSignatureVerification = true
+	config.Signing.Symmetric.SigningKey = ""
+	nullSigner, err := signature.NewSignVerify(&config.Signing, nil, nil)
+	Require(t, err)
+
+	redisClient, err := redisutil.RedisClientFromURL(redisutil.GetTestRedisURL(t))
+	Require(t, err)
+	redisClient.Del(ctx, redisutil.CHOSENSEQ_KEY, redisutil.MSG_COUNT_KEY, redisutil.WantsLockoutKeyFor("recommended"))
+
+	streamer, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	newCoordinator := func(url string) *SeqCoordinator {
+		redisCoordinator, err := redisutil.NewRedisCoordinator(config.RedisUrl)
+		Require(t, err)
+		coordinatorConfig := config
+		coordinatorConfig.MyUrlImpl = url
+		return &SeqCoordinator{
+			RedisCoordinator: *redisCoordinator,
+			streamer:         streamer,
+			config:           coordinatorConfig,
+			signer:           nullSigner,
+		}
+	}
+	promoted := newCoordinator("promoted")
+	recommended := newCoordinator("recommended")
+	chosenSequencer := func() string {
+		chosen, err := redisClient.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
+		if err != nil {
+			return ""
+		}
+		return chosen
+	}
+
+	// a standby took over after the chosen lease expired
+	Require(t, promoted.chosenOneUpdate(ctx, 0, 1, &arbstate.EmptyTestMessageWithMetadata))
+	promoted.promoted = true
+	promoted.prevChosenSequencer = "promoted"
+
+	// it keeps the lock with no live sequencer to hand it to, or while the recommended one catches up
+	for _, nextChosen := range []string{"", "recommended"} {
+		promoted.updatePrevKnownChosen(ctx, nextChosen)
+		if !promoted.CurrentlyChosen() || chosenSequencer() != "promoted" {
+			Fail(t, "promoted sequencer released the lock with", nextChosen, "recommended before it was caught up")
+		}
+	}
+
+	// once the recommended sequencer is caught up and waiting, the lock is handed over
+	Require(t, recommended.wantsLockoutUpdate(ctx))
+	promoted.updatePrevKnownChosen(ctx, "recommended")
+	if promoted.CurrentlyChosen() || chosenSequencer() != "" {
+		Fail(t, "promoted sequencer kept the lock after the recommended one was ready")
+	}
+	if promoted.promoted || promoted.prevChosenSequencer != "recommended" {
+		Fail(t, "promoted sequencer didn't hand over to the recommended one")
+	}
+	Require(t, recommended.chosenOneUpdate(ctx, 1, 1, nil))
+	if !recommended.CurrentlyChosen() || chosenSequencer() != "recommended" {
+		Fail(t, "recommended sequencer didn't take the released lock")
+	}
+	recommended.prevChosenSequencer = "recommended"
+
+	// a sequencer chosen as the recommended one releases the lock as soon as another is recommended
+	recommended.updatePrevKnownChosen(ctx, "promoted")
+	if recommended.CurrentlyChosen() || chosenSequencer() != "" {
+		Fail(t, "recommended sequencer waited to release the lock")
+	}
+}
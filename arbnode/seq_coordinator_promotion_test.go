@@ -0,0 +1,32 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbstate"
+)
+
+func TestReconcilePromotion(t *testing.T) {
+	streamer := &TransactionStreamer{}
+	coordinator := &SeqCoordinator{streamer: streamer}
+
+	Require(t, coordinator.reconcilePromotion(10, 10))
+	if err := coordinator.reconcilePromotion(11, 10); err == nil {
+		Fail(t, "promotion allowed with more local messages than the coordinator has")
+	}
+	if err := coordinator.reconcilePromotion(9, 10); err == nil {
+		Fail(t, "promotion allowed while behind the coordinator")
+	}
+
+	// feed messages queued behind a gap came from the old chosen sequencer
+	streamer.broadcasterQueuedMessages = make([]arbstate.MessageWithMetadata, 2)
+	streamer.broadcasterQueuedMessagesPos = 12
+	if err := coordinator.reconcilePromotion(10, 10); err == nil {
+		Fail(t, "promotion allowed with feed messages pending past the local message count")
+	}
+	streamer.broadcasterQueuedMessagesPos = 8
+	Require(t, coordinator.reconcilePromotion(10, 10))
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/big"
 	"runtime/debug"
 	"strings"
 	"sync"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/arbitrum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -40,6 +42,11 @@ var (
 	nonceCacheClearedCounter  = metrics.NewRegisteredCounter("arb/sequencer/noncecache/cleared", nil)
 	blockCreationTimer        = metrics.NewRegisteredTimer("arb/sequencer/block/creation", nil)
 	successfulBlocksCounter   = metrics.NewRegisteredCounter("arb/sequencer/block/successful", nil)
+	txsPerBlockHistogram      = metrics.NewRegisteredHistogram("arb/sequencer/block/txs", nil, metrics.NewExpDecaySample(1028, 0.015))
+	txExpiredCounter          = metrics.NewRegisteredCounter("arb/sequencer/queue/expired", nil)
+	queueBytesGauge           = metrics.NewRegisteredGauge("arb/sequencer/queue/bytes", nil)
+	queueFullCounter          = metrics.NewRegisteredCounter("arb/sequencer/queue/full", nil)
+	blockByteCapCounter       = metrics.NewRegisteredCounter("arb/sequencer/block/byte_cap", nil)
 )
 
 type SequencerConfig struct {
@@ -47,28 +54,82 @@ type SequencerConfig struct {
 	MaxBlockSpeed               time.Duration            `koanf:"max-block-speed" reload:"hot"`
 	MaxRevertGasReject          uint64                   `koanf:"max-revert-gas-reject" reload:"hot"`
 	MaxAcceptableTimestampDelta time.Duration            `koanf:"max-acceptable-timestamp-delta" reload:"hot"`
-	SenderWhitelist             string                   `koanf:"sender-whitelist"`
+	SenderWhitelist             string                   `koanf:"sender-whitelist" reload:"hot"`
+	SenderDenylist              string                   `koanf:"sender-denylist" reload:"hot"`
+	RecipientDenylist           string                   `koanf:"recipient-denylist" reload:"hot"`
 	Forwarder                   ForwarderConfig          `koanf:"forwarder"`
 	QueueSize                   int                      `koanf:"queue-size"`
+	MaxQueueBytes               int64                    `koanf:"max-queue-bytes" reload:"hot"`
 	QueueTimeout                time.Duration            `koanf:"queue-timeout" reload:"hot"`
+	TxTTL                       time.Duration            `koanf:"tx-ttl" reload:"hot"`
 	NonceCacheSize              int                      `koanf:"nonce-cache-size" reload:"hot"`
+	MaxTxAge                    time.Duration            `koanf:"max-tx-age" reload:"hot"`
+	SeenTxCacheSize             int                      `koanf:"seen-tx-cache-size" reload:"hot"`
+	Dedup                       TxDedupConfig            `koanf:"dedup" reload:"hot"`
 	MaxTxDataSize               int                      `koanf:"max-tx-data-size" reload:"hot"`
+	DelayedInclusionMargin      time.Duration            `koanf:"delayed-inclusion-margin" reload:"hot"`
+	MaxTxsPerBlock              int                      `koanf:"max-txs-per-block" reload:"hot"`
+	MaxBlockBytes               int                      `koanf:"max-block-bytes" reload:"hot"`
+	MinTxsPerBlock              int                      `koanf:"min-txs-per-block" reload:"hot"`
+	MinTxsWait                  time.Duration            `koanf:"min-txs-wait" reload:"hot"`
+	ReplacementPriceBump        uint64                   `koanf:"replacement-price-bump" reload:"hot"`
+	RejectionLogSample          uint64                   `koanf:"rejection-log-sample" reload:"hot"`
+	MinGasPrice                 uint64                   `koanf:"min-gas-price" reload:"hot"`
+	PriorityLane                PriorityLaneConfig       `koanf:"priority-lane" reload:"hot"`
+	AcceptWebhook               AcceptWebhookConfig      `koanf:"accept-webhook"`
 	Dangerous                   DangerousSequencerConfig `koanf:"dangerous"`
 }
 
-func (c *SequencerConfig) Validate() error {
-	entries := strings.Split(c.SenderWhitelist, ",")
-	for _, address := range entries {
+func validateAddressList(name string, list string) error {
+	for _, address := range strings.Split(list, ",") {
 		if len(address) == 0 {
 			continue
 		}
 		if !common.IsHexAddress(address) {
-			return fmt.Errorf("sequencer sender whitelist entry \"%v\" is not a valid address", address)
+			return fmt.Errorf("sequencer %v entry \"%v\" is not a valid address", name, address)
 		}
 	}
 	return nil
 }
 
+func (c *SequencerConfig) Validate() error {
+	if err := validateAddressList("sender whitelist", c.SenderWhitelist); err != nil {
+		return err
+	}
+	if err := validateAddressList("sender denylist", c.SenderDenylist); err != nil {
+		return err
+	}
+	if err := validateAddressList("recipient denylist", c.RecipientDenylist); err != nil {
+		return err
+	}
+	if c.MaxQueueBytes < 0 {
+		return fmt.Errorf("sequencer max-queue-bytes cannot be negative, not %v", c.MaxQueueBytes)
+	}
+	if c.TxTTL < 0 {
+		return fmt.Errorf("sequencer tx-ttl cannot be negative, not %v", c.TxTTL)
+	}
+	if c.TxTTL > 0 && c.QueueTimeout > 0 && c.TxTTL >= c.QueueTimeout {
+		// queue-timeout already drops transactions queued that long, so a longer ttl would never expire one
+		return fmt.Errorf("sequencer tx-ttl %v must be 0 or less than queue-timeout %v", c.TxTTL, c.QueueTimeout)
+	}
+	if c.MaxTxAge > 0 && c.SeenTxCacheSize <= 0 {
+		return fmt.Errorf("sequencer seen-tx-cache-size must be positive when max-tx-age is set, not %v", c.SeenTxCacheSize)
+	}
+	if c.MaxBlockBytes < 0 {
+		return fmt.Errorf("sequencer max-block-bytes cannot be negative, not %v", c.MaxBlockBytes)
+	}
+	if c.MaxTxsPerBlock > 0 && c.MinTxsPerBlock > c.MaxTxsPerBlock {
+		return fmt.Errorf("sequencer min-txs-per-block %v is greater than max-txs-per-block %v", c.MinTxsPerBlock, c.MaxTxsPerBlock)
+	}
+	if err := c.Dedup.Validate(); err != nil {
+		return err
+	}
+	if err := c.PriorityLane.Validate(); err != nil {
+		return err
+	}
+	return c.AcceptWebhook.Validate()
+}
+
 type SequencerConfigFetcher func() *SequencerConfig
 
 var DefaultSequencerConfig = SequencerConfig{
@@ -78,11 +139,26 @@ var DefaultSequencerConfig = SequencerConfig{
 	MaxAcceptableTimestampDelta: time.Hour,
 	Forwarder:                   DefaultSequencerForwarderConfig,
 	QueueSize:                   1024,
+	MaxQueueBytes:               64 * 1024 * 1024,
 	QueueTimeout:                time.Second * 12,
+	TxTTL:                       0,
 	NonceCacheSize:              1024,
+	MaxTxAge:                    0,
+	SeenTxCacheSize:             65536,
+	Dedup:                       DefaultTxDedupConfig,
 	Dangerous:                   DefaultDangerousSequencerConfig,
 	// 95% of the default batch poster limit, leaving 5KB for headers and such
-	MaxTxDataSize: 95000,
+	MaxTxDataSize:          95000,
+	DelayedInclusionMargin: time.Hour,
+	MaxTxsPerBlock:         0,
+	MaxBlockBytes:          0,
+	MinTxsPerBlock:         0,
+	MinTxsWait:             time.Millisecond * 50,
+	ReplacementPriceBump:   10,
+	PriorityLane:           DefaultPriorityLaneConfig,
+	RejectionLogSample:     100,
+	MinGasPrice:            0,
+	AcceptWebhook:          DefaultAcceptWebhookConfig,
 }
 
 var TestSequencerConfig = SequencerConfig{
@@ -91,12 +167,29 @@ var TestSequencerConfig = SequencerConfig{
 	MaxRevertGasReject:          params.TxGas + 10000,
 	MaxAcceptableTimestampDelta: time.Hour,
 	SenderWhitelist:             "",
+	SenderDenylist:              "",
+	RecipientDenylist:           "",
 	Forwarder:                   DefaultTestForwarderConfig,
 	QueueSize:                   128,
+	MaxQueueBytes:               64 * 1024 * 1024,
 	QueueTimeout:                time.Second * 5,
+	TxTTL:                       0,
 	NonceCacheSize:              4,
+	MaxTxAge:                    0,
+	SeenTxCacheSize:             1024,
+	Dedup:                       DefaultTxDedupConfig,
 	Dangerous:                   TestDangerousSequencerConfig,
 	MaxTxDataSize:               95000,
+	DelayedInclusionMargin:      time.Hour,
+	MaxTxsPerBlock:              0,
+	MaxBlockBytes:               0,
+	MinTxsPerBlock:              0,
+	MinTxsWait:                  time.Millisecond * 10,
+	ReplacementPriceBump:        10,
+	PriorityLane:                DefaultPriorityLaneConfig,
+	RejectionLogSample:          1,
+	MinGasPrice:                 0,
+	AcceptWebhook:               DefaultAcceptWebhookConfig,
 }
 
 func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -105,19 +198,39 @@ func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".max-revert-gas-reject", DefaultSequencerConfig.MaxRevertGasReject, "maximum gas executed in a revert for the sequencer to reject the transaction instead of posting it (anti-DOS)")
 	f.Duration(prefix+".max-acceptable-timestamp-delta", DefaultSequencerConfig.MaxAcceptableTimestampDelta, "maximum acceptable time difference between the local time and the latest L1 block's timestamp")
 	f.String(prefix+".sender-whitelist", DefaultSequencerConfig.SenderWhitelist, "comma separated whitelist of authorized senders (if empty, everyone is allowed)")
+	f.String(prefix+".sender-denylist", DefaultSequencerConfig.SenderDenylist, "comma separated list of senders whose transactions are rejected")
+	f.String(prefix+".recipient-denylist", DefaultSequencerConfig.RecipientDenylist, "comma separated list of addresses that transactions sent to are rejected (contract creations are unaffected)")
 	AddOptionsForSequencerForwarderConfig(prefix+".forwarder", f)
 	f.Int(prefix+".queue-size", DefaultSequencerConfig.QueueSize, "size of the pending tx queue")
+	f.Int64(prefix+".max-queue-bytes", DefaultSequencerConfig.MaxQueueBytes, "maximum total size in bytes of the transactions queued or being sequenced, past which new ones are rejected (0 = no limit)")
 	f.Duration(prefix+".queue-timeout", DefaultSequencerConfig.QueueTimeout, "maximum amount of time transaction can wait in queue")
+	f.Duration(prefix+".tx-ttl", DefaultSequencerConfig.TxTTL, "drop queued transactions, including ones waiting to be retried, this long after they were submitted, which must be less than queue-timeout if that's set (0 = only drop them at queue-timeout)")
 	f.Int(prefix+".nonce-cache-size", DefaultSequencerConfig.NonceCacheSize, "size of the tx sender nonce cache")
+	f.Duration(prefix+".max-tx-age", DefaultSequencerConfig.MaxTxAge, "reject resubmissions of a transaction, as duplicates within this long of when it was first seen and as too old after (0 = accept resubmissions)")
+	f.Int(prefix+".seen-tx-cache-size", DefaultSequencerConfig.SeenTxCacheSize, "number of recently submitted transaction hashes remembered for max-tx-age")
 	f.Int(prefix+".max-tx-data-size", DefaultSequencerConfig.MaxTxDataSize, "maximum transaction size the sequencer will accept")
+	f.Duration(prefix+".delayed-inclusion-margin", DefaultSequencerConfig.DelayedInclusionMargin, "sequence delayed messages before they're finalized once they're within this long of their force inclusion deadline (0 to always wait for finality)")
+	f.Int(prefix+".max-txs-per-block", DefaultSequencerConfig.MaxTxsPerBlock, "maximum number of transactions in a block, leaving the rest queued for the next one (0 = no limit)")
+	f.Int(prefix+".max-block-bytes", DefaultSequencerConfig.MaxBlockBytes, "maximum total size in bytes of the serialized transactions in a block, leaving the rest queued for the next one (0 = no limit)")
+	f.Int(prefix+".min-txs-per-block", DefaultSequencerConfig.MinTxsPerBlock, "wait up to min-txs-wait for this many transactions before creating a block, on top of the max-block-speed delay (0 = don't wait)")
+	f.Duration(prefix+".min-txs-wait", DefaultSequencerConfig.MinTxsWait, "maximum time to wait for min-txs-per-block transactions after the first one is taken from the queue")
+	f.Uint64(prefix+".replacement-price-bump", DefaultSequencerConfig.ReplacementPriceBump, "minimum percent by which a transaction must raise both the fee cap and tip cap of a queued transaction with the same sender and nonce to replace it")
+	f.Uint64(prefix+".min-gas-price", DefaultSequencerConfig.MinGasPrice, "minimum max fee per gas, in wei, that a transaction must offer for the sequencer to accept it (0 = no minimum)")
+	f.Uint64(prefix+".rejection-log-sample", DefaultSequencerConfig.RejectionLogSample, "log one in this many rejected transactions at debug level, with the reason they were rejected (0 = don't log)")
+	TxDedupConfigAddOptions(prefix+".dedup", f)
+	PriorityLaneConfigAddOptions(prefix+".priority-lane", f)
+	AcceptWebhookConfigAddOptions(prefix+".accept-webhook", f)
 	DangerousSequencerConfigAddOptions(prefix+".dangerous", f)
 }
 
 type txQueueItem struct {
-	tx             *types.Transaction
-	resultChan     chan<- error
-	returnedResult bool
-	ctx            context.Context
+	tx              *types.Transaction
+	resultChan      chan<- error
+	returnedResult  bool
+	ctx             context.Context
+	firstAppearance time.Time
+	queued          *queuedTx
+	options         *ConditionalOptions
 }
 
 func (i *txQueueItem) returnResult(err error) {
@@ -210,16 +323,90 @@ func (c *nonceCache) Resize(newSize int) {
 	c.cache.Resize(newSize)
 }
 
+// addressList is a hot-reloadable set of addresses, parsed again only when its config changes
+type addressList struct {
+	mutex     sync.Mutex
+	raw       string
+	addresses map[common.Address]struct{}
+}
+
+func (l *addressList) get(raw string) map[common.Address]struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.addresses != nil && l.raw == raw {
+		return l.addresses
+	}
+	addresses := make(map[common.Address]struct{})
+	for _, address := range strings.Split(raw, ",") {
+		if len(address) == 0 {
+			continue
+		}
+		addresses[common.HexToAddress(address)] = struct{}{}
+	}
+	l.raw = raw
+	l.addresses = addresses
+	return addresses
+}
+
+// deniedLogInterval is how often transactions blocked by a denylist are logged for each address
+const deniedLogInterval = time.Minute
+
+type deniedLogEntry struct {
+	logged     time.Time
+	suppressed uint64
+}
+
+// deniedLog rate limits logging the transactions a denylist blocks to one per address every
+// deniedLogInterval, so a spamming address can't flood the logs
+type deniedLog struct {
+	mutex   sync.Mutex
+	entries *containers.LruCache[common.Address, *deniedLogEntry]
+}
+
+func newDeniedLog() *deniedLog {
+	return &deniedLog{entries: containers.NewLruCache[common.Address, *deniedLogEntry](1024)}
+}
+
+// record returns whether to log a blocked transaction for the address, and how many blocked since
+// the last one logged weren't
+func (l *deniedLog) record(address common.Address, now time.Time) (bool, uint64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	entry, ok := l.entries.Get(address)
+	if ok && now.Sub(entry.logged) < deniedLogInterval {
+		entry.suppressed++
+		return false, 0
+	}
+	var suppressed uint64
+	if ok {
+		suppressed = entry.suppressed
+	}
+	l.entries.Add(address, &deniedLogEntry{logged: now})
+	return true, suppressed
+}
+
 type Sequencer struct {
 	stopwaiter.StopWaiter
 
-	txStreamer      *TransactionStreamer
-	txQueue         chan txQueueItem
-	txRetryQueue    containers.Queue[txQueueItem]
-	l1Reader        *headerreader.HeaderReader
-	config          SequencerConfigFetcher
-	senderWhitelist map[common.Address]struct{}
-	nonceCache      *nonceCache
+	txStreamer        *TransactionStreamer
+	txQueue           chan txQueueItem
+	txRetryQueue      containers.Queue[txQueueItem]
+	l1Reader          *headerreader.HeaderReader
+	config            SequencerConfigFetcher
+	devConfig         func() *DevConfig
+	senderWhitelist   addressList
+	senderDenylist    addressList
+	deniedSenders     *deniedLog
+	recipientDenylist addressList
+	deniedRecipients  *deniedLog
+	prioritySenders   addressList
+	nonceCache        *nonceCache
+	seenTxs           *seenTxs
+	acceptedTxs       *acceptedTxs
+	queuedTxs         *queuedTxTracker
+	acceptWebhook     *acceptWebhook
+	queueBytes        int64  // atomic
+	rejectionCount    uint64 // atomic
 
 	L1BlockAndTimeMutex sync.Mutex
 	l1BlockNumber       uint64
@@ -229,32 +416,35 @@ type Sequencer struct {
 	forwarder      *TxForwarder
 }
 
-func NewSequencer(txStreamer *TransactionStreamer, l1Reader *headerreader.HeaderReader, configFetcher SequencerConfigFetcher) (*Sequencer, error) {
+func NewSequencer(txStreamer *TransactionStreamer, l1Reader *headerreader.HeaderReader, configFetcher SequencerConfigFetcher, devConfig func() *DevConfig) (*Sequencer, error) {
 	config := configFetcher()
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
-	senderWhitelist := make(map[common.Address]struct{})
-	entries := strings.Split(config.SenderWhitelist, ",")
-	for _, address := range entries {
-		if len(address) == 0 {
-			continue
-		}
-		senderWhitelist[common.HexToAddress(address)] = struct{}{}
-	}
 	return &Sequencer{
-		txStreamer:      txStreamer,
-		txQueue:         make(chan txQueueItem, config.QueueSize),
-		l1Reader:        l1Reader,
-		config:          configFetcher,
-		senderWhitelist: senderWhitelist,
-		nonceCache:      newNonceCache(config.NonceCacheSize),
-		l1BlockNumber:   0,
-		l1Timestamp:     0,
+		txStreamer:       txStreamer,
+		txQueue:          make(chan txQueueItem, config.QueueSize),
+		l1Reader:         l1Reader,
+		config:           configFetcher,
+		devConfig:        devConfig,
+		nonceCache:       newNonceCache(config.NonceCacheSize),
+		seenTxs:          newSeenTxs(config.SeenTxCacheSize),
+		acceptedTxs:      newAcceptedTxs(config.Dedup.Size),
+		queuedTxs:        newQueuedTxTracker(),
+		deniedSenders:    newDeniedLog(),
+		deniedRecipients: newDeniedLog(),
+		acceptWebhook:    newAcceptWebhook(&config.AcceptWebhook),
+		l1BlockNumber:    0,
+		l1Timestamp:      0,
 	}, nil
 }
 
 var ErrRetrySequencer = errors.New("please retry transaction")
+var ErrTxExpired = errors.New("transaction expired in the sequencer queue")
+var ErrSequencerQueueFull = errors.New("sequencer queue full")
+var ErrSenderDenied = errors.New("transaction sender is on the sequencer's denylist")
+var ErrRecipientDenied = errors.New("transaction recipient is on the sequencer's denylist")
+var ErrGasPriceBelowMinimum = errors.New("transaction max fee per gas is below the sequencer's minimum gas price")
 
 func (s *Sequencer) ctxWithQueueTimeout(inctx context.Context) (context.Context, context.CancelFunc) {
 	timeout := s.config().QueueTimeout
@@ -265,32 +455,109 @@ func (s *Sequencer) ctxWithQueueTimeout(inctx context.Context) (context.Context,
 }
 
 func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Transaction) error {
+	return s.publishTransaction(parentCtx, tx, nil)
+}
+
+// PublishConditionalTransaction queues tx to be included only if options' conditions hold for the
+// block it's sequenced in, which is checked as that block is built
+func (s *Sequencer) PublishConditionalTransaction(parentCtx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
+	return s.publishTransaction(parentCtx, tx, options)
+}
+
+func (s *Sequencer) publishTransaction(parentCtx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
 	sequencerBacklogGauge.Inc(1)
 	defer sequencerBacklogGauge.Dec(1)
 
+	config := s.config()
+	if config.Dedup.Enable && s.acceptedTxs.accepted(tx.Hash(), time.Now(), config.Dedup.TTL) {
+		txDedupHitCounter.Inc(1)
+		return nil
+	}
+	if config.MaxTxAge > 0 {
+		if err := s.seenTxs.see(tx.Hash(), time.Now(), config.MaxTxAge, config.SeenTxCacheSize); err != nil {
+			s.recordRejection(tx, err)
+			return err
+		}
+	}
+	err := s.publishTransactionOrForward(parentCtx, tx, options)
+	if err != nil {
+		// it may be resubmitted once whatever stopped it is fixed
+		s.seenTxs.forget(tx.Hash())
+	} else if config.Dedup.Enable {
+		s.acceptedTxs.add(tx.Hash(), time.Now(), config.Dedup.Size)
+	}
+	return err
+}
+
+func (s *Sequencer) publishTransactionOrForward(parentCtx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
 	forwarder := s.GetForwarder()
 	if forwarder != nil {
-		err := forwarder.PublishTransaction(parentCtx, tx)
+		err := forwarder.publish(parentCtx, tx, options)
 		if !errors.Is(err, ErrNoSequencer) {
 			return err
 		}
 	}
 
-	if len(s.senderWhitelist) > 0 {
-		signer := types.LatestSigner(s.txStreamer.bc.Config())
-		sender, err := types.Sender(signer, tx)
-		if err != nil {
-			return err
-		}
-		_, authorized := s.senderWhitelist[sender]
+	err := s.publishTransactionImpl(parentCtx, tx, options)
+	if err != nil {
+		s.recordRejection(tx, err)
+	}
+	return err
+}
+
+func (s *Sequencer) publishTransactionImpl(parentCtx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
+	signer := types.LatestSigner(s.txStreamer.bc.Config())
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	config := s.config()
+	if whitelist := s.senderWhitelist.get(config.SenderWhitelist); len(whitelist) > 0 {
+		_, authorized := whitelist[sender]
 		if !authorized {
-			return errors.New("transaction sender is not on the whitelist")
+			return ErrSenderNotWhitelisted
+		}
+	}
+	if _, denied := s.senderDenylist.get(config.SenderDenylist)[sender]; denied {
+		if shouldLog, suppressed := s.deniedSenders.record(sender, time.Now()); shouldLog {
+			log.Info("rejected transaction from denylisted sender", "sender", sender, "tx", tx.Hash(), "unloggedSinceLast", suppressed)
+		}
+		return ErrSenderDenied
+	}
+	if to := tx.To(); to != nil {
+		if _, denied := s.recipientDenylist.get(config.RecipientDenylist)[*to]; denied {
+			if shouldLog, suppressed := s.deniedRecipients.record(*to, time.Now()); shouldLog {
+				log.Info("rejected transaction to denylisted recipient", "sender", sender, "to", *to, "tx", tx.Hash(), "unloggedSinceLast", suppressed)
+			}
+			return ErrRecipientDenied
 		}
 	}
 	if tx.Type() >= types.ArbitrumDepositTxType {
 		// Should be unreachable due to UnmarshalBinary not accepting Arbitrum internal txs
 		return types.ErrTxTypeNotSupported
 	}
+	// Arbitrum charges the base fee and drops tips, so what a transaction offers is its fee cap
+	if minGasPrice := config.MinGasPrice; minGasPrice > 0 && tx.GasFeeCapIntCmp(new(big.Int).SetUint64(minGasPrice)) < 0 {
+		return fmt.Errorf("%w: max fee per gas %v, minimum %v", ErrGasPriceBelowMinimum, tx.GasFeeCap(), minGasPrice)
+	}
+
+	txSize := int64(tx.Size())
+	queueBytes := atomic.AddInt64(&s.queueBytes, txSize)
+	queueBytesGauge.Update(queueBytes)
+	defer func() {
+		queueBytesGauge.Update(atomic.AddInt64(&s.queueBytes, -txSize))
+	}()
+	if maxBytes := config.MaxQueueBytes; maxBytes > 0 && queueBytes > maxBytes {
+		queueFullCounter.Inc(1)
+		return ErrSequencerQueueFull
+	}
+
+	queuedKey := queuedTxKey{sender, tx.Nonce()}
+	queued, err := s.queuedTxs.add(queuedKey, tx, config.ReplacementPriceBump)
+	if err != nil {
+		return err
+	}
+	defer s.queuedTxs.remove(queuedKey, queued)
 
 	ctx, cancelFunc := s.ctxWithQueueTimeout(parentCtx)
 	defer cancelFunc()
@@ -301,16 +568,31 @@ func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Tran
 		resultChan,
 		false,
 		ctx,
+		time.Now(),
+		queued,
+		options,
+	}
+	var expired <-chan time.Time
+	if ttl := config.TxTTL; ttl > 0 {
+		timer := time.NewTimer(ttl)
+		defer timer.Stop()
+		expired = timer.C
 	}
 	select {
 	case s.txQueue <- queueItem:
-	case <-ctx.Done():
-		return ctx.Err()
+	default:
+		s.queuedTxs.cancel(queuedKey, queued)
+		queueFullCounter.Inc(1)
+		return ErrSequencerQueueFull
 	}
 
 	select {
 	case res := <-resultChan:
 		return res
+	case <-expired:
+		// the queue drops this item when it next sees it, as its context is canceled on return
+		txExpiredCounter.Inc(1)
+		return ErrTxExpired
 	case <-ctx.Done():
 		return ctx.Err()
 	}
@@ -409,7 +691,7 @@ func (s *Sequencer) forwardIfSet(queueItems []txQueueItem) bool {
 		return false
 	}
 	for _, item := range queueItems {
-		res := forwarder.PublishTransaction(item.ctx, item.tx)
+		res := forwarder.publish(item.ctx, item.tx, item.options)
 		if errors.Is(res, ErrNoSequencer) {
 			s.requeueOrFail(item, ErrNoSequencer)
 		} else {
@@ -442,7 +724,12 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 	}()
 
 	config := s.config()
+	// set once the first tx is taken if we should wait for min-txs-per-block
+	var minTxsWait <-chan time.Time
 	for {
+		if config.MaxTxsPerBlock > 0 && len(txes) >= config.MaxTxsPerBlock {
+			break
+		}
 		var queueItem txQueueItem
 		if s.txRetryQueue.Len() > 0 {
 			queueItem = s.txRetryQueue.Pop()
@@ -452,6 +739,11 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			case <-ctx.Done():
 				return false
 			}
+			if config.MinTxsPerBlock > 1 && config.MinTxsWait > 0 && minTxsWait == nil {
+				timer := time.NewTimer(config.MinTxsWait)
+				defer timer.Stop()
+				minTxsWait = timer.C
+			}
 		} else {
 			done := false
 			select {
@@ -459,6 +751,16 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			default:
 				done = true
 			}
+			if done && minTxsWait != nil && len(txes) < config.MinTxsPerBlock {
+				// the queue is empty, but wait a bit longer for a fuller block
+				select {
+				case queueItem = <-s.txQueue:
+					done = false
+				case <-minTxsWait:
+					minTxsWait = nil
+				case <-ctx.Done():
+				}
+			}
 			if done {
 				break
 			}
@@ -468,6 +770,15 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			queueItem.returnResult(err)
 			continue
 		}
+		if config.TxTTL > 0 && time.Since(queueItem.firstAppearance) > config.TxTTL {
+			txExpiredCounter.Inc(1)
+			queueItem.returnResult(ErrTxExpired)
+			continue
+		}
+		if err := s.queuedTxs.take(queueItem.queued); err != nil {
+			queueItem.returnResult(err)
+			continue
+		}
 		txBytes, err := queueItem.tx.MarshalBinary()
 		if err != nil {
 			queueItem.returnResult(err)
@@ -484,6 +795,13 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			// End the batch here to put this tx in the next one
 			break
 		}
+		if config.MaxBlockBytes > 0 && len(txes) > 0 && totalBatchSize+len(txBytes) > config.MaxBlockBytes {
+			// This tx would take the block past the byte cap, so it goes in the next one.
+			// A tx over the cap by itself still gets a block of its own.
+			blockByteCapCounter.Inc(1)
+			s.txRetryQueue.Push(queueItem)
+			break
+		}
 		totalBatchSize += len(txBytes)
 		txes = append(txes, queueItem.tx)
 		queueItems = append(queueItems, queueItem)
@@ -493,6 +811,12 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		return false
 	}
 
+	queueItems = s.prioritize(queueItems, config)
+	txes = txes[:0]
+	for _, item := range queueItems {
+		txes = append(txes, item.tx)
+	}
+
 	timestamp := time.Now().Unix()
 	s.L1BlockAndTimeMutex.Lock()
 	l1Block := s.l1BlockNumber
@@ -520,11 +844,32 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 
 	s.nonceCache.Resize(config.NonceCacheSize) // Would probably be better in a config hook but this is basically free
 	s.nonceCache.BeginNewBlock()
+	// keyed by each submission's transaction rather than its hash, as the same transaction can be
+	// submitted again with other conditions or none
+	conditions := make(map[*types.Transaction]*ConditionalOptions)
+	for _, item := range queueItems {
+		if item.options != nil {
+			conditions[item.tx] = item.options
+		}
+	}
+	preTxFilter := s.preTxFilter
+	if len(conditions) > 0 {
+		preTxFilter = func(chainConfig *params.ChainConfig, header *types.Header, statedb *state.StateDB, arbState *arbosState.ArbosState, tx *types.Transaction, sender common.Address) error {
+			if err := s.preTxFilter(chainConfig, header, statedb, arbState, tx, sender); err != nil {
+				return err
+			}
+			if options := conditions[tx]; options != nil {
+				return options.Check(header, statedb)
+			}
+			return nil
+		}
+	}
 	hooks := &arbos.SequencingHooks{
-		PreTxFilter:            s.preTxFilter,
+		PreTxFilter:            preTxFilter,
 		PostTxFilter:           s.postTxFilter,
 		DiscardInvalidTxsEarly: true,
 		TxErrors:               []error{},
+		MaxStateGrowth:         s.devConfig().MaxStateGrowthPerBlock,
 	}
 	start := time.Now()
 	block, err := s.txStreamer.SequenceTransactions(header, txes, hooks)
@@ -565,9 +910,11 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 	}
 
 	madeBlock := false
+	txsInBlock := 0
 	for i, err := range hooks.TxErrors {
 		if err == nil {
 			madeBlock = true
+			txsInBlock++
 		}
 		queueItem := queueItems[i]
 		if errors.Is(err, core.ErrGasLimitReached) {
@@ -582,11 +929,31 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			// Strip additional information, as it's incorrect due to L1 data gas.
 			err = core.ErrIntrinsicGas
 		}
+		if err == nil && s.acceptWebhook != nil && block != nil {
+			s.notifyAccepted(queueItem.tx, block.NumberU64())
+		}
 		queueItem.returnResult(err)
 	}
+	if madeBlock {
+		txsPerBlockHistogram.Update(int64(txsInBlock))
+	}
 	return madeBlock
 }
 
+func (s *Sequencer) notifyAccepted(tx *types.Transaction, blockNumber uint64) {
+	sender, err := types.Sender(types.LatestSigner(s.txStreamer.bc.Config()), tx)
+	if err != nil {
+		log.Warn("failed to recover sender of accepted transaction", "tx", tx.Hash(), "err", err)
+		return
+	}
+	s.acceptWebhook.enqueue(AcceptedTransaction{
+		TxHash:      tx.Hash(),
+		Sender:      sender,
+		Nonce:       hexutil.Uint64(tx.Nonce()),
+		BlockNumber: hexutil.Uint64(blockNumber),
+	})
+}
+
 func (s *Sequencer) updateLatestL1Block(header *types.Header) {
 	s.L1BlockAndTimeMutex.Lock()
 	defer s.L1BlockAndTimeMutex.Unlock()
@@ -636,6 +1003,10 @@ func (s *Sequencer) Start(ctxIn context.Context) error {
 
 	}
 
+	if s.acceptWebhook != nil {
+		s.LaunchThread(s.acceptWebhook.run)
+	}
+
 	s.CallIteratively(func(ctx context.Context) time.Duration {
 		nextBlock := time.Now().Add(s.config().MaxBlockSpeed)
 		madeBlock := s.createBlock(ctx)
@@ -0,0 +1,27 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSequencerTxTTLValidation(t *testing.T) {
+	config := DefaultSequencerConfig
+	Require(t, config.Validate())
+	config.TxTTL = config.QueueTimeout - time.Second
+	Require(t, config.Validate())
+	config.TxTTL = config.QueueTimeout
+	if config.Validate() == nil {
+		Fail(t, "accepted a tx-ttl the queue timeout always drops transactions before")
+	}
+	// without a queue timeout the ttl is the only limit
+	config.QueueTimeout = 0
+	Require(t, config.Validate())
+	config.TxTTL = -time.Second
+	if config.Validate() == nil {
+		Fail(t, "accepted a negative tx-ttl")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var txDedupHitCounter = metrics.NewRegisteredCounter("arb/sequencer/dedup/hit", nil)
+
+type TxDedupConfig struct {
+	Enable bool          `koanf:"enable" reload:"hot"`
+	Size   int           `koanf:"size" reload:"hot"`
+	TTL    time.Duration `koanf:"ttl" reload:"hot"`
+}
+
+var DefaultTxDedupConfig = TxDedupConfig{
+	Enable: false,
+	Size:   65536,
+	TTL:    10 * time.Minute,
+}
+
+func TxDedupConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultTxDedupConfig.Enable, "answer resubmissions of a recently accepted transaction with its acceptance, without processing it again")
+	f.Int(prefix+".size", DefaultTxDedupConfig.Size, "number of recently accepted transaction hashes remembered")
+	f.Duration(prefix+".ttl", DefaultTxDedupConfig.TTL, "how long after a transaction is accepted its resubmissions are answered from the cache")
+}
+
+func (c *TxDedupConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Size <= 0 {
+		return fmt.Errorf("sequencer dedup size must be positive, not %v", c.Size)
+	}
+	if c.TTL <= 0 {
+		return fmt.Errorf("sequencer dedup ttl must be positive, not %v", c.TTL)
+	}
+	return nil
+}
+
+// acceptedTxs remembers when recently accepted transactions were accepted, so resubmissions of the
+// exact same transaction can be answered without queueing it again. Unlike a replacement, which
+// has the same sender and nonce, a duplicate has the same hash.
+type acceptedTxs struct {
+	mutex sync.Mutex
+	cache *containers.LruCache[common.Hash, time.Time]
+}
+
+func newAcceptedTxs(size int) *acceptedTxs {
+	return &acceptedTxs{
+		cache: containers.NewLruCache[common.Hash, time.Time](size),
+	}
+}
+
+// accepted returns whether hash was accepted within ttl of now
+func (a *acceptedTxs) accepted(hash common.Hash, now time.Time, ttl time.Duration) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	acceptedAt, ok := a.cache.Get(hash)
+	if !ok {
+		return false
+	}
+	if now.Sub(acceptedAt) > ttl {
+		a.cache.Remove(hash)
+		return false
+	}
+	return true
+}
+
+func (a *acceptedTxs) add(hash common.Hash, now time.Time, size int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.cache.Resize(size)
+	a.cache.Add(hash, now)
+}
@@ -0,0 +1,37 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestAcceptedTxs(t *testing.T) {
+	accepted := newAcceptedTxs(2)
+	ttl := time.Minute
+	start := time.Now()
+	hash := common.HexToHash("0x01")
+
+	if accepted.accepted(hash, start, ttl) {
+		Fail(t, "unknown transaction reported as accepted")
+	}
+	accepted.add(hash, start, 2)
+	if !accepted.accepted(hash, start.Add(ttl/2), ttl) {
+		Fail(t, "accepted transaction not remembered within its ttl")
+	}
+	if accepted.accepted(hash, start.Add(2*ttl), ttl) {
+		Fail(t, "accepted transaction remembered past its ttl")
+	}
+
+	// only the most recently accepted transactions are remembered
+	accepted.add(hash, start, 2)
+	accepted.add(common.HexToHash("0x02"), start, 2)
+	accepted.add(common.HexToHash("0x03"), start, 2)
+	if accepted.accepted(hash, start, ttl) {
+		Fail(t, "evicted transaction still remembered")
+	}
+}
@@ -94,6 +94,32 @@ func (i *SequencerInbox) GetAccumulator(ctx context.Context, sequenceNumber uint
 	return acc, errors.WithStack(err)
 }
 
+// MaxTimeVariation bounds how far batch timestamps and block numbers may be from L1's,
+// and so how long delayed messages can wait before they can be force included
+type MaxTimeVariation struct {
+	DelayBlocks   uint64
+	FutureBlocks  uint64
+	DelaySeconds  uint64
+	FutureSeconds uint64
+}
+
+func (i *SequencerInbox) GetMaxTimeVariation(ctx context.Context, blockNumber *big.Int) (MaxTimeVariation, error) {
+	opts := &bind.CallOpts{
+		Context:     ctx,
+		BlockNumber: blockNumber,
+	}
+	variation, err := i.con.MaxTimeVariation(opts)
+	if err != nil {
+		return MaxTimeVariation{}, errors.WithStack(err)
+	}
+	return MaxTimeVariation{
+		DelayBlocks:   variation.DelayBlocks.Uint64(),
+		FutureBlocks:  variation.FutureBlocks.Uint64(),
+		DelaySeconds:  variation.DelaySeconds.Uint64(),
+		FutureSeconds: variation.FutureSeconds.Uint64(),
+	}, nil
+}
+
 type SequencerInboxBatch struct {
 	BlockHash         common.Hash
 	BlockNumber       uint64
@@ -109,6 +135,15 @@ type SequencerInboxBatch struct {
 	serialized        []byte // nil if serialization isn't cached yet
 }
 
+func batchDataFromTxInput(txData []byte) ([]byte, error) {
+	args := make(map[string]interface{})
+	err := addSequencerL2BatchFromOriginCallABI.Inputs.UnpackIntoMap(args, txData[4:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return args["data"].([]byte), nil
+}
+
 func (m *SequencerInboxBatch) GetData(ctx context.Context, client arbutil.L1Interface) ([]byte, error) {
 	switch m.dataLocation {
 	case batchDataTxInput:
@@ -116,12 +151,7 @@ func (m *SequencerInboxBatch) GetData(ctx context.Context, client arbutil.L1Inte
 		if err != nil {
 			return nil, err
 		}
-		args := make(map[string]interface{})
-		err = addSequencerL2BatchFromOriginCallABI.Inputs.UnpackIntoMap(args, data[4:])
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		return args["data"].([]byte), nil
+		return batchDataFromTxInput(data)
 	case batchDataSeparateEvent:
 		var numberAsHash common.Hash
 		binary.BigEndian.PutUint64(numberAsHash[(32-8):], m.SequenceNumber)
@@ -159,6 +189,15 @@ func (m *SequencerInboxBatch) Serialize(ctx context.Context, client arbutil.L1In
 		return m.serialized, nil
 	}
 
+	data, err := m.GetData(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	m.serializeWithData(data)
+	return m.serialized, nil
+}
+
+func (m *SequencerInboxBatch) serializeWithData(data []byte) {
 	var fullData []byte
 
 	// Serialize the header
@@ -176,14 +215,37 @@ func (m *SequencerInboxBatch) Serialize(ctx context.Context, client arbutil.L1In
 	}
 
 	// Append the batch data
-	data, err := m.GetData(ctx, client)
-	if err != nil {
-		return nil, err
-	}
 	fullData = append(fullData, data...)
 
 	m.serialized = fullData
-	return fullData, nil
+}
+
+// prefetchBatchData serializes the batches whose data is in their transaction's input, looking up
+// those transactions in JSON-RPC batches of up to batchSize rather than one at a time
+func prefetchBatchData(ctx context.Context, client arbutil.L1Interface, batches []*SequencerInboxBatch, batchSize int) error {
+	var pending []*SequencerInboxBatch
+	var logs []types.Log
+	for _, batch := range batches {
+		if batch.serialized == nil && batch.dataLocation == batchDataTxInput {
+			pending = append(pending, batch)
+			logs = append(logs, batch.rawLog)
+		}
+	}
+	if len(pending) <= 1 {
+		return nil
+	}
+	txData, err := arbutil.GetLogEmitterTxDataBatch(ctx, client, logs, batchSize)
+	if err != nil {
+		return err
+	}
+	for i, batch := range pending {
+		data, err := batchDataFromTxInput(txData[i])
+		if err != nil {
+			return err
+		}
+		batch.serializeWithData(data)
+	}
+	return nil
 }
 
 func (i *SequencerInbox) LookupBatchesInRange(ctx context.Context, from, to *big.Int) ([]*SequencerInboxBatch, error) {
@@ -0,0 +1,91 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+)
+
+var (
+	priorityLaneTxsCounter   = metrics.NewRegisteredCounter("arb/sequencer/prioritylane/txs", nil)
+	priorityLaneBytesCounter = metrics.NewRegisteredCounter("arb/sequencer/prioritylane/bytes", nil)
+	priorityLaneFullCounter  = metrics.NewRegisteredCounter("arb/sequencer/prioritylane/full", nil)
+)
+
+type PriorityLaneConfig struct {
+	Senders     string  `koanf:"senders" reload:"hot"`
+	MaxFraction float64 `koanf:"max-fraction" reload:"hot"`
+}
+
+var DefaultPriorityLaneConfig = PriorityLaneConfig{
+	Senders:     "",
+	MaxFraction: 0.5,
+}
+
+func PriorityLaneConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".senders", DefaultPriorityLaneConfig.Senders, "comma separated list of senders whose transactions are ordered ahead of others in the same block")
+	f.Float64(prefix+".max-fraction", DefaultPriorityLaneConfig.MaxFraction, "maximum fraction of a block's max-tx-data-size that priority transactions are moved ahead into, after which they keep their place in the queue")
+}
+
+func (c *PriorityLaneConfig) Validate() error {
+	if err := validateAddressList("priority lane senders", c.Senders); err != nil {
+		return err
+	}
+	if c.MaxFraction < 0 || c.MaxFraction > 1 {
+		return fmt.Errorf("sequencer priority-lane max-fraction must be between 0 and 1, not %v", c.MaxFraction)
+	}
+	return nil
+}
+
+// prioritizeQueueItems moves the items isPriority selects ahead of the rest, keeping the order
+// within each group, until they'd take more than maxBytes. Each sender's transactions are all
+// either priority or not, so the reordering keeps every sender's nonces in order.
+func prioritizeQueueItems(items []txQueueItem, isPriority func(*types.Transaction) bool, maxBytes int) []txQueueItem {
+	priority := make([]txQueueItem, 0, len(items))
+	others := make([]txQueueItem, 0, len(items))
+	usedBytes := 0
+	full := false
+	for _, item := range items {
+		if !full && isPriority(item.tx) {
+			size := int(item.tx.Size())
+			if usedBytes+size <= maxBytes {
+				usedBytes += size
+				priority = append(priority, item)
+				continue
+			}
+			// later priority transactions keep their place too, so a sender's nonces stay in order
+			full = true
+			priorityLaneFullCounter.Inc(1)
+		}
+		others = append(others, item)
+	}
+	if len(priority) > 0 {
+		priorityLaneTxsCounter.Inc(int64(len(priority)))
+		priorityLaneBytesCounter.Inc(int64(usedBytes))
+	}
+	return append(priority, others...)
+}
+
+func (s *Sequencer) prioritize(queueItems []txQueueItem, config *SequencerConfig) []txQueueItem {
+	senders := s.prioritySenders.get(config.PriorityLane.Senders)
+	if len(senders) == 0 || len(queueItems) < 2 {
+		return queueItems
+	}
+	signer := types.LatestSigner(s.txStreamer.bc.Config())
+	isPriority := func(tx *types.Transaction) bool {
+		// the sender is cached from when the transaction was published
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return false
+		}
+		_, priority := senders[sender]
+		return priority
+	}
+	maxBytes := int(config.PriorityLane.MaxFraction * float64(config.MaxTxDataSize))
+	return prioritizeQueueItems(queueItems, isPriority, maxBytes)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestPrioritizeQueueItems(t *testing.T) {
+	var items []txQueueItem
+	for nonce := uint64(0); nonce < 6; nonce++ {
+		tx := types.NewTx(&types.LegacyTx{Nonce: nonce, Gas: 21000, Data: make([]byte, 100)})
+		items = append(items, txQueueItem{tx: tx})
+	}
+	// odd nonces stand in for priority senders
+	isPriority := func(tx *types.Transaction) bool {
+		return tx.Nonce()%2 == 1
+	}
+	nonces := func(items []txQueueItem) []uint64 {
+		var result []uint64
+		for _, item := range items {
+			result = append(result, item.tx.Nonce())
+		}
+		return result
+	}
+	expectOrder := func(got []txQueueItem, expected ...uint64) {
+		t.Helper()
+		gotNonces := nonces(got)
+		if len(gotNonces) != len(expected) {
+			Fail(t, "expected order", expected, "got", gotNonces)
+		}
+		for i := range expected {
+			if gotNonces[i] != expected[i] {
+				Fail(t, "expected order", expected, "got", gotNonces)
+			}
+		}
+	}
+
+	size := int(items[0].tx.Size())
+	expectOrder(prioritizeQueueItems(items, isPriority, size*10), 1, 3, 5, 0, 2, 4)
+	// once the lane is full, the remaining priority transactions keep their place
+	expectOrder(prioritizeQueueItems(items, isPriority, size*2), 1, 3, 0, 2, 4, 5)
+	expectOrder(prioritizeQueueItems(items, isPriority, 0), 0, 1, 2, 3, 4, 5)
+}
@@ -0,0 +1,123 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const rejectionReasonOther = "other"
+
+// rejectionReasons is the fixed set of rejection reasons counted, so the metrics' cardinality is bounded
+var rejectionReasons = []string{
+	"nonce_too_low",
+	"nonce_too_high",
+	"underpriced",
+	"below_min_gas_price",
+	"replace_underpriced",
+	"replaced",
+	"duplicate",
+	"too_old",
+	"queue_full",
+	"oversized",
+	"expired",
+	"timeout",
+	"intrinsic_gas",
+	"insufficient_funds",
+	"gas_limit",
+	"reverted",
+	"condition_not_met",
+	"not_whitelisted",
+	"denylisted",
+	"recipient_denylisted",
+	"unsupported_type",
+	rejectionReasonOther,
+}
+
+var rejectionCounters = func() map[string]metrics.Counter {
+	counters := make(map[string]metrics.Counter, len(rejectionReasons))
+	for _, reason := range rejectionReasons {
+		counters[reason] = metrics.NewRegisteredCounter("arb/sequencer/rejected/"+reason, nil)
+	}
+	return counters
+}()
+
+var ErrSenderNotWhitelisted = errors.New("transaction sender is not on the whitelist")
+
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, core.ErrNonceTooLow):
+		return "nonce_too_low"
+	case errors.Is(err, core.ErrNonceTooHigh):
+		return "nonce_too_high"
+	case errors.Is(err, core.ErrFeeCapTooLow), errors.Is(err, core.ErrTipAboveFeeCap):
+		return "underpriced"
+	case errors.Is(err, ErrGasPriceBelowMinimum):
+		return "below_min_gas_price"
+	case errors.Is(err, core.ErrReplaceUnderpriced):
+		return "replace_underpriced"
+	case errors.Is(err, ErrTxReplaced):
+		return "replaced"
+	case errors.Is(err, ErrTxAlreadySeen):
+		return "duplicate"
+	case errors.Is(err, ErrTxTooOld):
+		return "too_old"
+	case errors.Is(err, ErrSequencerQueueFull):
+		return "queue_full"
+	case errors.Is(err, core.ErrOversizedData):
+		return "oversized"
+	case errors.Is(err, ErrTxExpired):
+		return "expired"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, core.ErrIntrinsicGas):
+		return "intrinsic_gas"
+	case errors.Is(err, core.ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, core.ErrGasLimitReached):
+		return "gas_limit"
+	case errors.Is(err, ErrConditionNotMet):
+		return "condition_not_met"
+	case errors.Is(err, ErrSenderNotWhitelisted):
+		return "not_whitelisted"
+	case errors.Is(err, ErrSenderDenied):
+		return "denylisted"
+	case errors.Is(err, ErrRecipientDenied):
+		return "recipient_denylisted"
+	case errors.Is(err, types.ErrTxTypeNotSupported):
+		return "unsupported_type"
+	case errors.Is(err, vm.ErrExecutionReverted):
+		return "reverted"
+	}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		// revert reasons carry the revert data
+		return "reverted"
+	}
+	return rejectionReasonOther
+}
+
+// recordRejection counts a transaction the sequencer rejected by its reason, and logs one in every
+// node.sequencer.rejection-log-sample rejections at debug level
+func (s *Sequencer) recordRejection(tx *types.Transaction, err error) {
+	if errors.Is(err, context.Canceled) {
+		// the submitter went away, so nothing was rejected
+		return
+	}
+	reason := rejectionReason(err)
+	rejectionCounters[reason].Inc(1)
+	sample := s.config().RejectionLogSample
+	if sample > 0 && atomic.AddUint64(&s.rejectionCount, 1)%sample == 0 {
+		log.Debug("sequencer rejected transaction", "reason", reason, "tx", tx.Hash(), "nonce", tx.Nonce(), "size", tx.Size(), "err", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	txReplacedCounter           = metrics.NewRegisteredCounter("arb/sequencer/queue/replaced", nil)
+	txReplaceUnderpricedCounter = metrics.NewRegisteredCounter("arb/sequencer/queue/replace_underpriced", nil)
+)
+
+var ErrTxReplaced = errors.New("transaction replaced by one with the same nonce and a higher fee")
+
+type queuedTxKey struct {
+	sender common.Address
+	nonce  uint64
+}
+
+type queuedTx struct {
+	tx        *types.Transaction
+	key       queuedTxKey
+	order     uint64
+	replaced  bool
+	inFlight  bool
+	replacing *queuedTx
+}
+
+// queuedTxTracker indexes the transactions waiting in the sequencer queue by sender and nonce, so a
+// transaction with the same nonce and a high enough fee replaces the queued one, as in a mempool.
+// Once a transaction is taken for a block, it can no longer be replaced.
+type queuedTxTracker struct {
+	mutex     sync.RWMutex
+	txs       map[queuedTxKey]*queuedTx
+	byHash    map[common.Hash]*queuedTx
+	nextOrder uint64
+}
+
+func newQueuedTxTracker() *queuedTxTracker {
+	return &queuedTxTracker{
+		txs:    make(map[queuedTxKey]*queuedTx),
+		byHash: make(map[common.Hash]*queuedTx),
+	}
+}
+
+// hasReplacementBump returns whether replacement raises both the fee cap and tip cap of old by at
+// least priceBump percent
+func hasReplacementBump(old *types.Transaction, replacement *types.Transaction, priceBump uint64) bool {
+	if old.GasFeeCapCmp(replacement) >= 0 || old.GasTipCapCmp(replacement) >= 0 {
+		return false
+	}
+	multiplier := new(big.Int).SetUint64(100 + priceBump)
+	hundred := big.NewInt(100)
+	thresholdFeeCap := new(big.Int).Div(new(big.Int).Mul(multiplier, old.GasFeeCap()), hundred)
+	thresholdTip := new(big.Int).Div(new(big.Int).Mul(multiplier, old.GasTipCap()), hundred)
+	return replacement.GasFeeCapIntCmp(thresholdFeeCap) >= 0 && replacement.GasTipCapIntCmp(thresholdTip) >= 0
+}
+
+// add tracks tx as queued, replacing a queued transaction from the same sender with the same nonce,
+// or rejecting tx with core.ErrReplaceUnderpriced if its fees aren't priceBump percent higher
+func (t *queuedTxTracker) add(key queuedTxKey, tx *types.Transaction, priceBump uint64) (*queuedTx, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	existing := t.txs[key]
+	if existing != nil && !existing.inFlight && !existing.replaced {
+		if !hasReplacementBump(existing.tx, tx, priceBump) {
+			txReplaceUnderpricedCounter.Inc(1)
+			return nil, core.ErrReplaceUnderpriced
+		}
+		existing.replaced = true
+		txReplacedCounter.Inc(1)
+	} else {
+		existing = nil
+	}
+	entry := &queuedTx{tx: tx, key: key, order: t.nextOrder, replacing: existing}
+	t.nextOrder++
+	t.txs[key] = entry
+	t.byHash[tx.Hash()] = entry
+	return entry, nil
+}
+
+// take marks entry as being sequenced, returning ErrTxReplaced if it was replaced while queued
+func (t *queuedTxTracker) take(entry *queuedTx) error {
+	if entry == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if entry.replaced {
+		return ErrTxReplaced
+	}
+	entry.inFlight = true
+	return nil
+}
+
+func (t *queuedTxTracker) removeHash(entry *queuedTx) {
+	hash := entry.tx.Hash()
+	if t.byHash[hash] == entry {
+		delete(t.byHash, hash)
+	}
+}
+
+// remove stops tracking entry once its submitter has its result
+func (t *queuedTxTracker) remove(key queuedTxKey, entry *queuedTx) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.removeHash(entry)
+	if t.txs[key] == entry {
+		delete(t.txs, key)
+	}
+}
+
+// cancel stops tracking entry when it couldn't be queued, restoring the transaction it replaced
+func (t *queuedTxTracker) cancel(key queuedTxKey, entry *queuedTx) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.removeHash(entry)
+	if t.txs[key] != entry {
+		return
+	}
+	if entry.replacing != nil {
+		entry.replacing.replaced = false
+		t.txs[key] = entry.replacing
+	} else {
+		delete(t.txs, key)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+)
+
+func queuedTestTx(nonce uint64, feeCap int64, tipCap int64) *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasFeeCap: big.NewInt(feeCap),
+		GasTipCap: big.NewInt(tipCap),
+		Gas:       21000,
+	})
+}
+
+func TestQueuedTxReplacement(t *testing.T) {
+	tracker := newQueuedTxTracker()
+	key := queuedTxKey{common.HexToAddress("0x1234"), 5}
+
+	original, err := tracker.add(key, queuedTestTx(5, 1000, 100), 10)
+	Require(t, err)
+
+	// a 5% bump isn't enough
+	_, err = tracker.add(key, queuedTestTx(5, 1050, 105), 10)
+	if !errors.Is(err, core.ErrReplaceUnderpriced) {
+		Fail(t, "expected underpriced replacement to be rejected, got", err)
+	}
+	// both the fee cap and the tip cap must be bumped
+	_, err = tracker.add(key, queuedTestTx(5, 2000, 100), 10)
+	if !errors.Is(err, core.ErrReplaceUnderpriced) {
+		Fail(t, "expected replacement without a tip bump to be rejected, got", err)
+	}
+	if err := tracker.take(original); err != nil {
+		Fail(t, "rejected replacements replaced the original transaction:", err)
+	}
+
+	tracker = newQueuedTxTracker()
+	original, err = tracker.add(key, queuedTestTx(5, 1000, 100), 10)
+	Require(t, err)
+	replacement, err := tracker.add(key, queuedTestTx(5, 1100, 110), 10)
+	Require(t, err)
+	if err := tracker.take(original); !errors.Is(err, ErrTxReplaced) {
+		Fail(t, "expected the original transaction to be replaced, got", err)
+	}
+	Require(t, tracker.take(replacement))
+
+	// a replacement that couldn't be queued restores the transaction it replaced
+	tracker = newQueuedTxTracker()
+	original, err = tracker.add(key, queuedTestTx(5, 1000, 100), 10)
+	Require(t, err)
+	unqueued, err := tracker.add(key, queuedTestTx(5, 1100, 110), 10)
+	Require(t, err)
+	tracker.cancel(key, unqueued)
+	Require(t, tracker.take(original))
+	replacement, err = tracker.add(key, queuedTestTx(5, 1100, 110), 10)
+	Require(t, err)
+	Require(t, tracker.take(replacement))
+
+	// a transaction taken for a block can't be replaced
+	another, err := tracker.add(key, queuedTestTx(5, 5000, 500), 10)
+	Require(t, err)
+	tracker.remove(key, replacement)
+	if tracker.txs[key] != another {
+		Fail(t, "removing a superseded entry untracked the newer one")
+	}
+	tracker.remove(key, another)
+	if len(tracker.txs) != 0 {
+		Fail(t, "expected no tracked transactions, found", len(tracker.txs))
+	}
+}
+
+func TestSequencerTxReplacement(t *testing.T) {
+	config := TestSequencerConfig
+	sequencer, key, bc := newSequencerForTest(t, &config)
+	feeCap := int64(l2pricing.InitialBaseFeeWei * 2)
+	original := signedTestTx(t, bc, key, 0, feeCap)
+	replacement := signedTestTx(t, bc, key, 0, feeCap*2)
+
+	publish := func(tx *types.Transaction) <-chan error {
+		result := make(chan error, 1)
+		go func() {
+			result <- sequencer.PublishTransaction(context.Background(), tx)
+		}()
+		return result
+	}
+	waitForQueue := func(length int) {
+		t.Helper()
+		for i := 0; i < 100 && len(sequencer.txQueue) < length; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if len(sequencer.txQueue) != length {
+			Fail(t, "expected", length, "queued transactions, got", len(sequencer.txQueue))
+		}
+	}
+	originalResult := publish(original)
+	waitForQueue(1)
+	replacementResult := publish(replacement)
+	waitForQueue(2)
+
+	if !createBlockForTest(sequencer) {
+		Fail(t, "no block sequenced")
+	}
+	if err := <-originalResult; !errors.Is(err, ErrTxReplaced) {
+		Fail(t, "expected the original transaction to be replaced, got", err)
+	}
+	Require(t, <-replacementResult)
+	block := bc.CurrentBlock()
+	included := false
+	for _, tx := range block.Transactions() {
+		if tx.Hash() == original.Hash() {
+			Fail(t, "replaced transaction was sequenced")
+		}
+		if tx.Hash() == replacement.Hash() {
+			included = true
+		}
+	}
+	if !included {
+		Fail(t, "replacement transaction not sequenced in block", block.NumberU64())
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var (
+	txDuplicateCounter = metrics.NewRegisteredCounter("arb/sequencer/seen/duplicate", nil)
+	txTooOldCounter    = metrics.NewRegisteredCounter("arb/sequencer/seen/too_old", nil)
+)
+
+var ErrTxAlreadySeen = errors.New("transaction already submitted to the sequencer")
+var ErrTxTooOld = errors.New("transaction first submitted to the sequencer longer ago than its max-tx-age")
+
+// seenTxs remembers when the sequencer first saw recently submitted transactions, as transactions
+// carry no timestamp of their own. A submission of a transaction that's still remembered is
+// rejected, as a duplicate if it's within max-tx-age of the first and as stale after that.
+// Only the most recent seen-tx-cache-size transactions are remembered.
+type seenTxs struct {
+	mutex sync.Mutex
+	cache *containers.LruCache[common.Hash, time.Time]
+}
+
+func newSeenTxs(size int) *seenTxs {
+	return &seenTxs{
+		cache: containers.NewLruCache[common.Hash, time.Time](size),
+	}
+}
+
+// see records that hash was submitted at now, unless it was already
+func (s *seenTxs) see(hash common.Hash, now time.Time, maxAge time.Duration, size int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cache.Resize(size)
+	firstSeen, ok := s.cache.Get(hash)
+	if !ok {
+		s.cache.Add(hash, now)
+		return nil
+	}
+	if age := now.Sub(firstSeen); age > maxAge {
+		txTooOldCounter.Inc(1)
+		return fmt.Errorf("%w: first seen %v ago, max age %v", ErrTxTooOld, age.Round(time.Second), maxAge)
+	}
+	txDuplicateCounter.Inc(1)
+	return ErrTxAlreadySeen
+}
+
+// forget drops hash so it can be submitted again, for transactions that weren't sequenced
+func (s *seenTxs) forget(hash common.Hash) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cache.Remove(hash)
+}
@@ -0,0 +1,36 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSeenTxs(t *testing.T) {
+	seen := newSeenTxs(2)
+	maxAge := time.Minute
+	start := time.Now()
+	first := common.HexToHash("0x01")
+
+	Require(t, seen.see(first, start, maxAge, 2))
+	if err := seen.see(first, start.Add(time.Second), maxAge, 2); !errors.Is(err, ErrTxAlreadySeen) {
+		Fail(t, "expected resubmission within max age to be a duplicate, got", err)
+	}
+	if err := seen.see(first, start.Add(2*maxAge), maxAge, 2); !errors.Is(err, ErrTxTooOld) {
+		Fail(t, "expected resubmission after max age to be too old, got", err)
+	}
+
+	// forgotten transactions can be submitted again
+	seen.forget(first)
+	Require(t, seen.see(first, start.Add(2*maxAge), maxAge, 2))
+
+	// only the most recent transactions are remembered
+	Require(t, seen.see(common.HexToHash("0x02"), start, maxAge, 2))
+	Require(t, seen.see(common.HexToHash("0x03"), start, maxAge, 2))
+	Require(t, seen.see(first, start.Add(2*maxAge), maxAge, 2))
+}
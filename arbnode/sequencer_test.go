@@ -0,0 +1,185 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+)
+
+// newSequencerForTest returns a sequencer that isn't started, so its queue only moves when the test
+// calls createBlock, and a key funded on its chain
+func newSequencerForTest(t *testing.T, config *SequencerConfig) (*Sequencer, *ecdsa.PrivateKey, *core.BlockChain) {
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	streamer, _, bc := NewTransactionStreamerForTest(t, crypto.PubkeyToAddress(key.PublicKey))
+	sequencer, err := NewSequencer(streamer, nil, func() *SequencerConfig { return config }, func() *DevConfig { return &DefaultDevConfig })
+	Require(t, err)
+	return sequencer, key, bc
+}
+
+func signedTestTx(t *testing.T, bc *core.BlockChain, key *ecdsa.PrivateKey, nonce uint64, feeCap int64) *types.Transaction {
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   bc.Config().ChainID,
+		Nonce:     nonce,
+		GasFeeCap: big.NewInt(feeCap),
+		GasTipCap: big.NewInt(feeCap),
+		Gas:       1_000_000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	}), types.LatestSigner(bc.Config()), key)
+	Require(t, err)
+	return tx
+}
+
+// createBlockForTest runs createBlock, which waits for a transaction, until the queue is empty
+func createBlockForTest(sequencer *Sequencer) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	return sequencer.createBlock(ctx)
+}
+
+func TestSequencerTxTTL(t *testing.T) {
+	config := TestSequencerConfig
+	config.QueueTimeout = time.Minute
+	config.TxTTL = 50 * time.Millisecond
+	sequencer, key, bc := newSequencerForTest(t, &config)
+	feeCap := int64(l2pricing.InitialBaseFeeWei * 2)
+
+	// nothing builds blocks, so the transaction expires in the queue long before the queue timeout
+	start := time.Now()
+	err := sequencer.PublishTransaction(context.Background(), signedTestTx(t, bc, key, 0, feeCap))
+	if !errors.Is(err, ErrTxExpired) {
+		Fail(t, "expected the queued transaction to expire, got", err)
+	}
+	if elapsed := time.Since(start); elapsed >= config.QueueTimeout {
+		Fail(t, "transaction expired at the queue timeout rather than its ttl", elapsed)
+	}
+
+	// the block builder drops an expired transaction whose submitter is still waiting instead of sequencing it
+	resultChan := make(chan error, 1)
+	sequencer.txRetryQueue.Push(txQueueItem{
+		tx:              signedTestTx(t, bc, key, 0, feeCap),
+		resultChan:      resultChan,
+		ctx:             context.Background(),
+		firstAppearance: time.Now().Add(-time.Second),
+	})
+	if createBlockForTest(sequencer) {
+		Fail(t, "sequenced a block from expired transactions")
+	}
+	if err := <-resultChan; !errors.Is(err, ErrTxExpired) {
+		Fail(t, "expected the retried transaction to expire, got", err)
+	}
+	if number := bc.CurrentBlock().NumberU64(); number != 0 {
+		Fail(t, "expired transactions were sequenced into block", number)
+	}
+}
+
+func TestSequencerMaxBlockBytes(t *testing.T) {
+	config := TestSequencerConfig
+	sequencer, key, bc := newSequencerForTest(t, &config)
+	feeCap := int64(l2pricing.InitialBaseFeeWei * 2)
+	var txs []*types.Transaction
+	var sizes []int
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		tx := signedTestTx(t, bc, key, nonce, feeCap)
+		txBytes, err := tx.MarshalBinary()
+		Require(t, err)
+		txs = append(txs, tx)
+		sizes = append(sizes, len(txBytes))
+	}
+	// the first two fit, but not the third
+	config.MaxBlockBytes = sizes[0] + sizes[1] + sizes[2]/2
+
+	var results []chan error
+	publish := func(tx *types.Transaction) {
+		result := make(chan error, 1)
+		results = append(results, result)
+		go func() {
+			result <- sequencer.PublishTransaction(context.Background(), tx)
+		}()
+		for i := 0; i < 100 && len(sequencer.txQueue) < len(results); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if len(sequencer.txQueue) != len(results) {
+			Fail(t, "expected", len(results), "queued transactions, got", len(sequencer.txQueue))
+		}
+	}
+	expectBlock := func(expected ...*types.Transaction) {
+		t.Helper()
+		if !createBlockForTest(sequencer) {
+			Fail(t, "no block sequenced")
+		}
+		block := bc.CurrentBlock()
+		var sequenced []*types.Transaction
+		for _, tx := range block.Transactions() {
+			if tx.Type() != types.ArbitrumInternalTxType {
+				sequenced = append(sequenced, tx)
+			}
+		}
+		if len(sequenced) != len(expected) {
+			Fail(t, "expected", len(expected), "transactions in block", block.NumberU64(), "got", len(sequenced))
+		}
+		for i, tx := range expected {
+			if sequenced[i].Hash() != tx.Hash() {
+				Fail(t, "expected transaction with nonce", tx.Nonce(), "in block", block.NumberU64(), "got nonce", sequenced[i].Nonce())
+			}
+		}
+	}
+	for _, tx := range txs[:3] {
+		publish(tx)
+	}
+	capped := blockByteCapCounter.Count()
+	expectBlock(txs[0], txs[1])
+	if blockByteCapCounter.Count() != capped+1 {
+		Fail(t, "block ended at the byte cap wasn't counted")
+	}
+	Require(t, <-results[0])
+	Require(t, <-results[1])
+	// the transaction left out goes in the next block
+	expectBlock(txs[2])
+	Require(t, <-results[2])
+
+	// a transaction over the cap by itself still gets a block of its own
+	config.MaxBlockBytes = 1
+	results = nil
+	publish(txs[3])
+	expectBlock(txs[3])
+	Require(t, <-results[0])
+}
+
+func TestDeniedLogRateLimit(t *testing.T) {
+	deniedLog := newDeniedLog()
+	spammer := common.HexToAddress("0x01")
+	start := time.Now()
+
+	if shouldLog, _ := deniedLog.record(spammer, start); !shouldLog {
+		Fail(t, "first blocked transaction not logged")
+	}
+	for i := 0; i < 3; i++ {
+		if shouldLog, _ := deniedLog.record(spammer, start.Add(time.Second)); shouldLog {
+			Fail(t, "blocked transaction logged again within the interval")
+		}
+	}
+	// other addresses are logged separately
+	if shouldLog, _ := deniedLog.record(common.HexToAddress("0x02"), start.Add(time.Second)); !shouldLog {
+		Fail(t, "another address's blocked transaction not logged")
+	}
+	shouldLog, suppressed := deniedLog.record(spammer, start.Add(deniedLogInterval))
+	if !shouldLog || suppressed != 3 {
+		Fail(t, "expected a log reporting 3 unlogged transactions after the interval, got", shouldLog, suppressed)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	ShutdownPhaseRPC         = "rpc"
+	ShutdownPhaseSequencer   = "sequencer"
+	ShutdownPhaseBatchPoster = "batch-poster"
+	ShutdownPhaseServices    = "services"
+	ShutdownPhaseState       = "state"
+	ShutdownPhaseDatabase    = "database"
+)
+
+var shutdownPhases = []string{
+	ShutdownPhaseRPC,
+	ShutdownPhaseSequencer,
+	ShutdownPhaseBatchPoster,
+	ShutdownPhaseServices,
+	ShutdownPhaseState,
+	ShutdownPhaseDatabase,
+}
+
+// shutdownPhaseDependencies lists the phases that must come before each phase in a shutdown order
+var shutdownPhaseDependencies = map[string][]string{
+	// the final batch must include the sequencer's last block
+	ShutdownPhaseBatchPoster: {ShutdownPhaseSequencer},
+	// the batch poster reads from L1 and posts to the DAS
+	ShutdownPhaseServices: {ShutdownPhaseBatchPoster},
+	ShutdownPhaseDatabase: {ShutdownPhaseBatchPoster, ShutdownPhaseServices, ShutdownPhaseState},
+}
+
+type ShutdownConfig struct {
+	Order             []string      `koanf:"order"`
+	FinalBatchTimeout time.Duration `koanf:"final-batch-timeout"`
+}
+
+var DefaultShutdownConfig = ShutdownConfig{
+	Order:             shutdownPhases,
+	FinalBatchTimeout: 30 * time.Second,
+}
+
+var TestShutdownConfig = ShutdownConfig{
+	Order:             shutdownPhases,
+	FinalBatchTimeout: 0,
+}
+
+func ShutdownConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.StringSlice(prefix+".order", DefaultShutdownConfig.Order, "order to stop the node in, from the phases "+
+		"rpc (stop serving RPC), sequencer (finish sequencing the current block), batch-poster (post the last batch and stop), "+
+		"services (feed, L1 reader, inbox reader, and validation), state (finish executing blocks and write their state), "+
+		"and database (close the databases)")
+	f.Duration(prefix+".final-batch-timeout", DefaultShutdownConfig.FinalBatchTimeout, "how long the batch poster may spend posting the messages not yet in a batch when stopping (0 to stop without posting)")
+}
+
+func (c *ShutdownConfig) Validate() error {
+	seen := make(map[string]bool)
+	for _, phase := range c.Order {
+		known := false
+		for _, expected := range shutdownPhases {
+			if phase == expected {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown shutdown phase \"%v\", expected one of %v", phase, shutdownPhases)
+		}
+		if seen[phase] {
+			return fmt.Errorf("shutdown phase \"%v\" listed more than once", phase)
+		}
+		seen[phase] = true
+	}
+	if len(seen) != len(shutdownPhases) {
+		return fmt.Errorf("shutdown order %v must list every phase of %v", c.Order, shutdownPhases)
+	}
+	stopped := make(map[string]bool)
+	for _, phase := range c.Order {
+		for _, dependency := range shutdownPhaseDependencies[phase] {
+			if !stopped[dependency] {
+				return fmt.Errorf("shutdown phase \"%v\" must come after \"%v\" in order %v", phase, dependency, c.Order)
+			}
+		}
+		stopped[phase] = true
+	}
+	return nil
+}
+
+// OnStopRPC registers a function that stops an RPC server the node doesn't own, to be run in the rpc shutdown phase
+func (n *Node) OnStopRPC(stop func()) {
+	n.rpcStoppers = append(n.rpcStoppers, stop)
+}
+
+func (n *Node) shutdownConfig() *ShutdownConfig {
+	if n.configFetcher == nil {
+		return &DefaultShutdownConfig
+	}
+	return &n.configFetcher.Get().Shutdown
+}
+
+// StopAndWait stops the node one phase at a time in the configured order, logging how long each took
+func (n *Node) StopAndWait() {
+	config := n.shutdownConfig()
+	start := time.Now()
+	for _, phase := range config.Order {
+		phaseStart := time.Now()
+		n.stopPhase(phase, config)
+		log.Info("shutdown phase complete", "phase", phase, "elapsed", time.Since(phaseStart))
+	}
+	log.Info("shutdown complete", "elapsed", time.Since(start))
+}
+
+func (n *Node) stopPhase(phase string, config *ShutdownConfig) {
+	switch phase {
+	case ShutdownPhaseRPC:
+		for _, stop := range n.rpcStoppers {
+			stop()
+		}
+		n.rpcStoppers = nil
+		// stops the stack's HTTP, WS, and IPC servers without closing its databases, and is harmless to repeat on close
+		n.Stack.StopRPC()
+		if n.configFetcher != nil && n.configFetcher.Started() {
+			n.configFetcher.StopAndWait()
+		}
+	case ShutdownPhaseSequencer:
+		if n.DelayedSequencer != nil && n.DelayedSequencer.Started() {
+			n.DelayedSequencer.StopAndWait()
+		}
+		if n.SeqCoordinator != nil && n.SeqCoordinator.Started() {
+			n.SeqCoordinator.StopAndWait()
+		}
+		if n.TxPublisher.Started() {
+			n.TxPublisher.StopAndWait()
+		}
+	case ShutdownPhaseBatchPoster:
+		if n.BatchPoster != nil && n.BatchPoster.Started() {
+			if config.FinalBatchTimeout > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), config.FinalBatchTimeout)
+				n.BatchPoster.FlushAndStop(ctx)
+				cancel()
+			} else {
+				n.BatchPoster.StopAndWait()
+			}
+		}
+	case ShutdownPhaseServices:
+		if n.ChainBackup != nil && n.ChainBackup.Started() {
+			n.ChainBackup.StopAndWait()
+		}
+		if n.DivergenceMonitor != nil && n.DivergenceMonitor.Started() {
+			n.DivergenceMonitor.StopAndWait()
+		}
+		if n.FinalityFeed != nil && n.FinalityFeed.Started() {
+			n.FinalityFeed.StopAndWait()
+		}
+		if n.BroadcastClients != nil {
+			n.BroadcastClients.StopAndWait()
+		}
+		if n.BroadcastServer != nil && n.BroadcastServer.Started() {
+			n.BroadcastServer.StopAndWait()
+		}
+		if n.BlockValidator != nil && n.BlockValidator.Started() {
+			n.BlockValidator.StopAndWait()
+		}
+		if n.InboxReader != nil && n.InboxReader.Started() {
+			n.InboxReader.StopAndWait()
+		}
+		if n.L1Reader != nil && n.L1Reader.Started() {
+			n.L1Reader.StopAndWait()
+		}
+		if n.DASLifecycleManager != nil {
+			n.DASLifecycleManager.StopAndWaitUntil(2 * time.Second)
+		}
+	case ShutdownPhaseState:
+		if n.TxStreamer.Started() {
+			n.TxStreamer.StopAndWait()
+		}
+		n.ArbInterface.BlockChain().Stop() // does nothing if not running
+		if err := n.Backend.Stop(); err != nil {
+			log.Error("backend stop", "err", err)
+		}
+	case ShutdownPhaseDatabase:
+		if err := n.Stack.Close(); err != nil {
+			log.Error("error on stak close", "err", err)
+		}
+	}
+}
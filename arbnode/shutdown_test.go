@@ -0,0 +1,47 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import "testing"
+
+func TestShutdownConfigOrder(t *testing.T) {
+	valid := DefaultShutdownConfig
+	Require(t, valid.Validate())
+	valid.Order = []string{ShutdownPhaseSequencer, ShutdownPhaseRPC, ShutdownPhaseBatchPoster, ShutdownPhaseState, ShutdownPhaseServices, ShutdownPhaseDatabase}
+	Require(t, valid.Validate())
+
+	for _, order := range [][]string{
+		{ShutdownPhaseRPC, ShutdownPhaseSequencer, ShutdownPhaseBatchPoster, ShutdownPhaseServices, ShutdownPhaseDatabase, ShutdownPhaseState},
+		{ShutdownPhaseRPC, ShutdownPhaseSequencer, ShutdownPhaseDatabase, ShutdownPhaseBatchPoster, ShutdownPhaseServices, ShutdownPhaseState},
+		{ShutdownPhaseRPC, ShutdownPhaseSequencer, ShutdownPhaseServices, ShutdownPhaseBatchPoster, ShutdownPhaseState, ShutdownPhaseDatabase},
+		{ShutdownPhaseRPC, ShutdownPhaseSequencer, ShutdownPhaseBatchPoster, ShutdownPhaseServices, ShutdownPhaseState},
+		{ShutdownPhaseRPC, ShutdownPhaseBatchPoster, ShutdownPhaseSequencer, ShutdownPhaseServices, ShutdownPhaseState, ShutdownPhaseDatabase},
+	} {
+		config := DefaultShutdownConfig
+		config.Order = order
+		if config.Validate() == nil {
+			Fail(t, "accepted shutdown order", order)
+		}
+	}
+}
+
+func TestShutdownPhaseOrder(t *testing.T) {
+	position := make(map[string]int)
+	for i, phase := range DefaultShutdownConfig.Order {
+		position[phase] = i
+	}
+	expected := []string{ShutdownPhaseRPC, ShutdownPhaseSequencer, ShutdownPhaseBatchPoster, ShutdownPhaseState, ShutdownPhaseDatabase}
+	for i := 1; i < len(expected); i++ {
+		if position[expected[i-1]] >= position[expected[i]] {
+			Fail(t, "default shutdown order", DefaultShutdownConfig.Order, "doesn't stop", expected[i-1], "before", expected[i])
+		}
+	}
+	for phase, dependencies := range shutdownPhaseDependencies {
+		for _, dependency := range dependencies {
+			if position[dependency] >= position[phase] {
+				Fail(t, "default shutdown order", DefaultShutdownConfig.Order, "stops", phase, "before", dependency)
+			}
+		}
+	}
+}
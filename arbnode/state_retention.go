@@ -0,0 +1,103 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+var ErrStateNotAvailable = errors.New("state not available")
+
+type StateRetention struct {
+	Archive bool `json:"archive"`
+	// the number of recent blocks whose state is always kept, more may be kept until they're old enough to prune
+	RetainedBlocks hexutil.Uint64 `json:"retainedBlocks"`
+	HeadBlock      hexutil.Uint64 `json:"headBlock"`
+	// the oldest block whose state is available, unset for archive nodes
+	OldestBlock *hexutil.Uint64 `json:"oldestBlock,omitempty"`
+}
+
+func stateRetention(blockchain *core.BlockChain, caching *CachingConfig) *StateRetention {
+	head := blockchain.CurrentHeader().Number.Uint64()
+	retention := &StateRetention{
+		Archive:        caching.Archive,
+		RetainedBlocks: hexutil.Uint64(caching.BlockCount),
+		HeadBlock:      hexutil.Uint64(head),
+	}
+	if !caching.Archive {
+		oldest := oldestRetainedState(blockchain, caching, head, time.Now())
+		retention.OldestBlock = (*hexutil.Uint64)(&oldest)
+	}
+	return retention
+}
+
+// oldestRetainedState finds the oldest block whose state the caching config keeps, by count or by
+// age, then moves up to the oldest one from which the state of every block up to the head is
+// available. Only the state written to disk survives a restart, so until enough blocks are processed
+// it can be newer than the config says.
+func oldestRetainedState(blockchain *core.BlockChain, caching *CachingConfig, head uint64, now time.Time) uint64 {
+	oldest := arbmath.SaturatingUSub(head+1, caching.BlockCount)
+	if caching.BlockAge > 0 {
+		cutoff := arbmath.SaturatingUSub(uint64(now.Unix()), uint64(caching.BlockAge/time.Second))
+		youngEnough := uint64(sort.Search(int(oldest), func(number int) bool {
+			header := blockchain.GetHeaderByNumber(uint64(number))
+			return header != nil && header.Time >= cutoff
+		}))
+		if youngEnough < oldest {
+			oldest = youngEnough
+		}
+	}
+	// state committed before a restart is only kept at the blocks it was committed at, so there can
+	// be gaps in it, and the state is only guaranteed back to the first gap below the head
+	available := head
+	for available > oldest {
+		header := blockchain.GetHeaderByNumber(available - 1)
+		if header == nil || !blockchain.HasState(header.Root) {
+			break
+		}
+		available--
+	}
+	return available
+}
+
+// IsMissingStateError reports whether err came from reading state that was pruned
+func IsMissingStateError(err error) bool {
+	var missingNode *trie.MissingNodeError
+	return errors.As(err, &missingNode) || strings.Contains(err.Error(), "missing trie node")
+}
+
+// StateNotAvailableError is the error returned for requests for state older than a non-archive node retains
+func StateNotAvailableError(retainedBlocks uint64) error {
+	return fmt.Errorf("%w, node retains last %v blocks", ErrStateNotAvailable, retainedBlocks)
+}
+
+// translateMissingStateError replaces pruned state errors with the standard one. The arb and nitro
+// APIs use it, and http.state-errors rewrites the errors of other HTTP-RPC calls, but websocket and
+// in-process calls to geth's APIs still return geth's missing trie node errors.
+func translateMissingStateError(err error, caching *CachingConfig) error {
+	if err == nil || caching == nil || caching.Archive || !IsMissingStateError(err) {
+		return err
+	}
+	return StateNotAvailableError(caching.BlockCount)
+}
+
+// StateRetention reports how many recent blocks this node keeps the state of, and the oldest
+// block whose state is guaranteed to be available
+func (a *ArbAPI) StateRetention(ctx context.Context) (*StateRetention, error) {
+	if a.blockchain == nil || a.cachingConfig == nil {
+		return nil, errors.New("state retention unknown")
+	}
+	return stateRetention(a.blockchain, a.cachingConfig), nil
+}
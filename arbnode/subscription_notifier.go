@@ -0,0 +1,115 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type subscriber[T any] struct {
+	events  chan T
+	dropped chan struct{}
+}
+
+// subscriptionNotifier fans events out to subscribers without blocking the sender. Each subscriber
+// has a bounded buffer, and one that falls behind by more than that is dropped.
+type subscriptionNotifier[T any] struct {
+	name             string
+	bufferSize       func() int
+	subscribersGauge metrics.Gauge
+	dropCounter      metrics.Counter
+
+	mutex       sync.Mutex
+	subscribers map[*subscriber[T]]struct{}
+}
+
+func newSubscriptionNotifier[T any](name string, bufferSize func() int, subscribersGauge metrics.Gauge, dropCounter metrics.Counter) *subscriptionNotifier[T] {
+	return &subscriptionNotifier[T]{
+		name:             name,
+		bufferSize:       bufferSize,
+		subscribersGauge: subscribersGauge,
+		dropCounter:      dropCounter,
+		subscribers:      make(map[*subscriber[T]]struct{}),
+	}
+}
+
+func (n *subscriptionNotifier[T]) subscribe() *subscriber[T] {
+	size := n.bufferSize()
+	if size < 1 {
+		size = 1
+	}
+	sub := &subscriber[T]{
+		events:  make(chan T, size),
+		dropped: make(chan struct{}),
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.subscribers[sub] = struct{}{}
+	n.subscribersGauge.Update(int64(len(n.subscribers)))
+	return sub
+}
+
+func (n *subscriptionNotifier[T]) unsubscribe(sub *subscriber[T]) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	delete(n.subscribers, sub)
+	n.subscribersGauge.Update(int64(len(n.subscribers)))
+}
+
+func (n *subscriptionNotifier[T]) notify(event T) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for sub := range n.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(n.subscribers, sub)
+			close(sub.dropped)
+			n.dropCounter.Inc(1)
+			log.Warn("dropped subscriber that fell behind", "subscription", n.name, "buffer", cap(sub.events))
+		}
+	}
+	n.subscribersGauge.Update(int64(len(n.subscribers)))
+}
+
+// serve subscribes the RPC subscription in ctx to the events. If it's dropped for falling behind,
+// it's sent what was buffered and then droppedEvent, and no more after it.
+func (n *subscriptionNotifier[T]) serve(ctx context.Context, droppedEvent T) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+	sub := n.subscribe()
+	go func() {
+		defer n.unsubscribe(sub)
+		for {
+			select {
+			case event := <-sub.events:
+				if err := notifier.Notify(rpcSub.ID, event); err != nil {
+					return
+				}
+			case <-sub.dropped:
+				// deliver what was buffered before the notice
+				for len(sub.events) > 0 {
+					if err := notifier.Notify(rpcSub.ID, <-sub.events); err != nil {
+						return
+					}
+				}
+				_ = notifier.Notify(rpcSub.ID, droppedEvent)
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
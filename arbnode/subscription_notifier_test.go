@@ -0,0 +1,48 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestSubscriptionNotifier(t *testing.T) {
+	notifier := newSubscriptionNotifier[int]("test", func() int { return 2 }, metrics.NilGauge{}, metrics.NilCounter{})
+	fast := notifier.subscribe()
+	slow := notifier.subscribe()
+	gone := notifier.subscribe()
+	notifier.unsubscribe(gone)
+
+	notifier.notify(1)
+	<-fast.events
+	notifier.notify(2)
+	<-fast.events
+	notifier.notify(3)
+	// the slow subscriber's buffer of 2 is full
+	select {
+	case <-slow.dropped:
+	default:
+		Fail(t, "subscriber past its buffer wasn't dropped")
+	}
+	if len(slow.events) != 2 || <-slow.events != 1 || <-slow.events != 2 {
+		Fail(t, "dropped subscriber lost the events buffered before it fell behind")
+	}
+	if event := <-fast.events; event != 3 {
+		Fail(t, "unexpected event", event)
+	}
+	select {
+	case <-fast.dropped:
+		Fail(t, "subscriber keeping up was dropped")
+	default:
+	}
+	if len(gone.events) != 0 {
+		Fail(t, "unsubscribed subscriber was notified")
+	}
+	notifier.notify(4)
+	if len(notifier.subscribers) != 1 || len(slow.events) != 0 {
+		Fail(t, "dropped subscriber still notified")
+	}
+}
@@ -1,7 +1,9 @@
 package arbnode
 
 import (
+	"context"
 	"sync/atomic"
+	"time"
 
 	"github.com/offchainlabs/nitro/arbutil"
 	flag "github.com/spf13/pflag"
@@ -25,20 +27,26 @@ type SyncMonitorConfig struct {
 	BlockBuildLag               uint64 `koanf:"block-build-lag"`
 	BlockBuildSequencerInboxLag uint64 `koanf:"block-build-sequencer-inbox-lag"`
 	CoordinatorMsgLag           uint64 `koanf:"coordinator-msg-lag"`
+	L1Lag                       uint64 `koanf:"l1-lag"`
 }
 
 var DefaultSyncMonitorConfig = SyncMonitorConfig{
 	BlockBuildLag:               20,
 	BlockBuildSequencerInboxLag: 0,
 	CoordinatorMsgLag:           15,
+	L1Lag:                       0,
 }
 
 func SyncMonitorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".block-build-lag", DefaultSyncMonitorConfig.BlockBuildLag, "allowed lag between messages read and blocks built")
 	f.Uint64(prefix+".block-build-sequencer-inbox-lag", DefaultSyncMonitorConfig.BlockBuildSequencerInboxLag, "allowed lag between messages read from sequencer inbox and blocks built")
 	f.Uint64(prefix+".coordinator-msg-lag", DefaultSyncMonitorConfig.CoordinatorMsgLag, "allowed lag between local and remote messages")
+	f.Uint64(prefix+".l1-lag", DefaultSyncMonitorConfig.L1Lag, "allowed lag in blocks between the L1 head and the last L1 block read by the inbox reader (0 = don't check)")
 }
 
+// l1HeadTimeout bounds the L1 head lookup, which is normally cached by the header reader
+const l1HeadTimeout = 5 * time.Second
+
 func (s *SyncMonitor) Initialize(inboxReader *InboxReader, txStreamer *TransactionStreamer, coordinator *SeqCoordinator) {
 	s.inboxReader = inboxReader
 	s.txStreamer = txStreamer
@@ -95,6 +103,23 @@ func (s *SyncMonitor) SyncProgressMap() map[string]interface{} {
 		res["batchSeen"] = batchSeen
 		res["batchProcessed"] = batchProcessed
 
+		if s.config.L1Lag > 0 && s.inboxReader.l1Reader != nil {
+			lastReadBlock, _ := s.inboxReader.GetLastReadBlockAndBatchCount()
+			ctx, cancel := context.WithTimeout(context.Background(), l1HeadTimeout)
+			l1Head, err := s.inboxReader.l1Reader.LastHeader(ctx)
+			cancel()
+			if err != nil {
+				res["l1HeadError"] = err.Error()
+				syncing = true
+			} else {
+				res["l1Head"] = l1Head.Number.Uint64()
+				res["lastL1BlockRead"] = lastReadBlock
+				if lastReadBlock+s.config.L1Lag < l1Head.Number.Uint64() {
+					syncing = true
+				}
+			}
+		}
+
 		processedMetadata, err := s.inboxReader.Tracker().GetBatchMetadata(batchProcessed - 1)
 		if err != nil {
 			res["batchMetadataError"] = err.Error()
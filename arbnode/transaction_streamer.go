@@ -14,13 +14,16 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
+	flag "github.com/spf13/pflag"
 
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
@@ -32,6 +35,91 @@ import (
 	"github.com/offchainlabs/nitro/validator"
 )
 
+var executionRetryCounter = metrics.NewRegisteredCounter("arb/txstreamer/execution/retried", nil)
+
+type TransactionStreamerConfig struct {
+	ExecutionRetries    int           `koanf:"execution-retries" reload:"hot"`
+	ExecutionRetryDelay time.Duration `koanf:"execution-retry-delay" reload:"hot"`
+
+	ReorgSubscriptionBuffer int `koanf:"reorg-subscription-buffer" reload:"hot"`
+}
+
+type TransactionStreamerConfigFetcher func() *TransactionStreamerConfig
+
+var DefaultTransactionStreamerConfig = TransactionStreamerConfig{
+	ExecutionRetries:    5,
+	ExecutionRetryDelay: time.Millisecond * 100,
+
+	ReorgSubscriptionBuffer: 16,
+}
+
+var TestTransactionStreamerConfig = TransactionStreamerConfig{
+	ExecutionRetries:    2,
+	ExecutionRetryDelay: time.Millisecond * 10,
+
+	ReorgSubscriptionBuffer: 16,
+}
+
+func TransactionStreamerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".execution-retries", DefaultTransactionStreamerConfig.ExecutionRetries, "times to retry executing a message after a transient failure, such as a database or L1 read error, before backing off")
+	f.Duration(prefix+".execution-retry-delay", DefaultTransactionStreamerConfig.ExecutionRetryDelay, "delay before the first execution retry, doubling with each further retry")
+	f.Int(prefix+".reorg-subscription-buffer", DefaultTransactionStreamerConfig.ReorgSubscriptionBuffer, "reorgs to buffer for each nitro_subscribe(\"reorgs\") subscriber before dropping it for falling behind")
+}
+
+// maxExecutionRetryDelay caps the exponential backoff between execution retries
+const maxExecutionRetryDelay = 10 * time.Second
+
+// ConsensusExecutionError is a failure to execute a message that isn't caused by reading
+// the database or L1, so it would recur on every attempt and the node must halt
+type ConsensusExecutionError struct {
+	Pos arbutil.MessageIndex
+	Err error
+}
+
+func (e *ConsensusExecutionError) Error() string {
+	return fmt.Sprintf("failed to execute message %v: %v", e.Pos, e.Err)
+}
+
+func (e *ConsensusExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// isFatalExecutionError reports whether creating blocks failed in a way retrying can't fix
+func isFatalExecutionError(err error) bool {
+	var consensusErr *ConsensusExecutionError
+	return errors.Is(err, arbosState.ErrFatalNodeOutOfDate) || errors.As(err, &consensusErr)
+}
+
+// executeWithRetries executes the message at pos, retrying with backoff when the attempt failed
+// after execute reported a read failure from the database or L1. Without one, executing the same
+// message on the same state fails the same way, so the error is a ConsensusExecutionError.
+func executeWithRetries(ctx context.Context, pos arbutil.MessageIndex, config *TransactionStreamerConfig, execute func() (readFailed bool, err error)) error {
+	retryDelay := config.ExecutionRetryDelay
+	for attempt := 0; ; attempt++ {
+		readFailed, err := execute()
+		if err == nil {
+			return nil
+		}
+		if !readFailed {
+			return &ConsensusExecutionError{Pos: pos, Err: err}
+		}
+		if attempt >= config.ExecutionRetries || ctx.Err() != nil {
+			return err
+		}
+		executionRetryCounter.Inc(1)
+		log.Warn("transient failure executing message, retrying", "pos", pos, "attempt", attempt+1, "delay", retryDelay, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay):
+		}
+		retryDelay *= 2
+		if retryDelay > maxExecutionRetryDelay {
+			retryDelay = maxExecutionRetryDelay
+		}
+	}
+}
+
 // TransactionStreamer produces blocks from a node's L1 messages, storing the results in the blockchain and recording their positions
 // The streamer is notified when there's new batches to process
 type TransactionStreamer struct {
@@ -41,6 +129,7 @@ type TransactionStreamer struct {
 	bc           *core.BlockChain
 	chainId      uint64
 	fatalErrChan chan<- error
+	config       TransactionStreamerConfigFetcher
 
 	insertionMutex     sync.Mutex // cannot be acquired while reorgMutex or createBlocksMutex is held
 	createBlocksMutex  sync.Mutex // cannot be acquired while reorgMutex is held
@@ -55,6 +144,7 @@ type TransactionStreamer struct {
 	latestBlock                *types.Block
 	latestMessage              *arbos.L1IncomingMessage
 	newBlockNotifier           chan struct{}
+	reorgNotifier              *subscriptionNotifier[ReorgEvent]
 
 	coordinator     *SeqCoordinator
 	broadcastServer *broadcaster.Broadcaster
@@ -67,6 +157,7 @@ func NewTransactionStreamer(
 	bc *core.BlockChain,
 	broadcastServer *broadcaster.Broadcaster,
 	fatalErrChan chan<- error,
+	config TransactionStreamerConfigFetcher,
 ) (*TransactionStreamer, error) {
 	inbox := &TransactionStreamer{
 		db:                 db,
@@ -76,7 +167,9 @@ func NewTransactionStreamer(
 		broadcastServer:    broadcastServer,
 		chainId:            bc.Config().ChainID.Uint64(),
 		fatalErrChan:       fatalErrChan,
+		config:             config,
 	}
+	inbox.reorgNotifier = newReorgNotifier(func() int { return config().ReorgSubscriptionBuffer })
 	err := inbox.cleanupInconsistentState()
 	if err != nil {
 		return nil, err
@@ -103,6 +196,14 @@ func (s *TransactionStreamer) SetBlockValidator(validator *validator.BlockValida
 	s.validator = validator
 }
 
+// DetachBlockValidator stops the streamer from feeding a block validator that won't be started.
+// Unlike setting one, this is allowed after start.
+func (s *TransactionStreamer) DetachBlockValidator() {
+	s.reorgMutex.Lock()
+	defer s.reorgMutex.Unlock()
+	s.validator = nil
+}
+
 func (s *TransactionStreamer) SetSeqCoordinator(coordinator *SeqCoordinator) {
 	if s.Started() {
 		panic("trying to set coordinator after start")
@@ -146,11 +247,7 @@ func (s *TransactionStreamer) ReorgTo(count arbutil.MessageIndex) error {
 func (s *TransactionStreamer) ReorgToAndEndBatch(batch ethdb.Batch, count arbutil.MessageIndex) error {
 	s.insertionMutex.Lock()
 	defer s.insertionMutex.Unlock()
-	err := s.reorgToInternal(batch, count)
-	if err != nil {
-		return err
-	}
-	return batch.Write()
+	return s.reorgToInternal(batch, count)
 }
 
 func deleteStartingAt(db ethdb.Database, batch ethdb.Batch, prefix []byte, minKey []byte) error {
@@ -165,6 +262,7 @@ func deleteStartingAt(db ethdb.Database, batch ethdb.Batch, prefix []byte, minKe
 	return iter.Error()
 }
 
+// reorgToInternal writes the batch itself, so subscribers are only notified of a reorg once it's in the database
 func (s *TransactionStreamer) reorgToInternal(batch ethdb.Batch, count arbutil.MessageIndex) error {
 	if count == 0 {
 		return errors.New("cannot reorg out init message")
@@ -173,6 +271,10 @@ func (s *TransactionStreamer) reorgToInternal(batch ethdb.Batch, count arbutil.M
 	s.reorgMutex.Lock()
 	defer s.reorgMutex.Unlock()
 	atomic.AddUint32(&s.reorgPending, ^uint32(0)) // decrement
+	oldCount, err := s.GetMessageCount()
+	if err != nil {
+		return err
+	}
 	blockNum, err := s.MessageCountToBlockNumber(count)
 	if err != nil {
 		return err
@@ -199,8 +301,26 @@ func (s *TransactionStreamer) reorgToInternal(batch ethdb.Batch, count arbutil.M
 	if err != nil {
 		return err
 	}
+	err = setMessageCount(batch, count)
+	if err != nil {
+		return err
+	}
+	err = batch.Write()
+	if err != nil {
+		return err
+	}
 
-	return setMessageCount(batch, count)
+	event := ReorgEvent{
+		PreviousMessageCount: hexutil.Uint64(oldCount),
+		MessageCount:         hexutil.Uint64(count),
+		BlockNumber:          hexutil.Uint64(blockNum),
+	}
+	if targetBlock != nil {
+		hash := targetBlock.Hash()
+		event.BlockHash = &hash
+	}
+	s.reorgNotifier.notify(event)
+	return nil
 }
 
 func setMessageCount(batch ethdb.KeyValueWriter, count arbutil.MessageIndex) error {
@@ -309,6 +429,18 @@ func (s *TransactionStreamer) AddBroadcastMessages(feedMessages []*broadcaster.B
 	return nil
 }
 
+// FeedQueuedMessageEnd returns the message count the feed messages queued behind a gap would bring
+// the streamer to, or 0 if none are queued
+func (s *TransactionStreamer) FeedQueuedMessageEnd() arbutil.MessageIndex {
+	s.insertionMutex.Lock()
+	defer s.insertionMutex.Unlock()
+	if len(s.broadcasterQueuedMessages) == 0 {
+		return 0
+	}
+	pos := arbutil.MessageIndex(atomic.LoadUint64(&s.broadcasterQueuedMessagesPos))
+	return pos + arbutil.MessageIndex(len(s.broadcasterQueuedMessages))
+}
+
 // AddFakeInitMessage should only be used for testing or running a local dev node
 func (s *TransactionStreamer) AddFakeInitMessage() error {
 	return s.AddMessages(0, false, []arbstate.MessageWithMetadata{{
@@ -324,6 +456,19 @@ func (s *TransactionStreamer) AddFakeInitMessage() error {
 	}})
 }
 
+// GetDelayedMessagesRead returns how many delayed messages have been sequenced into the L2 chain
+func (s *TransactionStreamer) GetDelayedMessagesRead() (uint64, error) {
+	pos, err := s.GetMessageCount()
+	if err != nil || pos == 0 {
+		return 0, err
+	}
+	lastMsg, err := s.GetMessage(pos - 1)
+	if err != nil {
+		return 0, err
+	}
+	return lastMsg.DelayedMessagesRead, nil
+}
+
 func (s *TransactionStreamer) GetMessageCountSync() (arbutil.MessageIndex, error) {
 	s.insertionMutex.Lock()
 	defer s.insertionMutex.Unlock()
@@ -428,10 +573,6 @@ func (s *TransactionStreamer) addMessagesAndEndBatchImpl(pos arbutil.MessageInde
 			if err != nil {
 				return err
 			}
-			err = batch.Write()
-			if err != nil {
-				return err
-			}
 		} else {
 			return errors.New("reorg required but not allowed")
 		}
@@ -691,6 +832,46 @@ func (s *TransactionStreamer) ResumeReorgs() {
 	s.reorgMutex.RUnlock()
 }
 
+// FlushState writes the in-memory tries of recent blocks to disk, as the blockchain does in a graceful
+// shutdown, so that a restart doesn't need to re-execute blocks to recover their state. Block creation
+// and reorgs are paused while the tries are committed.
+func (s *TransactionStreamer) FlushState(cachingConfig *CachingConfig) error {
+	if cachingConfig.Archive {
+		// every block's state is already written to disk
+		return nil
+	}
+	s.insertionMutex.Lock()
+	defer s.insertionMutex.Unlock()
+	s.createBlocksMutex.Lock()
+	defer s.createBlocksMutex.Unlock()
+	s.reorgMutex.RLock()
+	defer s.reorgMutex.RUnlock()
+
+	start := time.Now()
+	triedb := s.bc.StateCache().TrieDB()
+	dirtyBefore, _ := triedb.Size()
+	head := s.bc.CurrentBlock().NumberU64()
+	offsets := []uint64{0, 1}
+	if cachingConfig.BlockCount > 1 {
+		offsets = append(offsets, cachingConfig.BlockCount-1)
+	}
+	for _, offset := range offsets {
+		if head < offset {
+			continue
+		}
+		block := s.bc.GetBlockByNumber(head - offset)
+		if block == nil {
+			return fmt.Errorf("block %v not found", head-offset)
+		}
+		if err := triedb.Commit(block.Root(), true, nil); err != nil {
+			return fmt.Errorf("error committing state of block %v: %w", block.NumberU64(), err)
+		}
+	}
+	dirtyAfter, _ := triedb.Size()
+	log.Info("flushed state to disk", "head", head, "flushed", dirtyBefore-dirtyAfter, "dirty", dirtyAfter, "elapsed", time.Since(start))
+	return nil
+}
+
 // The mutex must be held, and pos must be the latest message count.
 // `batch` may be nil, which initializes a new batch. The batch is closed out in this function.
 func (s *TransactionStreamer) writeMessages(pos arbutil.MessageIndex, messages []arbstate.MessageWithMetadata, batch ethdb.Batch) error {
@@ -760,17 +941,8 @@ func (s *TransactionStreamer) createBlocks(ctx context.Context) error {
 		}
 	}()
 
-	batchFetcher := func(batchNum uint64) ([]byte, error) {
-		return s.inboxReader.GetSequencerMessageBytes(ctx, batchNum)
-	}
-
 	for pos < msgCount {
 
-		statedb, err = s.bc.StateAt(lastBlockHeader.Root)
-		if err != nil {
-			return err
-		}
-
 		if atomic.LoadUint32(&s.reorgPending) > 0 {
 			// stop block creation as we need to reorg
 			break
@@ -781,23 +953,44 @@ func (s *TransactionStreamer) createBlocks(ctx context.Context) error {
 			return nil
 		}
 
-		statedb.StartPrefetcher("TransactionStreamer")
-
 		msg, err := s.GetMessage(pos)
 		if err != nil {
 			return err
 		}
 
-		startTime := time.Now()
-		block, receipts, err := arbos.ProduceBlock(
-			msg.Message,
-			msg.DelayedMessagesRead,
-			lastBlockHeader,
-			statedb,
-			s.bc,
-			s.bc.Config(),
-			batchFetcher,
-		)
+		var block *types.Block
+		var receipts types.Receipts
+		var startTime time.Time
+		err = executeWithRetries(ctx, pos, s.config(), func() (bool, error) {
+			if statedb != nil {
+				statedb.StopPrefetcher()
+			}
+			statedb, err = s.bc.StateAt(lastBlockHeader.Root)
+			if err != nil {
+				return true, err
+			}
+			statedb.StartPrefetcher("TransactionStreamer")
+
+			batchFetchFailed := false
+			batchFetcher := func(batchNum uint64) ([]byte, error) {
+				data, err := s.inboxReader.GetSequencerMessageBytes(ctx, batchNum)
+				if err != nil {
+					batchFetchFailed = true
+				}
+				return data, err
+			}
+			startTime = time.Now()
+			block, receipts, err = arbos.ProduceBlock(
+				msg.Message,
+				msg.DelayedMessagesRead,
+				lastBlockHeader,
+				statedb,
+				s.bc,
+				s.bc.Config(),
+				batchFetcher,
+			)
+			return batchFetchFailed || statedb.Error() != nil, err
+		})
 		if err != nil {
 			return err
 		}
@@ -844,7 +1037,7 @@ func (s *TransactionStreamer) Start(ctxIn context.Context) {
 			err := s.createBlocks(ctx)
 			if err != nil && !errors.Is(err, context.Canceled) {
 				log.Error("error creating blocks", "err", err.Error())
-				if errors.Is(err, arbosState.ErrFatalNodeOutOfDate) {
+				if isFatalExecutionError(err) {
 					s.fatalErrChan <- err
 				}
 			}
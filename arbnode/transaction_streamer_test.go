@@ -0,0 +1,81 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+func TestExecutionErrorClassification(t *testing.T) {
+	ctx := context.Background()
+	config := TestTransactionStreamerConfig
+	executionErr := errors.New("execution failed")
+	attempts := 0
+	// execute fails with a read failure readFailures times, then succeeds or fails without one
+	failing := func(readFailures int, succeed bool) func() (bool, error) {
+		attempts = 0
+		return func() (bool, error) {
+			attempts++
+			if attempts <= readFailures {
+				return true, executionErr
+			}
+			if succeed {
+				return false, nil
+			}
+			return false, executionErr
+		}
+	}
+
+	// a failure without a read failure recurs on every attempt, so it isn't retried
+	err := executeWithRetries(ctx, 7, &config, failing(0, false))
+	var consensusErr *ConsensusExecutionError
+	if !errors.As(err, &consensusErr) || consensusErr.Pos != 7 || !errors.Is(err, executionErr) {
+		Fail(t, "expected a consensus error for message 7, got", err)
+	}
+	if attempts != 1 {
+		Fail(t, "retried a consensus failure", attempts, "times")
+	}
+	if !isFatalExecutionError(fmt.Errorf("creating blocks: %w", err)) {
+		Fail(t, "consensus failure isn't fatal")
+	}
+
+	// read failures are retried until the message executes
+	err = executeWithRetries(ctx, 7, &config, failing(config.ExecutionRetries, true))
+	Require(t, err)
+	if attempts != config.ExecutionRetries+1 {
+		Fail(t, "expected", config.ExecutionRetries+1, "attempts, got", attempts)
+	}
+
+	// once the retries run out the read failure is returned, and isn't fatal
+	err = executeWithRetries(ctx, 7, &config, failing(config.ExecutionRetries+1, true))
+	if !errors.Is(err, executionErr) || errors.As(err, &consensusErr) || isFatalExecutionError(err) {
+		Fail(t, "expected a transient error after the retries ran out, got", err)
+	}
+	if attempts != config.ExecutionRetries+1 {
+		Fail(t, "expected", config.ExecutionRetries+1, "attempts, got", attempts)
+	}
+
+	// a read failure that becomes a consensus failure on retry halts
+	err = executeWithRetries(ctx, 7, &config, failing(1, false))
+	if !errors.As(err, &consensusErr) || attempts != 2 {
+		Fail(t, "expected a consensus error on the second attempt, got", err, "after", attempts, "attempts")
+	}
+
+	// shutting down stops the retries
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = executeWithRetries(cancelled, 7, &config, failing(config.ExecutionRetries+1, true))
+	if attempts != 1 || isFatalExecutionError(err) {
+		Fail(t, "retried after shutdown, got", err, "after", attempts, "attempts")
+	}
+
+	if !isFatalExecutionError(fmt.Errorf("creating blocks: %w", arbosState.ErrFatalNodeOutOfDate)) {
+		Fail(t, "node out of date isn't fatal")
+	}
+}
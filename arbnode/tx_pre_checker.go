@@ -98,6 +98,20 @@ func PreCheckTx(chainConfig *params.ChainConfig, header *types.Header, statedb *
 }
 
 func (c *TxPreChecker) PublishTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.preCheck(tx); err != nil {
+		return err
+	}
+	return c.TransactionPublisher.PublishTransaction(ctx, tx)
+}
+
+func (c *TxPreChecker) PublishConditionalTransaction(ctx context.Context, tx *types.Transaction, options *ConditionalOptions) error {
+	if err := c.preCheck(tx); err != nil {
+		return err
+	}
+	return c.TransactionPublisher.PublishConditionalTransaction(ctx, tx, options)
+}
+
+func (c *TxPreChecker) preCheck(tx *types.Transaction) error {
 	block := c.bc.CurrentBlock()
 	statedb, err := c.bc.StateAt(block.Root())
 	if err != nil {
@@ -107,9 +121,5 @@ func (c *TxPreChecker) PublishTransaction(ctx context.Context, tx *types.Transac
 	if err != nil {
 		return err
 	}
-	err = PreCheckTx(c.bc.Config(), block.Header(), statedb, arbos, tx, c.getStrictness())
-	if err != nil {
-		return err
-	}
-	return c.TransactionPublisher.PublishTransaction(ctx, tx)
+	return PreCheckTx(c.bc.Config(), block.Header(), statedb, arbos, tx, c.getStrictness())
 }
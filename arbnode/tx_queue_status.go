@@ -0,0 +1,115 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	TxQueueReasonNotQueued   = "not queued"
+	TxQueueReasonQueued      = "waiting for a block"
+	TxQueueReasonSequencing  = "being sequenced"
+	TxQueueReasonReplaced    = "replaced by a transaction with the same nonce"
+	TxQueueReasonFutureNonce = "nonce is ahead of the sender's account, so earlier nonces must be included first"
+	TxQueueReasonStaleNonce  = "nonce is already used by the sender's account"
+	TxQueueReasonBaseFee     = "max fee per gas is below the current base fee"
+)
+
+type TxQueueStatus struct {
+	Queued bool `json:"queued"`
+	// transactions queued ahead of this one, not counting those already being sequenced
+	Position   *hexutil.Uint64 `json:"position,omitempty"`
+	Sender     *common.Address `json:"sender,omitempty"`
+	Nonce      *hexutil.Uint64 `json:"nonce,omitempty"`
+	StateNonce *hexutil.Uint64 `json:"stateNonce,omitempty"`
+	Reason     string          `json:"reason"`
+}
+
+type queuedTxSnapshot struct {
+	entry    queuedTx
+	position uint64
+}
+
+// status returns a copy of hash's entry, and how many queued entries are ahead of it
+func (t *queuedTxTracker) status(hash common.Hash) (queuedTxSnapshot, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	entry := t.byHash[hash]
+	if entry == nil {
+		return queuedTxSnapshot{}, false
+	}
+	var position uint64
+	for _, other := range t.txs {
+		if !other.inFlight && !other.replaced && other.order < entry.order {
+			position++
+		}
+	}
+	return queuedTxSnapshot{*entry, position}, true
+}
+
+// TxQueueStatus reports whether the transaction is in the sequencer's queue, how many transactions
+// are ahead of it, and why it's still waiting
+func (s *Sequencer) TxQueueStatus(hash common.Hash) *TxQueueStatus {
+	snapshot, ok := s.queuedTxs.status(hash)
+	if !ok {
+		return &TxQueueStatus{Reason: TxQueueReasonNotQueued}
+	}
+	entry := &snapshot.entry
+	sender := entry.key.sender
+	nonce := hexutil.Uint64(entry.key.nonce)
+	status := &TxQueueStatus{
+		Queued: true,
+		Sender: &sender,
+		Nonce:  &nonce,
+		Reason: TxQueueReasonQueued,
+	}
+	if entry.replaced {
+		status.Reason = TxQueueReasonReplaced
+		return status
+	}
+	if entry.inFlight {
+		status.Reason = TxQueueReasonSequencing
+		return status
+	}
+	position := hexutil.Uint64(snapshot.position)
+	status.Position = &position
+
+	block := s.txStreamer.bc.CurrentBlock()
+	statedb, err := s.txStreamer.bc.StateAt(block.Root())
+	if err != nil {
+		return status
+	}
+	stateNonce := hexutil.Uint64(statedb.GetNonce(sender))
+	status.StateNonce = &stateNonce
+	if nonce > stateNonce {
+		status.Reason = TxQueueReasonFutureNonce
+	} else if nonce < stateNonce {
+		status.Reason = TxQueueReasonStaleNonce
+	} else if baseFee := block.BaseFee(); baseFee != nil && entry.tx.GasFeeCapIntCmp(baseFee) < 0 {
+		status.Reason = TxQueueReasonBaseFee
+	}
+	return status
+}
+
+func sequencerOf(publisher TransactionPublisher) *Sequencer {
+	if preChecker, ok := publisher.(*TxPreChecker); ok {
+		publisher = preChecker.TransactionPublisher
+	}
+	sequencer, _ := publisher.(*Sequencer)
+	return sequencer
+}
+
+// TxQueueStatus reports where a transaction is in this sequencer's queue, and why it's waiting
+func (api *NitroAPI) TxQueueStatus(ctx context.Context, hash common.Hash) (*TxQueueStatus, error) {
+	sequencer := sequencerOf(api.txPublisher)
+	if sequencer == nil {
+		return nil, errors.New("this node isn't a sequencer")
+	}
+	return sequencer.TxQueueStatus(hash), nil
+}
@@ -249,6 +249,9 @@ func (state *ArbosState) UpgradeArbosVersionIfNecessary(currentTimestamp uint64)
 
 var ErrFatalNodeOutOfDate error = errors.New("please upgrade to latest version of node software")
 
+// MaxSupportedArbosVersion is the newest version UpgradeArbosVersion knows how to upgrade to
+const MaxSupportedArbosVersion = 9
+
 func (state *ArbosState) UpgradeArbosVersion(upgradeTo uint64, firstTime bool) error {
 	for state.arbosVersion < upgradeTo {
 		ensure := func(err error) {
@@ -305,6 +308,19 @@ func (state *ArbosState) ScheduleArbOSUpgrade(newVersion uint64, timestamp uint6
 	return state.upgradeTimestamp.Set(timestamp)
 }
 
+// GetScheduledUpgrade returns the version an upgrade is planned to, or 0 if none is, and when it's planned for
+func (state *ArbosState) GetScheduledUpgrade() (uint64, uint64, error) {
+	version, err := state.upgradeVersion.Get()
+	if err != nil {
+		return 0, 0, err
+	}
+	timestamp, err := state.upgradeTimestamp.Get()
+	if err != nil {
+		return 0, 0, err
+	}
+	return version, timestamp, nil
+}
+
 func (state *ArbosState) BackingStorage() *storage.Storage {
 	return state.backingStorage
 }
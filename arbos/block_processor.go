@@ -82,6 +82,8 @@ type SequencingHooks struct {
 	DiscardInvalidTxsEarly bool
 	PreTxFilter            func(*params.ChainConfig, *types.Header, *state.StateDB, *arbosState.ArbosState, *types.Transaction, common.Address) error
 	PostTxFilter           func(*types.Header, *arbosState.ArbosState, *types.Transaction, common.Address, uint64, *core.ExecutionResult) error
+	// MaxStateGrowth bounds the estimated bytes of state the block's user txs may add (0 for no limit)
+	MaxStateGrowth uint64
 }
 
 func noopSequencingHooks() *SequencingHooks {
@@ -94,6 +96,7 @@ func noopSequencingHooks() *SequencingHooks {
 		func(*types.Header, *arbosState.ArbosState, *types.Transaction, common.Address, uint64, *core.ExecutionResult) error {
 			return nil
 		},
+		0,
 	}
 }
 
@@ -134,6 +137,9 @@ func ProduceBlock(
 // A marker for the sequencer that an ErrGasLimitReached is permanent
 var ErrMaxGasLimitReached = fmt.Errorf("%w", core.ErrGasLimitReached)
 
+var ErrStateGrowthLimitReached = fmt.Errorf("%w: block state growth limit reached", core.ErrGasLimitReached)
+var ErrMaxStateGrowthReached = fmt.Errorf("%w: tx exceeds the block state growth limit", ErrMaxGasLimitReached)
+
 // A bit more flexible than ProduceBlock for use in the sequencer.
 func ProduceBlockAdvanced(
 	l1Header *L1IncomingMessageHeader,
@@ -182,6 +188,8 @@ func ProduceBlockAdvanced(
 	expectedBalanceDelta := new(big.Int)
 	redeems := types.Transactions{}
 	userTxsProcessed := 0
+	var stateGrowth int64
+	stateGrowthLimitReached := false
 
 	// We'll check that the block can fit each message, so this pool is set to not run out
 	gethGas := core.GasPool(l2pricing.GethBlockGasLimit)
@@ -221,12 +229,16 @@ func ProduceBlockAdvanced(
 
 		var sender common.Address
 		var dataGas uint64 = 0
+		var growthTracer *stateGrowthTracer
 		preTxHeaderGasUsed := header.GasUsed
 		receipt, result, err := (func() (*types.Receipt, *core.ExecutionResult, error) {
 			// If we've done too much work in this block, discard the tx as early as possible
 			if blockGasLeft < params.TxGas && isUserTx {
 				return nil, nil, core.ErrGasLimitReached
 			}
+			if stateGrowthLimitReached && isUserTx {
+				return nil, nil, ErrStateGrowthLimitReached
+			}
 
 			sender, err = signer.Sender(tx)
 			if err != nil {
@@ -270,6 +282,12 @@ func ProduceBlockAdvanced(
 				return nil, nil, core.ErrGasLimitReached
 			}
 
+			vmConfig := vm.Config{}
+			if isUserTx && hooks.MaxStateGrowth > 0 {
+				growthTracer = newStateGrowthTracer(statedb)
+				vmConfig = vm.Config{Debug: true, Tracer: growthTracer}
+			}
+
 			snap := statedb.Snapshot()
 			statedb.Prepare(tx.Hash(), len(receipts)) // the number of successful state transitions
 
@@ -283,8 +301,22 @@ func ProduceBlockAdvanced(
 				header,
 				tx,
 				&header.GasUsed,
-				vm.Config{},
+				vmConfig,
 				func(result *core.ExecutionResult) error {
+					if growthTracer != nil {
+						limit := int64(math.MaxInt64)
+						if hooks.MaxStateGrowth < math.MaxInt64 {
+							limit = int64(hooks.MaxStateGrowth)
+						}
+						if stateGrowth+growthTracer.Growth() > limit {
+							if userTxsProcessed > 0 {
+								// stop adding txs to this block, leaving the rest for the next one
+								stateGrowthLimitReached = true
+								return ErrStateGrowthLimitReached
+							}
+							return ErrMaxStateGrowthReached
+						}
+					}
 					return hooks.PostTxFilter(header, state, tx, sender, dataGas, result)
 				},
 			)
@@ -383,6 +415,10 @@ func ProduceBlockAdvanced(
 			blockGasLeft = 0
 		}
 
+		if growthTracer != nil {
+			stateGrowth += growthTracer.Growth()
+		}
+
 		complete = append(complete, tx)
 		receipts = append(receipts, receipt)
 
@@ -0,0 +1,111 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbos
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Approximate sizes of the trie entries a transaction can add
+const (
+	storageSlotGrowth = 64  // 32 byte key and 32 byte value
+	accountGrowth     = 104 // 32 byte key and a ~72 byte RLP encoded account
+)
+
+// stateGrowthTracer estimates how many bytes of state a transaction adds, discarding the growth of
+// call frames that revert. Storage written by ArbOS outside the EVM isn't counted.
+type stateGrowthTracer struct {
+	statedb *state.StateDB
+	frames  []stateGrowthFrame
+	growth  int64
+}
+
+type stateGrowthFrame struct {
+	growth int64
+	create bool
+}
+
+func newStateGrowthTracer(statedb *state.StateDB) *stateGrowthTracer {
+	return &stateGrowthTracer{statedb: statedb}
+}
+
+// Growth returns the estimated bytes added by the transaction, which is negative if it freed more than it used
+func (t *stateGrowthTracer) Growth() int64 {
+	return t.growth
+}
+
+func (t *stateGrowthTracer) enter(to common.Address, create bool, value *big.Int) {
+	frame := stateGrowthFrame{create: create}
+	if create || (value != nil && value.Sign() > 0 && !t.statedb.Exist(to)) {
+		frame.growth = accountGrowth
+	}
+	t.frames = append(t.frames, frame)
+}
+
+func (t *stateGrowthTracer) exit(output []byte, err error) int64 {
+	if len(t.frames) == 0 {
+		return 0
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err != nil {
+		return 0
+	}
+	if frame.create {
+		frame.growth += int64(len(output))
+	}
+	return frame.growth
+}
+
+func (t *stateGrowthTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.frames = nil
+	t.growth = 0
+	t.enter(to, create, value)
+}
+
+func (t *stateGrowthTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	t.growth = t.exit(output, err)
+}
+
+func (t *stateGrowthTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.enter(to, typ == vm.CREATE || typ == vm.CREATE2, value)
+}
+
+func (t *stateGrowthTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	growth := t.exit(output, err)
+	if len(t.frames) > 0 {
+		t.frames[len(t.frames)-1].growth += growth
+	}
+}
+
+func (t *stateGrowthTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if op != vm.SSTORE || err != nil || len(t.frames) == 0 || len(scope.Stack.Data()) < 2 {
+		return
+	}
+	key := common.Hash(scope.Stack.Back(0).Bytes32())
+	value := common.Hash(scope.Stack.Back(1).Bytes32())
+	current := t.statedb.GetState(scope.Contract.Address(), key)
+	frame := &t.frames[len(t.frames)-1]
+	if current == (common.Hash{}) && value != (common.Hash{}) {
+		frame.growth += storageSlotGrowth
+	} else if current != (common.Hash{}) && value == (common.Hash{}) {
+		frame.growth -= storageSlotGrowth
+	}
+}
+
+func (t *stateGrowthTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *stateGrowthTracer) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
+}
+
+func (t *stateGrowthTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {}
+
+func (t *stateGrowthTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {
+}
@@ -0,0 +1,64 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbos
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+func TestStateGrowthTracer(t *testing.T) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	Require(t, err)
+	contractAddr := common.HexToAddress("0x1234")
+	freedKey := common.HexToHash("0x01")
+	statedb.SetState(contractAddr, freedKey, common.HexToHash("0xff"))
+
+	contract := vm.NewContract(vm.AccountRef(contractAddr), vm.AccountRef(contractAddr), big.NewInt(0), 0)
+	sstore := func(tracer *stateGrowthTracer, key, value common.Hash) {
+		scope := &vm.ScopeContext{
+			Memory:   vm.NewMemory(),
+			Stack:    util.TracingStackFromArgs(util.HashToUint256(key), util.HashToUint256(value)),
+			Contract: contract,
+		}
+		tracer.CaptureState(0, vm.SSTORE, 0, 0, scope, nil, 1, nil)
+	}
+
+	tracer := newStateGrowthTracer(statedb)
+	tracer.CaptureStart(nil, common.Address{}, contractAddr, false, nil, 0, big.NewInt(0))
+	sstore(tracer, common.HexToHash("0x02"), common.HexToHash("0x01"))
+	sstore(tracer, freedKey, common.Hash{})
+
+	// a reverted call's storage isn't counted
+	tracer.CaptureEnter(vm.CALL, contractAddr, contractAddr, nil, 0, big.NewInt(0))
+	sstore(tracer, common.HexToHash("0x03"), common.HexToHash("0x01"))
+	tracer.CaptureExit(nil, 0, errors.New("reverted"))
+
+	// a successful create counts its account and code
+	code := make([]byte, 100)
+	tracer.CaptureEnter(vm.CREATE, contractAddr, common.HexToAddress("0x5678"), nil, 0, big.NewInt(0))
+	tracer.CaptureExit(code, 0, nil)
+
+	tracer.CaptureEnd(nil, 0, 0, nil)
+	expected := int64(storageSlotGrowth - storageSlotGrowth + accountGrowth + len(code))
+	if tracer.Growth() != expected {
+		Fail(t, "unexpected state growth", tracer.Growth(), "expected", expected)
+	}
+
+	// a failed transaction adds nothing
+	tracer.CaptureStart(nil, common.Address{}, contractAddr, false, nil, 0, big.NewInt(0))
+	sstore(tracer, common.HexToHash("0x04"), common.HexToHash("0x01"))
+	tracer.CaptureEnd(nil, 0, 0, vm.ErrOutOfGas)
+	if tracer.Growth() != 0 {
+		Fail(t, "failed transaction grew state by", tracer.Growth())
+	}
+}
@@ -7,16 +7,50 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/pkg/errors"
 )
 
+var (
+	l1BatchCallsSavedCounter = metrics.NewRegisteredCounter("arb/l1/batch/calls_saved", nil)
+	l1BatchFallbackCounter   = metrics.NewRegisteredCounter("arb/l1/batch/fallbacks", nil)
+)
+
+// rpcClientProvider is implemented by L1 clients backed by an RPC connection, such as ethclient.Client
+type rpcClientProvider interface {
+	Client() *rpc.Client
+}
+
+// rpcBatchCaller is implemented by L1 clients that send batch requests themselves, such as one
+// limiting its concurrent requests, as well as by an RPC connection
+type rpcBatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+func batchCallerOf(client L1Interface) (rpcBatchCaller, bool) {
+	if caller, ok := client.(rpcBatchCaller); ok {
+		return caller, true
+	}
+	if provider, ok := client.(rpcClientProvider); ok {
+		return provider.Client(), true
+	}
+	return nil, false
+}
+
 // GetLogEmitterTxData requires that the tx's data is at least 4 bytes long
 func GetLogEmitterTxData(ctx context.Context, client L1Interface, log types.Log) ([]byte, error) {
 	tx, err := client.TransactionInBlock(ctx, log.BlockHash, log.TxIndex)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	return checkLogEmitterTx(tx, log)
+}
+
+func checkLogEmitterTx(tx *types.Transaction, log types.Log) ([]byte, error) {
 	if tx.Hash() != log.TxHash {
 		return nil, fmt.Errorf("L1 client returned unexpected transaction hash %v when looking up block %v transaction %v with expected hash %v", tx.Hash(), log.BlockHash, log.TxIndex, log.TxHash)
 	}
@@ -25,3 +59,64 @@ func GetLogEmitterTxData(ctx context.Context, client L1Interface, log types.Log)
 	}
 	return tx.Data(), nil
 }
+
+// GetLogEmitterTxDataBatch looks up the data of the transactions that emitted logs, sending up to
+// batchSize lookups per JSON-RPC batch request. If the client doesn't support batches, or a batch
+// fails, the lookups it held are made individually instead.
+func GetLogEmitterTxDataBatch(ctx context.Context, client L1Interface, logs []types.Log, batchSize int) ([][]byte, error) {
+	results := make([][]byte, len(logs))
+	rpcClient, canBatch := batchCallerOf(client)
+	if !canBatch || batchSize <= 1 || len(logs) <= 1 {
+		for i, ethLog := range logs {
+			data, err := GetLogEmitterTxData(ctx, client, ethLog)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = data
+		}
+		return results, nil
+	}
+	for start := 0; start < len(logs); start += batchSize {
+		end := start + batchSize
+		if end > len(logs) {
+			end = len(logs)
+		}
+		txs := make([]*types.Transaction, end-start)
+		elems := make([]rpc.BatchElem, end-start)
+		for i := range elems {
+			ethLog := logs[start+i]
+			elems[i] = rpc.BatchElem{
+				Method: "eth_getTransactionByBlockHashAndIndex",
+				Args:   []interface{}{ethLog.BlockHash, hexutil.Uint64(ethLog.TxIndex)},
+				Result: &txs[i],
+			}
+		}
+		batchErr := rpcClient.BatchCallContext(ctx, elems)
+		if batchErr != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			l1BatchFallbackCounter.Inc(1)
+			log.Debug("L1 batch request failed, falling back to individual calls", "calls", len(elems), "err", batchErr)
+		} else if len(elems) > 1 {
+			l1BatchCallsSavedCounter.Inc(int64(len(elems) - 1))
+		}
+		for i, elem := range elems {
+			ethLog := logs[start+i]
+			if batchErr != nil || elem.Error != nil || txs[i] == nil {
+				data, err := GetLogEmitterTxData(ctx, client, ethLog)
+				if err != nil {
+					return nil, err
+				}
+				results[start+i] = data
+				continue
+			}
+			data, err := checkLogEmitterTx(txs[i], ethLog)
+			if err != nil {
+				return nil, err
+			}
+			results[start+i] = data
+		}
+	}
+	return results, nil
+}
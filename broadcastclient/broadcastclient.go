@@ -5,7 +5,9 @@ package broadcastclient
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net"
@@ -20,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	flag "github.com/spf13/pflag"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 
@@ -34,6 +37,8 @@ import (
 var (
 	sourcesConnectedGauge    = metrics.NewRegisteredGauge("arb/feed/sources/connected", nil)
 	sourcesDisconnectedGauge = metrics.NewRegisteredGauge("arb/feed/sources/disconnected", nil)
+	feedGapsCounter          = metrics.NewRegisteredCounter("arb/feed/input/gaps", nil)
+	droppedMessagesCounter   = metrics.NewRegisteredCounter("arb/feed/input/dropped_unverified", nil)
 )
 
 type FeedConfig struct {
@@ -63,6 +68,8 @@ type Config struct {
 	Timeout                 time.Duration            `koanf:"timeout"`
 	URLs                    []string                 `koanf:"url"`
 	Verifier                signature.VerifierConfig `koanf:"verify"`
+	BackfillGaps            bool                     `koanf:"backfill-gaps"`
+	VerifyKey               string                   `koanf:"verify-key"`
 }
 
 func (c *Config) Enable() bool {
@@ -77,6 +84,8 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "duration to wait before timing out connection to sequencer feed")
 	f.StringSlice(prefix+".url", DefaultConfig.URLs, "URL of sequencer feed source")
 	signature.FeedVerifierConfigAddOptions(prefix+".verify", f)
+	f.String(prefix+".verify-key", DefaultConfig.VerifyKey, "if set, hex-encoded ECDSA public key that must have signed every feed message, otherwise the message is dropped")
+	f.Bool(prefix+".backfill-gaps", DefaultConfig.BackfillGaps, "on a gap in feed sequence numbers, reconnect once to request the missing messages before continuing (otherwise they're read from L1)")
 }
 
 var DefaultConfig = Config{
@@ -87,6 +96,8 @@ var DefaultConfig = Config{
 	Verifier:                signature.DefultFeedVerifierConfig,
 	URLs:                    []string{""},
 	Timeout:                 20 * time.Second,
+	BackfillGaps:            false,
+	VerifyKey:               "",
 }
 
 var DefaultTestConfig = Config{
@@ -97,6 +108,8 @@ var DefaultTestConfig = Config{
 	Verifier:                signature.DefultFeedVerifierConfig,
 	URLs:                    []string{""},
 	Timeout:                 200 * time.Millisecond,
+	BackfillGaps:            false,
+	VerifyKey:               "",
 }
 
 type TransactionStreamerInterface interface {
@@ -110,6 +123,7 @@ type BroadcastClient struct {
 	websocketUrl string
 	nextSeqNum   arbutil.MessageIndex
 	sigVerifier  *signature.Verifier
+	keyVerifier  *signature.Verifier
 
 	chainId uint64
 
@@ -119,6 +133,9 @@ type BroadcastClient struct {
 
 	retryCount int64
 
+	// the first missing sequence number of the last gap we reconnected to backfill
+	lastBackfillSeqNum arbutil.MessageIndex
+
 	retrying                        bool
 	shuttingDown                    bool
 	ConfirmedSequenceNumberListener chan arbutil.MessageIndex
@@ -146,6 +163,13 @@ func NewBroadcastClient(
 	if err != nil {
 		return nil, err
 	}
+	var keyVerifier *signature.Verifier
+	if config.VerifyKey != "" {
+		keyVerifier, err = newKeyVerifier(config.VerifyKey)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &BroadcastClient{
 		config:       config,
 		websocketUrl: websocketUrl,
@@ -154,10 +178,34 @@ func NewBroadcastClient(
 		txStreamer:   txStreamer,
 		fatalErrChan: fatalErrChan,
 		sigVerifier:  sigVerifier,
+		keyVerifier:  keyVerifier,
 		adjustCount:  adjustCount,
+
+		lastBackfillSeqNum: arbutil.MessageIndex(^uint64(0)),
 	}, err
 }
 
+// newKeyVerifier creates a verifier that only accepts signatures from the given public key
+func newKeyVerifier(verifyKey string) (*signature.Verifier, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(verifyKey, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding feed verify-key")
+	}
+	var pubKey *ecdsa.PublicKey
+	if len(keyBytes) == 33 {
+		pubKey, err = crypto.DecompressPubkey(keyBytes)
+	} else {
+		pubKey, err = crypto.UnmarshalPubkey(keyBytes)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing feed verify-key")
+	}
+	return signature.NewVerifier(&signature.VerifierConfig{
+		AllowedAddresses: []string{crypto.PubkeyToAddress(*pubKey).Hex()},
+		AcceptSequencer:  false,
+	}, nil)
+}
+
 func (bc *BroadcastClient) Start(ctxIn context.Context) {
 	bc.StopWaiter.Start(ctxIn, bc)
 	bc.LaunchThread(func(ctx context.Context) {
@@ -369,12 +417,36 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 
 				if res.Version == 1 {
 					if len(res.Messages) > 0 {
+						messages := make([]*broadcaster.BroadcastFeedMessage, 0, len(res.Messages))
+						backfilling := false
 						for _, message := range res.Messages {
 							if message == nil {
 								log.Warn("ignoring nil feed message")
 								continue
 							}
 
+							// checked first so an unverified message can't make the client reconnect for a gap
+							if bc.keyVerifier != nil {
+								hash, err := message.Hash(bc.chainId)
+								if err == nil {
+									err = bc.keyVerifier.VerifyHash(ctx, message.Signature, hash)
+								}
+								if err != nil {
+									log.Warn("dropping feed message not signed by verify-key", "err", err, "sequence number", message.SequenceNumber)
+									droppedMessagesCounter.Inc(1)
+									if message.SequenceNumber == bc.nextSeqNum {
+										// the dropped message is left to L1 rather than treated as a gap
+										bc.nextSeqNum++
+									}
+									continue
+								}
+							}
+
+							if bc.checkForGap(message.SequenceNumber) {
+								backfilling = true
+								break
+							}
+
 							err := bc.isValidSignature(ctx, message)
 							if err != nil {
 								log.Error("error validating feed signature", "error", err, "sequence number", message.SequenceNumber)
@@ -383,9 +455,17 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 							}
 
 							bc.nextSeqNum = message.SequenceNumber + 1
+							messages = append(messages, message)
 						}
-						if err := bc.txStreamer.AddBroadcastMessages(res.Messages); err != nil {
-							log.Error("Error adding message from Sequencer Feed", "err", err)
+						if len(messages) > 0 {
+							if err := bc.txStreamer.AddBroadcastMessages(messages); err != nil {
+								log.Error("Error adding message from Sequencer Feed", "err", err)
+							}
+						}
+						if backfilling {
+							log.Info("reconnecting to feed to backfill missing messages", "url", bc.websocketUrl, "nextSeqNum", bc.nextSeqNum)
+							_ = bc.conn.Close()
+							continue
 						}
 					}
 					if res.ConfirmedSequenceNumberMessage != nil && bc.ConfirmedSequenceNumberListener != nil {
@@ -397,6 +477,21 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 	})
 }
 
+// checkForGap reports skipped sequence numbers, returning true if the client should reconnect to backfill them
+func (bc *BroadcastClient) checkForGap(seqNum arbutil.MessageIndex) bool {
+	if seqNum <= bc.nextSeqNum {
+		return false
+	}
+	feedGapsCounter.Inc(1)
+	log.Warn("gap in feed sequence numbers", "url", bc.websocketUrl, "firstMissing", bc.nextSeqNum, "lastMissing", seqNum-1)
+	if !bc.config.BackfillGaps || bc.lastBackfillSeqNum == bc.nextSeqNum {
+		// the feed source couldn't fill this gap before, so leave it to L1
+		return false
+	}
+	bc.lastBackfillSeqNum = bc.nextSeqNum
+	return true
+}
+
 func (bc *BroadcastClient) GetRetryCount() int64 {
 	return atomic.LoadInt64(&bc.retryCount)
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/gobwas/ws"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/offchainlabs/nitro/arbstate"
@@ -132,6 +133,121 @@ func TestInvalidSignature(t *testing.T) {
 	}
 }
 
+func TestVerifyKeyDropsUnverifiedMessages(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := wsbroadcastserver.DefaultTestBroadcasterConfig
+	chainId := uint64(9742)
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	sequencerAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	dataSigner := signature.DataSignerFromPrivateKey(privateKey)
+
+	fatalErrChan := make(chan error, 10)
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &settings }, chainId, fatalErrChan, dataSigner)
+
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	otherPrivateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	config := DefaultTestConfig
+	config.VerifyKey = hexutil.Encode(crypto.CompressPubkey(&otherPrivateKey.PublicKey))
+
+	ts := NewDummyTransactionStreamer(chainId, &sequencerAddr)
+	broadcastClient, err := newTestBroadcastClient(config, b.ListenerAddr(), chainId, 0, ts, fatalErrChan, &sequencerAddr)
+	Require(t, err)
+	broadcastClient.Start(ctx)
+	defer broadcastClient.StopAndWait()
+
+	go func() {
+		Require(t, b.BroadcastSingle(arbstate.TestMessageWithMetadataAndRequestId, 0))
+	}()
+
+	timer := time.NewTimer(500 * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case msg := <-ts.messageReceiver:
+		t.Errorf("received message %v not signed by verify-key", msg.SequenceNumber)
+	case err := <-fatalErrChan:
+		t.Errorf("unexpected error occurred: %v", err)
+	case <-timer.C:
+	}
+}
+
+func TestVerifyKeyDeliversSignedMessages(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := wsbroadcastserver.DefaultTestBroadcasterConfig
+	chainId := uint64(9742)
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	sequencerAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	dataSigner := signature.DataSignerFromPrivateKey(privateKey)
+
+	fatalErrChan := make(chan error, 10)
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &settings }, chainId, fatalErrChan, dataSigner)
+
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	config := DefaultTestConfig
+	config.VerifyKey = hexutil.Encode(crypto.CompressPubkey(&privateKey.PublicKey))
+	config.BackfillGaps = true
+
+	ts := NewDummyTransactionStreamer(chainId, &sequencerAddr)
+	broadcastClient, err := newTestBroadcastClient(config, b.ListenerAddr(), chainId, 0, ts, fatalErrChan, &sequencerAddr)
+	Require(t, err)
+	broadcastClient.Start(ctx)
+	defer broadcastClient.StopAndWait()
+
+	// message 1 is signed by another key, so it's dropped between two messages signed by verify-key
+	otherPrivateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	forged := &broadcaster.BroadcastFeedMessage{
+		SequenceNumber: 1,
+		Message:        arbstate.TestMessageWithMetadataAndRequestId,
+	}
+	hash, err := forged.Hash(chainId)
+	Require(t, err)
+	forged.Signature, err = signature.DataSignerFromPrivateKey(otherPrivateKey)(hash.Bytes())
+	Require(t, err)
+	go func() {
+		Require(t, b.BroadcastSingle(arbstate.TestMessageWithMetadataAndRequestId, 0))
+		b.BroadcastSingleFeedMessage(forged)
+		Require(t, b.BroadcastSingle(arbstate.TestMessageWithMetadataAndRequestId, 2))
+	}()
+
+	for _, expected := range []arbutil.MessageIndex{0, 2} {
+		timer := time.NewTimer(5 * time.Second)
+		select {
+		case msg := <-ts.messageReceiver:
+			if msg.SequenceNumber != expected {
+				t.Fatalf("expected message %v signed by verify-key, received %v", expected, msg.SequenceNumber)
+			}
+		case err := <-fatalErrChan:
+			t.Fatalf("unexpected error occurred: %v", err)
+		case <-timer.C:
+			t.Fatalf("message %v signed by verify-key wasn't delivered", expected)
+		}
+		timer.Stop()
+	}
+	if broadcastClient.lastBackfillSeqNum != arbutil.MessageIndex(^uint64(0)) {
+		t.Errorf("dropped message treated as a gap from %v", broadcastClient.lastBackfillSeqNum)
+	}
+	if retries := broadcastClient.GetRetryCount(); retries != 0 {
+		t.Errorf("dropped message made the client reconnect %v times", retries)
+	}
+}
+
 type dummyTransactionStreamer struct {
 	messageReceiver chan broadcaster.BroadcastFeedMessage
 	chainId         uint64
@@ -5,12 +5,16 @@ package broadcaster
 
 import (
 	"context"
+	"encoding/json"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/gobwas/ws"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 
 	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/arbutil"
@@ -18,11 +22,22 @@ import (
 	"github.com/offchainlabs/nitro/wsbroadcastserver"
 )
 
+var (
+	framesSentCounter         = metrics.NewRegisteredCounter("arb/feed/output/frames", nil)
+	messagesPerFrameHistogram = metrics.NewRegisteredHistogram("arb/feed/output/messages_per_frame", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
 type Broadcaster struct {
 	server        *wsbroadcastserver.WSBroadcastServer
 	catchupBuffer *SequenceNumberCatchupBuffer
 	chainId       uint64
 	dataSigner    signature.DataSignerFunc
+	config        wsbroadcastserver.BroadcasterConfigFetcher
+
+	// feed messages waiting for the batch interval to elapse
+	batchMutex sync.Mutex
+	batch      []*BroadcastFeedMessage
+	batchTimer *time.Timer
 }
 
 // BroadcastMessage is the base message type for messages to send over the network.
@@ -44,6 +59,8 @@ type BroadcastMessage struct {
 	// TODO better name than messages since there are different types of messages
 	Messages                       []*BroadcastFeedMessage         `json:"messages,omitempty"`
 	ConfirmedSequenceNumberMessage *ConfirmedSequenceNumberMessage `json:"confirmedSequenceNumberMessage,omitempty"`
+	// only sent on the finality feed
+	FinalityCheckpointMessage *FinalityCheckpointMessage `json:"finalityCheckpointMessage,omitempty"`
 }
 
 type BroadcastFeedMessage struct {
@@ -61,12 +78,13 @@ type ConfirmedSequenceNumberMessage struct {
 }
 
 func NewBroadcaster(config wsbroadcastserver.BroadcasterConfigFetcher, chainId uint64, feedErrChan chan error, dataSigner signature.DataSignerFunc) *Broadcaster {
-	catchupBuffer := NewSequenceNumberCatchupBuffer()
+	catchupBuffer := NewSequenceNumberCatchupBuffer(func() int { return config().ReplayBufferSize })
 	return &Broadcaster{
 		server:        wsbroadcastserver.NewWSBroadcastServer(config, catchupBuffer, chainId, feedErrChan),
 		catchupBuffer: catchupBuffer,
 		chainId:       chainId,
 		dataSigner:    dataSigner,
+		config:        config,
 	}
 }
 
@@ -106,20 +124,82 @@ func (b *Broadcaster) BroadcastSingle(msg arbstate.MessageWithMetadata, seq arbu
 }
 
 func (b *Broadcaster) BroadcastSingleFeedMessage(bfm *BroadcastFeedMessage) {
-	broadcastFeedMessages := make([]*BroadcastFeedMessage, 0, 1)
+	b.batchMutex.Lock()
+	defer b.batchMutex.Unlock()
+	b.batch = append(b.batch, bfm)
+	interval := b.config().BatchInterval
+	if interval <= 0 {
+		b.flushBatchLocked()
+	} else if b.batchTimer == nil {
+		b.batchTimer = time.AfterFunc(interval, b.flushBatch)
+	}
+}
 
-	broadcastFeedMessages = append(broadcastFeedMessages, bfm)
+func (b *Broadcaster) flushBatch() {
+	b.batchMutex.Lock()
+	defer b.batchMutex.Unlock()
+	b.flushBatchLocked()
+}
 
-	bm := BroadcastMessage{
-		Version:  1,
-		Messages: broadcastFeedMessages,
+// flushBatchLocked sends the batched feed messages, in as few frames as fit the max message size,
+// and must be called with the batch mutex held
+func (b *Broadcaster) flushBatchLocked() {
+	if b.batchTimer != nil {
+		b.batchTimer.Stop()
+		b.batchTimer = nil
+	}
+	if len(b.batch) == 0 {
+		return
 	}
+	for _, frame := range splitFeedFrames(b.batch, b.config().MaxMessageSize) {
+		framesSentCounter.Inc(1)
+		messagesPerFrameHistogram.Update(int64(len(frame)))
+		b.server.Broadcast(BroadcastMessage{
+			Version:  1,
+			Messages: frame,
+		})
+	}
+	b.batch = nil
+}
 
-	b.server.Broadcast(bm)
+// feedFrameOverhead bounds the size a frame adds to its feed messages, for the JSON envelope and
+// the websocket header
+var feedFrameOverhead = len(`{"version":1,"messages":[]}`+"\n") + ws.MaxHeaderSize
+
+// splitFeedFrames groups messages in order into frames no larger than maxSize when encoded, with
+// no limit if maxSize is 0. A message too large for any frame gets one to itself, which the server
+// drops for clients while still keeping it for catchup.
+func splitFeedFrames(messages []*BroadcastFeedMessage, maxSize int) [][]*BroadcastFeedMessage {
+	if maxSize <= 0 {
+		return [][]*BroadcastFeedMessage{messages}
+	}
+	var frames [][]*BroadcastFeedMessage
+	var frame []*BroadcastFeedMessage
+	frameSize := feedFrameOverhead
+	for _, message := range messages {
+		encoded, err := json.Marshal(message)
+		if err != nil {
+			log.Warn("failed to encode feed message", "sequenceNumber", message.SequenceNumber, "err", err)
+		}
+		// plus the separating comma
+		size := len(encoded) + 1
+		if len(frame) > 0 && frameSize+size > maxSize {
+			frames = append(frames, frame)
+			frame = nil
+			frameSize = feedFrameOverhead
+		}
+		frame = append(frame, message)
+		frameSize += size
+	}
+	return append(frames, frame)
 }
 
 func (b *Broadcaster) Confirm(seq arbutil.MessageIndex) {
 	log.Debug("confirming sequence number", "sequenceNumber", seq)
+	b.batchMutex.Lock()
+	defer b.batchMutex.Unlock()
+	// send any batched messages first so clients see them before the confirmation
+	b.flushBatchLocked()
 	b.server.Broadcast(BroadcastMessage{
 		Version:                        1,
 		ConfirmedSequenceNumberMessage: &ConfirmedSequenceNumberMessage{seq}})
@@ -129,6 +209,10 @@ func (b *Broadcaster) ClientCount() int32 {
 	return b.server.ClientCount()
 }
 
+func (b *Broadcaster) Clients() []wsbroadcastserver.ClientInfo {
+	return b.server.Clients()
+}
+
 func (b *Broadcaster) ListenerAddr() net.Addr {
 	return b.server.ListenerAddr()
 }
@@ -150,6 +234,7 @@ func (b *Broadcaster) StartWithHeader(ctx context.Context, header ws.HandshakeHe
 }
 
 func (b *Broadcaster) StopAndWait() {
+	b.flushBatch()
 	b.server.StopAndWait()
 }
 
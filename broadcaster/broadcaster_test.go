@@ -5,11 +5,16 @@ package broadcaster
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/gobwas/ws"
+
+	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 	"github.com/offchainlabs/nitro/wsbroadcastserver"
 )
@@ -111,3 +116,114 @@ func Fail(t *testing.T, printables ...interface{}) {
 	t.Helper()
 	testhelpers.FailImpl(t, printables...)
 }
+
+func TestBroadcasterBatchesMessages(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+	config.BatchInterval = time.Hour
+
+	chainId := uint64(5555)
+	feedErrChan := make(chan error, 10)
+	b := NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, chainId, feedErrChan, nil)
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 1))
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 2))
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 3))
+	time.Sleep(50 * time.Millisecond)
+	if count := b.GetCachedMessageCount(); count != 0 {
+		t.Fatalf("batched messages were broadcast before the batch interval, cached count %v", count)
+	}
+
+	// confirming flushes the batch ahead of the confirmation
+	b.Confirm(1)
+	waitUntilUpdated(t, &messageCountPredicate{b, 2, "after confirming the first batched message", 0})
+}
+
+func TestBroadcasterOversizedMessageKeptForCatchup(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+	config.MaxMessageSize = 1000
+
+	chainId := uint64(5555)
+	feedErrChan := make(chan error, 10)
+	b := NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, chainId, feedErrChan, nil)
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	oversized := arbstate.MessageWithMetadata{
+		Message: &arbos.L1IncomingMessage{
+			Header: &arbos.L1IncomingMessageHeader{},
+			L2msg:  make([]byte, config.MaxMessageSize),
+		},
+	}
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 1))
+	Require(t, b.BroadcastSingle(oversized, 2))
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 3))
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 4))
+	// a gap from the dropped message would have cleared the buffer down to the messages after it
+	waitUntilUpdated(t, &messageCountPredicate{b, 4, "after an oversized message", 0})
+
+	b.Confirm(2)
+	waitUntilUpdated(t, &messageCountPredicate{b, 2, "after confirming the oversized message", 0})
+	bm := b.catchupBuffer.getCacheMessages(3)
+	if bm == nil || len(bm.Messages) != 2 || bm.Messages[0].SequenceNumber != 3 {
+		Fail(t, "catchup doesn't serve the messages after the oversized one", bm)
+	}
+}
+
+func TestSplitFeedFrames(t *testing.T) {
+	maxSize := 2000
+	var messages []*BroadcastFeedMessage
+	for i, size := range []int{0, 500, 500, 3000, 0, 800, 800} {
+		messages = append(messages, &BroadcastFeedMessage{
+			SequenceNumber: arbutil.MessageIndex(i),
+			Message: arbstate.MessageWithMetadata{
+				Message: &arbos.L1IncomingMessage{
+					Header: &arbos.L1IncomingMessageHeader{},
+					L2msg:  make([]byte, size),
+				},
+			},
+		})
+	}
+
+	frames := splitFeedFrames(messages, maxSize)
+	var next arbutil.MessageIndex
+	for _, frame := range frames {
+		encoded, err := json.Marshal(BroadcastMessage{Version: 1, Messages: frame})
+		Require(t, err)
+		if len(frame) > 1 && len(encoded)+1+ws.MaxHeaderSize > maxSize {
+			Fail(t, "frame of", len(frame), "messages is", len(encoded), "bytes, over the max size", maxSize)
+		}
+		for _, message := range frame {
+			if message.SequenceNumber != next {
+				Fail(t, "frames out of order, expected", next, "got", message.SequenceNumber)
+			}
+			next++
+		}
+	}
+	if int(next) != len(messages) {
+		Fail(t, "frames have", next, "of", len(messages), "messages")
+	}
+	for _, frame := range frames {
+		for _, message := range frame {
+			if message.SequenceNumber == 3 && len(frame) != 1 {
+				Fail(t, "oversized message shares a frame with", len(frame)-1, "others")
+			}
+		}
+	}
+	if len(frames) < 4 {
+		Fail(t, "expected the messages in at least 4 frames, got", len(frames))
+	}
+
+	if frames := splitFeedFrames(messages, 0); len(frames) != 1 {
+		Fail(t, "split messages without a max size")
+	}
+}
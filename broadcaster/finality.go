@@ -0,0 +1,143 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+var finalityCheckpointGauge = metrics.NewRegisteredGauge("arb/feed/finality/batch", nil)
+
+// keeps checkpoint signatures from being valid for anything else the sequencer signs
+var finalityCheckpointPrefix = []byte("Arbitrum Nitro Finality Checkpoint:")
+
+// FinalityCheckpointMessage says that the batches before BatchCount, and so the first MessageCount
+// messages up to the L2 block BlockHash, were posted in L1 blocks that are now finalized
+type FinalityCheckpointMessage struct {
+	BatchCount   uint64               `json:"batchCount"`
+	MessageCount arbutil.MessageIndex `json:"messageCount"`
+	BlockNumber  uint64               `json:"blockNumber"`
+	BlockHash    common.Hash          `json:"blockHash"`
+	L1Block      uint64               `json:"l1Block"`
+	Signature    []byte               `json:"signature"`
+}
+
+// Hash is what the sequencer signs, so consumers can check the signature with signature.Verifier.VerifyHash
+func (m *FinalityCheckpointMessage) Hash(chainId uint64) common.Hash {
+	data := make([]byte, 40)
+	binary.BigEndian.PutUint64(data[:8], chainId)
+	binary.BigEndian.PutUint64(data[8:16], m.BatchCount)
+	binary.BigEndian.PutUint64(data[16:24], uint64(m.MessageCount))
+	binary.BigEndian.PutUint64(data[24:32], m.BlockNumber)
+	binary.BigEndian.PutUint64(data[32:], m.L1Block)
+	return crypto.Keccak256Hash(finalityCheckpointPrefix, data, m.BlockHash.Bytes())
+}
+
+// latestCheckpointBuffer sends newly connected clients the latest checkpoint, which supersedes the earlier ones
+type latestCheckpointBuffer struct {
+	latest       *FinalityCheckpointMessage
+	messageCount int32
+}
+
+func (b *latestCheckpointBuffer) OnRegisterClient(ctx context.Context, clientConnection *wsbroadcastserver.ClientConnection) error {
+	if b.latest != nil {
+		err := clientConnection.Write(BroadcastMessage{
+			Version:                   1,
+			FinalityCheckpointMessage: b.latest,
+		})
+		if err != nil {
+			log.Error("error sending client the latest finality checkpoint", "error", err, "client", clientConnection.Name)
+			return err
+		}
+	}
+	log.Info("finality feed client registered", "client", clientConnection.Name)
+	return nil
+}
+
+func (b *latestCheckpointBuffer) OnDoBroadcast(bmi interface{}) error {
+	broadcastMessage, ok := bmi.(BroadcastMessage)
+	if !ok {
+		msg := "requested to broadcast message of unknown type"
+		log.Error(msg)
+		return errors.New(msg)
+	}
+	if broadcastMessage.FinalityCheckpointMessage != nil {
+		b.latest = broadcastMessage.FinalityCheckpointMessage
+		atomic.StoreInt32(&b.messageCount, 1)
+	}
+	return nil
+}
+
+func (b *latestCheckpointBuffer) GetMessageCount() int {
+	return int(atomic.LoadInt32(&b.messageCount))
+}
+
+// FinalityBroadcaster serves a feed, separate from the main one, of signed finality checkpoints
+type FinalityBroadcaster struct {
+	server     *wsbroadcastserver.WSBroadcastServer
+	chainId    uint64
+	dataSigner signature.DataSignerFunc
+}
+
+func NewFinalityBroadcaster(config wsbroadcastserver.BroadcasterConfigFetcher, chainId uint64, feedErrChan chan error, dataSigner signature.DataSignerFunc) *FinalityBroadcaster {
+	return &FinalityBroadcaster{
+		server:     wsbroadcastserver.NewWSBroadcastServer(config, &latestCheckpointBuffer{}, chainId, feedErrChan),
+		chainId:    chainId,
+		dataSigner: dataSigner,
+	}
+}
+
+// BroadcastCheckpoint signs the checkpoint and sends it to the finality feed's clients
+func (b *FinalityBroadcaster) BroadcastCheckpoint(checkpoint *FinalityCheckpointMessage) error {
+	hash := checkpoint.Hash(b.chainId)
+	sig, err := b.dataSigner(hash.Bytes())
+	if err != nil {
+		return err
+	}
+	checkpoint.Signature = sig
+	log.Debug("broadcasting finality checkpoint", "batchCount", checkpoint.BatchCount, "messageCount", checkpoint.MessageCount, "l1Block", checkpoint.L1Block)
+	finalityCheckpointGauge.Update(int64(checkpoint.BatchCount))
+	b.server.Broadcast(BroadcastMessage{
+		Version:                   1,
+		FinalityCheckpointMessage: checkpoint,
+	})
+	return nil
+}
+
+func (b *FinalityBroadcaster) ClientCount() int32 {
+	return b.server.ClientCount()
+}
+
+func (b *FinalityBroadcaster) ListenerAddr() net.Addr {
+	return b.server.ListenerAddr()
+}
+
+func (b *FinalityBroadcaster) Initialize() error {
+	return b.server.Initialize()
+}
+
+func (b *FinalityBroadcaster) Start(ctx context.Context) error {
+	return b.server.Start(ctx)
+}
+
+func (b *FinalityBroadcaster) StopAndWait() {
+	b.server.StopAndWait()
+}
+
+func (b *FinalityBroadcaster) Started() bool {
+	return b.server.Started()
+}
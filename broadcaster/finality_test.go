@@ -0,0 +1,52 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+func TestFinalityCheckpointSignature(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chainId := uint64(9742)
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	settings := wsbroadcastserver.DefaultTestBroadcasterConfig
+	b := NewFinalityBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &settings }, chainId, make(chan error, 10), signature.DataSignerFromPrivateKey(privateKey))
+
+	checkpoint := &FinalityCheckpointMessage{
+		BatchCount:   3,
+		MessageCount: 10,
+		BlockNumber:  10,
+		BlockHash:    common.HexToHash("0x1234"),
+		L1Block:      100,
+	}
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+	Require(t, b.BroadcastCheckpoint(checkpoint))
+
+	verifier, err := signature.NewVerifier(&signature.VerifierConfig{
+		AllowedAddresses: []string{crypto.PubkeyToAddress(privateKey.PublicKey).Hex()},
+	}, nil)
+	Require(t, err)
+	Require(t, verifier.VerifyHash(ctx, checkpoint.Signature, checkpoint.Hash(chainId)))
+
+	if err := verifier.VerifyHash(ctx, checkpoint.Signature, checkpoint.Hash(chainId+1)); err == nil {
+		Fail(t, "checkpoint signature verified for another chain")
+	}
+	checkpoint.BatchCount++
+	if err := verifier.VerifyHash(ctx, checkpoint.Signature, checkpoint.Hash(chainId)); err == nil {
+		Fail(t, "signature verified for a different checkpoint")
+	}
+}
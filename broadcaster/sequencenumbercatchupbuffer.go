@@ -20,13 +20,29 @@ var (
 	confirmedSequenceNumberGauge = metrics.NewRegisteredGauge("arb/sequencenumber/confirmed", nil)
 )
 
+// noRequestedSeqNum is the requested sequence number of clients that didn't ask for one
+const noRequestedSeqNum = arbutil.MessageIndex(^uint64(0))
+
 type SequenceNumberCatchupBuffer struct {
 	messages     []*BroadcastFeedMessage
 	messageCount int32
+	// replayBufferSize returns the number of most recent messages to replay to clients that
+	// don't request a sequence number, or 0 to disable replay. It doesn't limit the messages
+	// kept for clients that request one.
+	replayBufferSize func() int
+}
+
+func NewSequenceNumberCatchupBuffer(replayBufferSize func() int) *SequenceNumberCatchupBuffer {
+	return &SequenceNumberCatchupBuffer{
+		replayBufferSize: replayBufferSize,
+	}
 }
 
-func NewSequenceNumberCatchupBuffer() *SequenceNumberCatchupBuffer {
-	return &SequenceNumberCatchupBuffer{}
+func (b *SequenceNumberCatchupBuffer) replayLimit() int {
+	if b.replayBufferSize == nil {
+		return 0
+	}
+	return b.replayBufferSize()
 }
 
 func (b *SequenceNumberCatchupBuffer) getCacheMessages(requestedSeqNum arbutil.MessageIndex) *BroadcastMessage {
@@ -34,9 +50,17 @@ func (b *SequenceNumberCatchupBuffer) getCacheMessages(requestedSeqNum arbutil.M
 		return nil
 	}
 	var startingIndex int32
-	// Ignore messages older than requested sequence number
-	firstCachedSeqNum := b.messages[0].SequenceNumber
-	if firstCachedSeqNum < requestedSeqNum {
+	if requestedSeqNum == noRequestedSeqNum {
+		// Replay the most recent messages to clients that didn't request a starting point
+		limit := b.replayLimit()
+		if limit <= 0 {
+			return nil
+		}
+		if len(b.messages) > limit {
+			startingIndex = int32(len(b.messages) - limit)
+		}
+	} else if firstCachedSeqNum := b.messages[0].SequenceNumber; firstCachedSeqNum < requestedSeqNum {
+		// Ignore messages older than requested sequence number
 		lastCachedSeqNum := firstCachedSeqNum + arbutil.MessageIndex(len(b.messages))
 		if lastCachedSeqNum < requestedSeqNum {
 			// Past end, nothing to return
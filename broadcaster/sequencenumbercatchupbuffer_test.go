@@ -215,3 +215,44 @@ func TestBroadcastFutureSeqNum(t *testing.T) {
 	}
 
 }
+
+func TestReplayBuffer(t *testing.T) {
+	replayBufferSize := 3
+	buffer := NewSequenceNumberCatchupBuffer(func() int { return replayBufferSize })
+
+	bm := BroadcastMessage{
+		Messages: createDummyBroadcastMessages([]arbutil.MessageIndex{40, 41, 42, 43, 44}),
+	}
+	if err := buffer.OnDoBroadcast(bm); err != nil {
+		t.Fatal(err)
+	}
+	// The replay buffer size doesn't evict messages that requested catchup may still need
+	if len(buffer.messages) != 5 || buffer.GetMessageCount() != 5 {
+		t.Fatal("messages should not have been evicted, remaining: ", len(buffer.messages))
+	}
+	if caughtUp := buffer.getCacheMessages(40); caughtUp == nil || len(caughtUp.Messages) != 5 {
+		t.Fatal("expected requested catchup to be served from before the replay window")
+	}
+
+	// Clients without a requested sequence number get the replay buffer
+	replayed := buffer.getCacheMessages(noRequestedSeqNum)
+	if replayed == nil || len(replayed.Messages) != 3 || replayed.Messages[0].SequenceNumber != 42 {
+		t.Fatal("expected the replay buffer to be sent")
+	}
+
+	// Shrinking the replay buffer only replays the most recent messages
+	replayBufferSize = 1
+	replayed = buffer.getCacheMessages(noRequestedSeqNum)
+	if replayed == nil || len(replayed.Messages) != 1 || replayed.Messages[0].SequenceNumber != 44 {
+		t.Fatal("expected only the most recent message to be sent")
+	}
+
+	// Disabling replay sends nothing to clients without a requested sequence number
+	replayBufferSize = 0
+	if buffer.getCacheMessages(noRequestedSeqNum) != nil {
+		t.Error("should not have replayed anything")
+	}
+	if len(buffer.getCacheMessages(43).Messages) != 2 {
+		t.Error("requested sequence numbers should still be served")
+	}
+}
@@ -4,8 +4,12 @@
 package conf
 
 import (
+	"errors"
+	"time"
+
 	"github.com/offchainlabs/nitro/arbnode"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/gasoracle"
 	flag "github.com/spf13/pflag"
 )
 
@@ -14,7 +18,9 @@ type L1Config struct {
 	Rollup             arbnode.RollupAddressesConfig `koanf:"rollup"`
 	URL                string                        `koanf:"url"`
 	ConnectionAttempts int                           `koanf:"connection-attempts"`
+	Client             L1ClientConfig                `koanf:"client"`
 	Wallet             genericconf.WalletConfig      `koanf:"wallet"`
+	GasOracle          gasoracle.Config              `koanf:"gas-oracle"`
 }
 
 var L1ConfigDefault = L1Config{
@@ -22,7 +28,9 @@ var L1ConfigDefault = L1Config{
 	Rollup:             arbnode.RollupAddressesConfigDefault,
 	URL:                "",
 	ConnectionAttempts: 15,
+	Client:             L1ClientConfigDefault,
 	Wallet:             genericconf.WalletConfigDefault,
+	GasOracle:          gasoracle.DefaultConfig,
 }
 
 func L1ConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -30,13 +38,66 @@ func L1ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".url", L1ConfigDefault.URL, "layer 1 ethereum node RPC URL")
 	arbnode.RollupAddressesConfigAddOptions(prefix+".rollup", f)
 	f.Int(prefix+".connection-attempts", L1ConfigDefault.ConnectionAttempts, "layer 1 RPC connection attempts (spaced out at least 1 second per attempt, 0 to retry infinitely)")
+	L1ClientConfigAddOptions(prefix+".client", f)
 	genericconf.WalletConfigAddOptions(prefix+".wallet", f, "wallet")
+	gasoracle.ConfigAddOptions(prefix+".gas-oracle", f)
 }
 
 func (c *L1Config) ResolveDirectoryNames(chain string) {
 	c.Wallet.ResolveDirectoryNames(chain)
 }
 
+func (c *L1Config) Validate() error {
+	return c.Client.Validate()
+}
+
+// L1ClientConfig configures the HTTP transport of the L1 client, and is unused for websocket and IPC URLs
+type L1ClientConfig struct {
+	MaxIdleConns        int           `koanf:"max-idle-conns"`
+	MaxConnsPerHost     int           `koanf:"max-conns-per-host"`
+	HTTP2               bool          `koanf:"http2"`
+	RequestTimeout      time.Duration `koanf:"request-timeout"`
+	RateLimitBackoff    time.Duration `koanf:"rate-limit-backoff"`
+	RateLimitMaxBackoff time.Duration `koanf:"rate-limit-max-backoff"`
+}
+
+var L1ClientConfigDefault = L1ClientConfig{
+	MaxIdleConns:        64,
+	MaxConnsPerHost:     0,
+	HTTP2:               true,
+	RequestTimeout:      0,
+	RateLimitBackoff:    time.Second,
+	RateLimitMaxBackoff: time.Minute,
+}
+
+func L1ClientConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".max-idle-conns", L1ClientConfigDefault.MaxIdleConns, "maximum number of idle connections to keep open to the layer 1 node for reuse")
+	f.Int(prefix+".max-conns-per-host", L1ClientConfigDefault.MaxConnsPerHost, "maximum number of connections to open to the layer 1 node, including those in use (0 for no limit)")
+	f.Bool(prefix+".http2", L1ClientConfigDefault.HTTP2, "use HTTP/2 with layer 1 nodes that support it, multiplexing requests over fewer connections")
+	f.Duration(prefix+".request-timeout", L1ClientConfigDefault.RequestTimeout, "timeout for each request to the layer 1 node, after which the call fails so that it can be retried (0 for no timeout)")
+	f.Duration(prefix+".rate-limit-backoff", L1ClientConfigDefault.RateLimitBackoff, "how long to hold back requests to the layer 1 node after it rate limits one, doubling with each further rate limit until a request succeeds (0 to disable)")
+	f.Duration(prefix+".rate-limit-max-backoff", L1ClientConfigDefault.RateLimitMaxBackoff, "maximum time to hold back requests after a rate limit, including for the provider's Retry-After")
+}
+
+func (c *L1ClientConfig) Validate() error {
+	if c.MaxIdleConns < 0 {
+		return errors.New("l1.client.max-idle-conns cannot be negative")
+	}
+	if c.MaxConnsPerHost < 0 {
+		return errors.New("l1.client.max-conns-per-host cannot be negative")
+	}
+	if c.RequestTimeout < 0 {
+		return errors.New("l1.client.request-timeout cannot be negative")
+	}
+	if c.RateLimitBackoff < 0 {
+		return errors.New("l1.client.rate-limit-backoff cannot be negative")
+	}
+	if c.RateLimitBackoff > 0 && c.RateLimitMaxBackoff < c.RateLimitBackoff {
+		return errors.New("l1.client.rate-limit-max-backoff cannot be less than rate-limit-backoff")
+	}
+	return nil
+}
+
 type L2Config struct {
 	ChainID   uint64                   `koanf:"chain-id"`
 	DevWallet genericconf.WalletConfig `koanf:"dev-wallet"`
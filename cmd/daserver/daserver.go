@@ -18,10 +18,10 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/ethereum/go-ethereum/metrics/exp"
 
 	"github.com/offchainlabs/nitro/arbnode"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util"
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
 	"github.com/offchainlabs/nitro/das"
 )
@@ -143,10 +143,17 @@ func startup() error {
 			return nil
 		}
 
+		if err := serverConfig.MetricsServer.Validate(); err != nil {
+			return err
+		}
+		labels, err := serverConfig.MetricsServer.ParseLabels()
+		if err != nil {
+			return err
+		}
 		go metrics.CollectProcessMetrics(serverConfig.MetricsServer.UpdateInterval)
 
 		address := fmt.Sprintf("%v:%v", serverConfig.MetricsServer.Addr, serverConfig.MetricsServer.Port)
-		exp.Setup(address)
+		util.StartMetricsServer(address, labels)
 	}
 
 	sigint := make(chan os.Signal, 1)
@@ -4,6 +4,7 @@
 package genericconf
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -12,38 +13,60 @@ import (
 )
 
 type ConfConfig struct {
-	Dump           bool          `koanf:"dump"`
-	EnvPrefix      string        `koanf:"env-prefix"`
-	File           []string      `koanf:"file"`
-	S3             S3Config      `koanf:"s3"`
-	String         string        `koanf:"string"`
-	ReloadInterval time.Duration `koanf:"reload-interval" reload:"hot"`
+	BackupDir             string        `koanf:"backup-dir"`
+	MaxBackups            int           `koanf:"max-backups"`
+	DeferValidatorReloads bool          `koanf:"defer-validator-reloads" reload:"hot"`
+	Dump                  bool          `koanf:"dump"`
+	EnvPrefix             string        `koanf:"env-prefix"`
+	File                  []string      `koanf:"file"`
+	Format                string        `koanf:"format"`
+	S3                    S3Config      `koanf:"s3"`
+	String                string        `koanf:"string"`
+	Strict                bool          `koanf:"strict"`
+	ReloadInterval        time.Duration `koanf:"reload-interval" reload:"hot"`
 }
 
 func ConfConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".backup-dir", ConfConfigDefault.BackupDir, "directory to save the previous configuration to as JSON before each reload that changes it, for nitroadmin_rollbackConfig (empty to disable)")
+	f.Int(prefix+".max-backups", ConfConfigDefault.MaxBackups, "number of configuration backups to keep, deleting the oldest (0 for no limit)")
+	f.Bool(prefix+".defer-validator-reloads", ConfConfigDefault.DeferValidatorReloads, "while the validator is in a challenge, hold back reloaded block validator options until the challenge resolves, still applying other changes")
 	f.Bool(prefix+".dump", ConfConfigDefault.Dump, "print out currently active configuration file")
 	f.String(prefix+".env-prefix", ConfConfigDefault.EnvPrefix, "environment variables with given prefix will be loaded as configuration values")
-	f.StringSlice(prefix+".file", ConfConfigDefault.File, "name of configuration file")
+	f.StringSlice(prefix+".file", ConfConfigDefault.File, "name of configuration file (a JSON file can inline another with \"$include\": \"path\", relative to itself)")
+	f.String(prefix+".format", ConfConfigDefault.Format, "format of configuration files (json, yaml, or toml), detected from each file's extension if empty")
 	S3ConfigAddOptions(prefix+".s3", f)
 	f.String(prefix+".string", ConfConfigDefault.String, "configuration as JSON string")
+	f.Bool(prefix+".strict", ConfConfigDefault.Strict, "fail if any deprecated option is set, instead of warning")
 	f.Duration(prefix+".reload-interval", ConfConfigDefault.ReloadInterval, "how often to reload configuration (0=disable periodic reloading)")
 }
 
 var ConfConfigDefault = ConfConfig{
-	Dump:           false,
-	EnvPrefix:      "",
-	File:           nil,
-	S3:             DefaultS3Config,
-	String:         "",
-	ReloadInterval: 0,
+	BackupDir:             "",
+	MaxBackups:            10,
+	DeferValidatorReloads: true,
+	Dump:                  false,
+	EnvPrefix:             "",
+	File:                  nil,
+	Format:                "",
+	S3:                    DefaultS3Config,
+	String:                "",
+	Strict:                false,
+	ReloadInterval:        0,
+}
+
+func (c *ConfConfig) Validate() error {
+	if c.MaxBackups < 0 {
+		return fmt.Errorf("conf max-backups cannot be negative, not %v", c.MaxBackups)
+	}
+	return nil
 }
 
 type S3Config struct {
-	AccessKey string `koanf:"access-key"`
+	AccessKey string `koanf:"access-key" secret:"true"`
 	Bucket    string `koanf:"bucket"`
 	ObjectKey string `koanf:"object-key"`
 	Region    string `koanf:"region"`
-	SecretKey string `koanf:"secret-key"`
+	SecretKey string `koanf:"secret-key" secret:"true"`
 }
 
 func S3ConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -4,6 +4,11 @@
 package genericconf
 
 import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	flag "github.com/spf13/pflag"
@@ -19,6 +24,11 @@ type HTTPConfig struct {
 	CORSDomain     []string                `koanf:"corsdomain"`
 	VHosts         []string                `koanf:"vhosts"`
 	ServerTimeouts HTTPServerTimeoutConfig `koanf:"server-timeouts"`
+	UnixSocket     string                  `koanf:"unix-socket"`
+	UnixSocketMode string                  `koanf:"unix-socket-mode"`
+	TLS            TLSConfig               `koanf:"tls"`
+	SyncingHeader  bool                    `koanf:"syncing-header"`
+	StateErrors    bool                    `koanf:"state-errors"`
 }
 
 var HTTPConfigDefault = HTTPConfig{
@@ -29,6 +39,11 @@ var HTTPConfigDefault = HTTPConfig{
 	CORSDomain:     node.DefaultConfig.HTTPCors,
 	VHosts:         node.DefaultConfig.HTTPVirtualHosts,
 	ServerTimeouts: HTTPServerTimeoutConfigDefault,
+	UnixSocket:     "",
+	UnixSocketMode: "0600",
+	TLS:            TLSConfigDefault,
+	SyncingHeader:  false,
+	StateErrors:    false,
 }
 
 type HTTPServerTimeoutConfig struct {
@@ -66,8 +81,22 @@ func HTTPConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.StringSlice(prefix+".api", HTTPConfigDefault.API, "APIs offered over the HTTP-RPC interface")
 	f.String(prefix+".rpcprefix", HTTPConfigDefault.RPCPrefix, "HTTP path path prefix on which JSON-RPC is served. Use '/' to serve on all paths")
 	f.StringSlice(prefix+".corsdomain", HTTPConfigDefault.CORSDomain, "Comma separated list of domains from which to accept cross origin requests (browser enforced)")
-	f.StringSlice(prefix+".vhosts", HTTPConfigDefault.VHosts, "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard")
+	f.StringSlice(prefix+".vhosts", HTTPConfigDefault.VHosts, "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' and subdomain wildcards such as '*.example.com'")
 	HTTPServerTimeoutConfigAddOptions(prefix+".server-timeouts", f)
+	f.String(prefix+".unix-socket", HTTPConfigDefault.UnixSocket, "path of a Unix domain socket to also serve HTTP JSON-RPC on, offering every API regardless of http.api (empty to disable)")
+	f.String(prefix+".unix-socket-mode", HTTPConfigDefault.UnixSocketMode, "octal file permissions of the HTTP JSON-RPC Unix domain socket")
+	TLSConfigAddOptions(prefix+".tls", f)
+	f.Bool(prefix+".syncing-header", HTTPConfigDefault.SyncingHeader, "add an \"X-Nitro-Syncing: true\" header to HTTP-RPC responses while the node reports it's syncing")
+	f.Bool(prefix+".state-errors", HTTPConfigDefault.StateErrors, "replace the \"missing trie node\" errors of HTTP-RPC requests for pruned state with \"state not available, node retains last N blocks\" (buffers each response to check it, websocket responses aren't rewritten)")
+}
+
+// UnixSocketFileMode parses UnixSocketMode
+func (c HTTPConfig) UnixSocketFileMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(c.UnixSocketMode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unix-socket-mode %v: %w", c.UnixSocketMode, err)
+	}
+	return os.FileMode(mode), nil
 }
 
 func HTTPServerTimeoutConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -83,7 +112,11 @@ type WSConfig struct {
 	API       []string `koanf:"api"`
 	RPCPrefix string   `koanf:"rpcprefix"`
 	Origins   []string `koanf:"origins"`
+	VHosts    []string `koanf:"vhosts"`
 	ExposeAll bool     `koanf:"expose-all"`
+
+	MaxSubscriptionBuffer int                       `koanf:"max-subscription-buffer"`
+	OriginSubscriptions   OriginSubscriptionsConfig `koanf:"origin-subscriptions"`
 }
 
 var WSConfigDefault = WSConfig{
@@ -92,7 +125,11 @@ var WSConfigDefault = WSConfig{
 	API:       append(node.DefaultConfig.WSModules, "eth", "arb"),
 	RPCPrefix: node.DefaultConfig.WSPathPrefix,
 	Origins:   node.DefaultConfig.WSOrigins,
+	VHosts:    []string{},
 	ExposeAll: node.DefaultConfig.WSExposeAll,
+
+	MaxSubscriptionBuffer: 0,
+	OriginSubscriptions:   OriginSubscriptionsConfigDefault,
 }
 
 func (c WSConfig) Apply(stackConf *node.Config) {
@@ -109,8 +146,56 @@ func WSConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".port", WSConfigDefault.Port, "WS-RPC server listening port")
 	f.StringSlice(prefix+".api", WSConfigDefault.API, "APIs offered over the WS-RPC interface")
 	f.String(prefix+".rpcprefix", WSConfigDefault.RPCPrefix, "WS path path prefix on which JSON-RPC is served. Use '/' to serve on all paths")
-	f.StringSlice(prefix+".origins", WSConfigDefault.Origins, "Origins from which to accept websockets requests")
+	f.StringSlice(prefix+".origins", WSConfigDefault.Origins, "Origins from which to accept websockets requests. Accepts '*' and subdomain wildcards such as 'https://*.example.com'")
+	f.StringSlice(prefix+".vhosts", WSConfigDefault.VHosts, "Comma separated list of virtual hostnames from which to accept websocket connections, separately from http.vhosts (empty to accept any). Accepts '*' and subdomain wildcards such as '*.example.com'")
 	f.Bool(prefix+".expose-all", WSConfigDefault.ExposeAll, "expose private api via websocket")
+	f.Int(prefix+".max-subscription-buffer", WSConfigDefault.MaxSubscriptionBuffer, "maximum number of notifications buffered for a single subscription before the websocket connection is closed with a policy violation, ending all of its subscriptions (0 for unlimited)")
+	OriginSubscriptionsConfigAddOptions(prefix+".origin-subscriptions", f)
+}
+
+type OriginSubscriptionsConfig struct {
+	Enable       bool     `koanf:"enable"`
+	DefaultLimit int      `koanf:"default-limit"`
+	Limits       []string `koanf:"limits"`
+}
+
+var OriginSubscriptionsConfigDefault = OriginSubscriptionsConfig{
+	Enable:       false,
+	DefaultLimit: 100,
+	Limits:       []string{},
+}
+
+func OriginSubscriptionsConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", OriginSubscriptionsConfigDefault.Enable, "limit the websocket subscriptions open at once from each Origin, across all of its connections; connections without an Origin header, which browsers always send, aren't limited")
+	f.Int(prefix+".default-limit", OriginSubscriptionsConfigDefault.DefaultLimit, "maximum subscriptions open at once from an origin not in limits (0 to refuse their subscriptions)")
+	f.StringSlice(prefix+".limits", OriginSubscriptionsConfigDefault.Limits, "comma separated list of origin=limit entries overriding default-limit, with origins matched like ws.origins, eg 'https://app.example.com=1000,https://*.example.com=500'; the first matching entry applies")
+}
+
+// ParseLimits returns the origin patterns and their limits, in order
+func (c *OriginSubscriptionsConfig) ParseLimits() ([]string, []int, error) {
+	var origins []string
+	var limits []int
+	for _, entry := range c.Limits {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, nil, fmt.Errorf("invalid ws origin subscription limit %q, expected origin=limit", entry)
+		}
+		limit, err := strconv.Atoi(entry[i+1:])
+		if err != nil || limit < 0 {
+			return nil, nil, fmt.Errorf("invalid ws origin subscription limit %q, expected a non-negative limit", entry)
+		}
+		origins = append(origins, entry[:i])
+		limits = append(limits, limit)
+	}
+	return origins, limits, nil
+}
+
+func (c *OriginSubscriptionsConfig) Validate() error {
+	if c.DefaultLimit < 0 {
+		return fmt.Errorf("invalid ws origin subscription default-limit %v", c.DefaultLimit)
+	}
+	_, _, err := c.ParseLimits()
+	return err
 }
 
 type IPCConfig struct {
@@ -157,6 +242,7 @@ type MetricsServerConfig struct {
 	Port           int           `koanf:"port"`
 	Pprof          bool          `koanf:"pprof"`
 	UpdateInterval time.Duration `koanf:"update-interval"`
+	Labels         []string      `koanf:"labels"`
 }
 
 var MetricsServerConfigDefault = MetricsServerConfig{
@@ -164,11 +250,43 @@ var MetricsServerConfigDefault = MetricsServerConfig{
 	Port:           6070,
 	Pprof:          false,
 	UpdateInterval: 3 * time.Second,
+	Labels:         []string{},
+}
+
+var metricsLabelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ParseLabels returns the static labels to attach to every exported metric
+func (c *MetricsServerConfig) ParseLabels() (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, entry := range c.Labels {
+		i := strings.Index(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid metrics label %q, expected name=value", entry)
+		}
+		name := entry[:i]
+		if !metricsLabelNameRegex.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("invalid metrics label name %q, expected letters, digits and underscores not starting with a digit or __", name)
+		}
+		if _, ok := labels[name]; ok {
+			return nil, fmt.Errorf("duplicate metrics label %q", name)
+		}
+		labels[name] = entry[i+1:]
+	}
+	return labels, nil
+}
+
+func (c *MetricsServerConfig) Validate() error {
+	if c.UpdateInterval <= 0 {
+		return fmt.Errorf("invalid metrics-server update-interval %v, expected a positive duration", c.UpdateInterval)
+	}
+	_, err := c.ParseLabels()
+	return err
 }
 
 func MetricsServerAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".addr", MetricsServerConfigDefault.Addr, "metrics server address")
 	f.Int(prefix+".port", MetricsServerConfigDefault.Port, "metrics server port")
 	f.Bool(prefix+".pprof", MetricsServerConfigDefault.Pprof, "enable profiling for Go")
-	f.Duration(prefix+".update-interval", MetricsServerConfigDefault.UpdateInterval, "metrics server update interval")
+	f.Duration(prefix+".update-interval", MetricsServerConfigDefault.UpdateInterval, "how often process and system metrics are sampled")
+	f.StringSlice(prefix+".labels", MetricsServerConfigDefault.Labels, "comma separated list of name=value labels attached to every metric on the prometheus endpoint, eg 'environment=staging,region=us-east-1'")
 }
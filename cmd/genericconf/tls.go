@@ -0,0 +1,167 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package genericconf
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+type TLSConfig struct {
+	Cert         string   `koanf:"cert"`
+	Key          string   `koanf:"key"`
+	AutoReload   bool     `koanf:"auto-reload"`
+	MinVersion   string   `koanf:"min-version"`
+	CipherSuites []string `koanf:"cipher-suites"`
+}
+
+var TLSConfigDefault = TLSConfig{
+	Cert:         "",
+	Key:          "",
+	AutoReload:   false,
+	MinVersion:   "1.2",
+	CipherSuites: []string{},
+}
+
+func TLSConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".cert", TLSConfigDefault.Cert, "path to the PEM encoded TLS certificate chain (enables TLS when set together with key)")
+	f.String(prefix+".key", TLSConfigDefault.Key, "path to the PEM encoded TLS private key")
+	f.Bool(prefix+".auto-reload", TLSConfigDefault.AutoReload, "reload the TLS certificate and key when their files change")
+	f.String(prefix+".min-version", TLSConfigDefault.MinVersion, "minimum TLS version to accept (1.0, 1.1, 1.2 or 1.3)")
+	f.StringSlice(prefix+".cipher-suites", TLSConfigDefault.CipherSuites, "TLS 1.2 and earlier cipher suites to allow, by Go name (empty for Go's defaults)")
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func (c *TLSConfig) Enabled() bool {
+	return c.Cert != "" || c.Key != ""
+}
+
+func (c *TLSConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Cert == "" || c.Key == "" {
+		return errors.New("both tls cert and key must be set to enable TLS")
+	}
+	if _, ok := tlsVersions[c.MinVersion]; !ok {
+		return fmt.Errorf("invalid tls min-version %v", c.MinVersion)
+	}
+	_, err := c.cipherSuiteIds()
+	return err
+}
+
+func (c *TLSConfig) cipherSuiteIds() ([]uint16, error) {
+	if len(c.CipherSuites) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range c.CipherSuites {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure tls cipher suite %v", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ServerConfig loads the certificate and builds the tls.Config for a server
+func (c *TLSConfig) ServerConfig() (*tls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	cipherSuites, err := c.cipherSuiteIds()
+	if err != nil {
+		return nil, err
+	}
+	reloader := &certReloader{certFile: c.Cert, keyFile: c.Key, autoReload: c.AutoReload}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:     tlsVersions[c.MinVersion],
+		CipherSuites:   cipherSuites,
+		GetCertificate: reloader.getCertificate,
+	}, nil
+}
+
+// certReloadInterval bounds how often the certificate files are checked for changes
+const certReloadInterval = 5 * time.Second
+
+type certReloader struct {
+	certFile   string
+	keyFile    string
+	autoReload bool
+
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func (r *certReloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// load must be called with the mutex held or before the reloader is shared
+func (r *certReloader) load() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.autoReload && time.Since(r.lastCheck) >= certReloadInterval {
+		r.lastCheck = time.Now()
+		modTime, err := r.filesModTime()
+		if err != nil {
+			log.Warn("failed to check TLS certificate for changes", "cert", r.certFile, "err", err)
+		} else if modTime.After(r.modTime) {
+			if err := r.load(); err != nil {
+				log.Error("failed to reload TLS certificate, continuing to use the previous one", "cert", r.certFile, "err", err)
+			} else {
+				log.Info("reloaded TLS certificate", "cert", r.certFile)
+			}
+		}
+	}
+	return r.cert, nil
+}
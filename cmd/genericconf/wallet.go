@@ -14,8 +14,8 @@ const PASSWORD_NOT_SET = "PASSWORD_NOT_SET"
 
 type WalletConfig struct {
 	Pathname      string `koanf:"pathname"`
-	PasswordImpl  string `koanf:"password"`
-	PrivateKey    string `koanf:"private-key"`
+	PasswordImpl  string `koanf:"password" secret:"true"`
+	PrivateKey    string `koanf:"private-key" secret:"true"`
 	Account       string `koanf:"account"`
 	OnlyCreateKey bool   `koanf:"only-create-key"`
 }
@@ -0,0 +1,220 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+// BenchValidatorConfig holds the bench-validator options, which are given alongside the usual node options
+type BenchValidatorConfig struct {
+	From  uint64 `koanf:"from"`
+	Count uint64 `koanf:"count"`
+	Full  bool   `koanf:"full"`
+}
+
+var BenchValidatorConfigDefault = BenchValidatorConfig{
+	From:  0,
+	Count: 100,
+	Full:  false,
+}
+
+func benchValidatorConfigAddOptions(f *flag.FlagSet) {
+	f.Uint64("from", BenchValidatorConfigDefault.From, "first block to validate (0 for the first nitro block)")
+	f.Uint64("count", BenchValidatorConfigDefault.Count, "number of blocks to validate")
+	f.Bool("full", BenchValidatorConfigDefault.Full, "validate with the complete arbitrator machine instead of the jit one")
+}
+
+// splitBenchValidatorArgs separates the bench-validator options from the node options they're mixed in with
+func splitBenchValidatorArgs(args []string) ([]string, []string) {
+	var benchArgs, nodeArgs []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		hasValue := strings.Contains(name, "=")
+		if hasValue {
+			name = name[:strings.Index(name, "=")]
+		}
+		switch {
+		case !strings.HasPrefix(args[i], "-"):
+			nodeArgs = append(nodeArgs, args[i])
+		case name == "from" || name == "count":
+			benchArgs = append(benchArgs, args[i])
+			if !hasValue && i+1 < len(args) {
+				i++
+				benchArgs = append(benchArgs, args[i])
+			}
+		case name == "full":
+			benchArgs = append(benchArgs, args[i])
+		default:
+			nodeArgs = append(nodeArgs, args[i])
+		}
+	}
+	return benchArgs, nodeArgs
+}
+
+func parseBenchValidatorArgs(args []string) (*BenchValidatorConfig, []string, error) {
+	benchArgs, nodeArgs := splitBenchValidatorArgs(args)
+	f := flag.NewFlagSet("bench-validator", flag.ContinueOnError)
+	benchValidatorConfigAddOptions(f)
+	if err := f.Parse(benchArgs); err != nil {
+		return nil, nil, err
+	}
+	config := BenchValidatorConfigDefault
+	var err error
+	if config.From, err = f.GetUint64("from"); err != nil {
+		return nil, nil, err
+	}
+	if config.Count, err = f.GetUint64("count"); err != nil {
+		return nil, nil, err
+	}
+	if config.Full, err = f.GetBool("full"); err != nil {
+		return nil, nil, err
+	}
+	if config.Count == 0 {
+		return nil, nil, errors.New("--count must be positive")
+	}
+	return &config, nodeArgs, nil
+}
+
+// Apply keeps the node built for the benchmark from sequencing, posting, staking, or running its own validator
+func (c *BenchValidatorConfig) Apply(nodeConfig *NodeConfig) {
+	nodeConfig.Node.Sequencer.Enable = false
+	nodeConfig.Node.BatchPoster.Enable = false
+	nodeConfig.Node.DelayedSequencer.Enable = false
+	nodeConfig.Node.Feed.Output.Enable = false
+	nodeConfig.Node.Validator.Enable = false
+	nodeConfig.Node.BlockValidator.Enable = false
+}
+
+// cpuTime is the CPU time used by this process and its waited-for children, such as jit machines
+func cpuTime() time.Duration {
+	var total time.Duration
+	for _, who := range []int{syscall.RUSAGE_SELF, syscall.RUSAGE_CHILDREN} {
+		var usage syscall.Rusage
+		if err := syscall.Getrusage(who, &usage); err == nil {
+			total += time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+		}
+	}
+	return total
+}
+
+// benchModuleRoot resolves node.block-validator.current-module-root the way the block validator's
+// Initialize does, except that "current" is read from the rollup itself, as no staker runs to set it
+func benchModuleRoot(ctx context.Context, machineConfig validator.NitroMachineConfig, currentModuleRoot string, l1client arbutil.L1Interface, rollupAddress common.Address) (common.Hash, error) {
+	switch currentModuleRoot {
+	case "latest":
+		latest, err := machineConfig.ReadLatestWasmModuleRoot()
+		if err != nil {
+			return common.Hash{}, errors.Wrap(err, "failed to read the latest wasm module root")
+		}
+		return latest, nil
+	case "current":
+		if l1client == nil {
+			return common.Hash{}, errors.New("wasmModuleRoot set to 'current' - but there's no L1 connection to read it from the rollup")
+		}
+		callOpts := bind.CallOpts{Context: ctx}
+		rollup, err := validator.NewRollupWatcher(rollupAddress, l1client, callOpts)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		current, err := rollup.WasmModuleRoot(&callOpts)
+		if err != nil {
+			return common.Hash{}, errors.Wrap(err, "failed to read the wasm module root from the rollup")
+		}
+		if current == (common.Hash{}) {
+			return common.Hash{}, errors.New("wasmModuleRoot in rollup is zero")
+		}
+		return current, nil
+	default:
+		moduleRoot := common.HexToHash(currentModuleRoot)
+		if moduleRoot == (common.Hash{}) {
+			return common.Hash{}, errors.New("current-module-root config value illegal")
+		}
+		return moduleRoot, nil
+	}
+}
+
+// benchValidator validates the configured range of blocks one at a time and reports the throughput
+func benchValidator(ctx context.Context, currentNode *arbnode.Node, blockchain *core.BlockChain, currentModuleRoot string, l1client arbutil.L1Interface, rollupAddress common.Address, config *BenchValidatorConfig) int {
+	val := currentNode.StatelessBlockValidator
+	if val == nil {
+		log.Error("block validation isn't available, check that the machines for this chain are installed")
+		return 1
+	}
+	moduleRoot, err := benchModuleRoot(ctx, val.MachineLoader.GetConfig(), currentModuleRoot, l1client, rollupAddress)
+	if err != nil {
+		log.Error("no wasm module root to validate with", "currentModuleRoot", currentModuleRoot, "err", err)
+		return 1
+	}
+
+	from := config.From
+	if genesis := blockchain.Config().ArbitrumChainParams.GenesisBlockNum; from <= genesis {
+		// the genesis block has no message to validate
+		from = genesis + 1
+	}
+	to := from + config.Count - 1
+	if head := blockchain.CurrentBlock().NumberU64(); to > head {
+		to = head
+	}
+	if to < from {
+		log.Error("no blocks to validate", "from", from, "head", blockchain.CurrentBlock().NumberU64())
+		return 1
+	}
+	log.Info("benchmarking block validation", "from", from, "to", to, "moduleRoot", moduleRoot, "full", config.Full)
+
+	var memBefore, memAfter runtime.MemStats
+	runtime.ReadMemStats(&memBefore)
+	cpuBefore := cpuTime()
+	start := time.Now()
+	invalid := 0
+	for number := from; number <= to; number++ {
+		header := blockchain.GetHeaderByNumber(number)
+		blockStart := time.Now()
+		valid, err := val.ValidateBlock(ctx, header, config.Full, moduleRoot)
+		if err != nil {
+			log.Error("failed to validate block", "block", number, "err", err)
+			return 1
+		}
+		if !valid {
+			log.Error("block failed validation", "block", number)
+			invalid++
+		}
+		log.Debug("validated block", "block", number, "valid", valid, "elapsed", time.Since(blockStart))
+	}
+	elapsed := time.Since(start)
+	cpu := cpuTime() - cpuBefore
+	runtime.ReadMemStats(&memAfter)
+
+	blocks := to - from + 1
+	log.Info(
+		"block validation benchmark finished",
+		"blocks", blocks,
+		"invalid", invalid,
+		"elapsed", elapsed,
+		"blocksPerSecond", float64(blocks)/elapsed.Seconds(),
+		"cpuPerBlock", cpu/time.Duration(blocks),
+		"allocatedPerBlock", common.StorageSize((memAfter.TotalAlloc-memBefore.TotalAlloc)/blocks),
+		"heapInuse", common.StorageSize(memAfter.HeapInuse),
+		"sys", common.StorageSize(memAfter.Sys),
+	)
+	if invalid > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/validator"
+)
+
+func TestParseBenchValidatorArgs(t *testing.T) {
+	args := []string{"--from", "100", "--l1.url", "ws://localhost:8546", "--count=20", "--full", "--node.caching.archive"}
+	config, nodeArgs, err := parseBenchValidatorArgs(args)
+	Require(t, err)
+	expected := BenchValidatorConfig{From: 100, Count: 20, Full: true}
+	if *config != expected {
+		Fail(t, "unexpected bench-validator config", *config, "expected", expected)
+	}
+	expectedNodeArgs := []string{"--l1.url", "ws://localhost:8546", "--node.caching.archive"}
+	if !reflect.DeepEqual(nodeArgs, expectedNodeArgs) {
+		Fail(t, "unexpected node arguments", nodeArgs, "expected", expectedNodeArgs)
+	}
+
+	_, _, err = parseBenchValidatorArgs([]string{"--count", "0"})
+	if err == nil {
+		Fail(t, "accepted a zero block count")
+	}
+}
+
+func TestBenchModuleRoot(t *testing.T) {
+	ctx := context.Background()
+	machineConfig := validator.NitroMachineConfig{RootPath: t.TempDir()}
+	latest := common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
+	Require(t, os.MkdirAll(filepath.Join(machineConfig.RootPath, "latest"), 0755))
+	Require(t, os.WriteFile(filepath.Join(machineConfig.RootPath, "latest", "module-root.txt"), []byte(latest.Hex()+"\n"), 0644))
+
+	moduleRoot, err := benchModuleRoot(ctx, machineConfig, "latest", nil, common.Address{})
+	Require(t, err)
+	if moduleRoot != latest {
+		Fail(t, "expected the latest module root", latest, "got", moduleRoot)
+	}
+	configured := common.HexToHash("0x2222222222222222222222222222222222222222222222222222222222222222")
+	moduleRoot, err = benchModuleRoot(ctx, machineConfig, configured.Hex(), nil, common.Address{})
+	Require(t, err)
+	if moduleRoot != configured {
+		Fail(t, "expected the configured module root", configured, "got", moduleRoot)
+	}
+	if _, err := benchModuleRoot(ctx, machineConfig, "0x0", nil, common.Address{}); err == nil {
+		Fail(t, "accepted a zero module root")
+	}
+	if _, err := benchModuleRoot(ctx, machineConfig, "current", nil, common.Address{}); err == nil {
+		Fail(t, "resolved the current module root without L1")
+	}
+}
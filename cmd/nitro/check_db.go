@@ -0,0 +1,131 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+	flag "github.com/spf13/pflag"
+)
+
+type CheckDbConfig struct {
+	DbToolConfig `koanf:",squash"`
+	ToBlock      uint64 `koanf:"to-block"`
+	Blocks       uint64 `koanf:"blocks"`
+	CheckState   bool   `koanf:"check-state"`
+}
+
+var CheckDbConfigDefault = CheckDbConfig{
+	ToBlock:    0,
+	Blocks:     1000,
+	CheckState: false,
+}
+
+func checkDbConfigAddOptions(f *flag.FlagSet) {
+	f.Uint64("to-block", CheckDbConfigDefault.ToBlock, "last block to check (0 for the head block)")
+	f.Uint64("blocks", CheckDbConfigDefault.Blocks, "number of blocks to check, ending at to-block")
+	f.Bool("check-state", CheckDbConfigDefault.CheckState, "require the state of every checked block to be available (only expected of archive nodes)")
+}
+
+// checkBlock verifies the linkage of a canonical block's header, body, receipts, and optionally its state
+func checkBlock(chainDb ethdb.Database, stateDb state.Database, number uint64, prevHash common.Hash, checkState bool) (common.Hash, []string) {
+	var problems []string
+	hash := rawdb.ReadCanonicalHash(chainDb, number)
+	if hash == (common.Hash{}) {
+		return hash, []string{"missing canonical hash"}
+	}
+	header := rawdb.ReadHeader(chainDb, hash, number)
+	if header == nil {
+		return hash, []string{"missing header"}
+	}
+	if header.Hash() != hash {
+		problems = append(problems, fmt.Sprintf("header hashes to %v instead of %v", header.Hash(), hash))
+	}
+	if prevHash != (common.Hash{}) && header.ParentHash != prevHash {
+		problems = append(problems, fmt.Sprintf("parent hash %v doesn't match previous block %v", header.ParentHash, prevHash))
+	}
+	body := rawdb.ReadBody(chainDb, hash, number)
+	if body == nil {
+		problems = append(problems, "missing body")
+	} else if txHash := types.DeriveSha(types.Transactions(body.Transactions), trie.NewStackTrie(nil)); txHash != header.TxHash {
+		problems = append(problems, fmt.Sprintf("transactions hash to %v instead of %v", txHash, header.TxHash))
+	}
+	receipts := rawdb.ReadRawReceipts(chainDb, hash, number)
+	if receipts == nil && header.ReceiptHash != types.EmptyRootHash {
+		problems = append(problems, "missing receipts")
+	} else if receiptHash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); receiptHash != header.ReceiptHash {
+		problems = append(problems, fmt.Sprintf("receipts hash to %v instead of %v", receiptHash, header.ReceiptHash))
+	}
+	if checkState {
+		if _, err := stateDb.OpenTrie(header.Root); err != nil {
+			problems = append(problems, fmt.Sprintf("state root %v unavailable: %v", header.Root, err))
+		}
+	}
+	return hash, problems
+}
+
+func checkDb(args []string) int {
+	config := CheckDbConfigDefault
+	if err := parseDbToolConfig("check-db", args, checkDbConfigAddOptions, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing check-db options: %v\n", err)
+		return 1
+	}
+	stack, err := openDbToolStack(&config.DbToolConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening chain directory: %v\n", err)
+		return 1
+	}
+	defer stack.Close()
+
+	chainDb, err := stack.OpenDatabaseWithFreezer("l2chaindata", 0, 0, "", "", true)
+	if err != nil {
+		log.Error("failed to open database", "err", err)
+		return 1
+	}
+	defer closeDb(chainDb, "chainDb")
+
+	toBlock := config.ToBlock
+	if toBlock == 0 {
+		headHash := rawdb.ReadHeadBlockHash(chainDb)
+		headNumber := rawdb.ReadHeaderNumber(chainDb, headHash)
+		if headNumber == nil {
+			log.Error("failed to find head block", "hash", headHash)
+			return 1
+		}
+		toBlock = *headNumber
+	}
+	fromBlock := uint64(0)
+	if config.Blocks > 0 && config.Blocks <= toBlock {
+		fromBlock = toBlock - config.Blocks + 1
+	}
+	log.Info("checking database", "fromBlock", fromBlock, "toBlock", toBlock, "checkState", config.CheckState)
+
+	stateDb := state.NewDatabase(chainDb)
+	var prevHash common.Hash
+	problemBlocks := 0
+	for number := fromBlock; number <= toBlock; number++ {
+		hash, problems := checkBlock(chainDb, stateDb, number, prevHash, config.CheckState)
+		for _, problem := range problems {
+			log.Error("database problem", "block", number, "hash", hash, "problem", problem)
+		}
+		if len(problems) > 0 {
+			problemBlocks++
+		}
+		prevHash = hash
+	}
+	if problemBlocks > 0 {
+		log.Error("database check failed", "blocksChecked", toBlock-fromBlock+1, "problemBlocks", problemBlocks)
+		return 1
+	}
+	log.Info("database check passed", "blocksChecked", toBlock-fromBlock+1)
+	return 0
+}
@@ -0,0 +1,206 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+const (
+	configBackupPrefix     = "config-"
+	configBackupSuffix     = ".json"
+	configBackupTimeFormat = "20060102T150405.000000000"
+)
+
+// writeConfigBackup saves config to the backup directory, with secrets redacted, and deletes the
+// oldest backups past max-backups
+func writeConfigBackup(conf *genericconf.ConfConfig, config *NodeConfig, now time.Time) error {
+	data, err := json.MarshalIndent(arbnode.RedactedConfigValue(false, config), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(conf.BackupDir, 0o700); err != nil {
+		return err
+	}
+	name := filepath.Join(conf.BackupDir, configBackupPrefix+now.UTC().Format(configBackupTimeFormat)+configBackupSuffix)
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		return err
+	}
+	if conf.MaxBackups == 0 {
+		return nil
+	}
+	backups, err := configBackups(conf.BackupDir)
+	if err != nil {
+		return err
+	}
+	for len(backups) > conf.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// configBackups lists the backups in dir from oldest to newest
+func configBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, configBackupPrefix) && strings.HasSuffix(name, configBackupSuffix) {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	// the names sort by the time they were written
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func (c *LiveNodeConfig) rollback() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.config.Conf.BackupDir == "" {
+		return errors.New("conf.backup-dir not set, there are no config backups")
+	}
+	backups, err := configBackups(c.config.Conf.BackupDir)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("no config backups in %v to roll back to", c.config.Conf.BackupDir)
+	}
+	name := backups[len(backups)-1]
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	var options map[string]interface{}
+	if err := decoder.Decode(&options); err != nil {
+		return fmt.Errorf("failed to read config backup %v: %w", name, err)
+	}
+
+	config := *c.config
+	if err := applyHotOptions(reflect.ValueOf(&config).Elem(), options, ""); err != nil {
+		return fmt.Errorf("failed to apply config backup %v: %w", name, err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	// rolling back isn't backed up itself, so each rollback goes one reload further back
+	if err := c.setLocked(&config, false); err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil {
+		log.Warn("failed to delete config backup after rolling back to it", "file", name, "err", err)
+	}
+	log.Info("rolled back config", "backup", name)
+	return nil
+}
+
+// applyHotOptions sets the hot reloadable options of config to their values in options, which is
+// decoded from a backup. Cold options can't have changed since, and are left alone.
+func applyHotOptions(config reflect.Value, options map[string]interface{}, path string) error {
+	for i := 0; i < config.NumField(); i++ {
+		field := config.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get("reload") != "hot" {
+			continue
+		}
+		if arbnode.SquashedConfigField(field) && config.Field(i).Kind() == reflect.Struct {
+			if err := applyHotOptions(config.Field(i), options, path); err != nil {
+				return err
+			}
+			continue
+		}
+		name := field.Tag.Get("koanf")
+		if name == "" {
+			continue
+		}
+		option, ok := options[name]
+		if !ok {
+			continue
+		}
+		dot := name
+		if path != "" {
+			dot = path + "." + name
+		}
+		value := config.Field(i)
+		if value.Kind() == reflect.Struct {
+			nested, ok := option.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("config backup has %v for group of options %v", option, dot)
+			}
+			if err := applyHotOptions(value, nested, dot); err != nil {
+				return err
+			}
+			continue
+		}
+		if unchangedConfigOption(arbnode.SecretConfigField(field), value, option) {
+			// possibly a secret or URL password that wasn't saved in full
+			continue
+		}
+		if redactedBackupOption(option) {
+			// the backup doesn't have the value to restore, only its redacted form
+			log.Warn("config backup has a redacted value for option, leaving it as is", "option", dot)
+			continue
+		}
+		if err := decodeConfigValue(value, option); err != nil {
+			return fmt.Errorf("invalid value for config option %v in backup: %w", dot, err)
+		}
+	}
+	return nil
+}
+
+// redactedBackupOption returns whether the backup value of an option is, or has, a redacted secret or
+// URL password
+func redactedBackupOption(option interface{}) bool {
+	switch option := option.(type) {
+	case string:
+		return arbnode.IsRedactedConfigValue(option)
+	case []interface{}:
+		for _, element := range option {
+			if redactedBackupOption(element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unchangedConfigOption returns whether the backup has the current value of an option, comparing
+// them as they're written to a backup
+func unchangedConfigOption(secret bool, value reflect.Value, option interface{}) bool {
+	current, err := json.Marshal(arbnode.RedactedConfigValue(secret, value.Interface()))
+	if err != nil {
+		return false
+	}
+	backup, err := json.Marshal(option)
+	if err != nil {
+		return false
+	}
+	return string(current) == string(backup)
+}
@@ -0,0 +1,123 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbnode"
+)
+
+type ConfigAPI struct {
+	config *LiveNodeConfig
+}
+
+// SetConfigValue sets a hot reloadable option of the live config, like
+// nitroadmin_setConfigValue("node.sequencer.max-block-speed", "250ms"), applying it as a reload would.
+// The value lasts until the next reload from the config files or command line.
+func (api *ConfigAPI) SetConfigValue(path string, value interface{}) error {
+	return api.config.setValue(path, value)
+}
+
+// RollbackConfig restores the hot reloadable options of the most recent backup in conf.backup-dir,
+// undoing the last reload that changed the config, and deletes the backup so calling it again goes
+// further back. Like a value set over RPC, it lasts until the next reload.
+func (api *ConfigAPI) RollbackConfig() error {
+	return api.config.rollback()
+}
+
+// setValue applies the config with the option at path set to value, which is decoded like a value
+// from a config file
+func (c *LiveNodeConfig) setValue(path string, value interface{}) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	config := *c.config
+	field, err := hotConfigField(reflect.ValueOf(&config).Elem(), path)
+	if err != nil {
+		return err
+	}
+	if err := decodeConfigValue(field, value); err != nil {
+		return fmt.Errorf("invalid value for config option %v: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := c.setLocked(&config, true); err != nil {
+		return err
+	}
+	log.Info("config option set over RPC", "path", path, "value", value)
+	return nil
+}
+
+// decodeConfigValue sets field to value, decoded the way a value from a config file is
+func decodeConfigValue(field reflect.Value, value interface{}) error {
+	decoded := reflect.New(field.Type())
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(",")),
+		Result:           decoded.Interface(),
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(value); err != nil {
+		return err
+	}
+	field.Set(decoded.Elem())
+	return nil
+}
+
+// hotConfigField returns the option at the koanf path in config, which must be hot reloadable
+// along with every struct containing it
+func hotConfigField(config reflect.Value, path string) (reflect.Value, error) {
+	value := config
+	for _, name := range strings.Split(path, ".") {
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown config path %q", path)
+		}
+		field, hot, found := lookupConfigOption(value, name)
+		if !found {
+			return reflect.Value{}, fmt.Errorf("unknown config path %q", path)
+		}
+		if !hot {
+			return reflect.Value{}, fmt.Errorf("config option %v can't be changed at runtime, %v isn't hot reloadable", path, name)
+		}
+		value = field
+	}
+	if value.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("config path %q is a group of options, set them one at a time", path)
+	}
+	return value, nil
+}
+
+// lookupConfigOption returns the field of the struct value with the koanf tag name, looking inside
+// squashed structs, and whether it's hot reloadable along with any squashed struct containing it
+func lookupConfigOption(value reflect.Value, name string) (reflect.Value, bool, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		hot := field.Tag.Get("reload") == "hot"
+		if arbnode.SquashedConfigField(field) && value.Field(i).Kind() == reflect.Struct {
+			if option, optionHot, found := lookupConfigOption(value.Field(i), name); found {
+				return option, hot && optionHot, true
+			}
+			continue
+		}
+		if field.Tag.Get("koanf") == name {
+			return value.Field(i), hot, true
+		}
+	}
+	return reflect.Value{}, false, false
+}
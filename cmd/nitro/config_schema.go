@@ -0,0 +1,90 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbnode"
+)
+
+type ConfigSchemaEntry struct {
+	Path        string      `json:"path"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Hot         bool        `json:"hot"`
+	Description string      `json:"description,omitempty"`
+}
+
+// ConfigSchema describes every option in NodeConfigDefault, keyed by its koanf path
+func ConfigSchema() []ConfigSchemaEntry {
+	f := flag.NewFlagSet("", flag.ContinueOnError)
+	NodeConfigAddOptions(f)
+	return configSchema(NodeConfigDefault, f)
+}
+
+// configSchema describes every option in config, with the descriptions of their flags in f
+func configSchema(config interface{}, f *flag.FlagSet) []ConfigSchemaEntry {
+	var entries []ConfigSchemaEntry
+	var walk func(node reflect.Value, hot bool, path string)
+	walk = func(node reflect.Value, hot bool, path string) {
+		for i := 0; i < node.NumField(); i++ {
+			field := node.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldHot := hot && field.Tag.Get("reload") == "hot"
+			if arbnode.SquashedConfigField(field) && field.Type.Kind() == reflect.Struct {
+				// the options of a squashed struct belong to its parent
+				walk(node.Field(i), fieldHot, path)
+				continue
+			}
+			name := field.Tag.Get("koanf")
+			if name == "" || name == "-" {
+				continue
+			}
+			dot := name
+			if path != "" {
+				dot = path + "." + name
+			}
+			value := node.Field(i)
+			if value.Kind() == reflect.Struct {
+				walk(value, fieldHot, dot)
+				continue
+			}
+			var def interface{} = value.Interface()
+			if duration, ok := def.(time.Duration); ok {
+				def = duration.String()
+			}
+			entry := ConfigSchemaEntry{
+				Path:    dot,
+				Type:    value.Type().String(),
+				Default: def,
+				Hot:     fieldHot,
+			}
+			if option := f.Lookup(dot); option != nil {
+				entry.Description = option.Usage
+			}
+			entries = append(entries, entry)
+		}
+	}
+	walk(reflect.ValueOf(config), true, "")
+	return entries
+}
+
+func printConfigSchema() int {
+	schema, err := json.MarshalIndent(ConfigSchema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding config schema: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(schema))
+	return 0
+}
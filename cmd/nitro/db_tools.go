@@ -0,0 +1,90 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/cmd/conf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+)
+
+// DbToolConfig holds the options for subcommands that operate on a stopped node's databases
+type DbToolConfig struct {
+	Persistent conf.PersistentConfig `koanf:"persistent"`
+	LogLevel   int                   `koanf:"log-level"`
+	LogType    string                `koanf:"log-type"`
+}
+
+func parseDbToolConfig(name string, args []string, extraOptions func(f *flag.FlagSet), config interface{}) error {
+	f := flag.NewFlagSet(name, flag.ContinueOnError)
+	conf.PersistentConfigAddOptions("persistent", f)
+	f.Int("log-level", NodeConfigDefault.LogLevel, "log level")
+	f.String("log-type", NodeConfigDefault.LogType, "log type (plaintext or json)")
+	if extraOptions != nil {
+		extraOptions(f)
+	}
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return err
+	}
+	return confighelpers.EndCommonParse(k, config)
+}
+
+// openDbToolStack opens the geth stack for the chain directory, which fails if a node is still using it
+func openDbToolStack(config *DbToolConfig) (*node.Node, error) {
+	if config.Persistent.Chain == "" {
+		return nil, errors.New("--persistent.chain not specified")
+	}
+	if err := config.Persistent.ResolveDirectoryNames(); err != nil {
+		return nil, err
+	}
+	if err := initLog(config.LogType, log.Lvl(config.LogLevel), ""); err != nil {
+		return nil, err
+	}
+	stackConf := node.DefaultConfig
+	stackConf.DataDir = config.Persistent.Chain
+	stackConf.P2P.ListenAddr = ""
+	stackConf.P2P.NoDial = true
+	stackConf.P2P.NoDiscovery = true
+	return node.New(&stackConf)
+}
+
+func migrateDb(args []string) int {
+	var config DbToolConfig
+	if err := parseDbToolConfig("migrate-db", args, nil, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing migrate-db options: %v\n", err)
+		return 1
+	}
+	stack, err := openDbToolStack(&config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening chain directory: %v\n", err)
+		return 1
+	}
+	defer stack.Close()
+
+	arbDb, err := stack.OpenDatabase("arbitrumdata", 0, 0, "", false)
+	if err != nil {
+		log.Error("failed to open database", "err", err)
+		return 1
+	}
+	defer closeDb(arbDb, "arbDb")
+
+	err = arbnode.MigrateArbDb(arbDb, func(from, to uint64) {
+		log.Info("migrating database", "from", from, "to", to)
+	})
+	if err != nil {
+		log.Error("database migration failed", "err", err)
+		return 1
+	}
+	log.Info("database is at the current schema version")
+	return 0
+}
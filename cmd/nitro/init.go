@@ -267,6 +267,9 @@ func openInitializeChainDb(ctx context.Context, stack *node.Node, config *NodeCo
 		if err != nil {
 			return chainDb, nil, err
 		}
+		if err := config.Node.Dev.ApplyArbOSVersion(chainConfig); err != nil {
+			return chainDb, nil, err
+		}
 		testUpdateTxIndex(chainDb, chainConfig, &txIndexWg)
 		ancients, err := chainDb.Ancients()
 		if err != nil {
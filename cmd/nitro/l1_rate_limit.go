@@ -0,0 +1,198 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var l1RateLimitedCounter = metrics.NewRegisteredCounter("arb/l1/rate_limited", nil)
+
+// how much of a successful response is checked for a JSON-RPC rate limit error, which are short
+const rateLimitPeekSize = 512
+
+// a JSON-RPC error a provider uses for rate limits, matching codes other errors share by message
+type rateLimitError struct {
+	code int
+	// a lowercase part of the message, or empty to match any message
+	message string
+}
+
+var rateLimitErrors = []rateLimitError{
+	// infura, whose -32005 is also used for too many log results
+	{-32005, "rate limit"},
+	{-32005, "rate exceeded"},
+	{-32005, "request count exceeded"},
+	// alchemy
+	{429, ""},
+}
+
+type jsonRPCErrorResponse struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// rateLimitRoundTripper holds back every L1 request for a while after the provider rate limits one,
+// instead of letting callers keep retrying into the limit. The backoff starts at initial, doubling
+// up to max with each further rate limit, and is reset by a successful response. A Retry-After
+// header the provider sends is respected up to max.
+type rateLimitRoundTripper struct {
+	transport http.RoundTripper
+	initial   time.Duration
+	max       time.Duration
+
+	mutex   sync.Mutex
+	backoff time.Duration
+	until   time.Time
+}
+
+func newRateLimitRoundTripper(transport http.RoundTripper, initial time.Duration, max time.Duration) *rateLimitRoundTripper {
+	return &rateLimitRoundTripper{
+		transport: transport,
+		initial:   initial,
+		max:       max,
+	}
+}
+
+func (t *rateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mutex.Lock()
+	delay := time.Until(t.until)
+	t.mutex.Unlock()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if limited, retryAfter := checkRateLimited(resp); limited {
+		t.rateLimited(retryAfter)
+	} else if resp.StatusCode == http.StatusOK {
+		t.succeeded()
+	}
+	return resp, nil
+}
+
+func (t *rateLimitRoundTripper) rateLimited(retryAfter time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := time.Now()
+	if now.Before(t.until) {
+		// a request sent before the backoff started, which is already accounted for
+		return
+	}
+	if t.backoff == 0 {
+		t.backoff = t.initial
+	} else {
+		t.backoff *= 2
+	}
+	if t.backoff > t.max {
+		t.backoff = t.max
+	}
+	delay := t.backoff
+	if retryAfter > delay {
+		delay = retryAfter
+		if delay > t.max {
+			delay = t.max
+		}
+	}
+	t.until = now.Add(delay)
+	l1RateLimitedCounter.Inc(1)
+	log.Warn("L1 provider is rate limiting requests, backing off", "backoff", delay, "retryAfter", retryAfter)
+}
+
+func (t *rateLimitRoundTripper) succeeded() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if time.Now().After(t.until) {
+		t.backoff = 0
+	}
+}
+
+// checkRateLimited reports whether the response is a rate limit, either an HTTP 429 or a JSON-RPC
+// error, along with how long its Retry-After header asks to wait. The response body is left
+// unread, apart from what's buffered to check it.
+func checkRateLimited(resp *http.Response) (bool, time.Duration) {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true, parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	if resp.StatusCode != http.StatusOK || resp.Body == nil {
+		return false, 0
+	}
+	reader := bufio.NewReaderSize(resp.Body, rateLimitPeekSize)
+	resp.Body = &peekedBody{Reader: reader, Closer: resp.Body}
+	peeked, _ := reader.Peek(rateLimitPeekSize)
+	if !bytes.Contains(peeked, []byte(`"error"`)) {
+		return false, 0
+	}
+	if isRateLimitError(peeked) {
+		return true, parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return false, 0
+}
+
+// isRateLimitError reports whether body is a JSON-RPC response, or batch of them, with a rate limit
+// error. A body cut off by the peek doesn't parse, but rate limit errors are short.
+func isRateLimitError(body []byte) bool {
+	var responses []jsonRPCErrorResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		var response jsonRPCErrorResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return false
+		}
+		responses = append(responses, response)
+	}
+	for _, response := range responses {
+		if response.Error == nil {
+			continue
+		}
+		message := strings.ToLower(response.Error.Message)
+		for _, limit := range rateLimitErrors {
+			if response.Error.Code == limit.code && strings.Contains(message, limit.message) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or a date
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
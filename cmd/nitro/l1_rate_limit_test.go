@@ -0,0 +1,91 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitBackoff(t *testing.T) {
+	responses := []func(w http.ResponseWriter){
+		func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+		func(w http.ResponseWriter) {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Rate limit exceeded"}}`))
+		},
+		func(w http.ResponseWriter) {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+		},
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses[requests](w)
+		requests++
+	}))
+	defer server.Close()
+	transport := newRateLimitRoundTripper(http.DefaultTransport, 10*time.Millisecond, 2*time.Second)
+	client := &http.Client{Transport: transport}
+	get := func() string {
+		resp, err := client.Get(server.URL)
+		Require(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		Require(t, err)
+		return string(body)
+	}
+
+	get()
+	// the Retry-After is longer than the initial backoff
+	if backoff := time.Until(transport.until); backoff < 900*time.Millisecond {
+		Fail(t, "Retry-After not respected, backing off for", backoff)
+	}
+	start := time.Now()
+	// the JSON-RPC rate limit error is passed on, with its body intact
+	if body := get(); body != `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Rate limit exceeded"}}` {
+		Fail(t, "unexpected body", body)
+	}
+	if time.Since(start) < 900*time.Millisecond {
+		Fail(t, "request wasn't held back")
+	}
+	if transport.backoff != 20*time.Millisecond {
+		Fail(t, "backoff didn't escalate", transport.backoff)
+	}
+	time.Sleep(30 * time.Millisecond)
+	get()
+	if transport.backoff != 0 {
+		Fail(t, "backoff wasn't reset by a successful response", transport.backoff)
+	}
+}
+
+func TestRateLimitErrors(t *testing.T) {
+	limited := []string{
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Rate limit exceeded"}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"daily request count exceeded, request rate limited"}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":429,"message":"Your app has exceeded its compute units per second capacity"}}`,
+		`[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32005,"message":"project ID request rate exceeded"}}]`,
+	}
+	for _, body := range limited {
+		if !isRateLimitError([]byte(body)) {
+			Fail(t, "rate limit not detected in", body)
+		}
+	}
+	notLimited := []string{
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"gas limit exceeded"}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"query returned more than 10000 results"}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"execution reverted: rate limit"}}`,
+		`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Rate limit exce`,
+	}
+	for _, body := range notLimited {
+		if isRateLimitError([]byte(body)) {
+			Fail(t, "false rate limit detected in", body)
+		}
+	}
+}
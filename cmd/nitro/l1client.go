@@ -0,0 +1,143 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/cmd/conf"
+)
+
+var (
+	l1ActiveConnectionsGauge = metrics.NewRegisteredGauge("arb/l1/connections/active", nil)
+	l1RequestTimeoutCounter  = metrics.NewRegisteredCounter("arb/l1/requests/timeout", nil)
+)
+
+// trackedConn keeps l1ActiveConnectionsGauge up to date with the L1 connections open
+type trackedConn struct {
+	net.Conn
+	closeOnce sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() {
+		l1ActiveConnectionsGauge.Dec(1)
+	})
+	return c.Conn.Close()
+}
+
+func newL1Transport(config *conf.L1ClientConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			l1ActiveConnectionsGauge.Inc(1)
+			return &trackedConn{Conn: conn}, nil
+		},
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConns,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// a custom DialContext disables HTTP/2 unless it's forced
+		ForceAttemptHTTP2: config.HTTP2,
+	}
+	if !config.HTTP2 {
+		// a non-nil empty map keeps TLS connections on HTTP/1.1
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport
+}
+
+// timeoutRoundTripper bounds each L1 request, including reading its response, by timeout. A request
+// that times out fails with an error like any other, so callers that retry on errors retry it, each
+// attempt getting a timeout of its own.
+type timeoutRoundTripper struct {
+	transport http.RoundTripper
+	timeout   time.Duration
+}
+
+func (t *timeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		countL1Timeout(ctx, req.Context())
+		cancel()
+		return nil, err
+	}
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, ctx: ctx, parent: req.Context(), cancel: cancel}
+	return resp, nil
+}
+
+// countL1Timeout counts a failed request if its own deadline passed, rather than the caller's
+func countL1Timeout(ctx context.Context, parent context.Context) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+		l1RequestTimeoutCounter.Inc(1)
+	}
+}
+
+// timeoutBody releases the request's timeout once the response is closed
+type timeoutBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		countL1Timeout(b.ctx, b.parent)
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// dialL1 connects to the L1 node, using a transport configured by l1.client for HTTP URLs. The
+// request timeout and rate limit backoff only apply to HTTP, as websocket and IPC calls share one
+// long-lived connection.
+func dialL1(ctx context.Context, l1URL string, config *conf.L1ClientConfig) (*ethclient.Client, error) {
+	parsed, err := url.Parse(l1URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ethclient.DialContext(ctx, l1URL)
+	}
+	var transport http.RoundTripper = newL1Transport(config)
+	if config.RequestTimeout > 0 {
+		transport = &timeoutRoundTripper{transport: transport, timeout: config.RequestTimeout}
+	}
+	if config.RateLimitBackoff > 0 {
+		// outermost, so time spent held back doesn't count against the request timeout
+		transport = newRateLimitRoundTripper(transport, config.RateLimitBackoff, config.RateLimitMaxBackoff)
+	}
+	rpcClient, err := rpc.DialHTTPWithClient(l1URL, &http.Client{Transport: transport})
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(rpcClient), nil
+}
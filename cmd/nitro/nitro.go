@@ -37,16 +37,18 @@ import (
 	"github.com/ethereum/go-ethereum/graphql"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/ethereum/go-ethereum/metrics/exp"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/cmd/conf"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/cmd/util"
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
 	_ "github.com/offchainlabs/nitro/nodeInterface"
 	"github.com/offchainlabs/nitro/util/colors"
+	"github.com/offchainlabs/nitro/util/gasoracle"
 	"github.com/offchainlabs/nitro/util/headerreader"
 	"github.com/offchainlabs/nitro/util/signature"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
@@ -57,7 +59,7 @@ func printSampleUsage(name string) {
 	fmt.Printf("Sample usage: %s --help \n", name)
 }
 
-func initLog(logType string, logLevel log.Lvl) error {
+func initLog(logType string, logLevel log.Lvl, nodeName string) error {
 	logFormat, err := genericconf.ParseLogType(logType)
 	if err != nil {
 		flag.Usage()
@@ -65,7 +67,14 @@ func initLog(logType string, logLevel log.Lvl) error {
 	}
 	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, logFormat))
 	glogger.Verbosity(logLevel)
-	log.Root().SetHandler(glogger)
+	if nodeName == "" {
+		log.Root().SetHandler(glogger)
+		return nil
+	}
+	log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+		r.Ctx = append(r.Ctx, "node", nodeName)
+		return glogger.Log(r)
+	}))
 	return nil
 }
 
@@ -139,22 +148,38 @@ func mainImpl() int {
 	defer cancelFunc()
 
 	args := os.Args[1:]
+	var benchConfig *BenchValidatorConfig
+	if len(args) > 0 {
+		switch args[0] {
+		case "bench-validator":
+			var err error
+			benchConfig, args, err = parseBenchValidatorArgs(args[1:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing bench-validator options: %v\n", err)
+				return 1
+			}
+		case "print-config-schema":
+			return printConfigSchema()
+		case "migrate-db":
+			return migrateDb(args[1:])
+		case "check-db":
+			return checkDb(args[1:])
+		case "replay-feed":
+			return replayFeed(args[1:])
+		}
+	}
 	nodeConfig, l1Wallet, l2DevWallet, l1Client, l1ChainId, err := ParseNode(ctx, args)
 	if err != nil {
 		confighelpers.PrintErrorAndExit(err, printSampleUsage)
 	}
-	err = initLog(nodeConfig.LogType, log.Lvl(nodeConfig.LogLevel))
+	err = initLog(nodeConfig.LogType, log.Lvl(nodeConfig.LogLevel), nodeConfig.Node.Name)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
 		os.Exit(1)
 	}
-	if nodeConfig.Node.Archive {
-		log.Warn("--node.archive has been deprecated. Please use --node.caching.archive instead.")
-		nodeConfig.Node.Caching.Archive = true
-	}
 
 	vcsRevision, vcsTime := confighelpers.GetVersion()
-	log.Info("Running Arbitrum nitro node", "revision", vcsRevision, "vcs.time", vcsTime)
+	log.Info("Running Arbitrum nitro node", "name", nodeConfig.Node.Name, "revision", vcsRevision, "vcs.time", vcsTime)
 
 	if nodeConfig.Node.Dangerous.NoL1Listener {
 		nodeConfig.Node.L1Reader.Enable = false
@@ -163,20 +188,10 @@ func mainImpl() int {
 	} else {
 		nodeConfig.Node.L1Reader.Enable = true
 	}
-
-	if nodeConfig.Node.Sequencer.Enable {
-		if nodeConfig.Node.ForwardingTarget() != "" {
-			flag.Usage()
-			log.Crit("forwarding-target cannot be set when sequencer is enabled")
-		}
-		if nodeConfig.Node.L1Reader.Enable && nodeConfig.Node.InboxReader.HardReorg {
-			flag.Usage()
-			log.Crit("hard reorgs cannot safely be enabled with sequencer mode enabled")
-		}
-	} else if nodeConfig.Node.ForwardingTargetImpl == "" {
-		flag.Usage()
-		log.Crit("forwarding-target unset, and not sequencer (can set to \"null\" to disable forwarding)")
+	if benchConfig != nil {
+		benchConfig.Apply(nodeConfig)
 	}
+	nodeConfig.Node.WarnUnrecommended()
 
 	var l1TransactionOpts *bind.TransactOpts
 	var dataSigner signature.DataSignerFunc
@@ -215,7 +230,7 @@ func mainImpl() int {
 		}
 	}
 
-	if (nodeConfig.Node.BlockValidator.Enable || validatorCanAct) && !nodeConfig.Node.Caching.Archive {
+	if (nodeConfig.Node.BlockValidator.Enable || validatorCanAct || benchConfig != nil) && !nodeConfig.Node.Caching.Archive {
 		flag.Usage()
 		log.Crit("validator requires --node.caching.archive")
 	}
@@ -255,6 +270,14 @@ func mainImpl() int {
 	if nodeConfig.WS.ExposeAll {
 		stackConf.WSModules = append(stackConf.WSModules, "personal")
 	}
+	var rpcFrontEndpoints []rpcFrontEndpoint
+	if needsRPCFront(nodeConfig) {
+		var err error
+		rpcFrontEndpoints, err = moveRPCBehindFront(&stackConf, nodeConfig)
+		if err != nil {
+			log.Crit("failed to configure JSON-RPC front servers", "err", err)
+		}
+	}
 	stackConf.P2P.ListenAddr = ""
 	stackConf.P2P.NoDial = true
 	stackConf.P2P.NoDiscovery = true
@@ -302,13 +325,20 @@ func mainImpl() int {
 
 	if nodeConfig.Metrics {
 		go metrics.CollectProcessMetrics(nodeConfig.MetricsServer.UpdateInterval)
+		// geth metrics don't support labels, so the node name is exposed as a constant metric
+		metrics.NewRegisteredGauge("arb/node/name/"+nodeConfig.Node.Name, nil).Update(1)
 
 		if nodeConfig.MetricsServer.Addr != "" {
 			address := fmt.Sprintf("%v:%v", nodeConfig.MetricsServer.Addr, nodeConfig.MetricsServer.Port)
+			labels, err := nodeConfig.MetricsServer.ParseLabels()
+			if err != nil {
+				log.Error("invalid metrics labels", "err", err)
+				return 1
+			}
 			if nodeConfig.MetricsServer.Pprof {
-				startPprof(address)
+				startPprof(address, labels)
 			} else {
-				exp.Setup(address)
+				util.StartMetricsServer(address, labels)
 			}
 		}
 	} else if nodeConfig.MetricsServer.Pprof {
@@ -317,6 +347,17 @@ func mainImpl() int {
 		return 1
 	}
 
+	var l1Interface arbutil.L1Interface = l1Client
+	if l1Client != nil && nodeConfig.L1.GasOracle.URL != "" {
+		gasOracleClient, err := gasoracle.NewClient(ctx, l1Client, &nodeConfig.L1.GasOracle)
+		if err != nil {
+			log.Error("failed to connect to gas oracle", "err", err)
+			return 1
+		}
+		defer gasOracleClient.Close()
+		l1Interface = gasOracleClient
+	}
+
 	fatalErrChan := make(chan error, 10)
 	currentNode, err := arbnode.CreateNode(
 		ctx,
@@ -325,7 +366,7 @@ func mainImpl() int {
 		arbDb,
 		&NodeConfigFetcher{liveNodeConfig},
 		l2BlockChain,
-		l1Client,
+		l1Interface,
 		&rollupAddrs,
 		l1TransactionOpts,
 		dataSigner,
@@ -335,9 +376,27 @@ func mainImpl() int {
 		log.Error("failed to create node", "err", err)
 		return 1
 	}
+	if benchConfig != nil {
+		// the node isn't started, its components are only used to validate blocks
+		var rollupL1 arbutil.L1Interface
+		if nodeConfig.Node.L1Reader.Enable {
+			rollupL1 = l1Interface
+		}
+		return benchValidator(ctx, currentNode, l2BlockChain, nodeConfig.Node.BlockValidator.CurrentModuleRoot, rollupL1, rollupAddrs.Rollup, benchConfig)
+	}
 	liveNodeConfig.setOnReloadHook(func(old *NodeConfig, new *NodeConfig) error {
 		return currentNode.OnConfigReload(&old.Node, &new.Node)
 	})
+	if currentNode.Staker != nil {
+		liveNodeConfig.setChallengeCheck(currentNode.Staker.InChallenge)
+	}
+	// changing the config is left to operators who enable the admin namespace, not every nitro_ caller
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "nitroadmin",
+		Version:   "1.0",
+		Service:   &ConfigAPI{liveNodeConfig},
+		Public:    false,
+	}})
 
 	if nodeConfig.Node.Dangerous.NoL1Listener && nodeConfig.Init.DevInit {
 		// If we don't have any messages, we're not connected to the L1, and we're using a dev init,
@@ -366,6 +425,47 @@ func mainImpl() int {
 		fatalErrChan <- fmt.Errorf("error starting node: %w", err)
 	}
 
+	var syncStatus *cachedSyncStatus
+	if nodeConfig.HTTP.SyncingHeader {
+		syncStatus = &cachedSyncStatus{synced: currentNode.SyncMonitor.Synced}
+	}
+	var slowRequests *slowRequestLogger
+	if nodeConfig.Node.RPC.SlowRequestThreshold > 0 {
+		slowRequests = newSlowRequestLogger(func() time.Duration { return liveNodeConfig.get().Node.RPC.SlowRequestThreshold })
+	}
+	var traces *traceLimiter
+	if rpcConfig := &nodeConfig.Node.RPC; rpcConfig.MaxConcurrentTraces > 0 || rpcConfig.TraceTimeout > 0 || rpcConfig.TraceMaxSteps > 0 {
+		traces = newTraceLimiter(
+			rpcConfig.MaxConcurrentTraces,
+			rpcConfig.TraceQueueSize,
+			func() time.Duration { return liveNodeConfig.get().Node.RPC.TraceTimeout },
+			func() uint64 { return liveNodeConfig.get().Node.RPC.TraceMaxSteps },
+		)
+	}
+	rpcFrontServers, err := startRPCFront(stack, &nodeConfig.HTTP, rpcFrontEndpoints, syncStatus, slowRequests, traces)
+	if err != nil {
+		log.Error("failed to start JSON-RPC front servers", "err", err)
+		for _, server := range rpcFrontServers {
+			_ = server.Close()
+		}
+		currentNode.StopAndWait()
+		return 1
+	}
+	for _, server := range rpcFrontServers {
+		server := server
+		currentNode.OnStopRPC(func() { _ = server.Close() })
+	}
+
+	if nodeConfig.HTTP.UnixSocket != "" {
+		unixSocketServer, err := startUnixSocketRPC(stack, &nodeConfig.HTTP)
+		if err != nil {
+			log.Error("failed to start unix socket JSON-RPC server", "err", err)
+			currentNode.StopAndWait()
+			return 1
+		}
+		currentNode.OnStopRPC(func() { _ = unixSocketServer.Close() })
+	}
+
 	sigint := make(chan os.Signal, 1)
 	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
 
@@ -382,6 +482,7 @@ func mainImpl() int {
 	// cause future ctrl+c's to panic
 	close(sigint)
 
+	// the front and unix socket servers are closed in the rpc shutdown phase
 	currentNode.StopAndWait()
 
 	return exitCode
@@ -482,6 +583,40 @@ func (c *NodeConfig) CanReload(new *NodeConfig) error {
 }
 
 func (c *NodeConfig) Validate() error {
+	// the l1 reader is always enabled at startup unless the L1 listener is disabled
+	l1ReaderEnabled := !c.Node.Dangerous.NoL1Listener
+	if c.Node.Sequencer.Enable {
+		if c.Node.ForwardingTarget() != "" {
+			return errors.New("forwarding-target cannot be set when sequencer is enabled")
+		}
+		if l1ReaderEnabled && c.Node.InboxReader.HardReorg {
+			return errors.New("hard reorgs cannot safely be enabled with sequencer mode enabled")
+		}
+	} else if c.Node.ForwardingTargetImpl == "" {
+		return errors.New("forwarding-target unset, and not sequencer (can set to \"null\" to disable forwarding)")
+	}
+	if c.Node.Validator.Enable && !l1ReaderEnabled {
+		return errors.New("validator cannot be enabled with node.dangerous.no-l1-listener")
+	}
+	if err := c.L1.Validate(); err != nil {
+		return err
+	}
+	if err := c.Conf.Validate(); err != nil {
+		return err
+	}
+	if err := c.MetricsServer.Validate(); err != nil {
+		return err
+	}
+	if err := c.HTTP.TLS.Validate(); err != nil {
+		return err
+	}
+	if err := c.WS.OriginSubscriptions.Validate(); err != nil {
+		return err
+	}
+	daConfig := &c.Node.DataAvailability
+	if !daConfig.Enable && (daConfig.AggregatorConfig.Enable || daConfig.RestfulClientAggregatorConfig.Enable) {
+		return errors.New("data availability aggregators cannot be enabled without node.data-availability.enable")
+	}
 	return c.Node.Validate()
 }
 
@@ -504,8 +639,17 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 		if maxConnectionAttempts <= 0 {
 			maxConnectionAttempts = math.MaxInt
 		}
+		l1ClientConfig := conf.L1ClientConfig{
+			MaxIdleConns:    k.Int("l1.client.max-idle-conns"),
+			MaxConnsPerHost: k.Int("l1.client.max-conns-per-host"),
+			HTTP2:           k.Bool("l1.client.http2"),
+			RequestTimeout:  k.Duration("l1.client.request-timeout"),
+		}
+		if err := l1ClientConfig.Validate(); err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
 		for i := 1; i <= maxConnectionAttempts; i++ {
-			l1Client, err = ethclient.DialContext(ctx, l1URL)
+			l1Client, err = dialL1(ctx, l1URL, &l1ClientConfig)
 			if err == nil {
 				l1ChainId, err = l1Client.ChainID(ctx)
 				if err == nil {
@@ -600,6 +744,11 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 		return nil, nil, nil, nil, nil, err
 	}
 
+	err = confighelpers.ApplyDeprecatedOptions(f, k, deprecatedOptions)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
 	var nodeConfig NodeConfig
 	if err := confighelpers.EndCommonParse(k, &nodeConfig); err != nil {
 		return nil, nil, nil, nil, nil, err
@@ -631,6 +780,13 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 		return nil, nil, nil, nil, nil, err
 	}
 
+	if nodeConfig.Node.Name == "" {
+		hostname, err := os.Hostname()
+		if err == nil {
+			nodeConfig.Node.Name = hostname
+		}
+	}
+
 	// Don't pass around wallet contents with normal configuration
 	l1Wallet := nodeConfig.L1.Wallet
 	l2DevWallet := nodeConfig.L2.DevWallet
@@ -644,6 +800,11 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 	return &nodeConfig, &l1Wallet, &l2DevWallet, l1Client, l1ChainId, nil
 }
 
+// deprecatedOptions maps deprecated options to the options replacing them
+var deprecatedOptions = map[string]string{
+	"node.archive": "node.caching.archive",
+}
+
 func applyArbitrumOneParameters(k *koanf.Koanf) error {
 	return k.Load(confmap.Provider(map[string]interface{}{
 		"persistent.chain":                   "arb1",
@@ -721,6 +882,9 @@ func applyArbitrumAnytrustGoerliTestnetParameters(k *koanf.Koanf) error {
 
 type OnReloadHook func(old *NodeConfig, new *NodeConfig) error
 
+// how often to check whether the challenge holding back a reload has resolved
+const deferredReloadCheckInterval = time.Second
+
 func noopOnReloadHook(_ *NodeConfig, _ *NodeConfig) error {
 	return nil
 }
@@ -732,6 +896,9 @@ type LiveNodeConfig struct {
 	args         []string
 	config       *NodeConfig
 	onReloadHook OnReloadHook
+	inChallenge  func() bool
+	// a reload whose block validator options are held back until the challenge resolves
+	deferredReload *NodeConfig
 }
 
 func (c *LiveNodeConfig) get() *NodeConfig {
@@ -743,13 +910,23 @@ func (c *LiveNodeConfig) get() *NodeConfig {
 func (c *LiveNodeConfig) set(config *NodeConfig) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	return c.setLocked(config, true)
+}
 
+func (c *LiveNodeConfig) setLocked(config *NodeConfig, backup bool) error {
 	if err := c.config.CanReload(config); err != nil {
 		return err
 	}
-	if err := initLog(config.LogType, log.Lvl(config.LogLevel)); err != nil {
+	if err := initLog(config.LogType, log.Lvl(config.LogLevel), config.Node.Name); err != nil {
 		return err
 	}
+	config = c.deferValidatorReload(config)
+	if backup && c.config.Conf.BackupDir != "" && !reflect.DeepEqual(c.config, config) {
+		if err := writeConfigBackup(&c.config.Conf, c.config, time.Now()); err != nil {
+			log.Error("failed to back up config before reloading", "dir", c.config.Conf.BackupDir, "err", err)
+		}
+	}
+	config.Node.WarnUnrecommended()
 	if err := c.onReloadHook(c.config, config); err != nil {
 		// TODO(magic) panic? return err? only log the error?
 		log.Error("Failed to execute onReloadHook", "err", err)
@@ -758,9 +935,61 @@ func (c *LiveNodeConfig) set(config *NodeConfig) error {
 	return nil
 }
 
+// deferValidatorReload returns the part of a reload to apply now, holding back changes to the block
+// validator options while the validator is in a challenge. A later reload replaces the deferred
+// options, and one with the live options drops them.
+func (c *LiveNodeConfig) deferValidatorReload(config *NodeConfig) *NodeConfig {
+	if reflect.DeepEqual(c.config.Node.BlockValidator, config.Node.BlockValidator) {
+		if c.deferredReload != nil {
+			log.Info("reload reverted the deferred block validator options, dropping them")
+			c.deferredReload = nil
+		}
+		return config
+	}
+	if !config.Conf.DeferValidatorReloads || c.inChallenge == nil || !c.inChallenge() {
+		c.deferredReload = nil
+		return config
+	}
+	if c.deferredReload == nil {
+		log.Warn("validator in a challenge, deferring reload of block validator options until it resolves")
+	} else {
+		log.Warn("validator in a challenge, replacing the deferred reload of block validator options")
+	}
+	c.deferredReload = config
+	applied := *config
+	applied.Node.BlockValidator = c.config.Node.BlockValidator
+	return &applied
+}
+
+// applyDeferredReload applies the block validator options deferred during a challenge, once it's over
+func (c *LiveNodeConfig) applyDeferredReload() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.deferredReload == nil || (c.inChallenge != nil && c.inChallenge()) {
+		return
+	}
+	config := *c.config
+	config.Node.BlockValidator = c.deferredReload.Node.BlockValidator
+	c.deferredReload = nil
+	if err := config.Validate(); err != nil {
+		log.Error("deferred block validator options are no longer valid", "err", err)
+		return
+	}
+	if err := c.setLocked(&config, true); err != nil {
+		log.Error("error applying deferred block validator options", "err", err)
+		return
+	}
+	log.Info("challenge resolved, applied deferred block validator options")
+}
+
 func (c *LiveNodeConfig) Start(ctxIn context.Context) {
 	c.StopWaiter.Start(ctxIn, c)
 
+	c.CallIteratively(func(ctx context.Context) time.Duration {
+		c.applyDeferredReload()
+		return deferredReloadCheckInterval
+	})
+
 	sigusr1 := make(chan os.Signal, 1)
 	signal.Notify(sigusr1, syscall.SIGUSR1)
 
@@ -805,6 +1034,11 @@ func (c *LiveNodeConfig) setOnReloadHook(hook OnReloadHook) {
 	c.onReloadHook = hook
 }
 
+// setChallengeCheck is NOT thread-safe, inChallenge reports whether the validator is in a challenge
+func (c *LiveNodeConfig) setChallengeCheck(inChallenge func() bool) {
+	c.inChallenge = inChallenge
+}
+
 func NewLiveNodeConfig(args []string, config *NodeConfig) *LiveNodeConfig {
 	return &LiveNodeConfig{
 		args:         args,
@@ -821,8 +1055,8 @@ func (f *NodeConfigFetcher) Get() *arbnode.Config {
 	return &f.LiveNodeConfig.get().Node
 }
 
-func startPprof(address string) {
-	exp.Exp(metrics.DefaultRegistry)
+func startPprof(address string, labels map[string]string) {
+	util.RegisterMetricsHandlers(http.DefaultServeMux, labels)
 	log.Info("Starting metrics server with pprof", "addr", fmt.Sprintf("http://%s/debug/metrics", address))
 	log.Info("Pprof endpoint", "addr", fmt.Sprintf("http://%s/debug/pprof", address))
 	go func() {
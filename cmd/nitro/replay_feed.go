@@ -0,0 +1,136 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+type ReplayFeedConfig struct {
+	From     string                              `koanf:"from"`
+	Output   wsbroadcastserver.BroadcasterConfig `koanf:"output"`
+	ChainId  uint64                              `koanf:"chain-id"`
+	RealTime bool                                `koanf:"real-time"`
+	LogLevel int                                 `koanf:"log-level"`
+	LogType  string                              `koanf:"log-type"`
+}
+
+var ReplayFeedConfigDefault = ReplayFeedConfig{
+	From:     "",
+	Output:   wsbroadcastserver.DefaultBroadcasterConfig,
+	ChainId:  0,
+	RealTime: false,
+	LogLevel: NodeConfigDefault.LogLevel,
+	LogType:  NodeConfigDefault.LogType,
+}
+
+func replayFeedConfigAddOptions(f *flag.FlagSet) {
+	f.String("from", ReplayFeedConfigDefault.From, "feed archive file, or node.feed.output.archive.dir directory, to replay")
+	wsbroadcastserver.BroadcasterConfigAddOptions("output", f)
+	f.Uint64("chain-id", ReplayFeedConfigDefault.ChainId, "L2 chain id to tell feed clients, which check it against their own")
+	f.Bool("real-time", ReplayFeedConfigDefault.RealTime, "broadcast the messages as far apart as they were originally, instead of all at once")
+	f.Int("log-level", ReplayFeedConfigDefault.LogLevel, "log level")
+	f.String("log-type", ReplayFeedConfigDefault.LogType, "log type (plaintext or json)")
+}
+
+// replayArchivedMessage broadcasts an archived message again, returning how many feed messages it had
+func replayArchivedMessage(feed *broadcaster.Broadcaster, archived *wsbroadcastserver.ArchivedMessage) (int, error) {
+	var message broadcaster.BroadcastMessage
+	if err := json.Unmarshal(archived.Message, &message); err != nil {
+		return 0, err
+	}
+	for _, feedMessage := range message.Messages {
+		// the original signature is kept
+		feed.BroadcastSingleFeedMessage(feedMessage)
+	}
+	if message.ConfirmedSequenceNumberMessage != nil {
+		feed.Confirm(message.ConfirmedSequenceNumberMessage.SequenceNumber)
+	}
+	return len(message.Messages), nil
+}
+
+func replayFeed(args []string) int {
+	config := ReplayFeedConfigDefault
+	f := flag.NewFlagSet("replay-feed", flag.ContinueOnError)
+	replayFeedConfigAddOptions(f)
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err == nil {
+		err = confighelpers.EndCommonParse(k, &config)
+	}
+	if err == nil && config.From == "" {
+		err = errors.New("--from not specified")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing replay-feed options: %v\n", err)
+		return 1
+	}
+	if err := initLog(config.LogType, log.Lvl(config.LogLevel), ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logging: %v\n", err)
+		return 1
+	}
+	config.Output.Enable = true
+	// don't archive the archive again
+	config.Output.Archive.Dir = ""
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	feedErrChan := make(chan error, 10)
+	feed := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config.Output }, config.ChainId, feedErrChan, nil)
+	if err := feed.Initialize(); err != nil {
+		log.Error("failed to initialize feed", "err", err)
+		return 1
+	}
+	if err := feed.Start(ctx); err != nil {
+		log.Error("failed to start feed", "err", err)
+		return 1
+	}
+	defer feed.StopAndWait()
+	log.Info("replaying feed archive", "from", config.From, "addr", feed.ListenerAddr())
+
+	var lastTime time.Time
+	replayed := 0
+	err = wsbroadcastserver.ReadArchive(config.From, func(archived *wsbroadcastserver.ArchivedMessage) error {
+		if config.RealTime && !lastTime.IsZero() {
+			select {
+			case <-time.After(archived.Time.Sub(lastTime)):
+			case <-sigint:
+				return errors.New("interrupted")
+			}
+		}
+		lastTime = archived.Time
+		count, err := replayArchivedMessage(feed, archived)
+		replayed += count
+		return err
+	})
+	if err != nil {
+		log.Error("failed to replay feed archive", "from", config.From, "replayed", replayed, "err", err)
+		return 1
+	}
+	log.Info("replayed feed archive, serving it until stopped", "messages", replayed, "cached", feed.GetCachedMessageCount())
+
+	select {
+	case <-sigint:
+		log.Info("shutting down because of sigint")
+	case err := <-feedErrChan:
+		log.Error("feed error, exiting", "err", err)
+		return 1
+	}
+	return 0
+}
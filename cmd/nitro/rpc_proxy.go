@@ -0,0 +1,345 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+// rpcAllowlist holds the hosts and origins accepted by one of the RPC servers.
+// Entries may be "*" to accept anything or "*.example.com" to accept any subdomain.
+type rpcAllowlist struct {
+	name    string
+	vhosts  []string
+	origins []string
+}
+
+func matchesAllowlist(value string, allowed []string) bool {
+	value = strings.ToLower(value)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == value {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(value, pattern[1:]) {
+			return true
+		}
+		// origins such as "https://*.example.com"
+		if i := strings.Index(pattern, "://*."); i >= 0 && strings.HasPrefix(value, pattern[:i+3]) && strings.HasSuffix(value, pattern[i+4:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSubdomainWildcard(allowed []string) bool {
+	for _, pattern := range allowed {
+		if strings.Contains(pattern, "*.") {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowed mirrors geth's vhost check, which doesn't apply to requests addressed by IP
+func (a *rpcAllowlist) hostAllowed(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if host == "" || net.ParseIP(host) != nil {
+		return true
+	}
+	return matchesAllowlist(host, a.vhosts)
+}
+
+func (a *rpcAllowlist) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(a.origins) == 0 || matchesAllowlist(origin, a.origins) {
+		return true
+	}
+	// entries without a port accept the origin on any port
+	if parsed, err := url.Parse(origin); err == nil && parsed.Port() != "" {
+		return matchesAllowlist(parsed.Scheme+"://"+parsed.Hostname(), a.origins)
+	}
+	return false
+}
+
+// check rejects requests the allowlist doesn't accept, logging why so misconfigurations are visible
+func (a *rpcAllowlist) check(w http.ResponseWriter, r *http.Request) bool {
+	if len(a.vhosts) > 0 && !a.hostAllowed(r) {
+		log.Warn("rejected RPC request from host not in vhosts", "server", a.name, "host", r.Host, "remote", r.RemoteAddr)
+		http.Error(w, "invalid host specified", http.StatusForbidden)
+		return false
+	}
+	if !a.originAllowed(r) {
+		// browsers enforce CORS, but log the origin to make a missing allowlist entry obvious
+		log.Warn("RPC request from origin not in allowlist", "server", a.name, "origin", r.Header.Get("Origin"), "remote", r.RemoteAddr)
+		if isWebsocketRequest(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+func isWebsocketRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// rpcFrontEndpoint is a configured address served in front of a geth RPC server rebound to loopback
+type rpcFrontEndpoint struct {
+	addr      string
+	ws        bool
+	httpAllow *rpcAllowlist
+	wsAllow   *rpcAllowlist
+	// websocket connections are relayed frame by frame when either is set, see relayWebsocket
+	maxSubscriptionBuffer int
+	originLimits          *originSubscriptionLimits
+	// handles HTTP responses to requests for pruned state when set
+	missingState *missingStateHandler
+}
+
+func (e *rpcFrontEndpoint) relaysWebsocket() bool {
+	return e.maxSubscriptionBuffer > 0 || e.originLimits != nil
+}
+
+func (e *rpcFrontEndpoint) allow(w http.ResponseWriter, r *http.Request) bool {
+	if isWebsocketRequest(r) {
+		if e.wsAllow == nil {
+			http.Error(w, "websocket not enabled", http.StatusBadRequest)
+			return false
+		}
+		return e.wsAllow.check(w, r)
+	}
+	if e.httpAllow == nil {
+		http.Error(w, "only websocket connections are accepted", http.StatusBadRequest)
+		return false
+	}
+	return e.httpAllow.check(w, r)
+}
+
+// needsRPCFront reports whether the geth RPC servers must be fronted to apply options geth doesn't support
+func needsRPCFront(config *NodeConfig) bool {
+	return config.HTTP.TLS.Enabled() ||
+		config.HTTP.SyncingHeader ||
+		config.HTTP.StateErrors ||
+		config.Node.RPC.ArchiveFallback.URL != "" ||
+		config.Node.RPC.SlowRequestThreshold > 0 ||
+		config.Node.RPC.MaxConcurrentTraces > 0 ||
+		config.Node.RPC.TraceTimeout > 0 ||
+		config.Node.RPC.TraceMaxSteps > 0 ||
+		len(config.WS.VHosts) > 0 ||
+		hasSubdomainWildcard(config.HTTP.VHosts) ||
+		hasSubdomainWildcard(config.WS.Origins) ||
+		config.WS.MaxSubscriptionBuffer > 0 ||
+		config.WS.OriginSubscriptions.Enable
+}
+
+// moveRPCBehindFront rebinds the geth HTTP and WS servers to ephemeral loopback ports,
+// returning the configured addresses to serve in front of them. The allowlists are then
+// enforced by the front servers, so geth is configured to accept any host and origin.
+func moveRPCBehindFront(stackConf *node.Config, config *NodeConfig) ([]rpcFrontEndpoint, error) {
+	httpAllow := &rpcAllowlist{name: "http", vhosts: config.HTTP.VHosts, origins: config.HTTP.CORSDomain}
+	wsOrigins := config.WS.Origins
+	if len(wsOrigins) == 0 {
+		// like geth, only accept websocket origins from this host by default
+		wsOrigins = []string{"http://localhost"}
+		if hostname, err := os.Hostname(); err == nil {
+			wsOrigins = append(wsOrigins, "http://"+hostname)
+		}
+	}
+	wsAllow := &rpcAllowlist{name: "ws", vhosts: config.WS.VHosts, origins: wsOrigins}
+	var originLimits *originSubscriptionLimits
+	if config.WS.OriginSubscriptions.Enable {
+		var err error
+		originLimits, err = newOriginSubscriptionLimits(&config.WS.OriginSubscriptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	missingState := &missingStateHandler{}
+	if config.HTTP.StateErrors && !config.Node.Caching.Archive {
+		missingState.retainedBlocks = config.Node.Caching.BlockCount
+	}
+	if fallback := &config.Node.RPC.ArchiveFallback; fallback.URL != "" {
+		missingState.fallback = &archiveFallback{
+			url:    fallback.URL,
+			client: &http.Client{Timeout: fallback.Timeout},
+		}
+	}
+	if missingState.retainedBlocks == 0 && missingState.fallback == nil {
+		missingState = nil
+	}
+	var endpoints []rpcFrontEndpoint
+	sharedPort := stackConf.HTTPHost != "" && stackConf.WSHost != "" && stackConf.WSPort == stackConf.HTTPPort
+	if stackConf.HTTPHost != "" {
+		endpoint := rpcFrontEndpoint{
+			addr:         net.JoinHostPort(stackConf.HTTPHost, strconv.Itoa(stackConf.HTTPPort)),
+			httpAllow:    httpAllow,
+			missingState: missingState,
+		}
+		if sharedPort {
+			endpoint.wsAllow = wsAllow
+			endpoint.maxSubscriptionBuffer = config.WS.MaxSubscriptionBuffer
+			endpoint.originLimits = originLimits
+		}
+		endpoints = append(endpoints, endpoint)
+		stackConf.HTTPHost = "127.0.0.1"
+		stackConf.HTTPPort = 0
+		stackConf.HTTPVirtualHosts = []string{"*"}
+	}
+	if stackConf.WSHost != "" {
+		if !sharedPort {
+			endpoints = append(endpoints, rpcFrontEndpoint{
+				addr:                  net.JoinHostPort(stackConf.WSHost, strconv.Itoa(stackConf.WSPort)),
+				ws:                    true,
+				wsAllow:               wsAllow,
+				maxSubscriptionBuffer: config.WS.MaxSubscriptionBuffer,
+				originLimits:          originLimits,
+			})
+		}
+		stackConf.WSHost = "127.0.0.1"
+		stackConf.WSPort = 0
+		stackConf.WSOrigins = []string{"*"}
+	}
+	if len(config.WS.VHosts) > 0 && sharedPort {
+		log.Info("ws and http share a port, ws vhosts apply to websocket upgrades and http vhosts to other requests")
+	}
+	return endpoints, nil
+}
+
+// syncingHeader is added to HTTP responses while the node is syncing
+const syncingHeader = "X-Nitro-Syncing"
+
+// syncStatusCacheTime bounds how often the sync status is recomputed for the syncing header
+const syncStatusCacheTime = time.Second
+
+// cachedSyncStatus memoizes a sync check, which can make remote calls, across requests
+type cachedSyncStatus struct {
+	synced func() bool
+
+	mutex     sync.Mutex
+	syncing   bool
+	checkedAt time.Time
+}
+
+func (c *cachedSyncStatus) Syncing() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if time.Since(c.checkedAt) >= syncStatusCacheTime {
+		c.syncing = !c.synced()
+		c.checkedAt = time.Now()
+	}
+	return c.syncing
+}
+
+// startRPCFront serves each endpoint, with TLS if http.tls is set and http.server-timeouts like geth
+// applies to its own servers, checking its allowlists and then proxying requests and websocket
+// upgrades to the loopback geth servers. With a subscription buffer limit or origin subscription
+// limits, websocket connections are relayed by relayWebsocket instead of proxied. Slow HTTP requests
+// are logged by slowRequests and HTTP trace requests limited by traces when set.
+func startRPCFront(stack *node.Node, config *genericconf.HTTPConfig, endpoints []rpcFrontEndpoint, syncStatus *cachedSyncStatus, slowRequests *slowRequestLogger, traces *traceLimiter) ([]*http.Server, error) {
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	var serverConfig *tls.Config
+	if config.TLS.Enabled() {
+		var err error
+		serverConfig, err = config.TLS.ServerConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	var servers []*http.Server
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		backend := stack.HTTPEndpoint()
+		if endpoint.ws {
+			backend = stack.WSEndpoint()
+		}
+		target, err := url.Parse("http://" + strings.TrimPrefix(strings.TrimPrefix(backend, "http://"), "ws://"))
+		if err != nil {
+			return servers, err
+		}
+		var listener net.Listener
+		if serverConfig != nil {
+			listener, err = tls.Listen("tcp", endpoint.addr, serverConfig)
+		} else {
+			listener, err = net.Listen("tcp", endpoint.addr)
+		}
+		if err != nil {
+			return servers, errors.Wrapf(err, "failed to listen on %v", endpoint.addr)
+		}
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		if endpoint.missingState != nil {
+			proxy.ModifyResponse = endpoint.missingState.modifyResponse
+		}
+		serveHTTP := proxy.ServeHTTP
+		if slowRequests != nil {
+			serveProxy := serveHTTP
+			serveHTTP = func(w http.ResponseWriter, r *http.Request) { slowRequests.serve(w, r, serveProxy) }
+		}
+		if traces != nil {
+			// slow requests are timed without the wait for a trace slot
+			serveTimed := serveHTTP
+			serveHTTP = func(w http.ResponseWriter, r *http.Request) { traces.serve(w, r, serveTimed) }
+		}
+		server := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !endpoint.allow(w, r) {
+					return
+				}
+				if syncStatus != nil && syncStatus.Syncing() {
+					w.Header().Set(syncingHeader, "true")
+				}
+				if endpoint.relaysWebsocket() && isWebsocketRequest(r) {
+					relayWebsocket(w, r, "ws://"+target.Host, endpoint.maxSubscriptionBuffer, endpoint.originLimits)
+					return
+				}
+				if endpoint.missingState != nil && !isWebsocketRequest(r) {
+					var err error
+					r, err = endpoint.missingState.keepRequestBody(r)
+					if err != nil {
+						http.Error(w, "failed to read request", http.StatusBadRequest)
+						return
+					}
+				}
+				if !isWebsocketRequest(r) {
+					serveHTTP(w, r)
+					return
+				}
+				proxy.ServeHTTP(w, r)
+			}),
+			ReadTimeout:       config.ServerTimeouts.ReadTimeout,
+			ReadHeaderTimeout: config.ServerTimeouts.ReadHeaderTimeout,
+			WriteTimeout:      config.ServerTimeouts.WriteTimeout,
+			IdleTimeout:       config.ServerTimeouts.IdleTimeout,
+			TLSConfig:         serverConfig,
+		}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("JSON-RPC front server failed", "addr", listener.Addr(), "err", err)
+			}
+		}()
+		log.Info("JSON-RPC front server started", "addr", listener.Addr(), "tls", serverConfig != nil, "ws", endpoint.ws)
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
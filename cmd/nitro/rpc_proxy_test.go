@@ -0,0 +1,40 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRPCAllowlist(t *testing.T) {
+	allowlist := &rpcAllowlist{
+		name:    "test",
+		vhosts:  []string{"localhost", "*.example.com"},
+		origins: []string{"http://localhost", "https://*.example.com"},
+	}
+	for _, test := range []struct {
+		host    string
+		origin  string
+		allowed bool
+	}{
+		{"localhost:8547", "", true},
+		{"rpc.example.com", "https://app.example.com", true},
+		{"127.0.0.1:8547", "http://localhost:3000", true},
+		{"example.com", "", false},
+		{"rpc.example.org", "", false},
+		{"localhost", "https://example.com.evil.org", false},
+		{"localhost", "http://app.example.com", false},
+	} {
+		request := httptest.NewRequest("GET", "http://"+test.host+"/", nil)
+		request.Header.Set("Upgrade", "websocket")
+		if test.origin != "" {
+			request.Header.Set("Origin", test.origin)
+		}
+		recorder := httptest.NewRecorder()
+		if allowlist.check(recorder, request) != test.allowed {
+			Fail(t, "unexpected result for host", test.host, "origin", test.origin, "expected allowed", test.allowed)
+		}
+	}
+}
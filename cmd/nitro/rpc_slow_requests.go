@@ -0,0 +1,99 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var slowRequestCounter = metrics.NewRegisteredCounter("arb/rpc/slow_requests", nil)
+
+type slowRequest struct {
+	methods    []string
+	paramsSize int
+	duration   time.Duration
+	client     string
+}
+
+func (r *slowRequest) log() {
+	log.Warn("slow RPC request", "methods", r.methods, "paramsSize", r.paramsSize, "duration", r.duration, "client", r.client)
+}
+
+// slowRequestLogger logs the HTTP-RPC requests served by the front that take longer than
+// node.rpc.slow-request-threshold, which is read for each request so a reload can tune it
+type slowRequestLogger struct {
+	threshold func() time.Duration
+	report    func(*slowRequest)
+}
+
+func newSlowRequestLogger(threshold func() time.Duration) *slowRequestLogger {
+	return &slowRequestLogger{
+		threshold: threshold,
+		report:    (*slowRequest).log,
+	}
+}
+
+// serve passes the request to next, timing it if the threshold is set
+func (l *slowRequestLogger) serve(w http.ResponseWriter, r *http.Request, next func(http.ResponseWriter, *http.Request)) {
+	threshold := l.threshold()
+	if threshold <= 0 || r.Body == nil {
+		next(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxFallbackRequestSize+1))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+	// geth rejects requests over its size limit, which the rest of the body is still forwarded for
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	start := time.Now()
+	next(w, r)
+	duration := time.Since(start)
+	if duration <= threshold {
+		return
+	}
+	slowRequestCounter.Inc(1)
+	methods, paramsSize := requestMethods(body)
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	l.report(&slowRequest{
+		methods:    methods,
+		paramsSize: paramsSize,
+		duration:   duration,
+		client:     client,
+	})
+}
+
+// requestMethods returns the methods called by a JSON-RPC request or batch, and the total size of
+// their params, or nothing if it doesn't parse
+func requestMethods(body []byte) ([]string, int) {
+	requests, _, err := parseJSONRPC(body)
+	if err != nil {
+		return nil, 0
+	}
+	var methods []string
+	paramsSize := 0
+	for _, request := range requests {
+		var method string
+		if err := json.Unmarshal(request["method"], &method); err == nil {
+			methods = append(methods, method)
+		}
+		paramsSize += len(request["params"])
+	}
+	return methods, paramsSize
+}
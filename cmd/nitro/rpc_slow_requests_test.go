@@ -0,0 +1,53 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowRequestLogger(t *testing.T) {
+	threshold := time.Duration(0)
+	var reported []*slowRequest
+	logger := newSlowRequestLogger(func() time.Duration { return threshold })
+	logger.report = func(request *slowRequest) { reported = append(reported, request) }
+
+	request := `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_getLogs","params":[{"fromBlock":"0x0"}]}]`
+	serve := func(delay time.Duration) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(request))
+		r.RemoteAddr = "10.0.0.1:1234"
+		logger.serve(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			Require(t, err)
+			if string(body) != request {
+				Fail(t, "request body not forwarded intact", string(body))
+			}
+			time.Sleep(delay)
+		})
+	}
+
+	// disabled
+	serve(20 * time.Millisecond)
+	threshold = 10 * time.Millisecond
+	serve(0)
+	if len(reported) != 0 {
+		Fail(t, "reported a request that wasn't slow", reported)
+	}
+	serve(20 * time.Millisecond)
+	if len(reported) != 1 {
+		Fail(t, "expected one slow request, got", len(reported))
+	}
+	slow := reported[0]
+	if len(slow.methods) != 2 || slow.methods[0] != "eth_blockNumber" || slow.methods[1] != "eth_getLogs" {
+		Fail(t, "unexpected methods", slow.methods)
+	}
+	if slow.paramsSize != len(`[{"fromBlock":"0x0"}]`) || slow.client != "10.0.0.1" || slow.duration < threshold {
+		Fail(t, "unexpected slow request", slow)
+	}
+}
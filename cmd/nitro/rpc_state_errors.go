@@ -0,0 +1,248 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbnode"
+)
+
+var (
+	archiveFallbackRequestsCounter = metrics.NewRegisteredCounter("arb/rpc/archive_fallback/requests", nil)
+	archiveFallbackErrorsCounter   = metrics.NewRegisteredCounter("arb/rpc/archive_fallback/errors", nil)
+)
+
+var missingTrieNode = []byte("missing trie node")
+
+// the largest request body kept to forward to the archive node, like geth's own limit
+const maxFallbackRequestSize = 5 * 1024 * 1024
+
+type jsonRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type jsonRPCObject map[string]json.RawMessage
+
+func (o jsonRPCObject) id() string {
+	return string(o["id"])
+}
+
+func (o jsonRPCObject) isMissingStateError() bool {
+	raw, ok := o["error"]
+	if !ok {
+		return false
+	}
+	var rpcErr jsonRPCError
+	return json.Unmarshal(raw, &rpcErr) == nil && strings.Contains(rpcErr.Message, string(missingTrieNode))
+}
+
+// parseJSONRPC parses a JSON-RPC request or response, or a batch of them, reporting which it was
+func parseJSONRPC(body []byte) ([]jsonRPCObject, bool, error) {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []jsonRPCObject
+		err := json.Unmarshal(trimmed, &batch)
+		return batch, true, err
+	}
+	var object jsonRPCObject
+	err := json.Unmarshal(trimmed, &object)
+	return []jsonRPCObject{object}, false, err
+}
+
+func encodeJSONRPC(objects []jsonRPCObject, isBatch bool) ([]byte, error) {
+	if isBatch {
+		return json.Marshal(objects)
+	}
+	return json.Marshal(objects[0])
+}
+
+// rewriteMissingStateError replaces a response's pruned state error message, reporting whether it did
+func rewriteMissingStateError(response jsonRPCObject, message string) bool {
+	if !response.isMissingStateError() {
+		return false
+	}
+	var rpcErr jsonRPCError
+	if json.Unmarshal(response["error"], &rpcErr) != nil {
+		return false
+	}
+	rpcErr.Message = message
+	encoded, err := json.Marshal(rpcErr)
+	if err != nil {
+		return false
+	}
+	response["error"] = encoded
+	return true
+}
+
+// rewriteMissingStateErrors replaces the pruned state errors in a JSON-RPC response or batch of responses
+func rewriteMissingStateErrors(body []byte, message string) []byte {
+	responses, isBatch, err := parseJSONRPC(body)
+	if err != nil {
+		return body
+	}
+	rewritten := false
+	for _, response := range responses {
+		if rewriteMissingStateError(response, message) {
+			rewritten = true
+		}
+	}
+	if !rewritten {
+		return body
+	}
+	encoded, err := encodeJSONRPC(responses, isBatch)
+	if err != nil {
+		return body
+	}
+	return encoded
+}
+
+// archiveFallback forwards requests that failed for pruned state to an archive node
+type archiveFallback struct {
+	url    string
+	client *http.Client
+}
+
+// forward sends the requests to the archive node as a batch, returning its responses by id
+func (f *archiveFallback) forward(ctx context.Context, requests []jsonRPCObject) (map[string]jsonRPCObject, error) {
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("archive node responded with status %v", response.Status)
+	}
+	var responses []jsonRPCObject
+	if err := json.NewDecoder(response.Body).Decode(&responses); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]jsonRPCObject, len(responses))
+	for _, response := range responses {
+		byID[response.id()] = response
+	}
+	return byID, nil
+}
+
+type requestBodyKey struct{}
+
+// missingStateHandler handles the responses to HTTP-RPC requests for pruned state, first retrying
+// them against an archive node if there is one, then replacing any remaining "missing trie node"
+// errors with a standard error saying how many blocks the node retains
+type missingStateHandler struct {
+	// errors are left as geth returns them when 0
+	retainedBlocks uint64
+	// requests aren't retried when nil
+	fallback *archiveFallback
+}
+
+// keepRequestBody saves the request body to forward to the archive node
+func (h *missingStateHandler) keepRequestBody(r *http.Request) (*http.Request, error) {
+	if h.fallback == nil || r.Body == nil {
+		return r, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxFallbackRequestSize+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > maxFallbackRequestSize {
+		// geth rejects it anyway
+		return r, nil
+	}
+	return r.WithContext(context.WithValue(r.Context(), requestBodyKey{}, body)), nil
+}
+
+// retry forwards the requests whose responses are pruned state errors to the archive node,
+// replacing those responses with the archive node's
+func (h *missingStateHandler) retry(ctx context.Context, requestBody []byte, responses []jsonRPCObject) {
+	requests, _, err := parseJSONRPC(requestBody)
+	if err != nil {
+		return
+	}
+	requestsByID := make(map[string]jsonRPCObject, len(requests))
+	for _, request := range requests {
+		requestsByID[request.id()] = request
+	}
+	var retried []jsonRPCObject
+	for _, response := range responses {
+		if request, ok := requestsByID[response.id()]; ok && response.isMissingStateError() {
+			retried = append(retried, request)
+		}
+	}
+	if len(retried) == 0 {
+		return
+	}
+	archiveFallbackRequestsCounter.Inc(int64(len(retried)))
+	archiveResponses, err := h.fallback.forward(ctx, retried)
+	if err != nil {
+		archiveFallbackErrorsCounter.Inc(1)
+		log.Warn("failed to forward pruned state requests to archive node", "url", h.fallback.url, "requests", len(retried), "err", err)
+		return
+	}
+	for i, response := range responses {
+		if archiveResponse, ok := archiveResponses[response.id()]; ok && response.isMissingStateError() {
+			responses[i] = archiveResponse
+		}
+	}
+}
+
+// modifyResponse is the reverse proxy's response modifier
+func (h *missingStateHandler) modifyResponse(response *http.Response) error {
+	if response.StatusCode == http.StatusSwitchingProtocols ||
+		response.Header.Get("Content-Encoding") != "" ||
+		!strings.HasPrefix(response.Header.Get("Content-Type"), "application/json") {
+		return nil
+	}
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(body, missingTrieNode) {
+		body = h.handle(response.Request.Context(), body)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+func (h *missingStateHandler) handle(ctx context.Context, body []byte) []byte {
+	if requestBody, ok := ctx.Value(requestBodyKey{}).([]byte); ok && h.fallback != nil {
+		responses, isBatch, err := parseJSONRPC(body)
+		if err == nil {
+			h.retry(ctx, requestBody, responses)
+			if encoded, err := encodeJSONRPC(responses, isBatch); err == nil {
+				body = encoded
+			}
+		}
+	}
+	if h.retainedBlocks > 0 && bytes.Contains(body, missingTrieNode) {
+		body = rewriteMissingStateErrors(body, arbnode.StateNotAvailableError(h.retainedBlocks).Error())
+	}
+	return body
+}
@@ -0,0 +1,83 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRewriteMissingStateErrors(t *testing.T) {
+	message := "state not available, node retains last 128 blocks"
+	single := `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"missing trie node 1234 (path )"}}`
+	rewritten := rewriteMissingStateErrors([]byte(single), message)
+	var response struct {
+		ID    int          `json:"id"`
+		Error jsonRPCError `json:"error"`
+	}
+	Require(t, json.Unmarshal(rewritten, &response))
+	if response.ID != 1 || response.Error.Code != -32000 || response.Error.Message != message {
+		Fail(t, "unexpected rewritten response", string(rewritten))
+	}
+
+	batch := `[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"missing trie node abcd"}}]`
+	var responses []struct {
+		Result string        `json:"result"`
+		Error  *jsonRPCError `json:"error"`
+	}
+	Require(t, json.Unmarshal(rewriteMissingStateErrors([]byte(batch), message), &responses))
+	if len(responses) != 2 || responses[0].Result != "0x1" || responses[1].Error == nil || responses[1].Error.Message != message {
+		Fail(t, "unexpected rewritten batch", responses)
+	}
+
+	// other errors, including ones only mentioning trie nodes in a result, are left alone
+	other := `{"jsonrpc":"2.0","id":1,"result":"missing trie node"}`
+	if string(rewriteMissingStateErrors([]byte(other), message)) != other {
+		Fail(t, "rewrote a response without a missing state error")
+	}
+}
+
+func TestArchiveFallback(t *testing.T) {
+	var forwarded []jsonRPCObject
+	archive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		Require(t, err)
+		Require(t, json.Unmarshal(body, &forwarded))
+		_, _ = w.Write([]byte(`[{"jsonrpc":"2.0","id":2,"result":"0xarchive"}]`))
+	}))
+	defer archive.Close()
+	handler := &missingStateHandler{
+		retainedBlocks: 128,
+		fallback:       &archiveFallback{url: archive.URL, client: archive.Client()},
+	}
+
+	request := `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_getBalance","params":["0x0","0x1"]}]`
+	response := `[{"jsonrpc":"2.0","id":1,"result":"0x100"},{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"missing trie node abcd"}}]`
+	ctx := context.WithValue(context.Background(), requestBodyKey{}, []byte(request))
+	var responses []struct {
+		ID     int           `json:"id"`
+		Result string        `json:"result"`
+		Error  *jsonRPCError `json:"error"`
+	}
+	Require(t, json.Unmarshal(handler.handle(ctx, []byte(response)), &responses))
+	if len(forwarded) != 1 || forwarded[0].id() != "2" {
+		Fail(t, "unexpected requests forwarded to archive node", forwarded)
+	}
+	if len(responses) != 2 || responses[0].Result != "0x100" || responses[1].Result != "0xarchive" || responses[1].Error != nil {
+		Fail(t, "archive response not served", responses)
+	}
+
+	// when the archive node can't be reached the standard error is returned
+	archive.Close()
+	rewritten := handler.handle(ctx, []byte(response))
+	responses = nil
+	Require(t, json.Unmarshal(rewritten, &responses))
+	if len(responses) != 2 || responses[1].Error == nil || responses[1].Error.Message != "state not available, node retains last 128 blocks" {
+		Fail(t, "unexpected response with archive node down", string(rewritten))
+	}
+}
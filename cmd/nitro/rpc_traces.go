@@ -0,0 +1,384 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	tracesInFlightGauge   = metrics.NewRegisteredGauge("arb/rpc/traces/inflight", nil)
+	tracesQueuedGauge     = metrics.NewRegisteredGauge("arb/rpc/traces/queued", nil)
+	tracesRejectedCounter = metrics.NewRegisteredCounter("arb/rpc/traces/rejected", nil)
+)
+
+// the JSON-RPC error code geth returns for exceeded limits
+const rpcLimitExceededCode = -32005
+
+var errTraceQueueFull = errors.New("too many concurrent traces, try again later")
+
+// the timeout geth's tracers use when the trace config doesn't set one
+const gethDefaultTraceTimeout = 5 * time.Second
+
+// the index of the trace config in the params of each method returning traces
+var traceConfigParams = map[string]int{
+	"debug_traceTransaction":   1,
+	"debug_traceBlockByNumber": 1,
+	"debug_traceBlockByHash":   1,
+	"debug_traceBlock":         1,
+	"debug_traceBlockFromFile": 1,
+	"debug_traceBadBlock":      1,
+	"debug_traceCall":          2,
+}
+
+func isTraceMethod(method string) bool {
+	return strings.HasPrefix(method, "debug_trace") || strings.HasPrefix(method, "debug_standardTrace")
+}
+
+// traceLimiter runs at most node.rpc.max-concurrent-traces of the HTTP-RPC requests calling
+// debug_trace* methods at once, queueing up to node.rpc.trace-queue-size more and rejecting the rest
+// as busy. Requests without traces don't wait for trace slots.
+//
+// It also caps the trace config of each method returning traces, so geth aborts a transaction's
+// trace after node.rpc.trace-timeout, and replaces the traces of the default struct logger that
+// record more than node.rpc.trace-max-steps with an error. Both are read for each request so a
+// reload can tune them.
+type traceLimiter struct {
+	// concurrency isn't limited when nil
+	slots     chan struct{}
+	queueSize int32
+	queued    int32 // atomic
+	timeout   func() time.Duration
+	maxSteps  func() uint64
+}
+
+func newTraceLimiter(maxConcurrent int, queueSize int, timeout func() time.Duration, maxSteps func() uint64) *traceLimiter {
+	limiter := &traceLimiter{
+		queueSize: int32(queueSize),
+		timeout:   timeout,
+		maxSteps:  maxSteps,
+	}
+	if maxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, maxConcurrent)
+	}
+	return limiter
+}
+
+// serve passes the request to next once there's a trace slot for it, if it calls a trace method
+func (l *traceLimiter) serve(w http.ResponseWriter, r *http.Request, next func(http.ResponseWriter, *http.Request)) {
+	if r.Body == nil {
+		next(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxFallbackRequestSize+1))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+	// geth rejects requests over its size limit, which the rest of the body is still forwarded for
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	requests, isBatch, err := parseJSONRPC(body)
+	if err != nil || !callsTraceMethod(requests) {
+		next(w, r)
+		return
+	}
+	timeout, maxSteps := l.timeout(), l.maxSteps()
+	stepLimited := make(map[string]bool)
+	changed := false
+	for _, request := range requests {
+		requestChanged, structLogger := limitTraceConfig(request, timeout, maxSteps)
+		changed = changed || requestChanged
+		if structLogger && maxSteps > 0 {
+			stepLimited[request.id()] = true
+		}
+	}
+	if changed {
+		if body, err = encodeJSONRPC(requests, isBatch); err != nil {
+			http.Error(w, "failed to encode request", http.StatusInternalServerError)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	if err := l.acquire(r.Context()); err != nil {
+		if errors.Is(err, errTraceQueueFull) {
+			tracesRejectedCounter.Inc(1)
+			writeRPCErrors(w, requests, isBatch, rpcLimitExceededCode, err.Error())
+		}
+		return
+	}
+	defer l.release()
+	if len(stepLimited) == 0 {
+		next(w, r)
+		return
+	}
+	// the proxy decompresses the response when the client didn't ask for it compressed
+	r.Header.Del("Accept-Encoding")
+	buffered := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+	next(buffered, r)
+	response := buffered.body.Bytes()
+	if buffered.status == http.StatusOK && buffered.header.Get("Content-Encoding") == "" {
+		response = rejectLongTraces(response, stepLimited, maxSteps)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(response)))
+	w.WriteHeader(buffered.status)
+	if _, err := w.Write(response); err != nil {
+		log.Debug("failed to write JSON-RPC trace response", "err", err)
+	}
+}
+
+// acquire takes a trace slot, waiting in the queue for one if they're all taken
+func (l *traceLimiter) acquire(ctx context.Context) error {
+	if l.slots == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		tracesInFlightGauge.Inc(1)
+		return nil
+	default:
+	}
+	if atomic.AddInt32(&l.queued, 1) > l.queueSize {
+		atomic.AddInt32(&l.queued, -1)
+		return errTraceQueueFull
+	}
+	tracesQueuedGauge.Inc(1)
+	defer func() {
+		atomic.AddInt32(&l.queued, -1)
+		tracesQueuedGauge.Dec(1)
+	}()
+	select {
+	case l.slots <- struct{}{}:
+		tracesInFlightGauge.Inc(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *traceLimiter) release() {
+	if l.slots == nil {
+		return
+	}
+	<-l.slots
+	tracesInFlightGauge.Dec(1)
+}
+
+func callsTraceMethod(requests []jsonRPCObject) bool {
+	for _, request := range requests {
+		var method string
+		if err := json.Unmarshal(request["method"], &method); err == nil && isTraceMethod(method) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitTraceConfig lowers the timeout in the trace config of the request to the limit, and for the
+// default struct logger, which is the one that returns the steps it traced, lowers the number of
+// steps it records to one past maxSteps. It reports whether the request changed and whether it uses
+// the struct logger. A request geth would reject anyway is left as is.
+func limitTraceConfig(request jsonRPCObject, timeout time.Duration, maxSteps uint64) (bool, bool) {
+	var method string
+	if err := json.Unmarshal(request["method"], &method); err != nil {
+		return false, false
+	}
+	index, ok := traceConfigParams[method]
+	if !ok {
+		return false, false
+	}
+	var params []json.RawMessage
+	if len(request["params"]) > 0 {
+		if err := json.Unmarshal(request["params"], &params); err != nil {
+			return false, false
+		}
+	}
+	if len(params) < index {
+		return false, false
+	}
+	config := make(map[string]json.RawMessage)
+	if len(params) > index && string(params[index]) != "null" {
+		if err := json.Unmarshal(params[index], &config); err != nil {
+			return false, false
+		}
+	}
+	tracer, hasTracer := config["tracer"]
+	structLogger := !hasTracer || string(tracer) == "null"
+	changed := false
+	if timeout > 0 {
+		configTimeout := gethDefaultTraceTimeout
+		if raw, ok := config["timeout"]; ok {
+			var timeoutString string
+			if err := json.Unmarshal(raw, &timeoutString); err != nil {
+				return false, structLogger
+			}
+			parsed, err := time.ParseDuration(timeoutString)
+			if err != nil {
+				return false, structLogger
+			}
+			configTimeout = parsed
+		}
+		if configTimeout > timeout {
+			config["timeout"] = json.RawMessage(strconv.Quote(timeout.String()))
+			changed = true
+		}
+	}
+	if structLogger && maxSteps > 0 {
+		var limit uint64
+		if raw, ok := config["limit"]; ok {
+			if err := json.Unmarshal(raw, &limit); err != nil {
+				return false, structLogger
+			}
+		}
+		if limit == 0 || limit > maxSteps {
+			config["limit"] = json.RawMessage(strconv.FormatUint(maxSteps+1, 10))
+			changed = true
+		}
+	}
+	if !changed {
+		return false, structLogger
+	}
+	encodedConfig, err := json.Marshal(config)
+	if err != nil {
+		return false, structLogger
+	}
+	if len(params) == index {
+		params = append(params, encodedConfig)
+	} else {
+		params[index] = encodedConfig
+	}
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return false, structLogger
+	}
+	request["params"] = encodedParams
+	return true, structLogger
+}
+
+// rejectLongTraces replaces the results of the requests with ids in stepLimited that traced more
+// than maxSteps steps with an error
+func rejectLongTraces(body []byte, stepLimited map[string]bool, maxSteps uint64) []byte {
+	responses, isBatch, err := parseJSONRPC(body)
+	if err != nil {
+		return body
+	}
+	errorJSON, err := json.Marshal(jsonRPCError{
+		Code:    rpcLimitExceededCode,
+		Message: fmt.Sprintf("trace exceeded the node's limit of %v steps", maxSteps),
+	})
+	if err != nil {
+		return body
+	}
+	rejected := false
+	for _, response := range responses {
+		if stepLimited[response.id()] && traceExceedsSteps(response["result"], maxSteps) {
+			delete(response, "result")
+			response["error"] = errorJSON
+			rejected = true
+		}
+	}
+	if !rejected {
+		return body
+	}
+	encoded, err := encodeJSONRPC(responses, isBatch)
+	if err != nil {
+		return body
+	}
+	return encoded
+}
+
+// traceExceedsSteps checks the struct logs of a transaction's trace, or of each trace of a block
+func traceExceedsSteps(result json.RawMessage, maxSteps uint64) bool {
+	trimmed := bytes.TrimLeft(result, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var blockTraces []struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(trimmed, &blockTraces); err != nil {
+			return false
+		}
+		for _, trace := range blockTraces {
+			if traceExceedsSteps(trace.Result, maxSteps) {
+				return true
+			}
+		}
+		return false
+	}
+	var trace struct {
+		StructLogs []json.RawMessage `json:"structLogs"`
+	}
+	if err := json.Unmarshal(trimmed, &trace); err != nil {
+		return false
+	}
+	return uint64(len(trace.StructLogs)) > maxSteps
+}
+
+// bufferedResponse holds a response to check before it's written
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+// writeRPCErrors responds to each of the requests, except notifications, with the error
+func writeRPCErrors(w http.ResponseWriter, requests []jsonRPCObject, isBatch bool, code int, message string) {
+	errorJSON, err := json.Marshal(jsonRPCError{Code: code, Message: message})
+	if err != nil {
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	var responses []jsonRPCObject
+	for _, request := range requests {
+		if len(request["id"]) == 0 {
+			continue
+		}
+		responses = append(responses, jsonRPCObject{
+			"jsonrpc": json.RawMessage(`"2.0"`),
+			"id":      request["id"],
+			"error":   errorJSON,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	encoded, err := encodeJSONRPC(responses, isBatch)
+	if err != nil {
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	if _, err := w.Write(encoded); err != nil {
+		log.Debug("failed to write JSON-RPC error response", "err", err)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTraceLimiter(t *testing.T) {
+	limiter := newTraceLimiter(1, 1, func() time.Duration { return 0 }, func() uint64 { return 0 })
+	release := make(chan struct{})
+	started := make(chan string, 4)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		started <- r.Header.Get("X-Test")
+		if r.Header.Get("X-Test") != "call" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	serve := func(name string, request string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(request))
+		r.Header.Set("X-Test", name)
+		recorder := httptest.NewRecorder()
+		limiter.serve(recorder, r, next)
+		return recorder
+	}
+	trace := `{"jsonrpc":"2.0","id":1,"method":"debug_traceTransaction","params":["0x01"]}`
+	expectStarted := func(expected string) {
+		t.Helper()
+		select {
+		case name := <-started:
+			if name != expected {
+				Fail(t, "expected", expected, "to run, but", name, "did")
+			}
+		case <-time.After(time.Second):
+			Fail(t, expected, "didn't run")
+		}
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		serve("running", trace)
+		done <- struct{}{}
+	}()
+	expectStarted("running")
+	go func() {
+		serve("queued", trace)
+		done <- struct{}{}
+	}()
+	for i := 0; i < 100 && atomic.LoadInt32(&limiter.queued) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&limiter.queued) != 1 {
+		Fail(t, "second trace wasn't queued")
+	}
+
+	// past the queue, traces are rejected as busy, even in a batch
+	rejected := serve("rejected", `[{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"},`+trace+`]`)
+	var responses []jsonRPCObject
+	Require(t, json.Unmarshal(rejected.Body.Bytes(), &responses))
+	if len(responses) != 2 || !strings.Contains(string(responses[1]["error"]), errTraceQueueFull.Error()) {
+		Fail(t, "expected a busy error for each request, got", rejected.Body.String())
+	}
+
+	// other calls don't wait for trace slots
+	serve("call", `{"jsonrpc":"2.0","id":3,"method":"eth_call","params":[{},"latest"]}`)
+	expectStarted("call")
+
+	// the queued trace runs once the running one finishes
+	release <- struct{}{}
+	<-done
+	expectStarted("queued")
+	release <- struct{}{}
+	<-done
+	select {
+	case name := <-started:
+		Fail(t, "rejected request ran", name)
+	default:
+	}
+}
+
+func TestTraceLimiterConfigLimits(t *testing.T) {
+	limiter := newTraceLimiter(0, 0, func() time.Duration { return time.Second }, func() uint64 { return 2 })
+	serve := func(request string, response string) (jsonRPCObject, string) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(request))
+		r.Header.Set("Accept-Encoding", "gzip")
+		recorder := httptest.NewRecorder()
+		var forwarded jsonRPCObject
+		limiter.serve(recorder, r, func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			Require(t, err)
+			requests, _, err := parseJSONRPC(body)
+			Require(t, err)
+			forwarded = requests[0]
+			if r.ContentLength != int64(len(body)) {
+				Fail(t, "content length", r.ContentLength, "doesn't match the forwarded body", string(body))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, err = w.Write([]byte(response))
+			Require(t, err)
+		})
+		return forwarded, recorder.Body.String()
+	}
+	expectParams := func(forwarded jsonRPCObject, expected string) {
+		t.Helper()
+		var params, expectedParams interface{}
+		Require(t, json.Unmarshal(forwarded["params"], &params))
+		Require(t, json.Unmarshal([]byte(expected), &expectedParams))
+		if !reflect.DeepEqual(params, expectedParams) {
+			Fail(t, "forwarded params", string(forwarded["params"]), "expected", expected)
+		}
+	}
+	steps := func(count int) string {
+		return `{"gas":21000,"failed":false,"returnValue":"","structLogs":[` + strings.TrimSuffix(strings.Repeat(`{"op":"PUSH1"},`, count), ",") + `]}`
+	}
+
+	// the struct logger records one step past the limit, and longer timeouts are lowered
+	forwarded, response := serve(`{"jsonrpc":"2.0","id":1,"method":"debug_traceTransaction","params":["0x01"]}`, `{"jsonrpc":"2.0","id":1,"result":`+steps(2)+`}`)
+	expectParams(forwarded, `["0x01",{"timeout":"1s","limit":3}]`)
+	if !strings.Contains(response, `"structLogs"`) {
+		Fail(t, "trace within the step limit not returned", response)
+	}
+	forwarded, _ = serve(`{"jsonrpc":"2.0","id":1,"method":"debug_traceCall","params":[{},"latest",{"timeout":"1m","limit":1,"disableStack":true}]}`, `{"jsonrpc":"2.0","id":1,"result":`+steps(1)+`}`)
+	expectParams(forwarded, `[{},"latest",{"timeout":"1s","limit":1,"disableStack":true}]`)
+	forwarded, _ = serve(`{"jsonrpc":"2.0","id":1,"method":"debug_traceTransaction","params":["0x01",{"tracer":"callTracer","timeout":"100ms"}]}`, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+	expectParams(forwarded, `["0x01",{"tracer":"callTracer","timeout":"100ms"}]`)
+
+	// a trace with more steps fails, including a block's, while other requests in the batch succeed
+	_, response = serve(`[{"jsonrpc":"2.0","id":1,"method":"debug_traceBlockByNumber","params":["0x1"]},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"}]`,
+		`[{"jsonrpc":"2.0","id":1,"result":[{"result":`+steps(1)+`},{"result":`+steps(3)+`}]},{"jsonrpc":"2.0","id":2,"result":"0x1"}]`)
+	var responses []jsonRPCObject
+	Require(t, json.Unmarshal([]byte(response), &responses))
+	if len(responses) != 2 || !strings.Contains(string(responses[0]["error"]), "limit of 2 steps") || responses[0]["result"] != nil {
+		Fail(t, "expected the block trace to fail for its step count, got", response)
+	}
+	if string(responses[1]["result"]) != `"0x1"` {
+		Fail(t, "other request in the batch affected", response)
+	}
+}
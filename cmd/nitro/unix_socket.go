@@ -0,0 +1,59 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/pkg/errors"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+// startUnixSocketRPC serves the stack's JSON-RPC handler over HTTP on a Unix domain socket.
+// Access is controlled by the socket's file permissions, so every registered API is offered.
+func startUnixSocketRPC(stack *node.Node, config *genericconf.HTTPConfig) (*http.Server, error) {
+	mode, err := config.UnixSocketFileMode()
+	if err != nil {
+		return nil, err
+	}
+	handler, err := stack.RPCHandler()
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(config.UnixSocket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, errors.Errorf("%v already exists and is not a socket", config.UnixSocket)
+		}
+		// remove the stale socket of a previous run
+		if err := os.Remove(config.UnixSocket); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", config.UnixSocket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(config.UnixSocket, mode); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	server := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  config.ServerTimeouts.ReadTimeout,
+		WriteTimeout: config.ServerTimeouts.WriteTimeout,
+		IdleTimeout:  config.ServerTimeouts.IdleTimeout,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("unix socket JSON-RPC server failed", "err", err)
+		}
+	}()
+	log.Info("HTTP JSON-RPC server started on unix socket", "path", config.UnixSocket, "mode", mode)
+	return server, nil
+}
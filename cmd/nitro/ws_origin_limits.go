@@ -0,0 +1,259 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+var wsOriginSubscriptionLimitCounter = metrics.NewRegisteredCounter("arb/rpc/ws/origin_subscription_limit", nil)
+
+// originSubscriptionLimits counts the subscriptions open from each websocket origin across all of its connections
+type originSubscriptionLimits struct {
+	defaultLimit int
+	origins      []string
+	limits       []int
+
+	mutex  sync.Mutex
+	active map[string]int
+}
+
+func newOriginSubscriptionLimits(config *genericconf.OriginSubscriptionsConfig) (*originSubscriptionLimits, error) {
+	origins, limits, err := config.ParseLimits()
+	if err != nil {
+		return nil, err
+	}
+	return &originSubscriptionLimits{
+		defaultLimit: config.DefaultLimit,
+		origins:      origins,
+		limits:       limits,
+		active:       make(map[string]int),
+	}, nil
+}
+
+func (l *originSubscriptionLimits) limit(origin string) int {
+	for i, pattern := range l.origins {
+		if matchesAllowlist(origin, []string{pattern}) {
+			return l.limits[i]
+		}
+	}
+	return l.defaultLimit
+}
+
+// reserve counts count more subscriptions against the origin, unless that would take it past its limit
+func (l *originSubscriptionLimits) reserve(origin string, count int) (bool, int) {
+	limit := l.limit(origin)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.active[origin]+count > limit {
+		return false, limit
+	}
+	l.active[origin] += count
+	return true, limit
+}
+
+func (l *originSubscriptionLimits) release(origin string, count int) {
+	if count == 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.active[origin] -= count
+	if l.active[origin] <= 0 {
+		delete(l.active, origin)
+	}
+}
+
+// wsRPCMessage is the part of a JSON-RPC request or response needed to track subscriptions
+type wsRPCMessage struct {
+	ID     json.RawMessage   `json:"id,omitempty"`
+	Method string            `json:"method,omitempty"`
+	Params []json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage   `json:"result,omitempty"`
+	Error  json.RawMessage   `json:"error,omitempty"`
+}
+
+type wsRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type wsRPCErrorResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   wsRPCError      `json:"error"`
+}
+
+// parseRPCMessages parses a single JSON-RPC message or a batch, reporting which it was
+func parseRPCMessages(payload []byte) ([]wsRPCMessage, bool, error) {
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []wsRPCMessage
+		err := json.Unmarshal(trimmed, &batch)
+		return batch, true, err
+	}
+	var message wsRPCMessage
+	err := json.Unmarshal(trimmed, &message)
+	return []wsRPCMessage{message}, false, err
+}
+
+// wsSubscriptionTracker follows the subscriptions a single websocket connection opens and closes,
+// counting them against its origin's limit until they're unsubscribed or the connection closes
+type wsSubscriptionTracker struct {
+	limits *originSubscriptionLimits
+	origin string
+	remote string
+
+	mutex sync.Mutex
+	// subscriptions reserved for each subscribe request id still waiting for its response
+	pending map[string]int
+	active  map[string]bool
+}
+
+func newWSSubscriptionTracker(limits *originSubscriptionLimits, origin string, remote string) *wsSubscriptionTracker {
+	return &wsSubscriptionTracker{
+		limits:  limits,
+		origin:  strings.ToLower(origin),
+		remote:  remote,
+		pending: make(map[string]int),
+		active:  make(map[string]bool),
+	}
+}
+
+// onRequest tracks a message from the client, reporting whether to forward it. A message that isn't
+// forwarded is answered with the returned response, if it has any requests to answer.
+func (t *wsSubscriptionTracker) onRequest(payload []byte) ([]byte, bool) {
+	messages, isBatch, err := parseRPCMessages(payload)
+	if err != nil {
+		// geth responds to malformed requests itself
+		return nil, true
+	}
+	var subscribes []string
+	t.mutex.Lock()
+	batchIds := make(map[string]bool)
+	for _, message := range messages {
+		if !strings.HasSuffix(message.Method, "_subscribe") {
+			continue
+		}
+		id := string(message.ID)
+		if len(message.ID) == 0 || batchIds[id] || t.pending[id] > 0 {
+			// the response to a subscribe without a distinct id can't be matched to its reservation
+			t.mutex.Unlock()
+			log.Debug("rejected websocket subscribe without a distinct id", "origin", t.origin, "id", id, "remote", t.remote)
+			return rpcErrorResponse(messages, isBatch, -32600, "subscribe requests need an id distinct from the connection's pending requests"), false
+		}
+		batchIds[id] = true
+		subscribes = append(subscribes, id)
+	}
+	unsubscribed := 0
+	for _, message := range messages {
+		if strings.HasSuffix(message.Method, "_unsubscribe") && len(message.Params) > 0 {
+			var id string
+			if json.Unmarshal(message.Params[0], &id) == nil && t.active[id] {
+				delete(t.active, id)
+				unsubscribed++
+			}
+		}
+	}
+	t.mutex.Unlock()
+	t.limits.release(t.origin, unsubscribed)
+	if len(subscribes) == 0 {
+		return nil, true
+	}
+	if ok, limit := t.limits.reserve(t.origin, len(subscribes)); !ok {
+		wsOriginSubscriptionLimitCounter.Inc(1)
+		log.Warn("websocket origin reached its subscription limit", "origin", t.origin, "limit", limit, "remote", t.remote)
+		return rpcErrorResponse(messages, isBatch, -32005, "subscription limit reached for origin"), false
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, id := range subscribes {
+		t.pending[id]++
+	}
+	return nil, true
+}
+
+// rpcErrorResponse answers every request in the message with the error
+func rpcErrorResponse(messages []wsRPCMessage, isBatch bool, code int, errorMessage string) []byte {
+	var responses []wsRPCErrorResponse
+	for _, message := range messages {
+		if len(message.ID) == 0 {
+			// notifications don't get responses
+			continue
+		}
+		responses = append(responses, wsRPCErrorResponse{
+			Version: "2.0",
+			ID:      message.ID,
+			Error:   wsRPCError{Code: code, Message: errorMessage},
+		})
+	}
+	var response []byte
+	var err error
+	if isBatch && len(responses) > 0 {
+		response, err = json.Marshal(responses)
+	} else if len(responses) > 0 {
+		response, err = json.Marshal(responses[0])
+	}
+	if err != nil {
+		log.Error("failed to encode websocket error response", "err", err, "error", errorMessage)
+		return nil
+	}
+	return response
+}
+
+// onResponse tracks a message from geth, finding the results of pending subscribe requests
+func (t *wsSubscriptionTracker) onResponse(payload []byte) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.pending) == 0 {
+		// skip parsing notifications and other responses
+		return
+	}
+	messages, _, err := parseRPCMessages(payload)
+	if err != nil {
+		return
+	}
+	failed := 0
+	for _, message := range messages {
+		id := string(message.ID)
+		if len(message.ID) == 0 || t.pending[id] == 0 {
+			continue
+		}
+		t.pending[id]--
+		if t.pending[id] == 0 {
+			delete(t.pending, id)
+		}
+		var subscription string
+		if len(message.Error) > 0 || json.Unmarshal(message.Result, &subscription) != nil {
+			failed++
+			continue
+		}
+		t.active[subscription] = true
+	}
+	if failed > 0 {
+		// the limits take their own lock, which is never held while taking a tracker's
+		t.limits.release(t.origin, failed)
+	}
+}
+
+// close releases the connection's subscriptions, which geth ends along with the connection
+func (t *wsSubscriptionTracker) close() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	reserved := len(t.active)
+	for _, count := range t.pending {
+		reserved += count
+	}
+	t.limits.release(t.origin, reserved)
+	t.pending = make(map[string]int)
+	t.active = make(map[string]bool)
+}
@@ -0,0 +1,114 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+func TestOriginSubscriptionLimits(t *testing.T) {
+	limits, err := newOriginSubscriptionLimits(&genericconf.OriginSubscriptionsConfig{
+		Enable:       true,
+		DefaultLimit: 1,
+		Limits:       []string{"https://trusted.example.com=3"},
+	})
+	Require(t, err)
+	subscribe := func(id int) []byte {
+		return []byte(`{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"eth_subscribe","params":["newHeads"]}`)
+	}
+
+	trusted := newWSSubscriptionTracker(limits, "https://trusted.example.com", "")
+	for id := 1; id <= 3; id++ {
+		if response, forward := trusted.onRequest(subscribe(id)); !forward {
+			Fail(t, "trusted origin refused subscription", id, string(response))
+		}
+		trusted.onResponse([]byte(`{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"result":"0x` + strconv.Itoa(id) + `"}`))
+	}
+	if response, forward := trusted.onRequest(subscribe(4)); forward || !strings.Contains(string(response), "subscription limit") {
+		Fail(t, "trusted origin allowed past its limit")
+	}
+	// unsubscribing frees up a subscription
+	trusted.onRequest([]byte(`{"jsonrpc":"2.0","id":5,"method":"eth_unsubscribe","params":["0x1"]}`))
+	if response, forward := trusted.onRequest(subscribe(6)); !forward {
+		Fail(t, "trusted origin refused subscription after unsubscribing", string(response))
+	}
+
+	// the default limit is shared by every connection from an origin
+	first := newWSSubscriptionTracker(limits, "https://unknown.example.org", "")
+	second := newWSSubscriptionTracker(limits, "https://unknown.example.org", "")
+	if response, forward := first.onRequest(subscribe(1)); !forward {
+		Fail(t, "unknown origin refused its first subscription", string(response))
+	}
+	if _, forward := second.onRequest(subscribe(1)); forward {
+		Fail(t, "unknown origin allowed past the default limit")
+	}
+	// a failed subscribe doesn't count
+	first.onResponse([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"no such method"}}`))
+	if response, forward := second.onRequest(subscribe(2)); !forward {
+		Fail(t, "failed subscription still counted", string(response))
+	}
+	// closing the connection releases its subscriptions
+	second.close()
+	if response, forward := first.onRequest(subscribe(3)); !forward {
+		Fail(t, "closed connection's subscriptions still counted", string(response))
+	}
+	if limits.active["https://trusted.example.com"] != 3 {
+		Fail(t, "unexpected trusted origin subscription count", limits.active)
+	}
+}
+
+func TestOriginSubscriptionLimitsRequestIds(t *testing.T) {
+	limits, err := newOriginSubscriptionLimits(&genericconf.OriginSubscriptionsConfig{
+		Enable:       true,
+		DefaultLimit: 4,
+	})
+	Require(t, err)
+	origin := "https://spoofed.example.org"
+	tracker := newWSSubscriptionTracker(limits, origin, "")
+
+	// a batch with duplicate and missing ids is answered without reserving anything
+	response, forward := tracker.onRequest([]byte(`[` +
+		`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]},` +
+		`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]},` +
+		`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"]}]`))
+	if forward || strings.Count(string(response), `"id":1`) != 2 {
+		Fail(t, "batch with duplicate and missing subscribe ids wasn't answered", string(response))
+	}
+	if limits.active[origin] != 0 {
+		Fail(t, "rejected batch reserved subscriptions", limits.active)
+	}
+	// a lone subscribe without an id can't be answered, so it's dropped
+	if response, forward := tracker.onRequest([]byte(`{"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"]}`)); forward || response != nil {
+		Fail(t, "subscribe without an id wasn't dropped", string(response))
+	}
+
+	// an id still pending from an earlier message can't be reused
+	if response, forward := tracker.onRequest([]byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]},` +
+		`{"jsonrpc":"2.0","id":2,"method":"eth_subscribe","params":["logs"]}]`)); !forward {
+		Fail(t, "batch with distinct subscribe ids refused", string(response))
+	}
+	if response, forward := tracker.onRequest([]byte(`{"jsonrpc":"2.0","id":2,"method":"eth_subscribe","params":["newHeads"]}`)); forward || !strings.Contains(string(response), "distinct") {
+		Fail(t, "subscribe reusing a pending id wasn't answered", string(response))
+	}
+	if limits.active[origin] != 2 {
+		Fail(t, "unexpected origin subscription count", limits.active)
+	}
+	tracker.onResponse([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+
+	// closing the connection releases exactly what it reserved, pending or active
+	tracker.close()
+	if _, ok := limits.active[origin]; ok {
+		Fail(t, "closed connection leaked subscriptions", limits.active)
+	}
+	for id := 1; id <= 4; id++ {
+		other := newWSSubscriptionTracker(limits, origin, "")
+		if response, forward := other.onRequest([]byte(`{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"eth_subscribe","params":["newHeads"]}`)); !forward {
+			Fail(t, "origin locked out after its connection closed", string(response))
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+var wsSubscriptionOverflowCounter = metrics.NewRegisteredCounter("arb/rpc/ws/subscription_buffer_overflow", nil)
+
+// errSubscriptionBufferFull is returned once a subscription has more notifications queued than allowed
+var errSubscriptionBufferFull = errors.New("subscription buffer full")
+
+// wsMaxClientMessageSize matches the read limit of geth's websocket server, so client messages too
+// large for geth aren't buffered here first
+const wsMaxClientMessageSize = 15 * 1024 * 1024
+
+var errWSMessageTooLarge = errors.New("websocket message too large")
+
+// wsFrameConn serializes whole frames onto a connection written to by both relay directions
+type wsFrameConn struct {
+	net.Conn
+	reader io.Reader
+	state  ws.State
+	// the largest frame or message read from the connection, or 0 for no limit
+	maxMessageSize int64
+	mutex          sync.Mutex
+}
+
+func (c *wsFrameConn) writeFrame(frame ws.Frame) error {
+	if c.state.ClientSide() {
+		frame = ws.MaskFrameInPlace(frame)
+	}
+	data, err := ws.CompileFrame(frame)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, err = c.Conn.Write(data)
+	return err
+}
+
+// readMessages returns the control frames and data message at the head of the connection, failing
+// with errWSMessageTooLarge rather than reading a frame or message longer than maxMessageSize
+func (c *wsFrameConn) readMessages() ([]wsutil.Message, error) {
+	var messages []wsutil.Message
+	reader := wsutil.Reader{
+		Source:       c.reader,
+		State:        c.state,
+		CheckUTF8:    true,
+		MaxFrameSize: c.maxMessageSize,
+		OnIntermediate: func(header ws.Header, src io.Reader) error {
+			payload, err := io.ReadAll(src)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, wsutil.Message{OpCode: header.OpCode, Payload: payload})
+			return nil
+		},
+	}
+	header, err := reader.NextFrame()
+	if errors.Is(err, wsutil.ErrFrameTooLarge) {
+		return messages, errWSMessageTooLarge
+	}
+	if err != nil {
+		return messages, err
+	}
+	var src io.Reader = &reader
+	if c.maxMessageSize > 0 {
+		// a fragmented message can be longer than any of its frames
+		src = io.LimitReader(src, c.maxMessageSize+1)
+	}
+	payload, err := io.ReadAll(src)
+	if errors.Is(err, wsutil.ErrFrameTooLarge) || (c.maxMessageSize > 0 && int64(len(payload)) > c.maxMessageSize) {
+		return messages, errWSMessageTooLarge
+	}
+	if err != nil {
+		return messages, err
+	}
+	return append(messages, wsutil.Message{OpCode: header.OpCode, Payload: payload}), nil
+}
+
+// wsNotification is the part of a subscription notification needed to attribute it to its subscription
+type wsNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string `json:"subscription"`
+	} `json:"params"`
+}
+
+func subscriptionID(payload []byte) string {
+	var notification wsNotification
+	if err := json.Unmarshal(payload, &notification); err != nil || notification.Method == "" {
+		return ""
+	}
+	return notification.Params.Subscription
+}
+
+type wsQueuedMessage struct {
+	message      wsutil.Message
+	subscription string
+}
+
+// wsRelayQueue holds the messages from geth still to be written to a client, counting the
+// notifications queued for each subscription against the limit.
+type wsRelayQueue struct {
+	limit int
+
+	mutex    sync.Mutex
+	messages []wsQueuedMessage
+	pending  map[string]int
+	ready    chan struct{}
+}
+
+func newWSRelayQueue(limit int) *wsRelayQueue {
+	return &wsRelayQueue{
+		limit:   limit,
+		pending: make(map[string]int),
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+func (q *wsRelayQueue) push(message wsutil.Message) error {
+	subscription := ""
+	if message.OpCode.IsData() {
+		subscription = subscriptionID(message.Payload)
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if subscription != "" {
+		if q.limit > 0 && q.pending[subscription] >= q.limit {
+			return errSubscriptionBufferFull
+		}
+		q.pending[subscription]++
+	}
+	q.messages = append(q.messages, wsQueuedMessage{message, subscription})
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *wsRelayQueue) pop() (wsutil.Message, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.messages) == 0 {
+		return wsutil.Message{}, false
+	}
+	queued := q.messages[0]
+	q.messages[0] = wsQueuedMessage{}
+	q.messages = q.messages[1:]
+	if queued.subscription != "" {
+		q.pending[queued.subscription]--
+		if q.pending[queued.subscription] == 0 {
+			delete(q.pending, queued.subscription)
+		}
+	}
+	return queued.message, true
+}
+
+// relayWebsocket serves a websocket connection by relaying it frame by frame to the geth websocket
+// server at backend, rather than proxying raw bytes, so that notifications geth produces faster than
+// the client reads them are queued here. Once any one subscription has more than maxBuffer
+// notifications queued, the client is sent a policy violation close frame and the connection is
+// dropped, which ends all of its subscriptions in geth. Other messages, such as call responses,
+// are only produced in response to the client and aren't limited. A maxBuffer of 0 doesn't limit
+// the queue. With originLimits, subscribe requests that would take the connection's origin past its
+// limit are answered with an error rather than forwarded.
+func relayWebsocket(w http.ResponseWriter, r *http.Request, backend string, maxBuffer int, originLimits *originSubscriptionLimits) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	backendConn, backendReader, _, err := ws.Dial(ctx, backend+r.URL.RequestURI())
+	if err != nil {
+		log.Warn("failed to connect websocket to RPC server", "backend", backend, "err", err)
+		http.Error(w, "websocket backend unavailable", http.StatusBadGateway)
+		return
+	}
+	clientConn, clientBuffer, _, err := ws.UpgradeHTTP(r, w)
+	if err != nil {
+		_ = backendConn.Close()
+		log.Debug("failed to upgrade websocket connection", "remote", r.RemoteAddr, "err", err)
+		return
+	}
+	client := &wsFrameConn{Conn: clientConn, reader: clientBuffer.Reader, state: ws.StateServerSide, maxMessageSize: wsMaxClientMessageSize}
+	server := &wsFrameConn{Conn: backendConn, reader: backendConn, state: ws.StateClientSide}
+	if backendReader != nil {
+		server.reader = backendReader
+	}
+	defer func() {
+		_ = client.Close()
+		_ = server.Close()
+		if backendReader != nil {
+			ws.PutReader(backendReader)
+		}
+	}()
+
+	var tracker *wsSubscriptionTracker
+	if origin := r.Header.Get("Origin"); originLimits != nil && origin != "" {
+		tracker = newWSSubscriptionTracker(originLimits, origin, r.RemoteAddr)
+		defer tracker.close()
+	}
+
+	queue := newWSRelayQueue(maxBuffer)
+	done := make(chan struct{}, 3)
+	go func() {
+		defer func() { done <- struct{}{} }()
+		relayFrames(client, server, func(message wsutil.Message) error {
+			if tracker != nil && message.OpCode.IsData() {
+				if response, forward := tracker.onRequest(message.Payload); !forward {
+					if response == nil {
+						return nil
+					}
+					return queue.push(wsutil.Message{OpCode: ws.OpText, Payload: response})
+				}
+			}
+			return server.writeFrame(ws.NewFrame(message.OpCode, true, message.Payload))
+		})
+	}()
+	go func() {
+		defer func() { done <- struct{}{} }()
+		relayFrames(server, client, func(message wsutil.Message) error {
+			if tracker != nil && message.OpCode.IsData() {
+				tracker.onResponse(message.Payload)
+			}
+			err := queue.push(message)
+			if errors.Is(err, errSubscriptionBufferFull) {
+				wsSubscriptionOverflowCounter.Inc(1)
+				log.Warn("closing websocket connection with too many buffered subscription notifications", "remote", r.RemoteAddr, "limit", maxBuffer)
+				_ = client.writeFrame(ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusPolicyViolation, "subscription buffer full")))
+			}
+			return err
+		})
+	}()
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for {
+			message, ok := queue.pop()
+			if !ok {
+				select {
+				case <-queue.ready:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err := client.writeFrame(ws.NewFrame(message.OpCode, true, message.Payload)); err != nil {
+				return
+			}
+		}
+	}()
+	// closing both connections unblocks the remaining goroutines
+	<-done
+}
+
+// relayFrames reads messages from src until it closes, answering pings itself and passing the
+// remaining messages to forward. A close frame is forwarded before stopping.
+func relayFrames(src *wsFrameConn, dst *wsFrameConn, forward func(wsutil.Message) error) {
+	for {
+		messages, err := src.readMessages()
+		for _, message := range messages {
+			switch message.OpCode {
+			case ws.OpPing:
+				if src.writeFrame(ws.NewPongFrame(message.Payload)) != nil {
+					return
+				}
+			case ws.OpPong:
+			case ws.OpClose:
+				_ = dst.writeFrame(ws.NewCloseFrame(message.Payload))
+				return
+			default:
+				if forward(message) != nil {
+					return
+				}
+			}
+		}
+		if errors.Is(err, errWSMessageTooLarge) {
+			log.Debug("closing websocket connection that sent a message over the size limit", "limit", src.maxMessageSize)
+			_ = src.writeFrame(ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusMessageTooBig, "message too large")))
+			_ = dst.writeFrame(ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusGoingAway, "")))
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
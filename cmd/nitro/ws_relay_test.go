@@ -0,0 +1,78 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+func TestWSRelayQueueLimit(t *testing.T) {
+	notification := func(subscription string) wsutil.Message {
+		payload := `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"` + subscription + `","result":{}}}`
+		return wsutil.Message{OpCode: ws.OpText, Payload: []byte(payload)}
+	}
+	response := wsutil.Message{OpCode: ws.OpText, Payload: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)}
+
+	queue := newWSRelayQueue(2)
+	Require(t, queue.push(notification("0xa")))
+	Require(t, queue.push(notification("0xa")))
+	// other subscriptions and responses aren't counted against a full subscription
+	Require(t, queue.push(notification("0xb")))
+	Require(t, queue.push(response))
+	if err := queue.push(notification("0xa")); !errors.Is(err, errSubscriptionBufferFull) {
+		Fail(t, "expected subscription buffer to be full, got", err)
+	}
+	if _, ok := queue.pop(); !ok {
+		Fail(t, "expected a queued message")
+	}
+	Require(t, queue.push(notification("0xa")))
+	for i := 0; i < 4; i++ {
+		if _, ok := queue.pop(); !ok {
+			Fail(t, "expected a queued message")
+		}
+	}
+	if _, ok := queue.pop(); ok {
+		Fail(t, "expected an empty queue")
+	}
+	if len(queue.pending) != 0 {
+		Fail(t, "pending counts not cleared", queue.pending)
+	}
+}
+
+func TestWSFrameConnMessageLimit(t *testing.T) {
+	frames := func(fragments ...string) *bytes.Buffer {
+		var buf bytes.Buffer
+		for i, fragment := range fragments {
+			opCode := ws.OpContinuation
+			if i == 0 {
+				opCode = ws.OpText
+			}
+			frame := ws.MaskFrameInPlace(ws.NewFrame(opCode, i == len(fragments)-1, []byte(fragment)))
+			Require(t, ws.WriteFrame(&buf, frame))
+		}
+		return &buf
+	}
+	read := func(src *bytes.Buffer) ([]wsutil.Message, error) {
+		conn := &wsFrameConn{reader: src, state: ws.StateServerSide, maxMessageSize: 8}
+		return conn.readMessages()
+	}
+
+	messages, err := read(frames("1234", "5678"))
+	Require(t, err)
+	if len(messages) != 1 || string(messages[0].Payload) != "12345678" {
+		Fail(t, "unexpected messages read", messages)
+	}
+	if _, err := read(frames("123456789")); !errors.Is(err, errWSMessageTooLarge) {
+		Fail(t, "read a frame over the limit, got", err)
+	}
+	// every frame is under the limit, but the message isn't
+	if _, err := read(frames("1234", "5678", "9")); !errors.Is(err, errWSMessageTooLarge) {
+		Fail(t, "read a fragmented message over the limit, got", err)
+	}
+}
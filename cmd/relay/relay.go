@@ -15,9 +15,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/ethereum/go-ethereum/metrics/exp"
 
 	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util"
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
 	"github.com/offchainlabs/nitro/relay"
 )
@@ -74,10 +74,17 @@ func startup() error {
 	}
 
 	if relayConfig.Metrics && relayConfig.MetricsServer.Addr != "" {
+		if err := relayConfig.MetricsServer.Validate(); err != nil {
+			return err
+		}
+		labels, err := relayConfig.MetricsServer.ParseLabels()
+		if err != nil {
+			return err
+		}
 		go metrics.CollectProcessMetrics(relayConfig.MetricsServer.UpdateInterval)
 
 		address := fmt.Sprintf("%v:%v", relayConfig.MetricsServer.Addr, relayConfig.MetricsServer.Port)
-		exp.Setup(address)
+		util.StartMetricsServer(address, labels)
 	}
 
 	select {
@@ -6,11 +6,14 @@ package confighelpers
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/json"
 	koanfjson "github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
@@ -51,7 +54,20 @@ func ApplyOverrides(f *flag.FlagSet, k *koanf.Koanf) error {
 	configFiles := k.Strings("conf.file")
 	for _, configFile := range configFiles {
 		if len(configFile) > 0 {
-			if err := k.Load(file.Provider(configFile), json.Parser()); err != nil {
+			parser, err := configFileParser(configFile, k.String("conf.format"))
+			if err != nil {
+				return err
+			}
+			if _, isJSON := parser.(*json.JSON); isJSON {
+				// JSON config files can include other files, which are resolved on every load
+				options, err := loadJSONConfigFile(configFile)
+				if err != nil {
+					return errors.Wrap(err, "error loading local config file")
+				}
+				if err := k.Load(confmap.Provider(options, ""), nil); err != nil {
+					return errors.Wrap(err, "error loading local config file")
+				}
+			} else if err := k.Load(file.Provider(configFile), parser); err != nil {
 				return errors.Wrap(err, "error loading local config file")
 			}
 
@@ -64,6 +80,27 @@ func ApplyOverrides(f *flag.FlagSet, k *koanf.Koanf) error {
 	return nil
 }
 
+// configFileParser picks a parser from the explicit format if given, or else the file's extension
+func configFileParser(configFile string, format string) (koanf.Parser, error) {
+	explicit := format != ""
+	if !explicit {
+		format = strings.TrimPrefix(filepath.Ext(configFile), ".")
+	}
+	switch strings.ToLower(format) {
+	case "json":
+		return json.Parser(), nil
+	case "yaml", "yml":
+		return yaml.Parser(), nil
+	case "toml":
+		return toml.Parser(), nil
+	}
+	if explicit {
+		return nil, fmt.Errorf("unsupported config file format \"%v\"", format)
+	}
+	// files without a recognized extension have always been parsed as JSON
+	return json.Parser(), nil
+}
+
 // applyOverrideOverrides for configuration values that need to be re-applied for each configuration item applied
 func applyOverrideOverrides(f *flag.FlagSet, k *koanf.Koanf) error {
 	// Command line overrides config file or config string
@@ -116,6 +153,37 @@ func loadS3Variables(k *koanf.Koanf) error {
 	}), nil)
 }
 
+// ApplyDeprecatedOptions copies any deprecated options that are set to their replacements,
+// where deprecated maps each deprecated option to its replacement. In strict mode, it errors instead.
+func ApplyDeprecatedOptions(f *flag.FlagSet, k *koanf.Koanf, deprecated map[string]string) error {
+	for name, replacement := range deprecated {
+		option := f.Lookup(name)
+		if option == nil {
+			return fmt.Errorf("deprecated option %v is not registered", name)
+		}
+		value := k.Get(name)
+		if !option.Changed && (value == nil || fmt.Sprint(value) == option.DefValue) {
+			continue
+		}
+		if k.Bool("conf.strict") {
+			return fmt.Errorf("deprecated option --%v is set, use --%v instead", name, replacement)
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: --%v has been deprecated. Please use --%v instead.\n", name, replacement)
+		replacementOption := f.Lookup(replacement)
+		if replacementOption == nil {
+			return fmt.Errorf("replacement option %v for %v is not registered", replacement, name)
+		}
+		if replacementOption.Changed {
+			// the replacement was given explicitly and takes precedence
+			continue
+		}
+		if err := k.Load(confmap.Provider(map[string]interface{}{replacement: value}, "."), nil); err != nil {
+			return errors.Wrap(err, "error applying deprecated option")
+		}
+	}
+	return nil
+}
+
 var ErrVersion = errors.New("configuration: version requested")
 
 func GetVersion() (string, string) {
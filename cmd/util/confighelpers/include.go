@@ -0,0 +1,114 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package confighelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IncludeDirective is the key of a JSON config object whose value is a path to another JSON config
+// file, relative to the including file, whose options are inlined into that object
+const IncludeDirective = "$include"
+
+// loadJSONConfigFile reads a JSON config file, resolving its includes
+func loadJSONConfigFile(configFile string) (map[string]interface{}, error) {
+	return resolveConfigFile(configFile, nil)
+}
+
+// resolveConfigFile reads a JSON config file and its includes, where including lists the files
+// already being resolved, outermost first
+func resolveConfigFile(configFile string, including []string) (map[string]interface{}, error) {
+	path, err := filepath.Abs(configFile)
+	if err != nil {
+		return nil, err
+	}
+	for i, previous := range including {
+		if previous == path {
+			cycle := append(append([]string{}, including[i:]...), path)
+			return nil, fmt.Errorf("circular config include: %v", strings.Join(cycle, " -> "))
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if len(including) > 0 {
+			return nil, fmt.Errorf("config file %v includes %v, which can't be read: %w", including[len(including)-1], configFile, err)
+		}
+		return nil, err
+	}
+	var options map[string]interface{}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return nil, fmt.Errorf("error parsing config file %v: %w", path, err)
+	}
+	including = append(including, path)
+	resolved, err := resolveIncludes(options, filepath.Dir(path), including)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]interface{}), nil
+}
+
+// resolveIncludes replaces the include directives in a decoded JSON value. The other options of an
+// object with an include take precedence over the included ones.
+func resolveIncludes(value interface{}, dir string, including []string) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		options := make(map[string]interface{}, len(value))
+		if include, ok := value[IncludeDirective]; ok {
+			includePath, ok := include.(string)
+			if !ok || includePath == "" {
+				return nil, fmt.Errorf("%v in config file %v must be a file path, not %v", IncludeDirective, including[len(including)-1], include)
+			}
+			if !filepath.IsAbs(includePath) {
+				includePath = filepath.Join(dir, includePath)
+			}
+			included, err := resolveConfigFile(includePath, including)
+			if err != nil {
+				return nil, err
+			}
+			options = included
+		}
+		for name, option := range value {
+			if name == IncludeDirective {
+				continue
+			}
+			resolved, err := resolveIncludes(option, dir, including)
+			if err != nil {
+				return nil, err
+			}
+			options[name] = mergeConfigOptions(options[name], resolved)
+		}
+		return options, nil
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		for i, element := range value {
+			resolved, err := resolveIncludes(element, dir, including)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = resolved
+		}
+		return values, nil
+	}
+	return value, nil
+}
+
+// mergeConfigOptions merges groups of options, with override taking precedence
+func mergeConfigOptions(base interface{}, override interface{}) interface{} {
+	baseOptions, ok := base.(map[string]interface{})
+	if !ok {
+		return override
+	}
+	overrideOptions, ok := override.(map[string]interface{})
+	if !ok {
+		return override
+	}
+	for name, option := range overrideOptions {
+		baseOptions[name] = mergeConfigOptions(baseOptions[name], option)
+	}
+	return baseOptions
+}
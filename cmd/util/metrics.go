@@ -0,0 +1,129 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/metrics/exp"
+	"github.com/ethereum/go-ethereum/metrics/prometheus"
+)
+
+// RegisterMetricsHandlers serves the default registry's metrics on mux, like exp.Exp, with labels
+// attached to everything on the prometheus endpoint
+func RegisterMetricsHandlers(mux *http.ServeMux, labels map[string]string) {
+	mux.Handle("/debug/metrics", exp.ExpHandler(metrics.DefaultRegistry))
+	mux.Handle("/debug/metrics/prometheus", LabeledPrometheusHandler(prometheus.Handler(metrics.DefaultRegistry), labels))
+}
+
+// StartMetricsServer serves the default registry's metrics on address, like exp.Setup
+func StartMetricsServer(address string, labels map[string]string) {
+	mux := http.NewServeMux()
+	RegisterMetricsHandlers(mux, labels)
+	log.Info("Starting metrics server", "addr", fmt.Sprintf("http://%s/debug/metrics", address), "labels", labels)
+	go func() {
+		// #nosec G114
+		if err := http.ListenAndServe(address, mux); err != nil {
+			log.Error("Failure in running metrics server", "err", err)
+		}
+	}()
+}
+
+// LabeledPrometheusHandler adds labels to every sample of the prometheus text format handler serves
+func LabeledPrometheusHandler(handler http.Handler, labels map[string]string) http.Handler {
+	if len(labels) == 0 {
+		return handler
+	}
+	labelString := FormatMetricsLabels(labels)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}
+		handler.ServeHTTP(recorder, r)
+		for key, values := range recorder.header {
+			w.Header()[key] = values
+		}
+		body := recorder.body.Bytes()
+		if recorder.status == http.StatusOK {
+			body = AddMetricsLabels(body, labelString)
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(recorder.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// FormatMetricsLabels formats labels as the prometheus text format does, sorted by name and
+// without the enclosing braces
+func FormatMetricsLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var builder strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[name])
+		fmt.Fprintf(&builder, "%s=\"%s\"", name, value)
+	}
+	return builder.String()
+}
+
+// AddMetricsLabels adds the formatted labels to each sample line of a prometheus text format body
+func AddMetricsLabels(body []byte, labelString string) []byte {
+	var out bytes.Buffer
+	out.Grow(len(body))
+	for _, line := range bytes.SplitAfter(body, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 || trimmed[0] == '#' {
+			out.Write(line)
+			continue
+		}
+		nameEnd := bytes.IndexAny(line, " {")
+		if nameEnd < 0 {
+			out.Write(line)
+			continue
+		}
+		out.Write(line[:nameEnd])
+		rest := bytes.TrimLeft(line[nameEnd:], " ")
+		out.WriteByte('{')
+		out.WriteString(labelString)
+		if len(rest) > 0 && rest[0] == '{' {
+			// geth writes summary quantiles as "name {quantile=...} value"
+			if len(rest) > 1 && rest[1] != '}' {
+				out.WriteByte(',')
+			}
+			out.Write(rest[1:])
+		} else {
+			out.WriteString("} ")
+			out.Write(rest)
+		}
+	}
+	return out.Bytes()
+}
+
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
@@ -0,0 +1,78 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package util
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+func TestAddMetricsLabels(t *testing.T) {
+	labelString := FormatMetricsLabels(map[string]string{"region": "us-east-1", "environment": `say "hi"`})
+	if labelString != `environment="say \"hi\"",region="us-east-1"` {
+		t.Fatal("unexpected label string", labelString)
+	}
+	body := "# TYPE arb_sequencer_active gauge\n" +
+		"arb_sequencer_active 1\n" +
+		"\n" +
+		"# TYPE arb_sequencer_block_txs summary\n" +
+		"arb_sequencer_block_txs {quantile=\"0.5\"} 3\n" +
+		"arb_sequencer_block_txs_count 7\n"
+	expected := "# TYPE arb_sequencer_active gauge\n" +
+		"arb_sequencer_active{env=\"test\"} 1\n" +
+		"\n" +
+		"# TYPE arb_sequencer_block_txs summary\n" +
+		"arb_sequencer_block_txs{env=\"test\",quantile=\"0.5\"} 3\n" +
+		"arb_sequencer_block_txs_count{env=\"test\"} 7\n"
+	labeled := string(AddMetricsLabels([]byte(body), `env="test"`))
+	if labeled != expected {
+		t.Fatalf("unexpected labeled metrics:\n%v\nexpected:\n%v", labeled, expected)
+	}
+}
+
+func TestLabeledPrometheusHandler(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("arb_feed_clients 2\n"))
+	})
+	server := httptest.NewServer(LabeledPrometheusHandler(inner, map[string]string{"chain": "nova"}))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "arb_feed_clients{chain=\"nova\"} 2\n" {
+		t.Fatal("unexpected labeled response", string(body))
+	}
+	if resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatal("content type not passed through", resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestParseMetricsLabels(t *testing.T) {
+	config := genericconf.MetricsServerConfigDefault
+	config.Labels = []string{"environment=staging", "region=eu=west"}
+	labels, err := config.ParseLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["environment"] != "staging" || labels["region"] != "eu=west" {
+		t.Fatal("unexpected labels", labels)
+	}
+	for _, invalid := range [][]string{{"novalue"}, {"=value"}, {"1st=value"}, {"__reserved=value"}, {"bad-name=value"}, {"a=1", "a=2"}} {
+		config.Labels = invalid
+		if err := config.Validate(); err == nil {
+			t.Fatal("expected invalid labels to be rejected", invalid)
+		}
+	}
+}
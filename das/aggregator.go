@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"math/bits"
 	"os"
+	"sync"
 	"time"
 
 	flag "github.com/spf13/pflag"
@@ -26,19 +27,24 @@ import (
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/util/contracts"
 	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
 
 type AggregatorConfig struct {
-	Enable        bool   `koanf:"enable"`
-	AssumedHonest int    `koanf:"assumed-honest"`
-	Backends      string `koanf:"backends"`
-	DumpKeyset    bool   `koanf:"dump-keyset"`
+	Enable               bool          `koanf:"enable"`
+	AssumedHonest        int           `koanf:"assumed-honest"`
+	Backends             string        `koanf:"backends"`
+	DumpKeyset           bool          `koanf:"dump-keyset"`
+	ExcludeAfterFailures int           `koanf:"exclude-after-failures"`
+	ProbeInterval        time.Duration `koanf:"probe-interval"`
 }
 
 var DefaultAggregatorConfig = AggregatorConfig{
-	AssumedHonest: 0,
-	Backends:      "",
-	DumpKeyset:    false,
+	AssumedHonest:        0,
+	Backends:             "",
+	DumpKeyset:           false,
+	ExcludeAfterFailures: 0,
+	ProbeInterval:        time.Minute,
 }
 
 func AggregatorConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -46,9 +52,22 @@ func AggregatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".assumed-honest", DefaultAggregatorConfig.AssumedHonest, "Number of assumed honest backends (H). If there are N backends, K=N+1-H valid responses are required to consider an Store request to be successful.")
 	f.String(prefix+".backends", DefaultAggregatorConfig.Backends, "JSON RPC backend configuration")
 	f.Bool(prefix+".dump-keyset", DefaultAggregatorConfig.DumpKeyset, "Dump the keyset encoded in hexadecimal for the backends string")
+	f.Int(prefix+".exclude-after-failures", DefaultAggregatorConfig.ExcludeAfterFailures, "stop sending Store requests to a backend after this many consecutive failures, as long as enough backends remain to store a message, until it passes a health check (0 to disable)")
+	f.Duration(prefix+".probe-interval", DefaultAggregatorConfig.ProbeInterval, "how often an excluded backend is health checked for re-inclusion, and its exclusion warning repeated")
+}
+
+func (c *AggregatorConfig) Validate() error {
+	if c.ExcludeAfterFailures < 0 {
+		return fmt.Errorf("rpc-aggregator exclude-after-failures cannot be negative, not %v", c.ExcludeAfterFailures)
+	}
+	if c.ExcludeAfterFailures > 0 && c.ProbeInterval <= 0 {
+		return fmt.Errorf("rpc-aggregator probe-interval must be positive to re-include excluded backends, not %v", c.ProbeInterval)
+	}
+	return nil
 }
 
 type Aggregator struct {
+	stopwaiter.StopWaiter
 	config         AggregatorConfig
 	services       []ServiceDetails
 	requestTimeout time.Duration
@@ -59,6 +78,10 @@ type Aggregator struct {
 	keysetHash                     [32]byte
 	keysetBytes                    []byte
 	bpVerifier                     *contracts.BatchPosterVerifier
+
+	healthMutex   sync.Mutex
+	health        map[uint64]*backendHealth
+	excludedCount int
 }
 
 type ServiceDetails struct {
@@ -120,6 +143,9 @@ func NewAggregatorWithSeqInboxCaller(
 	services []ServiceDetails,
 	seqInboxCaller *bridgegen.SequencerInboxCaller,
 ) (*Aggregator, error) {
+	if err := config.AggregatorConfig.Validate(); err != nil {
+		return nil, err
+	}
 	var aggSignersMask uint64
 	pubKeys := []blsSignatures.PublicKey{}
 	for _, d := range services {
@@ -156,6 +182,11 @@ func NewAggregatorWithSeqInboxCaller(
 		bpVerifier = contracts.NewBatchPosterVerifier(seqInboxCaller)
 	}
 
+	health := make(map[uint64]*backendHealth, len(services))
+	for _, d := range services {
+		health[d.signersMask] = &backendHealth{}
+	}
+
 	return &Aggregator{
 		config:                         config.AggregatorConfig,
 		services:                       services,
@@ -165,6 +196,7 @@ func NewAggregatorWithSeqInboxCaller(
 		keysetHash:                     keysetHash,
 		keysetBytes:                    ksBuf.Bytes(),
 		bpVerifier:                     bpVerifier,
+		health:                         health,
 	}, nil
 }
 
@@ -208,10 +240,11 @@ func (a *Aggregator) Store(ctx context.Context, message []byte, timeout uint64,
 		}
 	}
 
-	responses := make(chan storeResponse, len(a.services))
+	services := a.includedServices()
+	responses := make(chan storeResponse, len(services))
 
 	expectedHash := dastree.Hash(message)
-	for _, d := range a.services {
+	for _, d := range services {
 		go func(ctx context.Context, d ServiceDetails) {
 			storeCtx, cancel := context.WithTimeout(ctx, a.requestTimeout)
 			const metricBase string = "arb/das/rpc/aggregator/store"
@@ -286,14 +319,17 @@ func (a *Aggregator) Store(ctx context.Context, message []byte, timeout uint64,
 		var pubKeys []blsSignatures.PublicKey
 		var sigs []blsSignatures.Signature
 		var aggSignersMask uint64
-		var storeFailures, successfullyStoredCount int
+		var successfullyStoredCount int
+		// excluded backends count as failures up front
+		storeFailures := len(a.services) - len(services)
 		var returned bool
-		for i := 0; i < len(a.services); i++ {
+		for i := 0; i < len(services); i++ {
 
 			select {
 			case <-ctx.Done():
 				break
 			case r := <-responses:
+				a.recordStoreResult(r.details, r.err)
 				if r.err != nil {
 					storeFailures++
 					log.Warn("das.Aggregator: Error from backend", "backend", r.details.service, "signerMask", r.details.signersMask, "err", r.err)
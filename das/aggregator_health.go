@@ -0,0 +1,188 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var excludedBackendsGauge = metrics.NewRegisteredGauge("arb/das/rpc/aggregator/excluded", nil)
+
+type backendHealth struct {
+	consecutiveFailures int
+	excluded            bool
+	excludedAt          time.Time
+	lastProbe           time.Time
+	lastProbeErr        error
+}
+
+type healthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// BackendStatus is a backend's health as seen by the aggregator
+type BackendStatus struct {
+	Backend             string     `json:"backend"`
+	SignersMask         uint64     `json:"signersMask"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Excluded            bool       `json:"excluded"`
+	ExcludedAt          *time.Time `json:"excludedAt,omitempty"`
+	LastProbe           *time.Time `json:"lastProbe,omitempty"`
+	LastProbeError      string     `json:"lastProbeError,omitempty"`
+}
+
+// BackendStatusReporter is implemented by DAS writers that can report the health of their backends
+type BackendStatusReporter interface {
+	BackendStatus() []BackendStatus
+}
+
+// Start health checks the excluded backends every probe-interval, so they're re-included whether or
+// not Store is being called
+func (a *Aggregator) Start(ctx context.Context) {
+	a.StopWaiter.Start(ctx, a)
+	if a.config.ExcludeAfterFailures <= 0 {
+		return
+	}
+	a.CallIteratively(func(ctx context.Context) time.Duration {
+		a.probeExcluded(ctx)
+		return a.config.ProbeInterval
+	})
+}
+
+func (a *Aggregator) Close(ctx context.Context) error {
+	a.StopAndWait()
+	return nil
+}
+
+// includedServices returns the backends Store should be sent to
+func (a *Aggregator) includedServices() []ServiceDetails {
+	a.healthMutex.Lock()
+	defer a.healthMutex.Unlock()
+	if a.excludedCount == 0 {
+		return a.services
+	}
+	services := make([]ServiceDetails, 0, len(a.services))
+	for _, d := range a.services {
+		if !a.health[d.signersMask].excluded {
+			services = append(services, d)
+		}
+	}
+	return services
+}
+
+// probeExcluded repeats the warning for each excluded backend and health checks it
+func (a *Aggregator) probeExcluded(ctx context.Context) {
+	a.healthMutex.Lock()
+	now := time.Now()
+	var excluded []ServiceDetails
+	for _, d := range a.services {
+		health := a.health[d.signersMask]
+		if health.excluded {
+			log.Warn("DAS backend still excluded from Store requests", "backend", d.service, "signerMask", d.signersMask, "excludedFor", now.Sub(health.excludedAt), "lastProbeErr", health.lastProbeErr)
+			excluded = append(excluded, d)
+		}
+	}
+	a.healthMutex.Unlock()
+	for _, d := range excluded {
+		a.probe(ctx, d)
+	}
+}
+
+func (a *Aggregator) probe(ctx context.Context, d ServiceDetails) {
+	var err error
+	if checker, ok := d.service.(healthChecker); ok {
+		probeCtx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+		err = checker.HealthCheck(probeCtx)
+		cancel()
+	}
+	// backends without a health check are re-included to be tried again by Store
+
+	a.healthMutex.Lock()
+	defer a.healthMutex.Unlock()
+	health := a.health[d.signersMask]
+	health.lastProbe = time.Now()
+	health.lastProbeErr = err
+	if err != nil || !health.excluded {
+		return
+	}
+	health.excluded = false
+	health.consecutiveFailures = 0
+	a.excludedCount--
+	excludedBackendsGauge.Update(int64(a.excludedCount))
+	log.Info("DAS backend passed its health check and is re-included in Store requests", "backend", d.service, "signerMask", d.signersMask, "excludedFor", health.lastProbe.Sub(health.excludedAt))
+}
+
+// recordStoreResult tracks the backend's consecutive failures, excluding it once there are
+// exclude-after-failures of them if enough backends would still remain to store a message
+func (a *Aggregator) recordStoreResult(d ServiceDetails, err error) {
+	a.healthMutex.Lock()
+	defer a.healthMutex.Unlock()
+	health := a.health[d.signersMask]
+	if err == nil {
+		health.consecutiveFailures = 0
+		return
+	}
+	health.consecutiveFailures++
+	if a.config.ExcludeAfterFailures <= 0 || health.excluded || health.consecutiveFailures < a.config.ExcludeAfterFailures {
+		return
+	}
+	if len(a.services)-a.excludedCount-1 < a.requiredServicesForStore {
+		log.Warn("DAS backend keeps failing but can't be excluded without making Store impossible", "backend", d.service, "signerMask", d.signersMask, "failures", health.consecutiveFailures, "err", err)
+		return
+	}
+	now := time.Now()
+	health.excluded = true
+	health.excludedAt = now
+	a.excludedCount++
+	excludedBackendsGauge.Update(int64(a.excludedCount))
+	log.Warn("DAS backend excluded from Store requests until it passes a health check", "backend", d.service, "signerMask", d.signersMask, "failures", health.consecutiveFailures, "err", err)
+}
+
+func (a *Aggregator) BackendStatus() []BackendStatus {
+	a.healthMutex.Lock()
+	defer a.healthMutex.Unlock()
+	statuses := make([]BackendStatus, 0, len(a.services))
+	for _, d := range a.services {
+		health := a.health[d.signersMask]
+		status := BackendStatus{
+			Backend:             d.service.String(),
+			SignersMask:         d.signersMask,
+			Healthy:             health.consecutiveFailures == 0 && !health.excluded,
+			ConsecutiveFailures: health.consecutiveFailures,
+			Excluded:            health.excluded,
+		}
+		if health.excluded {
+			excludedAt := health.excludedAt
+			status.ExcludedAt = &excludedAt
+		}
+		if !health.lastProbe.IsZero() {
+			lastProbe := health.lastProbe
+			status.LastProbe = &lastProbe
+		}
+		if health.lastProbeErr != nil {
+			status.LastProbeError = health.lastProbeErr.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (d *StoreSigningDAS) BackendStatus() []BackendStatus {
+	if reporter, ok := d.DataAvailabilityServiceWriter.(BackendStatusReporter); ok {
+		return reporter.BackendStatus()
+	}
+	return nil
+}
+
+func (w *WriterPanicWrapper) BackendStatus() []BackendStatus {
+	if reporter, ok := w.DataAvailabilityServiceWriter.(BackendStatusReporter); ok {
+		return reporter.BackendStatus()
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+)
+
+type healthCheckedWriter struct {
+	healthy int32
+}
+
+func (w *healthCheckedWriter) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (w *healthCheckedWriter) HealthCheck(ctx context.Context) error {
+	if atomic.LoadInt32(&w.healthy) == 0 {
+		return errors.New("unhealthy")
+	}
+	return nil
+}
+
+func (w *healthCheckedWriter) String() string {
+	return "healthCheckedWriter"
+}
+
+func TestDAS_AggregatorExcludesFailingBackends(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var writers []*healthCheckedWriter
+	var backends []ServiceDetails
+	for i := 0; i < 3; i++ {
+		pubKey, _, err := blsSignatures.GenerateKeys()
+		Require(t, err)
+		writers = append(writers, &healthCheckedWriter{})
+		details, err := NewServiceDetails(writers[i], pubKey, uint64(1<<i), "service"+strconv.Itoa(i))
+		Require(t, err)
+		backends = append(backends, *details)
+	}
+	// two of the three backends are needed to store a message
+	aggregator, err := NewAggregator(ctx, DataAvailabilityConfig{
+		AggregatorConfig: AggregatorConfig{AssumedHonest: 2, ExcludeAfterFailures: 2, ProbeInterval: 10 * time.Millisecond},
+		L1NodeURL:        "none",
+	}, backends)
+	Require(t, err)
+
+	storeErr := errors.New("store failed")
+	aggregator.recordStoreResult(backends[0], storeErr)
+	if len(aggregator.includedServices()) != 3 {
+		Fail(t, "backend excluded before reaching exclude-after-failures")
+	}
+	aggregator.recordStoreResult(backends[0], storeErr)
+	if len(aggregator.includedServices()) != 2 {
+		Fail(t, "backend not excluded after reaching exclude-after-failures")
+	}
+	status := aggregator.BackendStatus()
+	if !status[0].Excluded || status[0].Healthy || status[0].ExcludedAt == nil || status[1].Excluded || !status[1].Healthy {
+		Fail(t, "unexpected backend status", status)
+	}
+
+	// excluding another backend would leave too few to store a message
+	aggregator.recordStoreResult(backends[1], storeErr)
+	aggregator.recordStoreResult(backends[1], storeErr)
+	if len(aggregator.includedServices()) != 2 {
+		Fail(t, "backend excluded despite too few backends remaining")
+	}
+
+	aggregator.probe(ctx, backends[0])
+	status = aggregator.BackendStatus()
+	if !status[0].Excluded || status[0].LastProbe == nil || status[0].LastProbeError == "" {
+		Fail(t, "backend re-included despite failing its health check", status)
+	}
+	atomic.StoreInt32(&writers[0].healthy, 1)
+	aggregator.probe(ctx, backends[0])
+	status = aggregator.BackendStatus()
+	if status[0].Excluded || status[0].LastProbeError != "" || len(aggregator.includedServices()) != 3 {
+		Fail(t, "backend not re-included after passing its health check", status)
+	}
+
+	// once started, excluded backends are probed without waiting for a Store
+	atomic.StoreInt32(&writers[0].healthy, 0)
+	aggregator.recordStoreResult(backends[0], storeErr)
+	aggregator.recordStoreResult(backends[0], storeErr)
+	if len(aggregator.includedServices()) != 2 {
+		Fail(t, "backend not excluded again")
+	}
+	aggregator.Start(ctx)
+	defer aggregator.StopAndWait()
+	atomic.StoreInt32(&writers[0].healthy, 1)
+	for i := 0; i < 100 && len(aggregator.includedServices()) != 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(aggregator.includedServices()) != 3 {
+		Fail(t, "started aggregator didn't re-include a backend that passed its health check")
+	}
+
+	config := AggregatorConfig{ExcludeAfterFailures: 1}
+	if config.Validate() == nil {
+		Fail(t, "accepted exclude-after-failures without a positive probe-interval")
+	}
+}
@@ -27,7 +27,7 @@ func CreatePersistentStorageService(
 			return nil, nil, err
 		}
 		lifecycleManager.Register(s)
-		storageServices = append(storageServices, s)
+		storageServices = append(storageServices, NewStorageServiceMetricsWrapper(s, "local_db"))
 	}
 
 	if config.LocalFileStorageConfig.Enable {
@@ -36,7 +36,7 @@ func CreatePersistentStorageService(
 			return nil, nil, err
 		}
 		lifecycleManager.Register(s)
-		storageServices = append(storageServices, s)
+		storageServices = append(storageServices, NewStorageServiceMetricsWrapper(s, "local_file"))
 	}
 
 	if config.S3StorageServiceConfig.Enable {
@@ -45,7 +45,7 @@ func CreatePersistentStorageService(
 			return nil, nil, err
 		}
 		lifecycleManager.Register(s)
-		storageServices = append(storageServices, s)
+		storageServices = append(storageServices, NewStorageServiceMetricsWrapper(s, "s3"))
 	}
 
 	if len(storageServices) > 1 {
@@ -85,11 +85,15 @@ func CreateBatchPosterDAS(
 		return nil, nil, nil, errors.New("--node.data-availability.key.key-dir, priv-key may not be set when running a Batch Poster in AnyTrust mode.")
 	}
 
+	var lifecycleManager LifecycleManager
 	var daWriter DataAvailabilityServiceWriter
-	daWriter, err := NewRPCAggregator(ctx, *config)
+	rpcAgg, err := NewRPCAggregator(ctx, *config)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	rpcAgg.Start(ctx)
+	lifecycleManager.Register(rpcAgg)
+	daWriter = rpcAgg
 	if dataSigner != nil {
 		// In some tests the batch poster does not sign Store requests
 		daWriter, err = NewStoreSigningDAS(daWriter, dataSigner)
@@ -97,19 +101,20 @@ func CreateBatchPosterDAS(
 			return nil, nil, nil, err
 		}
 	}
+	daWriter = NewWriterMetricsWrapper(daWriter)
 
 	restAgg, err := NewRestfulClientAggregator(ctx, &config.RestfulClientAggregatorConfig)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	restAgg.Start(ctx)
-	var lifecycleManager LifecycleManager
 	lifecycleManager.Register(restAgg)
 	var daReader DataAvailabilityServiceReader = restAgg
 	daReader, err = NewChainFetchReader(daReader, l1Reader, sequencerInboxAddr)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	daReader = NewReaderMetricsWrapper(daReader)
 
 	return daWriter, daReader, &lifecycleManager, nil
 }
@@ -0,0 +1,175 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbstate"
+)
+
+var (
+	storeRequestCounter = metrics.NewRegisteredCounter("arb/das/store/requests", nil)
+	storeSuccessCounter = metrics.NewRegisteredCounter("arb/das/store/success", nil)
+	storeFailureCounter = metrics.NewRegisteredCounter("arb/das/store/failure", nil)
+	// Stores are infrequent, see rpcStoreDurationHistogram
+	storeDurationHistogram = metrics.NewRegisteredHistogram("arb/das/store/duration", nil, metrics.NewExpDecaySample(32, 0.015))
+
+	retrieveRequestCounter    = metrics.NewRegisteredCounter("arb/das/retrieve/requests", nil)
+	retrieveSuccessCounter    = metrics.NewRegisteredCounter("arb/das/retrieve/success", nil)
+	retrieveNotFoundCounter   = metrics.NewRegisteredCounter("arb/das/retrieve/not_found", nil)
+	retrieveFailureCounter    = metrics.NewRegisteredCounter("arb/das/retrieve/failure", nil)
+	retrieveDurationHistogram = metrics.NewRegisteredHistogram("arb/das/retrieve/duration", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+	signFailureCounter    = metrics.NewRegisteredCounter("arb/das/sign/failure", nil)
+	signDurationHistogram = metrics.NewRegisteredHistogram("arb/das/sign/duration", nil, metrics.NewExpDecaySample(32, 0.015))
+)
+
+func recordStore(start time.Time, err error) {
+	storeRequestCounter.Inc(1)
+	if err != nil {
+		storeFailureCounter.Inc(1)
+	} else {
+		storeSuccessCounter.Inc(1)
+	}
+	storeDurationHistogram.Update(time.Since(start).Nanoseconds())
+}
+
+func recordRetrieve(start time.Time, err error) {
+	retrieveRequestCounter.Inc(1)
+	if errors.Is(err, ErrNotFound) {
+		retrieveNotFoundCounter.Inc(1)
+	} else if err != nil {
+		retrieveFailureCounter.Inc(1)
+	} else {
+		retrieveSuccessCounter.Inc(1)
+	}
+	retrieveDurationHistogram.Update(time.Since(start).Nanoseconds())
+}
+
+// MetricsWrapper records the stores and retrievals of a DAS under arb/das/store and arb/das/retrieve
+type MetricsWrapper struct {
+	DataAvailabilityService
+}
+
+func NewMetricsWrapper(dataAvailabilityService DataAvailabilityService) DataAvailabilityService {
+	return &MetricsWrapper{
+		DataAvailabilityService: dataAvailabilityService,
+	}
+}
+
+func (w *MetricsWrapper) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	start := time.Now()
+	cert, err := w.DataAvailabilityService.Store(ctx, message, timeout, sig)
+	recordStore(start, err)
+	return cert, err
+}
+
+func (w *MetricsWrapper) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	start := time.Now()
+	data, err := w.DataAvailabilityService.GetByHash(ctx, hash)
+	recordRetrieve(start, err)
+	return data, err
+}
+
+func (w *MetricsWrapper) String() string {
+	return fmt.Sprintf("MetricsWrapper{%v}", w.DataAvailabilityService)
+}
+
+type WriterMetricsWrapper struct {
+	DataAvailabilityServiceWriter
+}
+
+func NewWriterMetricsWrapper(dataAvailabilityService DataAvailabilityServiceWriter) DataAvailabilityServiceWriter {
+	return &WriterMetricsWrapper{
+		DataAvailabilityServiceWriter: dataAvailabilityService,
+	}
+}
+
+func (w *WriterMetricsWrapper) Store(ctx context.Context, message []byte, timeout uint64, sig []byte) (*arbstate.DataAvailabilityCertificate, error) {
+	start := time.Now()
+	cert, err := w.DataAvailabilityServiceWriter.Store(ctx, message, timeout, sig)
+	recordStore(start, err)
+	return cert, err
+}
+
+func (w *WriterMetricsWrapper) String() string {
+	return fmt.Sprintf("WriterMetricsWrapper{%v}", w.DataAvailabilityServiceWriter)
+}
+
+type ReaderMetricsWrapper struct {
+	DataAvailabilityServiceReader
+}
+
+func NewReaderMetricsWrapper(dataAvailabilityService DataAvailabilityServiceReader) DataAvailabilityServiceReader {
+	return &ReaderMetricsWrapper{
+		DataAvailabilityServiceReader: dataAvailabilityService,
+	}
+}
+
+func (w *ReaderMetricsWrapper) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	start := time.Now()
+	data, err := w.DataAvailabilityServiceReader.GetByHash(ctx, hash)
+	recordRetrieve(start, err)
+	return data, err
+}
+
+func (w *ReaderMetricsWrapper) String() string {
+	return fmt.Sprintf("ReaderMetricsWrapper{%v}", w.DataAvailabilityServiceReader)
+}
+
+// StorageServiceMetricsWrapper records the latency and failures of a storage backend under
+// arb/das/storage/<name>
+type StorageServiceMetricsWrapper struct {
+	StorageService
+	putDurationHistogram metrics.Histogram
+	putFailureCounter    metrics.Counter
+	getDurationHistogram metrics.Histogram
+	getNotFoundCounter   metrics.Counter
+	getFailureCounter    metrics.Counter
+}
+
+func NewStorageServiceMetricsWrapper(storageService StorageService, name string) StorageService {
+	metricBase := "arb/das/storage/" + name
+	return &StorageServiceMetricsWrapper{
+		StorageService:       storageService,
+		putDurationHistogram: metrics.GetOrRegisterHistogram(metricBase+"/put/duration", nil, metrics.NewExpDecaySample(32, 0.015)),
+		putFailureCounter:    metrics.GetOrRegisterCounter(metricBase+"/put/failure", nil),
+		getDurationHistogram: metrics.GetOrRegisterHistogram(metricBase+"/get/duration", nil, metrics.NewExpDecaySample(1028, 0.015)),
+		getNotFoundCounter:   metrics.GetOrRegisterCounter(metricBase+"/get/not_found", nil),
+		getFailureCounter:    metrics.GetOrRegisterCounter(metricBase+"/get/failure", nil),
+	}
+}
+
+func (w *StorageServiceMetricsWrapper) Put(ctx context.Context, data []byte, expirationTime uint64) error {
+	start := time.Now()
+	err := w.StorageService.Put(ctx, data, expirationTime)
+	w.putDurationHistogram.Update(time.Since(start).Nanoseconds())
+	if err != nil {
+		w.putFailureCounter.Inc(1)
+	}
+	return err
+}
+
+func (w *StorageServiceMetricsWrapper) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	start := time.Now()
+	data, err := w.StorageService.GetByHash(ctx, hash)
+	w.getDurationHistogram.Update(time.Since(start).Nanoseconds())
+	if errors.Is(err, ErrNotFound) {
+		w.getNotFoundCounter.Inc(1)
+	} else if err != nil {
+		w.getFailureCounter.Inc(1)
+	}
+	return data, err
+}
+
+func (w *StorageServiceMetricsWrapper) String() string {
+	return fmt.Sprintf("StorageServiceMetricsWrapper{%v}", w.StorageService)
+}
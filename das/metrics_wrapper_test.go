@@ -0,0 +1,57 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestMetricsWrapper(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the package level metrics are registered before a test can enable metrics, but the storage
+	// backend ones are registered by the wrapper
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+
+	keyDir := t.TempDir()
+	_, _, err := GenerateAndStoreKeys(keyDir)
+	Require(t, err)
+	config := DataAvailabilityConfig{
+		Enable:    true,
+		KeyConfig: KeyConfig{KeyDir: keyDir},
+		L1NodeURL: "none",
+	}
+	storage := NewStorageServiceMetricsWrapper(NewMemoryBackedStorageService(ctx), "metrics_test")
+	signAfterStoreDAS, err := NewSignAfterStoreDAS(ctx, config, storage)
+	Require(t, err)
+	das := NewMetricsWrapper(signAfterStoreDAS)
+
+	message := []byte("hello world")
+	cert, err := das.Store(ctx, message, uint64(time.Now().Add(time.Hour).Unix()), []byte{})
+	Require(t, err)
+	retrieved, err := das.GetByHash(ctx, cert.DataHash)
+	Require(t, err)
+	if !bytes.Equal(retrieved, message) {
+		Fail(t, "retrieved message differs from stored one")
+	}
+	if _, err := das.GetByHash(ctx, common.Hash{1}); !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected not found, got", err)
+	}
+
+	wrapper, ok := storage.(*StorageServiceMetricsWrapper)
+	if !ok {
+		Fail(t, "unexpected storage wrapper type")
+	}
+	if wrapper.putDurationHistogram.Count() != 1 || wrapper.getDurationHistogram.Count() != 2 || wrapper.getNotFoundCounter.Count() != 1 {
+		Fail(t, "storage backend latency not recorded")
+	}
+}
@@ -34,11 +34,11 @@ type S3Downloader interface {
 
 type S3StorageServiceConfig struct {
 	Enable              bool   `koanf:"enable"`
-	AccessKey           string `koanf:"access-key"`
+	AccessKey           string `koanf:"access-key" secret:"true"`
 	Bucket              string `koanf:"bucket"`
 	ObjectPrefix        string `koanf:"object-prefix"`
 	Region              string `koanf:"region"`
-	SecretKey           string `koanf:"secret-key"`
+	SecretKey           string `koanf:"secret-key" secret:"true"`
 	DiscardAfterTimeout bool   `koanf:"discard-after-timeout"`
 }
 
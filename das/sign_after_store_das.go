@@ -29,7 +29,7 @@ import (
 
 type KeyConfig struct {
 	KeyDir  string `koanf:"key-dir"`
-	PrivKey string `koanf:"priv-key"`
+	PrivKey string `koanf:"priv-key" secret:"true"`
 }
 
 func (c *KeyConfig) BLSPrivKey() (blsSignatures.PrivateKey, error) {
@@ -211,8 +211,11 @@ func (d *SignAfterStoreDAS) Store(
 	}
 
 	fields := c.SerializeSignableFields()
+	signStart := time.Now()
 	c.Sig, err = blsSignatures.SignMessage(d.privKey, fields)
+	signDurationHistogram.Update(time.Since(signStart).Nanoseconds())
 	if err != nil {
+		signFailureCounter.Inc(1)
 		return nil, err
 	}
 
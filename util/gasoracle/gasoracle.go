@@ -0,0 +1,102 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package gasoracle
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+var (
+	oracleTipCapGauge     = metrics.NewRegisteredGauge("arb/l1/gasoracle/oracle/tip_cap", nil)
+	internalTipCapGauge   = metrics.NewRegisteredGauge("arb/l1/gasoracle/internal/tip_cap", nil)
+	oracleGasPriceGauge   = metrics.NewRegisteredGauge("arb/l1/gasoracle/oracle/gas_price", nil)
+	internalGasPriceGauge = metrics.NewRegisteredGauge("arb/l1/gasoracle/internal/gas_price", nil)
+	oracleFailureCounter  = metrics.NewRegisteredCounter("arb/l1/gasoracle/failures", nil)
+)
+
+type Config struct {
+	URL     string        `koanf:"url"`
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+var DefaultConfig = Config{
+	URL:     "",
+	Timeout: 5 * time.Second,
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", DefaultConfig.URL, "if set, JSON-RPC URL of an external gas oracle serving eth_maxPriorityFeePerGas and eth_gasPrice, used for L1 transactions with a fallback to the L1 node's own estimates")
+	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "timeout for requests to the external gas oracle")
+}
+
+// Client wraps an L1 client, taking fee suggestions from an external oracle when it's available
+type Client struct {
+	arbutil.L1Interface
+	oracle  *rpc.Client
+	timeout time.Duration
+}
+
+func NewClient(ctx context.Context, l1Client arbutil.L1Interface, config *Config) (*Client, error) {
+	if config.URL == "" {
+		return nil, errors.New("gas oracle url must be set")
+	}
+	oracle, err := rpc.DialContext(ctx, config.URL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		L1Interface: l1Client,
+		oracle:      oracle,
+		timeout:     config.Timeout,
+	}, nil
+}
+
+func (c *Client) queryOracle(ctx context.Context, method string) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var result hexutil.Big
+	err := c.oracle.CallContext(ctx, &result, method)
+	if err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
+func (c *Client) suggest(ctx context.Context, method string, internal func(context.Context) (*big.Int, error), oracleGauge, internalGauge metrics.Gauge) (*big.Int, error) {
+	internalValue, internalErr := internal(ctx)
+	if internalErr == nil {
+		internalGauge.Update(internalValue.Int64())
+	}
+	oracleValue, err := c.queryOracle(ctx, method)
+	if err != nil {
+		oracleFailureCounter.Inc(1)
+		log.Warn("error querying gas oracle, falling back to L1 node estimate", "method", method, "err", err)
+		return internalValue, internalErr
+	}
+	oracleGauge.Update(oracleValue.Int64())
+	return oracleValue, nil
+}
+
+func (c *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return c.suggest(ctx, "eth_maxPriorityFeePerGas", c.L1Interface.SuggestGasTipCap, oracleTipCapGauge, internalTipCapGauge)
+}
+
+func (c *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return c.suggest(ctx, "eth_gasPrice", c.L1Interface.SuggestGasPrice, oracleGasPriceGauge, internalGasPriceGauge)
+}
+
+func (c *Client) Close() {
+	c.oracle.Close()
+}
@@ -38,23 +38,25 @@ type HeaderReader struct {
 }
 
 type Config struct {
-	Enable               bool          `koanf:"enable"`
-	PollOnly             bool          `koanf:"poll-only" reload:"hot"`
-	PollInterval         time.Duration `koanf:"poll-interval" reload:"hot"`
-	SubscribeErrInterval time.Duration `koanf:"subscribe-err-interval" reload:"hot"`
-	TxTimeout            time.Duration `koanf:"tx-timeout" reload:"hot"`
-	OldHeaderTimeout     time.Duration `koanf:"old-header-timeout" reload:"hot"`
+	Enable                bool          `koanf:"enable"`
+	PollOnly              bool          `koanf:"poll-only" reload:"hot"`
+	PollInterval          time.Duration `koanf:"poll-interval" reload:"hot"`
+	SubscribeErrInterval  time.Duration `koanf:"subscribe-err-interval" reload:"hot"`
+	TxTimeout             time.Duration `koanf:"tx-timeout" reload:"hot"`
+	OldHeaderTimeout      time.Duration `koanf:"old-header-timeout" reload:"hot"`
+	MaxConcurrentRequests int           `koanf:"max-concurrent-requests"`
 }
 
 type ConfigFetcher func() *Config
 
 var DefaultConfig = Config{
-	Enable:               true,
-	PollOnly:             false,
-	PollInterval:         15 * time.Second,
-	SubscribeErrInterval: 5 * time.Minute,
-	TxTimeout:            5 * time.Minute,
-	OldHeaderTimeout:     5 * time.Minute,
+	Enable:                true,
+	PollOnly:              false,
+	PollInterval:          15 * time.Second,
+	SubscribeErrInterval:  5 * time.Minute,
+	TxTimeout:             5 * time.Minute,
+	OldHeaderTimeout:      5 * time.Minute,
+	MaxConcurrentRequests: 0,
 }
 
 func AddOptions(prefix string, f *flag.FlagSet) {
@@ -63,6 +65,7 @@ func AddOptions(prefix string, f *flag.FlagSet) {
 	f.Duration(prefix+".poll-interval", DefaultConfig.PollInterval, "interval when polling endpoint")
 	f.Duration(prefix+".tx-timeout", DefaultConfig.TxTimeout, "timeout when waiting for a transaction")
 	f.Duration(prefix+".old-header-timeout", DefaultConfig.OldHeaderTimeout, "warns if the latest l1 block is at least this old")
+	f.Int(prefix+".max-concurrent-requests", DefaultConfig.MaxConcurrentRequests, "maximum number of L1 requests the inbox reader has in flight at once, queueing the rest (0 for no limit)")
 }
 
 var TestConfig = Config{
@@ -0,0 +1,217 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package headerreader
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+var (
+	limitedInFlightGauge = metrics.NewRegisteredGauge("arb/l1/requests/inflight", nil)
+	limitedWaitingGauge  = metrics.NewRegisteredGauge("arb/l1/requests/waiting", nil)
+)
+
+// LimitedClient allows at most a fixed number of requests to the L1 client it wraps to be in
+// flight at once, queueing the rest until a request finishes. Subscriptions aren't limited.
+type LimitedClient struct {
+	arbutil.L1Interface
+	slots chan struct{}
+}
+
+type rpcClientProvider interface {
+	Client() *rpc.Client
+}
+
+// limitedRPCClient makes raw and batch requests over the wrapped client's RPC connection, each of
+// which counts as one request. It doesn't expose the connection itself, which would bypass the limit.
+type limitedRPCClient struct {
+	*LimitedClient
+	provider rpcClientProvider
+}
+
+func (c *limitedRPCClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := c.acquire(ctx); err != nil {
+		return err
+	}
+	defer c.release()
+	return c.provider.Client().CallContext(ctx, result, method, args...)
+}
+
+func (c *limitedRPCClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if err := c.acquire(ctx); err != nil {
+		return err
+	}
+	defer c.release()
+	return c.provider.Client().BatchCallContext(ctx, b)
+}
+
+// NewLimitedClient wraps client to allow at most maxConcurrent requests in flight at once
+func NewLimitedClient(client arbutil.L1Interface, maxConcurrent int) arbutil.L1Interface {
+	limited := &LimitedClient{
+		L1Interface: client,
+		slots:       make(chan struct{}, maxConcurrent),
+	}
+	if provider, ok := client.(rpcClientProvider); ok {
+		return &limitedRPCClient{limited, provider}
+	}
+	return limited
+}
+
+func (c *LimitedClient) acquire(ctx context.Context) error {
+	select {
+	case c.slots <- struct{}{}:
+	default:
+		limitedWaitingGauge.Inc(1)
+		defer limitedWaitingGauge.Dec(1)
+		select {
+		case c.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	limitedInFlightGauge.Inc(1)
+	return nil
+}
+
+func (c *LimitedClient) release() {
+	limitedInFlightGauge.Dec(1)
+	<-c.slots
+}
+
+func (c *LimitedClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.CodeAt(ctx, contract, blockNumber)
+}
+
+func (c *LimitedClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.CallContract(ctx, call, blockNumber)
+}
+
+func (c *LimitedClient) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.PendingCallContract(ctx, call)
+}
+
+func (c *LimitedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.HeaderByNumber(ctx, number)
+}
+
+func (c *LimitedClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.HeaderByHash(ctx, hash)
+}
+
+func (c *LimitedClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.BlockByNumber(ctx, number)
+}
+
+func (c *LimitedClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.BlockByHash(ctx, hash)
+}
+
+func (c *LimitedClient) BlockNumber(ctx context.Context) (uint64, error) {
+	if err := c.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer c.release()
+	return c.L1Interface.BlockNumber(ctx)
+}
+
+func (c *LimitedClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.FilterLogs(ctx, query)
+}
+
+func (c *LimitedClient) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, false, err
+	}
+	defer c.release()
+	return c.L1Interface.TransactionByHash(ctx, txHash)
+}
+
+func (c *LimitedClient) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.TransactionInBlock(ctx, blockHash, index)
+}
+
+func (c *LimitedClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.TransactionReceipt(ctx, txHash)
+}
+
+func (c *LimitedClient) TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error) {
+	if err := c.acquire(ctx); err != nil {
+		return common.Address{}, err
+	}
+	defer c.release()
+	return c.L1Interface.TransactionSender(ctx, tx, block, index)
+}
+
+func (c *LimitedClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.BalanceAt(ctx, account, blockNumber)
+}
+
+func (c *LimitedClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	return c.L1Interface.StorageAt(ctx, account, key, blockNumber)
+}
+
+func (c *LimitedClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	if err := c.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer c.release()
+	return c.L1Interface.NonceAt(ctx, account, blockNumber)
+}
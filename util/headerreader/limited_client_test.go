@@ -0,0 +1,98 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package headerreader
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+type slowService struct {
+	inFlight    int32
+	maxInFlight int32
+}
+
+func (s *slowService) Wait() int {
+	inFlight := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+	for {
+		highest := atomic.LoadInt32(&s.maxInFlight)
+		if inFlight <= highest || atomic.CompareAndSwapInt32(&s.maxInFlight, highest, inFlight) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return 1
+}
+
+func TestLimitedClientRawRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := &slowService{}
+	server := rpc.NewServer()
+	Require(t, server.RegisterName("test", service))
+	defer server.Stop()
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+
+	maxConcurrent := 2
+	client := NewLimitedClient(ethclient.NewClient(rpcClient), maxConcurrent)
+	if _, ok := client.(interface{ Client() *rpc.Client }); ok {
+		Fail(t, "limited client exposes its unlimited RPC connection")
+	}
+	caller, ok := client.(interface {
+		CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+		BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+	})
+	if !ok {
+		Fail(t, "limited client doesn't make raw requests")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var result int
+			errs <- caller.CallContext(ctx, &result, "test_wait")
+		}()
+		go func() {
+			defer wg.Done()
+			results := make([]int, 3)
+			elems := make([]rpc.BatchElem, len(results))
+			for j := range elems {
+				elems[j] = rpc.BatchElem{Method: "test_wait", Result: &results[j]}
+			}
+			errs <- caller.BatchCallContext(ctx, elems)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		Require(t, err)
+	}
+	if highest := atomic.LoadInt32(&service.maxInFlight); highest > int32(maxConcurrent) {
+		Fail(t, "had", highest, "requests in flight, over the limit of", maxConcurrent)
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
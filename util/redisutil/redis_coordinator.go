@@ -13,12 +13,14 @@ import (
 	"github.com/offchainlabs/nitro/arbutil"
 )
 
-const CHOSENSEQ_KEY string = "coordinator.chosen"              // Never overwritten. Expires or released only
-const MSG_COUNT_KEY string = "coordinator.msgCount"            // Only written by sequencer holding CHOSEN key
-const PRIORITIES_KEY string = "coordinator.priorities"         // Read only
-const LIVELINESS_KEY_PREFIX string = "coordinator.liveliness." // Per server. Only written by self
-const MESSAGE_KEY_PREFIX string = "coordinator.msg."           // Per Message. Only written by sequencer holding CHOSEN
-const SIGNATURE_KEY_PREFIX string = "coordinator.msg.sig."     // Per Message. Only written by sequencer holding CHOSEN
+const CHOSENSEQ_KEY string = "coordinator.chosen"                   // Never overwritten. Expires or released only
+const MSG_COUNT_KEY string = "coordinator.msgCount"                 // Only written by sequencer holding CHOSEN key
+const PRIORITIES_KEY string = "coordinator.priorities"              // Read only
+const FENCING_TOKEN_KEY string = "coordinator.fencingToken"         // Incremented by each sequencer taking the CHOSEN key
+const LIVELINESS_KEY_PREFIX string = "coordinator.liveliness."      // Per server. Only written by self
+const WANTS_LOCKOUT_KEY_PREFIX string = "coordinator.wantsLockout." // Per server. Only written by self
+const MESSAGE_KEY_PREFIX string = "coordinator.msg."                // Per Message. Only written by sequencer holding CHOSEN
+const SIGNATURE_KEY_PREFIX string = "coordinator.msg.sig."          // Per Message. Only written by sequencer holding CHOSEN
 const LIVELINESS_VAL string = "OK"
 const INVALID_VAL string = "INVALID"
 const INVALID_URL string = "<?INVALID-URL?>"
@@ -29,6 +31,8 @@ type RedisCoordinator struct {
 
 func LivelinessKeyFor(url string) string { return LIVELINESS_KEY_PREFIX + url }
 
+func WantsLockoutKeyFor(url string) string { return WANTS_LOCKOUT_KEY_PREFIX + url }
+
 func NewRedisCoordinator(redisUrl string) (*RedisCoordinator, error) {
 	redisClient, err := RedisClientFromURL(redisUrl)
 	if err != nil {
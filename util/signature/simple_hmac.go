@@ -43,8 +43,8 @@ func NewSimpleHmac(config *SimpleHmacConfig) (*SimpleHmac, error) {
 }
 
 type SimpleHmacConfig struct {
-	SigningKey              string                    `koanf:"signing-key"`
-	FallbackVerificationKey string                    `koanf:"fallback-verification-key"`
+	SigningKey              string                    `koanf:"signing-key" secret:"true"`
+	FallbackVerificationKey string                    `koanf:"fallback-verification-key" secret:"true"`
 	Dangerous               SimpleHmacDangerousConfig `koanf:"dangerous"`
 }
 
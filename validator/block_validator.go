@@ -58,6 +58,7 @@ type BlockValidator struct {
 
 type BlockValidatorConfig struct {
 	Enable                   bool                          `koanf:"enable"`
+	Required                 bool                          `koanf:"required"`
 	ArbitratorValidator      bool                          `koanf:"arbitrator-validator"`
 	JitValidator             bool                          `koanf:"jit-validator"`
 	JitValidatorCranelift    bool                          `koanf:"jit-validator-cranelift"`
@@ -77,6 +78,7 @@ type BlockValidatorConfigFetcher func() *BlockValidatorConfig
 
 func BlockValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultBlockValidatorConfig.Enable, "enable block-by-block validation")
+	f.Bool(prefix+".required", DefaultBlockValidatorConfig.Required, "fail to start if block-by-block validation is enabled but its machines can't be loaded (if false, validation is disabled with a warning instead)")
 	f.Bool(prefix+".arbitrator-validator", DefaultBlockValidatorConfig.ArbitratorValidator, "enable the complete, arbitrator block validator")
 	f.Bool(prefix+".jit-validator", DefaultBlockValidatorConfig.JitValidator, "enable the faster, jit-accelerated block validator")
 	f.Bool(prefix+".jit-validator-cranelift", DefaultBlockValidatorConfig.JitValidatorCranelift, "use Cranelift instead of LLVM when validating blocks using the jit-accelerated block validator")
@@ -94,6 +96,7 @@ func BlockValidatorDangerousConfigAddOptions(prefix string, f *flag.FlagSet) {
 
 var DefaultBlockValidatorConfig = BlockValidatorConfig{
 	Enable:                   false,
+	Required:                 true,
 	ArbitratorValidator:      false,
 	JitValidator:             true,
 	JitValidatorCranelift:    true,
@@ -107,6 +110,7 @@ var DefaultBlockValidatorConfig = BlockValidatorConfig{
 
 var TestBlockValidatorConfig = BlockValidatorConfig{
 	Enable:                   false,
+	Required:                 true,
 	ArbitratorValidator:      false,
 	JitValidator:             false,
 	JitValidatorCranelift:    true,
@@ -891,7 +895,7 @@ func (v *BlockValidator) Initialize() error {
 	case "latest":
 		latest, err := v.MachineLoader.GetConfig().ReadLatestWasmModuleRoot()
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to read the latest wasm module root from %v: %w", v.MachineLoader.GetConfig().getMachinePath(common.Hash{}), err)
 		}
 		v.currentWasmModuleRoot = latest
 	case "current":
@@ -906,12 +910,12 @@ func (v *BlockValidator) Initialize() error {
 	}
 	if config.ArbitratorValidator {
 		if err := v.MachineLoader.CreateMachine(v.currentWasmModuleRoot, true, false); err != nil {
-			return err
+			return v.machineLoadError(err)
 		}
 	}
 	if config.JitValidator {
 		if err := v.MachineLoader.CreateMachine(v.currentWasmModuleRoot, true, true); err != nil {
-			return err
+			return v.machineLoadError(err)
 		}
 	}
 
@@ -919,6 +923,20 @@ func (v *BlockValidator) Initialize() error {
 	return nil
 }
 
+// Required returns whether the node should fail to start if the validator can't be initialized
+func (v *BlockValidator) Required() bool {
+	return v.config().Required
+}
+
+// machineLoadError explains which machine failed to load and where it was expected
+func (v *BlockValidator) machineLoadError(err error) error {
+	machinePath := v.MachineLoader.GetConfig().getMachinePath(v.currentWasmModuleRoot)
+	return fmt.Errorf(
+		"failed to load the machine for wasm module root %v (current-module-root %q) from %v; install the machine for this module root there or point --node.wasm.root-path at a machines directory containing it: %w",
+		v.currentWasmModuleRoot, v.config().CurrentModuleRoot, machinePath, err,
+	)
+}
+
 func (v *BlockValidator) Start(ctxIn context.Context) error {
 	v.StopWaiter.Start(ctxIn, v)
 	v.LaunchThread(func(ctx context.Context) {
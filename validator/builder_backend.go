@@ -24,6 +24,7 @@ type ValidatorTxBuilder struct {
 	arbutil.L1Interface
 	transactions []*types.Transaction
 	builderAuth  *bind.TransactOpts
+	baseGasPrice *big.Int
 	isAuthFake   bool
 	wallet       ValidatorWalletInterface
 }
@@ -44,10 +45,11 @@ func NewValidatorTxBuilder(wallet ValidatorWalletInterface) (*ValidatorTxBuilder
 		isAuthFake = true
 	}
 	return &ValidatorTxBuilder{
-		builderAuth: builderAuth,
-		wallet:      wallet,
-		L1Interface: wallet.L1Client(),
-		isAuthFake:  isAuthFake,
+		builderAuth:  builderAuth,
+		baseGasPrice: builderAuth.GasPrice,
+		wallet:       wallet,
+		L1Interface:  wallet.L1Client(),
+		isAuthFake:   isAuthFake,
 	}, nil
 }
 
@@ -77,6 +79,22 @@ func (b *ValidatorTxBuilder) SendTransaction(ctx context.Context, tx *types.Tran
 	return nil
 }
 
+// SetGasPrice overrides the gas price of the transactions built from now on, or restores the wallet's own if nil
+func (b *ValidatorTxBuilder) SetGasPrice(gasPrice *big.Int) {
+	if gasPrice == nil {
+		gasPrice = b.baseGasPrice
+	}
+	b.builderAuth.GasPrice = gasPrice
+}
+
+// GasPriceOverride returns the gas price set by SetGasPrice, if any
+func (b *ValidatorTxBuilder) GasPriceOverride() *big.Int {
+	if b.builderAuth.GasPrice == b.baseGasPrice {
+		return nil
+	}
+	return b.builderAuth.GasPrice
+}
+
 func (b *ValidatorTxBuilder) AuthWithAmount(ctx context.Context, amount *big.Int) *bind.TransactOpts {
 	return &bind.TransactOpts{
 		From:     b.builderAuth.From,
@@ -0,0 +1,145 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var divergenceDetectedCounter = metrics.NewRegisteredCounter("arb/consensus/divergence_detected", nil)
+
+type DivergenceCheckConfig struct {
+	Enable   bool          `koanf:"enable"`
+	Interval time.Duration `koanf:"interval"`
+}
+
+var DefaultDivergenceCheckConfig = DivergenceCheckConfig{
+	Enable:   false,
+	Interval: time.Minute,
+}
+
+func DivergenceCheckConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultDivergenceCheckConfig.Enable, "compare the blocks of confirmed rollup assertions against the locally computed chain (requires the L1 reader)")
+	f.Duration(prefix+".interval", DefaultDivergenceCheckConfig.Interval, "how often to check the latest confirmed assertion")
+}
+
+// DivergenceMonitor watches the latest confirmed rollup node, and checks that the block it asserts
+// is the block this node computed at the same position in the inbox
+type DivergenceMonitor struct {
+	stopwaiter.StopWaiter
+	config             *DivergenceCheckConfig
+	rollup             *RollupWatcher
+	l2Blockchain       *core.BlockChain
+	inboxTracker       InboxTrackerInterface
+	genesisBlockNumber uint64
+	lastChecked        uint64
+}
+
+func NewDivergenceMonitor(
+	config *DivergenceCheckConfig,
+	client arbutil.L1Interface,
+	rollupAddress common.Address,
+	l2Blockchain *core.BlockChain,
+	inboxTracker InboxTrackerInterface,
+	txStreamer TransactionStreamerInterface,
+) (*DivergenceMonitor, error) {
+	rollup, err := NewRollupWatcher(rollupAddress, client, bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	genesisBlockNumber, err := txStreamer.GetGenesisBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	return &DivergenceMonitor{
+		config:             config,
+		rollup:             rollup,
+		l2Blockchain:       l2Blockchain,
+		inboxTracker:       inboxTracker,
+		genesisBlockNumber: genesisBlockNumber,
+	}, nil
+}
+
+func (m *DivergenceMonitor) Start(ctxIn context.Context) {
+	m.StopWaiter.Start(ctxIn, m)
+	m.CallIteratively(func(ctx context.Context) time.Duration {
+		if err := m.checkLatestConfirmed(ctx); err != nil {
+			log.Warn("error checking confirmed assertion against local chain", "err", err)
+		}
+		return m.config.Interval
+	})
+}
+
+// checkLatestConfirmed compares the latest confirmed node's block against the local one, once the
+// node has read the batches and executed the blocks the assertion covers
+func (m *DivergenceMonitor) checkLatestConfirmed(ctx context.Context) error {
+	latestConfirmed, err := m.rollup.LatestConfirmed(m.rollup.getCallOpts(ctx))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if latestConfirmed == 0 || latestConfirmed == m.lastChecked {
+		return nil
+	}
+	info, err := m.rollup.LookupNode(ctx, latestConfirmed)
+	if err != nil {
+		return err
+	}
+	afterState := info.AfterState()
+	globalState := afterState.GlobalState
+	batchCount, err := m.inboxTracker.GetBatchCount()
+	if err != nil {
+		return err
+	}
+	if batchCount < afterState.RequiredBatches() {
+		// the inbox reader hasn't caught up to the assertion
+		return nil
+	}
+	blockNum, inboxPositionInvalid, err := blockNumberFromGlobalState(m.inboxTracker, m.genesisBlockNumber, globalState)
+	if err != nil {
+		return err
+	}
+	if blockNum < 0 || uint64(blockNum) > m.l2Blockchain.CurrentBlock().NumberU64() {
+		return nil
+	}
+	block := m.l2Blockchain.GetBlockByNumber(uint64(blockNum))
+	if block == nil {
+		return errors.Errorf("local block %v not found", blockNum)
+	}
+	var localSendRoot common.Hash
+	if extra, err := types.DeserializeHeaderExtraInformation(block.Header()); err == nil {
+		localSendRoot = extra.SendRoot
+	}
+	m.lastChecked = latestConfirmed
+	if !inboxPositionInvalid && block.Hash() == globalState.BlockHash && localSendRoot == globalState.SendRoot {
+		log.Debug("confirmed assertion matches local chain", "node", latestConfirmed, "block", blockNum)
+		return nil
+	}
+	divergenceDetectedCounter.Inc(1)
+	log.Error(
+		"CONSENSUS DIVERGENCE DETECTED: a confirmed assertion doesn't match this node's chain",
+		"node", latestConfirmed,
+		"block", blockNum,
+		"confirmedBlockHash", globalState.BlockHash,
+		"localBlockHash", block.Hash(),
+		"confirmedSendRoot", globalState.SendRoot,
+		"localSendRoot", localSendRoot,
+		"batch", globalState.Batch,
+		"posInBatch", globalState.PosInBatch,
+		"inboxPositionInvalid", inboxPositionInvalid,
+	)
+	return nil
+}
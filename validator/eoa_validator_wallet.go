@@ -5,7 +5,9 @@ package validator
 
 import (
 	"context"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -93,6 +95,13 @@ func (w *EoaValidatorWallet) TimeoutChallenges(ctx context.Context, timeouts []u
 	return w.challengeManager.Timeout(&auth, timeouts[0])
 }
 
+func (w *EoaValidatorWallet) TransferFunds(ctx context.Context, amount *big.Int, destination common.Address) (*types.Transaction, error) {
+	auth := *w.auth
+	auth.Context = ctx
+	auth.Value = amount
+	return bind.NewBoundContract(destination, abi.ABI{}, nil, w.client, nil).Transfer(&auth)
+}
+
 func (w *EoaValidatorWallet) CanBatchTxs() bool {
 	return false
 }
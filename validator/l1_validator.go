@@ -118,6 +118,12 @@ func (v *L1Validator) Initialize(ctx context.Context) error {
 	return v.updateBlockValidatorModuleRoot(ctx)
 }
 
+// DetachBlockValidator makes the validator stop consulting a block validator that won't be
+// started. It must be called before the staker is started.
+func (v *L1Validator) DetachBlockValidator() {
+	v.blockValidator = nil
+}
+
 func (v *L1Validator) updateBlockValidatorModuleRoot(ctx context.Context) error {
 	if v.blockValidator == nil {
 		return nil
@@ -225,10 +231,14 @@ type OurStakerInfo struct {
 // Returns (block number, global state inbox position is invalid, error).
 // If global state is invalid, block number is set to the last of the batch.
 func (v *L1Validator) blockNumberFromGlobalState(gs GoGlobalState) (int64, bool, error) {
+	return blockNumberFromGlobalState(v.inboxTracker, v.genesisBlockNumber, gs)
+}
+
+func blockNumberFromGlobalState(inboxTracker InboxTrackerInterface, genesisBlockNumber uint64, gs GoGlobalState) (int64, bool, error) {
 	var batchHeight arbutil.MessageIndex
 	if gs.Batch > 0 {
 		var err error
-		batchHeight, err = v.inboxTracker.GetBatchMessageCount(gs.Batch - 1)
+		batchHeight, err = inboxTracker.GetBatchMessageCount(gs.Batch - 1)
 		if err != nil {
 			return 0, false, err
 		}
@@ -236,7 +246,7 @@ func (v *L1Validator) blockNumberFromGlobalState(gs GoGlobalState) (int64, bool,
 
 	// Validate the PosInBatch if it's non-zero
 	if gs.PosInBatch > 0 {
-		nextBatchHeight, err := v.inboxTracker.GetBatchMessageCount(gs.Batch)
+		nextBatchHeight, err := inboxTracker.GetBatchMessageCount(gs.Batch)
 		if err != nil {
 			return 0, false, err
 		}
@@ -244,11 +254,11 @@ func (v *L1Validator) blockNumberFromGlobalState(gs GoGlobalState) (int64, bool,
 		if gs.PosInBatch >= uint64(nextBatchHeight-batchHeight) {
 			// This PosInBatch would enter the next batch. Return the last block before the next batch.
 			// We can be sure that MessageCountToBlockNumber will return a non-negative number as nextBatchHeight must be nonzero.
-			return arbutil.MessageCountToBlockNumber(nextBatchHeight, v.genesisBlockNumber), true, nil
+			return arbutil.MessageCountToBlockNumber(nextBatchHeight, genesisBlockNumber), true, nil
 		}
 	}
 
-	return arbutil.MessageCountToBlockNumber(batchHeight+arbutil.MessageIndex(gs.PosInBatch), v.genesisBlockNumber), false, nil
+	return arbutil.MessageCountToBlockNumber(batchHeight+arbutil.MessageIndex(gs.PosInBatch), genesisBlockNumber), false, nil
 }
 
 func (v *L1Validator) generateNodeAction(ctx context.Context, stakerInfo *OurStakerInfo, strategy StakerStrategy, makeAssertionInterval time.Duration) (nodeAction, bool, error) {
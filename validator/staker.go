@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/offchainlabs/nitro/arbstate"
@@ -17,12 +18,20 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/pkg/errors"
 	flag "github.com/spf13/pflag"
 
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
 
+var (
+	stakerBalanceGauge  = metrics.NewRegisteredGaugeFloat64("arb/staker/l1_balance_wei", nil)
+	badAssertionCounter = metrics.NewRegisteredCounter("arb/validator/bad_assertion_detected", nil)
+	stakerIntervalGauge = metrics.NewRegisteredGauge("arb/staker/effective_interval_ms", nil)
+)
+
 type StakerStrategy uint8
 
 const (
@@ -51,35 +60,79 @@ func L1PostingStrategyAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int64(prefix+".high-gas-delay-blocks", DefaultL1PostingStrategy.HighGasDelayBlocks, "high gas delay blocks")
 }
 
+type ChallengeGasConfig struct {
+	GasMultiplier              float64 `koanf:"gas-multiplier"`
+	MaxGasPriceDuringChallenge float64 `koanf:"max-gas-price-during-challenge"`
+}
+
+var DefaultChallengeGasConfig = ChallengeGasConfig{
+	GasMultiplier:              1.5,
+	MaxGasPriceDuringChallenge: 0,
+}
+
+func ChallengeGasConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Float64(prefix+".gas-multiplier", DefaultChallengeGasConfig.GasMultiplier, "while defending a challenge, multiply the suggested L1 gas price by this and ignore the high gas delay (0 to price challenge transactions normally)")
+	f.Float64(prefix+".max-gas-price-during-challenge", DefaultChallengeGasConfig.MaxGasPriceDuringChallenge, "the highest L1 gas price (in gwei) to pay while defending a challenge (0 for no limit)")
+}
+
+type AutoWithdrawConfig struct {
+	Enable      bool          `koanf:"enable"`
+	Destination string        `koanf:"destination"`
+	MinAmount   float64       `koanf:"min-amount"`
+	Timeout     time.Duration `koanf:"timeout"`
+}
+
+var DefaultAutoWithdrawConfig = AutoWithdrawConfig{
+	Enable:      false,
+	Destination: "",
+	MinAmount:   0.1,
+	Timeout:     10 * time.Minute,
+}
+
+func AutoWithdrawConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAutoWithdrawConfig.Enable, "automatically withdraw refunded stake from the rollup once it reaches min-amount")
+	f.String(prefix+".destination", DefaultAutoWithdrawConfig.Destination, "address to forward withdrawn stake to (empty to leave it in the validator wallet)")
+	f.Float64(prefix+".min-amount", DefaultAutoWithdrawConfig.MinAmount, "the smallest withdrawal (in ETH) worth sending transactions for")
+	f.Duration(prefix+".timeout", DefaultAutoWithdrawConfig.Timeout, "how long the withdrawal and forwarding may hold up the staker before they're abandoned until the next idle check (0 = no limit)")
+}
+
 type L1ValidatorConfig struct {
-	Enable                   bool              `koanf:"enable"`
-	Strategy                 string            `koanf:"strategy"`
-	StakerInterval           time.Duration     `koanf:"staker-interval"`
-	MakeAssertionInterval    time.Duration     `koanf:"make-assertion-interval"`
-	L1PostingStrategy        L1PostingStrategy `koanf:"posting-strategy"`
-	DisableChallenge         bool              `koanf:"disable-challenge"`
-	TargetMachineCount       int               `koanf:"target-machine-count"`
-	ConfirmationBlocks       int64             `koanf:"confirmation-blocks"`
-	UseSmartContractWallet   bool              `koanf:"use-smart-contract-wallet"`
-	OnlyCreateWalletContract bool              `koanf:"only-create-wallet-contract"`
-	ContractWalletAddress    string            `koanf:"contract-wallet-address"`
-	GasRefunderAddress       string            `koanf:"gas-refunder-address"`
-	Dangerous                DangerousConfig   `koanf:"dangerous"`
+	Enable                   bool               `koanf:"enable"`
+	Strategy                 string             `koanf:"strategy"`
+	StakerInterval           time.Duration      `koanf:"staker-interval"`
+	MaxIdleStakerInterval    time.Duration      `koanf:"max-idle-staker-interval"`
+	MakeAssertionInterval    time.Duration      `koanf:"make-assertion-interval"`
+	L1PostingStrategy        L1PostingStrategy  `koanf:"posting-strategy"`
+	DisableChallenge         bool               `koanf:"disable-challenge"`
+	Challenge                ChallengeGasConfig `koanf:"challenge"`
+	TargetMachineCount       int                `koanf:"target-machine-count"`
+	ConfirmationBlocks       int64              `koanf:"confirmation-blocks"`
+	UseSmartContractWallet   bool               `koanf:"use-smart-contract-wallet"`
+	OnlyCreateWalletContract bool               `koanf:"only-create-wallet-contract"`
+	ContractWalletAddress    string             `koanf:"contract-wallet-address"`
+	GasRefunderAddress       string             `koanf:"gas-refunder-address"`
+	MinL1Balance             float64            `koanf:"min-l1-balance"`
+	AutoWithdraw             AutoWithdrawConfig `koanf:"auto-withdraw"`
+	Dangerous                DangerousConfig    `koanf:"dangerous"`
 }
 
 var DefaultL1ValidatorConfig = L1ValidatorConfig{
 	Enable:                   false,
 	Strategy:                 "Watchtower",
 	StakerInterval:           time.Minute,
+	MaxIdleStakerInterval:    0,
 	MakeAssertionInterval:    time.Hour,
 	L1PostingStrategy:        L1PostingStrategy{},
 	DisableChallenge:         false,
+	Challenge:                DefaultChallengeGasConfig,
 	TargetMachineCount:       4,
 	ConfirmationBlocks:       12,
 	UseSmartContractWallet:   false,
 	OnlyCreateWalletContract: false,
 	ContractWalletAddress:    "",
 	GasRefunderAddress:       "",
+	MinL1Balance:             0,
+	AutoWithdraw:             DefaultAutoWithdrawConfig,
 	Dangerous:                DefaultDangerousConfig,
 }
 
@@ -87,18 +140,32 @@ func L1ValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultL1ValidatorConfig.Enable, "enable validator")
 	f.String(prefix+".strategy", DefaultL1ValidatorConfig.Strategy, "L1 validator strategy, either watchtower, defensive, stakeLatest, or makeNodes")
 	f.Duration(prefix+".staker-interval", DefaultL1ValidatorConfig.StakerInterval, "how often the L1 validator should check the status of the L1 rollup and maybe take action with its stake")
+	f.Duration(prefix+".max-idle-staker-interval", DefaultL1ValidatorConfig.MaxIdleStakerInterval, "while there are no nodes to resolve or challenges, double the staker interval after each idle check up to this (0 to always use staker-interval)")
 	f.Duration(prefix+".make-assertion-interval", DefaultL1ValidatorConfig.MakeAssertionInterval, "if configured with the makeNodes strategy, how often to create new assertions (bypassed in case of a dispute)")
 	L1PostingStrategyAddOptions(prefix+".posting-strategy", f)
 	f.Bool(prefix+".disable-challenge", DefaultL1ValidatorConfig.DisableChallenge, "disable validator challenge")
+	ChallengeGasConfigAddOptions(prefix+".challenge", f)
 	f.Int(prefix+".target-machine-count", DefaultL1ValidatorConfig.TargetMachineCount, "target machine count")
 	f.Int64(prefix+".confirmation-blocks", DefaultL1ValidatorConfig.ConfirmationBlocks, "confirmation blocks")
 	f.Bool(prefix+".use-smart-contract-wallet", DefaultL1ValidatorConfig.UseSmartContractWallet, "use a smart contract wallet instead of an EOA address")
 	f.Bool(prefix+".only-create-wallet-contract", DefaultL1ValidatorConfig.OnlyCreateWalletContract, "only create smart wallet contract and exit")
 	f.String(prefix+".contract-wallet-address", DefaultL1ValidatorConfig.ContractWalletAddress, "validator smart contract wallet public address")
 	f.String(prefix+".gas-refunder-address", DefaultL1ValidatorConfig.GasRefunderAddress, "The gas refunder contract address (optional)")
+	f.Float64(prefix+".min-l1-balance", DefaultL1ValidatorConfig.MinL1Balance, "warn when the staker's L1 balance (in ETH) approaches this amount, and log an error below it (0 to disable)")
+	AutoWithdrawConfigAddOptions(prefix+".auto-withdraw", f)
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 }
 
+func (c *L1ValidatorConfig) Validate() error {
+	if _, err := stakerStrategyFromString(c.Strategy); err != nil {
+		return err
+	}
+	if c.AutoWithdraw.Destination != "" && !common.IsHexAddress(c.AutoWithdraw.Destination) {
+		return errors.New("invalid validator auto-withdraw destination address")
+	}
+	return nil
+}
+
 type DangerousConfig struct {
 	WithoutBlockValidator bool `koanf:"without-block-validator"`
 }
@@ -130,6 +197,14 @@ type Staker struct {
 	bringActiveUntilNode    uint64
 	inboxReader             InboxReaderInterface
 	nitroMachineLoader      *NitroMachineLoader
+	challengeGasActive      bool
+	idle                    bool
+	idleInterval            time.Duration
+	lastActionUnixNano      int64 // atomic
+	inChallenge             int32 // atomic
+	// withdrawn stake not yet forwarded to the auto-withdraw destination, and the transfer sent for it
+	pendingForward   *big.Int
+	pendingForwardTx *types.Transaction
 }
 
 func stakerStrategyFromString(s string) (StakerStrategy, error) {
@@ -217,12 +292,14 @@ func (s *Staker) Start(ctxIn context.Context) {
 		if err != nil {
 			log.Warn("error updating latest wasm module root", "err", err)
 		}
+		s.checkL1Balance(ctx)
 		arbTx, err := s.Act(ctx)
 		if err == nil && arbTx != nil {
 			_, err = s.l1Reader.WaitForTxApproval(ctx, arbTx)
 			err = errors.Wrap(err, "error waiting for tx receipt")
 			if err == nil {
 				log.Info("successfully executed staker transaction", "hash", arbTx.Hash())
+				s.recordAction()
 			}
 		}
 		if err == nil {
@@ -231,8 +308,14 @@ func (s *Staker) Start(ctxIn context.Context) {
 				// Try to create another tx
 				return 0
 			}
-			return s.config.StakerInterval
+			if arbTx == nil {
+				if err := s.autoWithdraw(ctx); err != nil {
+					log.Warn("error automatically withdrawing refunded stake", "err", err)
+				}
+			}
+			return s.nextInterval()
 		}
+		s.idleInterval = 0
 		backoff *= 2
 		if backoff > time.Minute {
 			backoff = time.Minute
@@ -244,6 +327,119 @@ func (s *Staker) Start(ctxIn context.Context) {
 	})
 }
 
+// nextInterval widens the time until the next check while the staker stays idle, and resets it once action is needed
+func (s *Staker) nextInterval() time.Duration {
+	interval := s.config.StakerInterval
+	if s.idle && s.config.MaxIdleStakerInterval > interval {
+		if s.idleInterval != 0 {
+			interval = s.idleInterval * 2
+		}
+		if interval > s.config.MaxIdleStakerInterval {
+			interval = s.config.MaxIdleStakerInterval
+		}
+		s.idleInterval = interval
+	} else {
+		s.idleInterval = 0
+	}
+	stakerIntervalGauge.Update(interval.Milliseconds())
+	return interval
+}
+
+// autoWithdraw withdraws refunded stake from the rollup once it's worth the gas, and forwards it to
+// the configured destination. It waits while the validator is in a challenge, so the challenge's
+// moves aren't held up behind it. Stake that was withdrawn but not forwarded is forwarded before
+// anything else is withdrawn.
+func (s *Staker) autoWithdraw(ctx context.Context) error {
+	config := s.config.AutoWithdraw
+	walletAddress := s.wallet.Address()
+	if !config.Enable || s.strategy == WatchtowerStrategy || walletAddress == nil || s.InChallenge() {
+		return nil
+	}
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	if s.pendingForward != nil {
+		return s.forwardWithdrawn(ctx)
+	}
+	withdrawable, err := s.rollup.WithdrawableFunds(s.getCallOpts(ctx), *walletAddress)
+	if err != nil {
+		return err
+	}
+	minAmount, _ := big.NewFloat(config.MinAmount * params.Ether).Int(nil)
+	if withdrawable.Sign() <= 0 || withdrawable.Cmp(minAmount) < 0 {
+		return nil
+	}
+	s.builder.ClearTransactions()
+	_, err = s.rollup.WithdrawStakerFunds(s.builder.Auth(ctx))
+	if err != nil {
+		return err
+	}
+	tx, err := s.wallet.ExecuteTransactions(ctx, s.builder, common.HexToAddress(s.config.GasRefunderAddress))
+	if err != nil {
+		return err
+	}
+	if _, err := s.l1Reader.WaitForTxApproval(ctx, tx); err != nil {
+		return errors.Wrap(err, "error waiting for withdrawal receipt")
+	}
+	s.recordAction()
+	log.Info("withdrew refunded stake", "amount", withdrawable, "wallet", *walletAddress, "hash", tx.Hash())
+	if config.Destination == "" {
+		return nil
+	}
+	s.pendingForward = withdrawable
+	return s.forwardWithdrawn(ctx)
+}
+
+// forwardWithdrawn sends the pending withdrawn stake to the auto-withdraw destination. It stays
+// pending until the transfer succeeds, so a failed one is retried on the next idle check. A transfer
+// that was sent is waited for again rather than sent twice, unless it failed on chain.
+func (s *Staker) forwardWithdrawn(ctx context.Context) error {
+	destination := common.HexToAddress(s.config.AutoWithdraw.Destination)
+	if s.pendingForwardTx == nil {
+		tx, err := s.wallet.TransferFunds(ctx, s.pendingForward, destination)
+		if err != nil {
+			return errors.Wrap(err, "error forwarding withdrawn stake")
+		}
+		s.pendingForwardTx = tx
+	}
+	receipt, err := s.l1Reader.WaitForTxApproval(ctx, s.pendingForwardTx)
+	if err != nil {
+		if receipt != nil {
+			s.pendingForwardTx = nil
+		}
+		return errors.Wrap(err, "error waiting for forwarding receipt")
+	}
+	log.Info("forwarded withdrawn stake", "amount", s.pendingForward, "destination", destination, "hash", s.pendingForwardTx.Hash())
+	s.pendingForward = nil
+	s.pendingForwardTx = nil
+	return nil
+}
+
+func (s *Staker) checkL1Balance(ctx context.Context) {
+	sender := s.wallet.TxSenderAddress()
+	if sender == nil {
+		return
+	}
+	balance, err := s.client.BalanceAt(ctx, *sender, nil)
+	if err != nil {
+		log.Warn("error getting staker L1 balance", "err", err, "address", *sender)
+		return
+	}
+	balanceFloat, _ := new(big.Float).SetInt(balance).Float64()
+	stakerBalanceGauge.Update(balanceFloat)
+	if s.config.MinL1Balance <= 0 {
+		return
+	}
+	minBalance := s.config.MinL1Balance * params.Ether
+	if balanceFloat < minBalance {
+		log.Error("staker L1 balance is below the configured minimum, staker transactions may fail", "address", *sender, "balance", balance, "minBalanceEth", s.config.MinL1Balance)
+	} else if balanceFloat < 2*minBalance {
+		log.Warn("staker L1 balance is running low", "address", *sender, "balance", balance, "minBalanceEth", s.config.MinL1Balance)
+	}
+}
+
 func (s *Staker) IsWhitelisted(ctx context.Context) (bool, error) {
 	callOpts := s.getCallOpts(ctx)
 	whitelistDisabled, err := s.rollup.ValidatorWhitelistDisabled(callOpts)
@@ -309,7 +505,60 @@ func (s *Staker) shouldAct(ctx context.Context) bool {
 	}
 }
 
+// challengeGasPrice scales the suggested L1 gas price for responding to a challenge, capped by the configured maximum
+func (s *Staker) challengeGasPrice(ctx context.Context) (*big.Int, error) {
+	suggested, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, _ := new(big.Float).Mul(new(big.Float).SetInt(suggested), big.NewFloat(s.config.Challenge.GasMultiplier)).Int(nil)
+	if s.config.Challenge.MaxGasPriceDuringChallenge > 0 {
+		maxGasPrice, _ := big.NewFloat(s.config.Challenge.MaxGasPriceDuringChallenge * params.GWei).Int(nil)
+		if gasPrice.Cmp(maxGasPrice) > 0 {
+			gasPrice = maxGasPrice
+		}
+	}
+	return gasPrice, nil
+}
+
+// updateChallengeGasPrice switches the staker's transactions to challenge gas pricing while a challenge is active,
+// which also bypasses the high gas delay, and back to normal pricing afterwards
+func (s *Staker) updateChallengeGasPrice(ctx context.Context, inChallenge bool) {
+	if !inChallenge || s.config.Challenge.GasMultiplier <= 0 {
+		if s.challengeGasActive {
+			log.Info("no longer defending a challenge, returning to normal gas pricing")
+			s.builder.SetGasPrice(nil)
+			s.challengeGasActive = false
+		}
+		return
+	}
+	gasPrice, err := s.challengeGasPrice(ctx)
+	if err != nil {
+		// bind will still estimate a gas price for the transaction
+		log.Warn("error getting gas price for challenge response", "err", err)
+		s.builder.SetGasPrice(nil)
+	} else {
+		s.builder.SetGasPrice(gasPrice)
+	}
+	if !s.challengeGasActive {
+		log.Warn(
+			"defending a challenge, using challenge gas pricing",
+			"gasPrice", gasPrice,
+			"multiplier", s.config.Challenge.GasMultiplier,
+			"maxGasPriceGwei", s.config.Challenge.MaxGasPriceDuringChallenge,
+		)
+		s.challengeGasActive = true
+	}
+}
+
+// InChallenge returns whether the staker was in a challenge when it last checked L1. It's safe to
+// call from other goroutines.
+func (s *Staker) InChallenge() bool {
+	return atomic.LoadInt32(&s.inChallenge) != 0
+}
+
 func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
+	s.idle = false
 	if s.strategy != WatchtowerStrategy {
 		whitelisted, err := s.IsWhitelisted(ctx)
 		if err != nil {
@@ -319,12 +568,6 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			log.Warn("validator address isn't whitelisted", "address", s.wallet.Address(), "txSender", s.wallet.TxSenderAddress())
 		}
 	}
-	if !s.shouldAct(ctx) {
-		// The fact that we're delaying acting is alreay logged in `shouldAct`
-		return nil, nil
-	}
-	callOpts := s.getCallOpts(ctx)
-	s.builder.ClearTransactions()
 	var rawInfo *StakerInfo
 	walletAddressOrZero := s.wallet.AddressOrZero()
 	if walletAddressOrZero != (common.Address{}) {
@@ -334,6 +577,19 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			return nil, err
 		}
 	}
+	inChallenge := rawInfo != nil && rawInfo.CurrentChallenge != nil
+	var inChallengeFlag int32
+	if inChallenge {
+		inChallengeFlag = 1
+	}
+	atomic.StoreInt32(&s.inChallenge, inChallengeFlag)
+	s.updateChallengeGasPrice(ctx, inChallenge)
+	if !s.challengeGasActive && !s.shouldAct(ctx) {
+		// The fact that we're delaying acting is alreay logged in `shouldAct`
+		return nil, nil
+	}
+	callOpts := s.getCallOpts(ctx)
+	s.builder.ClearTransactions()
 	// If the wallet address is zero, or the wallet address isn't staked,
 	// this will return the latest node and its hash (atomically).
 	latestStakedNodeNum, latestStakedNodeInfo, err := s.validatorUtils.LatestStaked(
@@ -381,6 +637,18 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 		info.LatestStakedNodeHash = s.inactiveLastCheckedNode.hash
 	}
 
+	if effectiveStrategy == WatchtowerStrategy {
+		// A watchtower only checks the assertions, and never sends L1 transactions
+		for i := 0; info.CanProgress && i < 20; i++ {
+			if err := s.advanceStake(ctx, &info, effectiveStrategy); err != nil {
+				return nil, err
+			}
+		}
+		s.builder.ClearTransactions()
+		s.idle = !inChallenge
+		return nil, nil
+	}
+
 	latestConfirmedNode, err := s.rollup.LatestConfirmed(callOpts)
 	if err != nil {
 		return nil, err
@@ -434,16 +702,19 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			if err != nil {
 				return nil, err
 			}
-			_, err = s.rollup.WithdrawStakerFunds(s.builder.Auth(ctx))
-			if err != nil {
-				return nil, err
+			if !s.config.AutoWithdraw.Enable {
+				_, err = s.rollup.WithdrawStakerFunds(s.builder.Auth(ctx))
+				if err != nil {
+					return nil, err
+				}
 			}
 			log.Info("removing old stake and withdrawing funds")
 			return s.wallet.ExecuteTransactions(ctx, s.builder, common.HexToAddress(s.config.GasRefunderAddress))
 		}
 	}
 
-	if walletAddressOrZero != (common.Address{}) && canActFurther() {
+	// With auto-withdraw enabled, funds are withdrawn separately once they're worth it
+	if walletAddressOrZero != (common.Address{}) && canActFurther() && !s.config.AutoWithdraw.Enable {
 		withdrawable, err := s.rollup.WithdrawableFunds(callOpts, walletAddressOrZero)
 		if err != nil {
 			return nil, err
@@ -483,6 +754,7 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 	}
 
 	if s.builder.BuildingTransactionCount() == 0 {
+		s.idle = !inChallenge && !resolvingNode
 		return nil, nil
 	}
 
@@ -540,8 +812,11 @@ func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiv
 	if err != nil {
 		return err
 	}
+	if wrongNodesExist {
+		badAssertionCounter.Inc(1)
+	}
 	if wrongNodesExist && effectiveStrategy == WatchtowerStrategy {
-		log.Error("found incorrect assertion in watchtower mode")
+		log.Error("BAD ASSERTION DETECTED: found incorrect assertion in watchtower mode, bring up a staker to challenge it", "latestStakedNode", info.LatestStakedNode)
 	}
 	if action == nil {
 		info.CanProgress = false
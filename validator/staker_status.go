@@ -0,0 +1,73 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type StakerStatus struct {
+	Strategy            string          `json:"strategy"`
+	Wallet              *common.Address `json:"wallet,omitempty"`
+	TxSender            *common.Address `json:"txSender,omitempty"`
+	Staked              bool            `json:"staked"`
+	StakedNode          uint64          `json:"stakedNode"`
+	AmountStaked        *hexutil.Big    `json:"amountStaked,omitempty"`
+	InChallenge         bool            `json:"inChallenge"`
+	ChallengeIndex      *uint64         `json:"challengeIndex,omitempty"`
+	L1Balance           *hexutil.Big    `json:"l1Balance,omitempty"`
+	LastActionTime      *time.Time      `json:"lastActionTime,omitempty"`
+	LatestConfirmedNode uint64          `json:"latestConfirmedNode"`
+}
+
+func (s *Staker) recordAction() {
+	atomic.StoreInt64(&s.lastActionUnixNano, time.Now().UnixNano())
+}
+
+// Status reads the staker's stake, challenge, and balance from L1. It only makes calls, so it's safe
+// to use alongside the staker's own loop.
+func (s *Staker) Status(ctx context.Context) (*StakerStatus, error) {
+	callOpts := s.getCallOpts(ctx)
+	status := &StakerStatus{
+		Strategy: s.config.Strategy,
+		Wallet:   s.wallet.Address(),
+		TxSender: s.wallet.TxSenderAddress(),
+	}
+	if lastAction := atomic.LoadInt64(&s.lastActionUnixNano); lastAction != 0 {
+		lastActionTime := time.Unix(0, lastAction)
+		status.LastActionTime = &lastActionTime
+	}
+	walletAddressOrZero := s.wallet.AddressOrZero()
+	if walletAddressOrZero != (common.Address{}) {
+		info, err := s.rollup.StakerInfo(ctx, walletAddressOrZero)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			status.Staked = true
+			status.StakedNode = info.LatestStakedNode
+			status.AmountStaked = (*hexutil.Big)(info.AmountStaked)
+			status.InChallenge = info.CurrentChallenge != nil
+			status.ChallengeIndex = info.CurrentChallenge
+		}
+	}
+	latestConfirmed, err := s.rollup.LatestConfirmed(callOpts)
+	if err != nil {
+		return nil, err
+	}
+	status.LatestConfirmedNode = latestConfirmed
+	if status.TxSender != nil {
+		balance, err := s.client.BalanceAt(ctx, *status.TxSender, nil)
+		if err != nil {
+			return nil, err
+		}
+		status.L1Balance = (*hexutil.Big)(balance)
+	}
+	return status, nil
+}
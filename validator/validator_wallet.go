@@ -47,6 +47,8 @@ type ValidatorWalletInterface interface {
 	TestTransactions(context.Context, []*types.Transaction) error
 	ExecuteTransactions(context.Context, *ValidatorTxBuilder, common.Address) (*types.Transaction, error)
 	TimeoutChallenges(context.Context, []uint64) (*types.Transaction, error)
+	// TransferFunds sends ETH held by the wallet to the destination
+	TransferFunds(ctx context.Context, amount *big.Int, destination common.Address) (*types.Transaction, error)
 	CanBatchTxs() bool
 	AuthIfEoa() *bind.TransactOpts
 }
@@ -222,6 +224,12 @@ func (v *ContractValidatorWallet) ExecuteTransactions(ctx context.Context, build
 		return nil, err
 	}
 
+	if gasPrice := builder.GasPriceOverride(); gasPrice != nil {
+		oldGasPrice := v.auth.GasPrice
+		v.auth.GasPrice = gasPrice
+		defer (func() { v.auth.GasPrice = oldGasPrice })()
+	}
+
 	if len(txes) == 1 {
 		arbTx, err := v.executeTransaction(ctx, txes[0], gasRefunder)
 		if err != nil {
@@ -267,6 +275,17 @@ func (v *ContractValidatorWallet) TimeoutChallenges(ctx context.Context, challen
 	return v.con.TimeoutChallenges(v.auth, v.challengeManagerAddress, challenges)
 }
 
+// TransferFunds withdraws ETH from the wallet contract, which only its owner can do
+func (v *ContractValidatorWallet) TransferFunds(ctx context.Context, amount *big.Int, destination common.Address) (*types.Transaction, error) {
+	if v.con == nil || v.auth == nil {
+		return nil, errors.New("validator smart contract wallet not available")
+	}
+	auth := *v.auth
+	auth.Context = ctx
+	auth.Value = nil
+	return v.con.WithdrawEth(&auth, amount, destination)
+}
+
 func (v *ContractValidatorWallet) L1Client() arbutil.L1Interface {
 	return v.l1Reader.Client()
 }
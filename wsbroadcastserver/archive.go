@@ -0,0 +1,335 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	archiveMessagesCounter = metrics.NewRegisteredCounter("arb/feed/archive/messages", nil)
+	archiveErrorsCounter   = metrics.NewRegisteredCounter("arb/feed/archive/errors", nil)
+	archiveDroppedCounter  = metrics.NewRegisteredCounter("arb/feed/archive/dropped", nil)
+)
+
+const (
+	archiveFilePrefix     = "feed-"
+	archiveFileSuffix     = ".jsonl"
+	archiveFileTimeFormat = "20060102T150405.000000000"
+	// how often files past max-age are looked for between rotations
+	archivePruneInterval = time.Minute
+)
+
+type ArchiveConfig struct {
+	Dir         string        `koanf:"dir"`
+	MaxFileSize int64         `koanf:"max-file-size" reload:"hot"`
+	MaxFiles    int           `koanf:"max-files" reload:"hot"`
+	MaxAge      time.Duration `koanf:"max-age" reload:"hot"`
+	QueueSize   int           `koanf:"queue-size"`
+}
+
+var DefaultArchiveConfig = ArchiveConfig{
+	Dir:         "",
+	MaxFileSize: 128 * 1024 * 1024,
+	MaxFiles:    64,
+	MaxAge:      0,
+	QueueSize:   1024,
+}
+
+func ArchiveConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".dir", DefaultArchiveConfig.Dir, "directory to write every broadcast message to, in rotating files that nitro replay-feed can serve again (empty to disable)")
+	f.Int64(prefix+".max-file-size", DefaultArchiveConfig.MaxFileSize, "size in bytes past which the archive moves on to a new file")
+	f.Int(prefix+".max-files", DefaultArchiveConfig.MaxFiles, "number of archive files to keep, deleting the oldest (0 for no limit)")
+	f.Duration(prefix+".max-age", DefaultArchiveConfig.MaxAge, "delete archive files last written longer ago than this (0 for no limit)")
+	f.Int(prefix+".queue-size", DefaultArchiveConfig.QueueSize, "number of messages that can wait to be written to the archive, past which messages are dropped from it rather than delaying the feed")
+}
+
+func (c *ArchiveConfig) Validate() error {
+	if c.Dir == "" {
+		return nil
+	}
+	if c.MaxFileSize <= 0 {
+		return fmt.Errorf("feed archive max-file-size must be positive, not %v", c.MaxFileSize)
+	}
+	if c.MaxFiles < 0 {
+		return fmt.Errorf("feed archive max-files cannot be negative, not %v", c.MaxFiles)
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("feed archive max-age cannot be negative, not %v", c.MaxAge)
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("feed archive queue-size must be positive, not %v", c.QueueSize)
+	}
+	return nil
+}
+
+// ArchivedMessage is a line of an archive file, a broadcast message with when it was broadcast
+type ArchivedMessage struct {
+	Time    time.Time       `json:"time"`
+	Message json.RawMessage `json:"message"`
+}
+
+type queuedArchiveMessage struct {
+	time    time.Time
+	message interface{}
+}
+
+// feedArchive appends broadcast messages to files in the archive directory, moving on to a new
+// file once the current one reaches max-file-size and deleting the files past max-files or max-age.
+// Messages are written from a queue so a slow disk doesn't hold up the feed, and are dropped while
+// the queue is full.
+type feedArchive struct {
+	config func() *ArchiveConfig
+
+	queueMutex sync.Mutex
+	queue      chan queuedArchiveMessage
+	done       chan struct{}
+	// the number of messages dropped since the queue last had room
+	dropped uint64
+
+	mutex    sync.Mutex
+	file     *os.File
+	written  int64
+	prunedAt time.Time
+}
+
+func newFeedArchive(config func() *ArchiveConfig) *feedArchive {
+	return &feedArchive{config: config}
+}
+
+// start launches the writer, which runs until close
+func (a *feedArchive) start() {
+	a.queueMutex.Lock()
+	defer a.queueMutex.Unlock()
+	if a.queue != nil {
+		return
+	}
+	a.queue = make(chan queuedArchiveMessage, a.config().QueueSize)
+	a.done = make(chan struct{})
+	go func(queue chan queuedArchiveMessage, done chan struct{}) {
+		defer close(done)
+		for queued := range queue {
+			if err := a.write(queued.time, queued.message); err != nil {
+				archiveErrorsCounter.Inc(1)
+				log.Error("failed to archive feed message", "dir", a.config().Dir, "err", err)
+			}
+		}
+	}(a.queue, a.done)
+}
+
+// enqueue queues message to be written, dropping it if the writer is behind by queue-size messages
+func (a *feedArchive) enqueue(message interface{}) {
+	a.queueMutex.Lock()
+	defer a.queueMutex.Unlock()
+	select {
+	case a.queue <- queuedArchiveMessage{time: time.Now(), message: message}:
+		if a.dropped > 0 {
+			log.Warn("feed archive caught up after dropping messages", "dropped", a.dropped)
+			a.dropped = 0
+		}
+		return
+	default:
+	}
+	// also covers a writer that isn't running, as a nil queue is never ready
+	archiveDroppedCounter.Inc(1)
+	if a.dropped == 0 {
+		log.Error("feed archive writes falling behind, dropping messages from the archive", "dir", a.config().Dir, "queue-size", cap(a.queue))
+	}
+	a.dropped++
+}
+
+func (a *feedArchive) write(now time.Time, message interface{}) error {
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(ArchivedMessage{Time: now, Message: encoded})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	config := a.config()
+	if a.file == nil || a.written >= config.MaxFileSize {
+		if err := a.rotate(now); err != nil {
+			return err
+		}
+	} else if config.MaxAge > 0 && now.Sub(a.prunedAt) >= archivePruneInterval {
+		// a slow feed can go longer than max-age without rotating
+		a.prune(config, now)
+	}
+	n, err := a.file.Write(line)
+	a.written += int64(n)
+	if err != nil {
+		return err
+	}
+	archiveMessagesCounter.Inc(1)
+	return nil
+}
+
+// rotate closes the current file and opens a new one, and must be called with the mutex held
+func (a *feedArchive) rotate(now time.Time) error {
+	if a.file != nil {
+		if err := a.file.Close(); err != nil {
+			log.Warn("error closing feed archive file", "file", a.file.Name(), "err", err)
+		}
+		a.file = nil
+	}
+	config := a.config()
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return err
+	}
+	name := filepath.Join(config.Dir, archiveFilePrefix+now.UTC().Format(archiveFileTimeFormat)+archiveFileSuffix)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	a.file = file
+	a.written = 0
+	a.prune(config, now)
+	return nil
+}
+
+// prune deletes the files past the retention, and must be called with the mutex held
+func (a *feedArchive) prune(config *ArchiveConfig, now time.Time) {
+	a.prunedAt = now
+	if err := pruneArchive(config, now, a.file.Name()); err != nil {
+		log.Warn("error deleting old feed archive files", "dir", config.Dir, "err", err)
+	}
+}
+
+// close writes the queued messages, stops the writer and closes the current file
+func (a *feedArchive) close() {
+	a.queueMutex.Lock()
+	queue, done := a.queue, a.done
+	a.queue = nil
+	a.queueMutex.Unlock()
+	if queue != nil {
+		close(queue)
+		<-done
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.file == nil {
+		return
+	}
+	if err := a.file.Close(); err != nil {
+		log.Warn("error closing feed archive file", "file", a.file.Name(), "err", err)
+	}
+	a.file = nil
+}
+
+// ArchiveFiles lists the archive files in dir from oldest to newest
+func ArchiveFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, archiveFilePrefix) && strings.HasSuffix(name, archiveFileSuffix) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	// the names sort by the time the files were started
+	sort.Strings(files)
+	return files, nil
+}
+
+// pruneArchive deletes the files past the configured retention, except current
+func pruneArchive(config *ArchiveConfig, now time.Time, current string) error {
+	files, err := ArchiveFiles(config.Dir)
+	if err != nil {
+		return err
+	}
+	for i, file := range files {
+		if file == current {
+			continue
+		}
+		remove := config.MaxFiles > 0 && len(files)-i > config.MaxFiles
+		if !remove && config.MaxAge > 0 {
+			info, err := os.Stat(file)
+			if err != nil {
+				return err
+			}
+			remove = now.Sub(info.ModTime()) > config.MaxAge
+		}
+		if remove {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadArchive calls handle with each message in the archive file, or each file of the archive
+// directory from oldest to newest, stopping at the first error
+func ReadArchive(path string, handle func(*ArchivedMessage) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files, err = ArchiveFiles(path)
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range files {
+		if err := readArchiveFile(name, handle); err != nil {
+			return errors.Wrapf(err, "failed to read feed archive file %v", name)
+		}
+	}
+	return nil
+}
+
+// readArchiveFile calls handle with each message in the file. A final line cut short, as when the
+// node stopped while writing it, is skipped with a warning.
+func readArchiveFile(name string, handle func(*ArchivedMessage) error) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				log.Warn("skipping incomplete last line of feed archive file", "file", name, "size", len(line))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var message ArchivedMessage
+		if err := json.Unmarshal(line, &message); err != nil {
+			return err
+		}
+		if err := handle(&message); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFeedArchiveRotation(t *testing.T) {
+	config := ArchiveConfig{
+		Dir:         t.TempDir(),
+		MaxFileSize: 1,
+		MaxFiles:    2,
+	}
+	archive := newFeedArchive(func() *ArchiveConfig { return &config })
+	for i := 0; i < 5; i++ {
+		if err := archive.write(time.Now(), map[string]int{"message": i}); err != nil {
+			t.Fatal(err)
+		}
+		// the file names have nanosecond precision, but make sure they differ
+		time.Sleep(time.Millisecond)
+	}
+	archive.close()
+
+	files, err := ArchiveFiles(config.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatal("expected 2 archive files to be kept, found", files)
+	}
+	var replayed []int
+	err = ReadArchive(config.Dir, func(archived *ArchivedMessage) error {
+		var message map[string]int
+		if err := json.Unmarshal(archived.Message, &message); err != nil {
+			return err
+		}
+		replayed = append(replayed, message["message"])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 2 || replayed[0] != 3 || replayed[1] != 4 {
+		t.Fatal("unexpected replayed messages", replayed)
+	}
+}
+
+func TestFeedArchiveQueue(t *testing.T) {
+	config := ArchiveConfig{
+		Dir:         t.TempDir(),
+		MaxFileSize: 1 << 20,
+		QueueSize:   2,
+	}
+	archive := newFeedArchive(func() *ArchiveConfig { return &config })
+	// a slow write holds up the writer, but not the messages being queued
+	archive.mutex.Lock()
+	archive.start()
+	archive.enqueue(0)
+	for i := 0; i < 100 && len(archive.queue) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(archive.queue) > 0 {
+		t.Fatal("writer didn't take the first message")
+	}
+	for i := 1; i <= 3; i++ {
+		archive.enqueue(i)
+	}
+	archive.mutex.Unlock()
+	// closing writes the messages still queued
+	archive.close()
+
+	var replayed []int
+	err := ReadArchive(config.Dir, func(archived *ArchivedMessage) error {
+		var message int
+		if err := json.Unmarshal(archived.Message, &message); err != nil {
+			return err
+		}
+		replayed = append(replayed, message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 3 || replayed[0] != 0 || replayed[1] != 1 || replayed[2] != 2 {
+		t.Fatal("expected the message past the queue size to be dropped, replayed", replayed)
+	}
+}
+
+func TestFeedArchivePruneAge(t *testing.T) {
+	config := ArchiveConfig{
+		Dir:         t.TempDir(),
+		MaxFileSize: 1 << 20,
+		MaxAge:      time.Hour,
+	}
+	archive := newFeedArchive(func() *ArchiveConfig { return &config })
+	defer archive.close()
+	if err := archive.write(time.Now(), map[string]int{"message": 0}); err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(config.Dir, archiveFilePrefix+"20000101T000000.000000000"+archiveFileSuffix)
+	if err := os.WriteFile(old, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oldTime := time.Now().Add(-2 * config.MaxAge)
+	if err := os.Chtimes(old, oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+	// the current file is far from full, so this write only prunes once the interval has passed
+	archive.prunedAt = time.Now().Add(-archivePruneInterval)
+	if err := archive.write(time.Now(), map[string]int{"message": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatal("archive file past max age not pruned without rotation", err)
+	}
+	files, err := ArchiveFiles(config.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatal("expected only the current archive file, found", files)
+	}
+}
+
+func TestReadArchiveTruncated(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, archiveFilePrefix+"20000101T000000.000000000"+archiveFileSuffix)
+	second := filepath.Join(dir, archiveFilePrefix+"20000101T000001.000000000"+archiveFileSuffix)
+	// the node stopped in the middle of writing the last line of the first file
+	if err := os.WriteFile(first, []byte(`{"time":"2000-01-01T00:00:00Z","message":0}`+"\n"+`{"time":"2000-01-01T00:00:00Z","mes`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`{"time":"2000-01-01T00:00:01Z","message":1}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var replayed []int
+	err := ReadArchive(dir, func(archived *ArchivedMessage) error {
+		var message int
+		if err := json.Unmarshal(archived.Message, &message); err != nil {
+			return err
+		}
+		replayed = append(replayed, message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 2 || replayed[0] != 0 || replayed[1] != 1 {
+		t.Fatal("unexpected replayed messages", replayed)
+	}
+
+	// a bad line that isn't the last is still an error
+	if err := os.WriteFile(first, []byte(`{"time":"2000-01-01T00:00:00Z","mes`+"\n"+`{"time":"2000-01-01T00:00:00Z","message":0}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ReadArchive(dir, func(*ArchivedMessage) error { return nil }) == nil {
+		t.Fatal("accepted a corrupt archive line")
+	}
+}
@@ -35,17 +35,35 @@ type ClientConnection struct {
 
 	lastHeardUnix int64
 	out           chan []byte
+
+	connectedAt  time.Time
+	messagesSent uint64
+	// caughtUpUnixNano is the last time the client's send queue was empty
+	caughtUpUnixNano int64
+}
+
+// ClientInfo is a point in time snapshot of a connected client
+type ClientInfo struct {
+	Name         string
+	RemoteAddr   string
+	ConnectedAt  time.Time
+	Age          time.Duration
+	QueueDepth   int
+	MessagesSent uint64
+	Lag          time.Duration
 }
 
 func NewClientConnection(conn net.Conn, desc *netpoll.Desc, clientManager *ClientManager, requestedSeqNum arbutil.MessageIndex) *ClientConnection {
 	return &ClientConnection{
-		conn:            conn,
-		desc:            desc,
-		Name:            conn.RemoteAddr().String() + strconv.Itoa(rand.Intn(10)),
-		clientManager:   clientManager,
-		requestedSeqNum: requestedSeqNum,
-		lastHeardUnix:   time.Now().Unix(),
-		out:             make(chan []byte, clientManager.config().MaxSendQueue),
+		conn:             conn,
+		desc:             desc,
+		Name:             conn.RemoteAddr().String() + strconv.Itoa(rand.Intn(10)),
+		clientManager:    clientManager,
+		requestedSeqNum:  requestedSeqNum,
+		lastHeardUnix:    time.Now().Unix(),
+		out:              make(chan []byte, clientManager.config().MaxSendQueue),
+		connectedAt:      time.Now(),
+		caughtUpUnixNano: time.Now().UnixNano(),
 	}
 }
 
@@ -63,6 +81,10 @@ func (cc *ClientConnection) Start(parentCtx context.Context) {
 					cc.clientManager.Remove(cc)
 					return
 				}
+				atomic.AddUint64(&cc.messagesSent, 1)
+				if len(cc.out) == 0 {
+					cc.markCaughtUp()
+				}
 			}
 		}
 	})
@@ -80,6 +102,30 @@ func (cc *ClientConnection) RequestedSeqNum() arbutil.MessageIndex {
 	return cc.requestedSeqNum
 }
 
+func (cc *ClientConnection) Info() ClientInfo {
+	return ClientInfo{
+		Name:         cc.Name,
+		RemoteAddr:   cc.conn.RemoteAddr().String(),
+		ConnectedAt:  cc.connectedAt,
+		Age:          time.Since(cc.connectedAt),
+		QueueDepth:   len(cc.out),
+		MessagesSent: atomic.LoadUint64(&cc.messagesSent),
+		Lag:          cc.Lag(),
+	}
+}
+
+func (cc *ClientConnection) markCaughtUp() {
+	atomic.StoreInt64(&cc.caughtUpUnixNano, time.Now().UnixNano())
+}
+
+// Lag returns how long the client has had messages waiting in its send queue
+func (cc *ClientConnection) Lag() time.Duration {
+	if len(cc.out) == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, atomic.LoadInt64(&cc.caughtUpUnixNano)))
+}
+
 func (cc *ClientConnection) GetLastHeard() time.Time {
 	return time.Unix(atomic.LoadInt64(&cc.lastHeardUnix), 0)
 }
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"net"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -28,6 +29,8 @@ import (
 var (
 	clientsConnectedGauge = metrics.NewRegisteredGauge("arb/feed/clients/connected", nil)
 	clientsTotalCounter   = metrics.NewRegisteredCounter("arb/feed/clients/total", nil)
+	oversizedDropCounter  = metrics.NewRegisteredCounter("arb/feed/output/dropped_oversized", nil)
+	laggingClientsCounter = metrics.NewRegisteredCounter("arb/feed/clients/lagging_disconnected", nil)
 )
 
 // CatchupBuffer is a Protocol-specific client catch-up logic can be injected using this interface
@@ -41,6 +44,9 @@ type CatchupBuffer interface {
 type ClientManager struct {
 	stopwaiter.StopWaiter
 
+	// clientPtrMap is only modified by the main ClientManager thread, which holds clientsMutex
+	// while doing so, so that other threads can take snapshots
+	clientsMutex  sync.RWMutex
 	clientPtrMap  map[*ClientConnection]bool
 	clientCount   int32
 	pool          *gopool.Pool
@@ -80,7 +86,9 @@ func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *C
 	}
 
 	clientConnection.Start(ctx)
+	cm.clientsMutex.Lock()
 	cm.clientPtrMap[clientConnection] = true
+	cm.clientsMutex.Unlock()
 	clientsConnectedGauge.Inc(1)
 	clientsTotalCounter.Inc(1)
 	atomic.AddInt32(&cm.clientCount, 1)
@@ -132,7 +140,9 @@ func (cm *ClientManager) removeClient(clientConnection *ClientConnection) {
 
 	cm.removeClientImpl(clientConnection)
 
+	cm.clientsMutex.Lock()
 	delete(cm.clientPtrMap, clientConnection)
+	cm.clientsMutex.Unlock()
 }
 
 func (cm *ClientManager) Remove(clientConnection *ClientConnection) {
@@ -146,16 +156,23 @@ func (cm *ClientManager) ClientCount() int32 {
 	return atomic.LoadInt32(&cm.clientCount)
 }
 
+// Clients returns a snapshot of the connected clients without blocking the broadcast loop
+func (cm *ClientManager) Clients() []ClientInfo {
+	cm.clientsMutex.RLock()
+	defer cm.clientsMutex.RUnlock()
+	clients := make([]ClientInfo, 0, len(cm.clientPtrMap))
+	for client := range cm.clientPtrMap {
+		clients = append(clients, client.Info())
+	}
+	return clients
+}
+
 // Broadcast sends batch item to all clients.
 func (cm *ClientManager) Broadcast(bm interface{}) {
 	cm.broadcastChan <- bm
 }
 
 func (cm *ClientManager) doBroadcast(bm interface{}) ([]*ClientConnection, error) {
-	if err := cm.catchupBuffer.OnDoBroadcast(bm); err != nil {
-		return nil, err
-	}
-
 	var buf bytes.Buffer
 	writer := wsutil.NewWriter(&buf, ws.StateServerSide, ws.OpText)
 	encoder := json.NewEncoder(writer)
@@ -166,8 +183,28 @@ func (cm *ClientManager) doBroadcast(bm interface{}) ([]*ClientConnection, error
 		return nil, errors.Wrap(err, "unable to flush message")
 	}
 
+	// The catchup buffer sees every message, even one too large to send, so it keeps its sequence
+	// numbers contiguous and applies any confirmation the message carries
+	if err := cm.catchupBuffer.OnDoBroadcast(bm); err != nil {
+		return nil, err
+	}
+
+	if maxSize := cm.config().MaxMessageSize; maxSize > 0 && buf.Len() > maxSize {
+		// Clients will backfill the dropped message from L1
+		log.Warn("dropping oversized message from feed", "size", buf.Len(), "maxSize", maxSize)
+		oversizedDropCounter.Inc(1)
+		return nil, nil
+	}
+
 	clientDeleteList := make([]*ClientConnection, 0, len(cm.clientPtrMap))
 	for client := range cm.clientPtrMap {
+		if cm.isLagging(client) {
+			clientDeleteList = append(clientDeleteList, client)
+			continue
+		}
+		if len(client.out) == 0 {
+			client.markCaughtUp()
+		}
 		select {
 		case client.out <- buf.Bytes():
 		default:
@@ -180,6 +217,21 @@ func (cm *ClientManager) doBroadcast(bm interface{}) ([]*ClientConnection, error
 	return clientDeleteList, nil
 }
 
+// isLagging reports whether a client has fallen further behind than the configured max client lag
+func (cm *ClientManager) isLagging(client *ClientConnection) bool {
+	maxLag := cm.config().MaxClientLag
+	if maxLag <= 0 {
+		return false
+	}
+	lag := client.Lag()
+	if lag <= maxLag {
+		return false
+	}
+	log.Info("disconnecting because client is lagging", "client", client.Name, "lag", lag, "maxLag", maxLag, "queued", len(client.out))
+	laggingClientsCounter.Inc(1)
+	return true
+}
+
 // verifyClients should be called every cm.config.ClientPingInterval
 func (cm *ClientManager) verifyClients() []*ClientConnection {
 	clientConnectionCount := len(cm.clientPtrMap)
@@ -194,6 +246,8 @@ func (cm *ClientManager) verifyClients() []*ClientConnection {
 		if diff > cm.config().ClientTimeout {
 			log.Info("disconnecting because connection timed out", "client", client.Name)
 			clientDeleteList = append(clientDeleteList, client)
+		} else if cm.isLagging(client) {
+			clientDeleteList = append(clientDeleteList, client)
 		} else {
 			err := client.Ping()
 			if err != nil {
@@ -21,9 +21,14 @@ import (
 	flag "github.com/spf13/pflag"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/offchainlabs/nitro/arbutil"
 )
 
+var (
+	listenerRestartsCounter = metrics.NewRegisteredCounter("arb/feed/output/restarts", nil)
+)
+
 const (
 	HTTPHeaderFeedServerVersion       = "Arbitrum-Feed-Server-Version"
 	HTTPHeaderFeedClientVersion       = "Arbitrum-Feed-Client-Version"
@@ -35,18 +40,25 @@ const (
 )
 
 type BroadcasterConfig struct {
-	Enable         bool          `koanf:"enable"`
-	Signed         bool          `koanf:"signed"`
-	Addr           string        `koanf:"addr"`                         // TODO(magic) needs tcp server restart on change
-	IOTimeout      time.Duration `koanf:"io-timeout" reload:"hot"`      // reloading will affect only new connections
-	Port           string        `koanf:"port"`                         // TODO(magic) needs tcp server restart on change
-	Ping           time.Duration `koanf:"ping" reload:"hot"`            // reloaded value will change future ping intervals
-	ClientTimeout  time.Duration `koanf:"client-timeout" reload:"hot"`  // reloaded value will affect all clients (next time the timeout is checked)
-	Queue          int           `koanf:"queue"`                        // TODO(magic) ClientManager.pool needs to be recreated on change
-	Workers        int           `koanf:"workers"`                      // TODO(magic) ClientManager.pool needs to be recreated on change
-	MaxSendQueue   int           `koanf:"max-send-queue" reload:"hot"`  // reloaded value will affect only new connections
-	RequireVersion bool          `koanf:"require-version" reload:"hot"` // reloaded value will affect only future upgrades to websocket
-	DisableSigning bool          `koanf:"disable-signing"`
+	Enable           bool          `koanf:"enable"`
+	Signed           bool          `koanf:"signed"`
+	Addr             string        `koanf:"addr"`                         // TODO(magic) needs tcp server restart on change
+	IOTimeout        time.Duration `koanf:"io-timeout" reload:"hot"`      // reloading will affect only new connections
+	Port             string        `koanf:"port"`                         // TODO(magic) needs tcp server restart on change
+	Ping             time.Duration `koanf:"ping" reload:"hot"`            // reloaded value will change future ping intervals
+	ClientTimeout    time.Duration `koanf:"client-timeout" reload:"hot"`  // reloaded value will affect all clients (next time the timeout is checked)
+	Queue            int           `koanf:"queue"`                        // TODO(magic) ClientManager.pool needs to be recreated on change
+	Workers          int           `koanf:"workers"`                      // TODO(magic) ClientManager.pool needs to be recreated on change
+	MaxSendQueue     int           `koanf:"max-send-queue" reload:"hot"`  // reloaded value will affect only new connections
+	RequireVersion   bool          `koanf:"require-version" reload:"hot"` // reloaded value will affect only future upgrades to websocket
+	DisableSigning   bool          `koanf:"disable-signing"`
+	MaxRestarts      int           `koanf:"max-restarts" reload:"hot"`
+	RestartDelay     time.Duration `koanf:"restart-delay" reload:"hot"`
+	ReplayBufferSize int           `koanf:"replay-buffer-size" reload:"hot"`
+	MaxMessageSize   int           `koanf:"max-message-size" reload:"hot"`
+	MaxClientLag     time.Duration `koanf:"max-client-lag" reload:"hot"`
+	BatchInterval    time.Duration `koanf:"batch-interval" reload:"hot"`
+	Archive          ArchiveConfig `koanf:"archive" reload:"hot"`
 }
 
 type BroadcasterConfigFetcher func() *BroadcasterConfig
@@ -64,36 +76,57 @@ func BroadcasterConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-send-queue", DefaultBroadcasterConfig.MaxSendQueue, "maximum number of messages allowed to accumulate before client is disconnected")
 	f.Bool(prefix+".require-version", DefaultBroadcasterConfig.RequireVersion, "don't connect if client version not present")
 	f.Bool(prefix+".disable-signing", DefaultBroadcasterConfig.DisableSigning, "don't sign feed messages")
+	f.Int(prefix+".max-restarts", DefaultBroadcasterConfig.MaxRestarts, "number of times to try restarting the feed listener after a fatal error before stopping the node (0 to stop immediately)")
+	f.Duration(prefix+".restart-delay", DefaultBroadcasterConfig.RestartDelay, "delay before the first feed listener restart attempt, doubled after each failed attempt")
+	f.Int(prefix+".replay-buffer-size", DefaultBroadcasterConfig.ReplayBufferSize, "number of most recent messages to send to newly connected clients that don't request a sequence number (0 to disable)")
+	f.Int(prefix+".max-message-size", DefaultBroadcasterConfig.MaxMessageSize, "maximum encoded size in bytes of a feed message, larger messages are dropped and must be read from L1 (0 for no limit)")
+	f.Duration(prefix+".max-client-lag", DefaultBroadcasterConfig.MaxClientLag, "maximum time a client can have messages waiting to be sent before it is disconnected (0 for no limit, max-send-queue still bounds the number of waiting messages)")
+	f.Duration(prefix+".batch-interval", DefaultBroadcasterConfig.BatchInterval, "send the messages broadcast within this interval together in one websocket frame, to which max-message-size applies as a whole (0 to send each immediately)")
+	ArchiveConfigAddOptions(prefix+".archive", f)
 }
 
 var DefaultBroadcasterConfig = BroadcasterConfig{
-	Enable:         false,
-	Signed:         false,
-	Addr:           "",
-	IOTimeout:      5 * time.Second,
-	Port:           "9642",
-	Ping:           5 * time.Second,
-	ClientTimeout:  15 * time.Second,
-	Queue:          100,
-	Workers:        100,
-	MaxSendQueue:   4096,
-	RequireVersion: false,
-	DisableSigning: true,
+	Enable:           false,
+	Signed:           false,
+	Addr:             "",
+	IOTimeout:        5 * time.Second,
+	Port:             "9642",
+	Ping:             5 * time.Second,
+	ClientTimeout:    15 * time.Second,
+	Queue:            100,
+	Workers:          100,
+	MaxSendQueue:     4096,
+	RequireVersion:   false,
+	DisableSigning:   true,
+	MaxRestarts:      5,
+	RestartDelay:     time.Second,
+	ReplayBufferSize: 0,
+	MaxMessageSize:   0,
+	MaxClientLag:     0,
+	BatchInterval:    0,
+	Archive:          DefaultArchiveConfig,
 }
 
 var DefaultTestBroadcasterConfig = BroadcasterConfig{
-	Enable:         false,
-	Signed:         false,
-	Addr:           "0.0.0.0",
-	IOTimeout:      2 * time.Second,
-	Port:           "0",
-	Ping:           5 * time.Second,
-	ClientTimeout:  15 * time.Second,
-	Queue:          1,
-	Workers:        100,
-	MaxSendQueue:   4096,
-	RequireVersion: false,
-	DisableSigning: false,
+	Enable:           false,
+	Signed:           false,
+	Addr:             "0.0.0.0",
+	IOTimeout:        2 * time.Second,
+	Port:             "0",
+	Ping:             5 * time.Second,
+	ClientTimeout:    15 * time.Second,
+	Queue:            1,
+	Workers:          100,
+	MaxSendQueue:     4096,
+	RequireVersion:   false,
+	DisableSigning:   false,
+	MaxRestarts:      5,
+	RestartDelay:     10 * time.Millisecond,
+	ReplayBufferSize: 0,
+	MaxMessageSize:   0,
+	MaxClientLag:     0,
+	BatchInterval:    0,
+	Archive:          DefaultArchiveConfig,
 }
 
 type WSBroadcastServer struct {
@@ -102,6 +135,7 @@ type WSBroadcastServer struct {
 
 	acceptDescMutex sync.Mutex
 	acceptDesc      *netpoll.Desc
+	listenerMutex   sync.Mutex
 
 	listener      net.Listener
 	config        BroadcasterConfigFetcher
@@ -110,15 +144,22 @@ type WSBroadcastServer struct {
 	catchupBuffer CatchupBuffer
 	chainId       uint64
 	fatalErrChan  chan error
+	// writes every broadcast message to disk when set
+	archive *feedArchive
 }
 
 func NewWSBroadcastServer(config BroadcasterConfigFetcher, catchupBuffer CatchupBuffer, chainId uint64, fatalErrChan chan error) *WSBroadcastServer {
+	var archive *feedArchive
+	if config().Archive.Dir != "" {
+		archive = newFeedArchive(func() *ArchiveConfig { return &config().Archive })
+	}
 	return &WSBroadcastServer{
 		config:        config,
 		started:       false,
 		catchupBuffer: catchupBuffer,
 		chainId:       chainId,
 		fatalErrChan:  fatalErrChan,
+		archive:       archive,
 	}
 }
 
@@ -159,6 +200,9 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 	}
 
 	s.clientManager.Start(ctx)
+	if s.archive != nil {
+		s.archive.start()
+	}
 
 	// handle incoming connection requests.
 	// It upgrades TCP connection to WebSocket, registers netpoll listener on
@@ -269,6 +313,17 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		}
 	}
 
+	if err := s.listen(ctx, handle); err != nil {
+		return err
+	}
+
+	s.started = true
+
+	return nil
+}
+
+// listen creates the tcp server for relay connections, passing accepted connections to handle
+func (s *WSBroadcastServer) listen(ctx context.Context, handle func(conn net.Conn)) error {
 	// Create tcp server for relay connections
 	ln, err := net.Listen("tcp", s.config().Addr+":"+s.config().Port)
 	if err != nil {
@@ -276,7 +331,9 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		return err
 	}
 
+	s.listenerMutex.Lock()
 	s.listener = ln
+	s.listenerMutex.Unlock()
 
 	log.Info("arbitrum websocket broadcast server is listening", "address", ln.Addr().String())
 
@@ -287,7 +344,9 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		log.Error("error calling HandleListener", "err", err)
 		return err
 	}
+	s.acceptDescMutex.Lock()
 	s.acceptDesc = acceptDesc
+	s.acceptDescMutex.Unlock()
 
 	// acceptErrChan blocks until connection accepted or error occurred
 	// OneShot is used, so reusing a single channel is fine
@@ -344,7 +403,7 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		s.acceptDescMutex.Unlock()
 		if err != nil {
 			log.Warn("error in poller.Resume", "err", err)
-			s.fatalErrChan <- errors.Wrap(err, "error in poller.Resume")
+			go s.restartListener(ctx, handle, errors.Wrap(err, "error in poller.Resume"))
 			return
 		}
 	})
@@ -353,35 +412,75 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		return err
 	}
 
-	s.started = true
-
 	return nil
 }
 
-func (s *WSBroadcastServer) ListenerAddr() net.Addr {
-	return s.listener.Addr()
+// restartListener replaces a failed listener, backing off between attempts and
+// reporting a fatal error once the configured number of restarts is exhausted
+func (s *WSBroadcastServer) restartListener(ctx context.Context, handle func(conn net.Conn), cause error) {
+	delay := s.config().RestartDelay
+	for attempt := 1; ; attempt++ {
+		if attempt > s.config().MaxRestarts {
+			s.fatalErrChan <- cause
+			return
+		}
+		listenerRestartsCounter.Inc(1)
+		log.Warn("restarting broadcast listener", "attempt", attempt, "delay", delay, "err", cause)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.closeListener()
+		cause = s.listen(ctx, handle)
+		if cause == nil {
+			log.Info("restarted broadcast listener", "attempt", attempt)
+			return
+		}
+		delay *= 2
+	}
 }
 
-func (s *WSBroadcastServer) StopAndWait() {
-	err := s.listener.Close()
-	if err != nil {
-		log.Warn("error in listener.Close", "err", err)
+func (s *WSBroadcastServer) closeListener() {
+	s.listenerMutex.Lock()
+	if s.listener != nil {
+		err := s.listener.Close()
+		if err != nil {
+			log.Warn("error in listener.Close", "err", err)
+		}
 	}
+	s.listenerMutex.Unlock()
 
-	err = s.poller.Stop(s.acceptDesc)
+	s.acceptDescMutex.Lock()
+	defer s.acceptDescMutex.Unlock()
+	if s.acceptDesc == nil {
+		return
+	}
+	err := s.poller.Stop(s.acceptDesc)
 	if err != nil {
 		log.Warn("error in poller.Stop", "err", err)
 	}
-
-	s.acceptDescMutex.Lock()
 	err = s.acceptDesc.Close()
 	s.acceptDesc = nil
-	s.acceptDescMutex.Unlock()
 	if err != nil {
 		log.Warn("error in acceptDesc.Close", "err", err)
 	}
+}
+
+func (s *WSBroadcastServer) ListenerAddr() net.Addr {
+	s.listenerMutex.Lock()
+	defer s.listenerMutex.Unlock()
+	return s.listener.Addr()
+}
 
+func (s *WSBroadcastServer) StopAndWait() {
+	s.closeListener()
 	s.clientManager.StopAndWait()
+	if s.archive != nil {
+		s.archive.close()
+	}
 	s.started = false
 }
 
@@ -391,6 +490,9 @@ func (s *WSBroadcastServer) Started() bool {
 
 // Broadcast sends batch item to all clients.
 func (s *WSBroadcastServer) Broadcast(bm interface{}) {
+	if s.archive != nil {
+		s.archive.enqueue(bm)
+	}
 	s.clientManager.Broadcast(bm)
 }
 
@@ -398,6 +500,10 @@ func (s *WSBroadcastServer) ClientCount() int32 {
 	return s.clientManager.ClientCount()
 }
 
+func (s *WSBroadcastServer) Clients() []ClientInfo {
+	return s.clientManager.Clients()
+}
+
 // deadliner is a wrapper around net.Conn that sets read/write deadlines before
 // every Read() or Write() call.
 type deadliner struct {